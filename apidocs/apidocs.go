@@ -0,0 +1,97 @@
+// Package apidocs serves the OpenAPI document of the service and a docs page to browse it.
+package apidocs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/yaml"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	PathOpenAPIJSON = "/openapi.json"
+	PathOpenAPIYAML = "/openapi.yaml"
+	PathDocs        = "/docs"
+
+	mimeApplicationYAML = "application/yaml"
+)
+
+// docsPage renders PathOpenAPIJSON in the browser. It has no external assets so the docs work offline.
+//
+//go:embed index.html
+var docsPage []byte
+
+type Handler struct {
+	swagger   *openapi3.T
+	serverURL string
+}
+
+type NewHandlerOptions struct {
+	Swagger *openapi3.T
+
+	// ServerURL is advertised in the `servers` of the served document. When empty, the URL the document was
+	// requested from is used.
+	ServerURL string
+}
+
+func NewHandler(opts NewHandlerOptions) *Handler {
+	return &Handler{
+		swagger:   opts.Swagger,
+		serverURL: strings.TrimSuffix(opts.ServerURL, "/"),
+	}
+}
+
+// RegisterRoutes registers the OpenAPI document and docs page routes.
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.GET(PathOpenAPIJSON, h.OpenAPIJSON)
+	e.GET(PathOpenAPIYAML, h.OpenAPIYAML)
+	e.GET(PathDocs, h.Docs)
+}
+
+// OpenAPIJSON returns the OpenAPI document as JSON.
+func (h *Handler) OpenAPIJSON(ctx echo.Context) error {
+	document, err := h.document(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, document)
+}
+
+// OpenAPIYAML returns the OpenAPI document as YAML.
+func (h *Handler) OpenAPIYAML(ctx echo.Context) error {
+	document, err := h.document(ctx)
+	if err != nil {
+		return err
+	}
+
+	document, err = yaml.JSONToYAML(document)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Blob(http.StatusOK, mimeApplicationYAML, document)
+}
+
+// Docs returns the docs page.
+func (h *Handler) Docs(ctx echo.Context) error {
+	return ctx.HTMLBlob(http.StatusOK, docsPage)
+}
+
+// document returns the JSON encoded OpenAPI document with its servers pointing at this service.
+func (h *Handler) document(ctx echo.Context) ([]byte, error) {
+	serverURL := h.serverURL
+	if serverURL == "" {
+		serverURL = ctx.Scheme() + "://" + ctx.Request().Host
+	}
+
+	// Copy the document so the spec shared with the request validator is never modified
+	swagger := *h.swagger
+	swagger.Servers = openapi3.Servers{{URL: serverURL}}
+
+	return json.Marshal(&swagger)
+}
@@ -0,0 +1,112 @@
+package apidocs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UserService/generated"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		serverURL string
+		path      string
+
+		wantHttpStatusCode int
+		wantContentType    string
+		wantServerURL      string
+		wantBodyContains   string
+	}{
+		{
+			name:               "json-server-url-from-request",
+			path:               PathOpenAPIJSON,
+			wantHttpStatusCode: http.StatusOK,
+			wantContentType:    echo.MIMEApplicationJSONCharsetUTF8,
+			wantServerURL:      "http://api.example.com:1323",
+		},
+		{
+			name:               "json-configured-server-url",
+			serverURL:          "https://users.example.com/",
+			path:               PathOpenAPIJSON,
+			wantHttpStatusCode: http.StatusOK,
+			wantContentType:    echo.MIMEApplicationJSONCharsetUTF8,
+			wantServerURL:      "https://users.example.com",
+		},
+		{
+			name:               "yaml",
+			serverURL:          "https://users.example.com",
+			path:               PathOpenAPIYAML,
+			wantHttpStatusCode: http.StatusOK,
+			wantContentType:    mimeApplicationYAML,
+			wantServerURL:      "https://users.example.com",
+		},
+		{
+			name:               "docs",
+			path:               PathDocs,
+			wantHttpStatusCode: http.StatusOK,
+			wantContentType:    echo.MIMETextHTMLCharsetUTF8,
+			wantBodyContains:   `fetch("openapi.json")`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			swagger, err := generated.GetSwagger()
+			if err != nil {
+				t.Fatalf("generated.GetSwagger() err = %v", err)
+			}
+			specServerURL := swagger.Servers[0].URL
+
+			e := echo.New()
+			NewHandler(NewHandlerOptions{Swagger: swagger, ServerURL: test.serverURL}).RegisterRoutes(e)
+
+			request := httptest.NewRequest(http.MethodGet, "http://api.example.com:1323"+test.path, nil)
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantHttpStatusCode {
+				t.Errorf("apidocs.Handler httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, test.wantHttpStatusCode)
+			}
+
+			if gotContentType := recorder.Header().Get(echo.HeaderContentType); gotContentType != test.wantContentType {
+				t.Errorf("apidocs.Handler contentType = %v, wantContentType %v", gotContentType, test.wantContentType)
+			}
+
+			if !strings.Contains(recorder.Body.String(), test.wantBodyContains) {
+				t.Errorf("apidocs.Handler body = %v, wantBodyContains %v", recorder.Body.String(), test.wantBodyContains)
+			}
+
+			if test.wantServerURL != "" {
+				document, err := openapi3.NewLoader().LoadFromData(recorder.Body.Bytes())
+				if err != nil {
+					t.Fatalf("openapi3.Loader.LoadFromData() err = %v", err)
+				}
+
+				if gotServerURL := document.Servers[0].URL; gotServerURL != test.wantServerURL {
+					t.Errorf("apidocs.Handler serverURL = %v, wantServerURL %v", gotServerURL, test.wantServerURL)
+				}
+
+				if err := document.Validate(request.Context()); err != nil {
+					t.Errorf("apidocs.Handler document is not valid: %v", err)
+				}
+
+				gotPaths, _ := json.Marshal(document.Paths)
+				wantPaths, _ := json.Marshal(swagger.Paths)
+				if string(gotPaths) != string(wantPaths) {
+					t.Errorf("apidocs.Handler paths = %s, wantPaths %s", gotPaths, wantPaths)
+				}
+			}
+
+			// The spec is shared with the request validator and must not be modified
+			if swagger.Servers[0].URL != specServerURL {
+				t.Errorf("apidocs.Handler modified the spec server URL to %v", swagger.Servers[0].URL)
+			}
+		})
+	}
+}
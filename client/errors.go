@@ -0,0 +1,62 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Error is a failure reported by the user service. Rely on Code rather than on Message, which is localized.
+type Error struct {
+	StatusCode int
+
+	// Code is the stable error code of the failure. It is empty if the service did not return a problem document,
+	// i.e. when the response comes from a proxy.
+	Code ErrorCode
+
+	Message       string
+	InvalidParams []InvalidParam
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("user service: %d %s", e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("user service: %d %s (%s)", e.StatusCode, e.Message, e.Code)
+}
+
+// newError returns the Error of an unsuccessful response, parsed from its problem document or ErrorResponse
+// envelope.
+func newError(response *http.Response, body []byte) error {
+	apiErr := &Error{
+		StatusCode: response.StatusCode,
+		Message:    http.StatusText(response.StatusCode),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	switch mediaType {
+	case mimeApplicationProblemJSON:
+		problem := Problem{}
+		if err := json.Unmarshal(body, &problem); err != nil {
+			return apiErr
+		}
+
+		apiErr.Code = problem.Code
+		apiErr.Message = problem.Title
+		if problem.InvalidParams != nil {
+			apiErr.InvalidParams = *problem.InvalidParams
+		}
+	case mimeApplicationJSON:
+		envelope := ErrorResponse{}
+		if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Header.Messages) == 0 {
+			return apiErr
+		}
+
+		apiErr.Message = strings.Join(envelope.Header.Messages, "; ")
+	}
+
+	return apiErr
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// retryingDoer retries requests that are safe to send again when they fail with a network error or a status
+// telling the service is temporarily unavailable.
+type retryingDoer struct {
+	doer       HttpRequestDoer
+	maxRetries int
+	backoff    time.Duration
+}
+
+func (d *retryingDoer) Do(req *http.Request) (*http.Response, error) {
+	retryable := isIdempotent(req) && (req.Body == nil || req.GetBody != nil)
+
+	backoff := d.backoff
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		response, err := d.doer.Do(attemptReq)
+		if !retryable || attempt >= d.maxRetries || !shouldRetry(response, err) {
+			return response, err
+		}
+
+		if response != nil {
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
+
+// isIdempotent reports whether req can be sent again without side effects. POST requests can when they carry an
+// Idempotency-Key, as the service replays the response of the first one.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return req.Header.Get("Idempotency-Key") != ""
+	default:
+		return false
+	}
+}
+
+func shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		// Do not retry requests cancelled by the caller
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch response.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
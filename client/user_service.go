@@ -0,0 +1,295 @@
+// Package client is a Go client of the user service HTTP API.
+//
+// client.gen.go is generated from api.yml by oapi-codegen, see oapi-codegen.yaml and the Makefile. Most callers
+// should use UserServiceClient, which wraps the generated client to manage tokens, retries and errors.
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 100 * time.Millisecond
+
+	// refreshBefore is how long before its expiry a token is refreshed
+	refreshBefore = time.Minute
+
+	mimeApplicationJSON        = "application/json"
+	mimeApplicationProblemJSON = "application/problem+json"
+)
+
+// ErrNotLoggedIn is returned by calls that require a token before Login succeeded.
+var ErrNotLoggedIn = errors.New("client: not logged in")
+
+// UserServiceClient is a client of the v2 API of the user service:
+//   - it keeps the token returned in the Authorization header by Login and sends it with every call,
+//   - it logs in again with the same credentials when the token is about to expire or was rejected as expired,
+//   - it retries idempotent calls, and POST calls with an Idempotency-Key, on network errors and 502, 503 and 504;
+//     logins are not retried,
+//   - it returns failures reported by the service as *Error.
+//
+// It is safe for concurrent use.
+type UserServiceClient struct {
+	client *ClientWithResponses
+
+	mu          sync.Mutex
+	credentials UserLoginRequest
+	session     Session
+	token       string
+}
+
+type NewUserServiceClientOptions struct {
+	// Server is the base URL of the service, i.e. http://localhost:1323
+	Server string
+
+	// HTTPClient sends the requests, defaults to http.DefaultClient
+	HTTPClient HttpRequestDoer
+
+	// MaxRetries is how many times a failed call is retried, defaults to 3. Set it to -1 to disable retries.
+	MaxRetries int
+
+	// RetryBackoff is how long to wait before the first retry, doubled before every next retry. Defaults to 100ms.
+	RetryBackoff time.Duration
+}
+
+func NewUserServiceClient(opts NewUserServiceClientOptions) (*UserServiceClient, error) {
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	retryBackoff := opts.RetryBackoff
+	if retryBackoff == 0 {
+		retryBackoff = defaultRetryBackoff
+	}
+
+	client, err := NewClientWithResponses(opts.Server,
+		WithHTTPClient(&retryingDoer{doer: httpClient, maxRetries: maxRetries, backoff: retryBackoff}),
+		WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
+			// Problem documents carry a stable error code, see Error
+			req.Header.Set("Accept", mimeApplicationJSON+", "+mimeApplicationProblemJSON)
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserServiceClient{client: client}, nil
+}
+
+// Register creates a new user.
+func (c *UserServiceClient) Register(ctx context.Context, request User) (User, error) {
+	response, err := c.client.CreateUserWithResponse(ctx, &CreateUserParams{IdempotencyKey: newIdempotencyKey()}, request)
+	if err != nil {
+		return User{}, err
+	}
+	if response.JSON201 == nil {
+		return User{}, newError(response.HTTPResponse, response.Body)
+	}
+
+	return *response.JSON201, nil
+}
+
+// Login creates a session for the user. Its token is sent with the next calls, and refreshed by logging in again
+// with the same credentials until Logout.
+//
+// Login is not retried: the service does not replay logins, whose response carries the token, so a retry would
+// create another session and count another login.
+func (c *UserServiceClient) Login(ctx context.Context, request UserLoginRequest) (Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.login(ctx, request)
+}
+
+// login must be called with c.mu held.
+func (c *UserServiceClient) login(ctx context.Context, request UserLoginRequest) (Session, error) {
+	// Without an Idempotency-Key the login is not retried, see Login
+	response, err := c.client.CreateSessionWithResponse(ctx, &CreateSessionParams{}, request)
+	if err != nil {
+		return Session{}, err
+	}
+	if response.JSON201 == nil {
+		return Session{}, newError(response.HTTPResponse, response.Body)
+	}
+
+	token := strings.TrimPrefix(response.HTTPResponse.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return Session{}, errors.New("client: login response is missing the Authorization header")
+	}
+
+	c.credentials = request
+	c.session = *response.JSON201
+	c.token = token
+
+	return c.session, nil
+}
+
+// Logout deletes the session created by Login.
+func (c *UserServiceClient) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	session, token := c.session, c.token
+	c.mu.Unlock()
+
+	if token == "" || session.Id == nil {
+		return ErrNotLoggedIn
+	}
+
+	response, err := c.client.DeleteSessionWithResponse(ctx, *session.Id, withToken(token))
+	if err != nil {
+		return err
+	}
+	// The session is gone either way
+	if response.StatusCode() != http.StatusNoContent && response.StatusCode() != http.StatusNotFound {
+		return newError(response.HTTPResponse, response.Body)
+	}
+
+	c.mu.Lock()
+	c.credentials, c.session, c.token = UserLoginRequest{}, Session{}, ""
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Token returns the token of the current session, or an empty string before Login.
+func (c *UserServiceClient) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.token
+}
+
+// GetCurrentUser returns the logged in user.
+func (c *UserServiceClient) GetCurrentUser(ctx context.Context) (User, error) {
+	var user User
+	err := c.authenticated(ctx, func(token string) error {
+		response, err := c.client.GetCurrentUserWithResponse(ctx, withToken(token))
+		if err != nil {
+			return err
+		}
+		if response.JSON200 == nil {
+			return newError(response.HTTPResponse, response.Body)
+		}
+
+		user = *response.JSON200
+		return nil
+	})
+
+	return user, err
+}
+
+// GetUser returns the user with the given ID.
+func (c *UserServiceClient) GetUser(ctx context.Context, id int64) (User, error) {
+	var user User
+	err := c.authenticated(ctx, func(token string) error {
+		response, err := c.client.GetUserByIDWithResponse(ctx, id, withToken(token))
+		if err != nil {
+			return err
+		}
+		if response.JSON200 == nil {
+			return newError(response.HTTPResponse, response.Body)
+		}
+
+		user = *response.JSON200
+		return nil
+	})
+
+	return user, err
+}
+
+// UpdateUser replaces the profile of the user with request and returns the updated user.
+func (c *UserServiceClient) UpdateUser(ctx context.Context, id int64, request ReplaceUserRequest) (User, error) {
+	var user User
+	err := c.authenticated(ctx, func(token string) error {
+		response, err := c.client.UpdateUserByIDWithResponse(ctx, id, nil, request, withToken(token))
+		if err != nil {
+			return err
+		}
+		if response.JSON200 == nil {
+			return newError(response.HTTPResponse, response.Body)
+		}
+
+		user = *response.JSON200
+		return nil
+	})
+
+	return user, err
+}
+
+// authenticated runs call with a token that is not about to expire. If the token is still rejected as expired,
+// i.e. because of clock skew, it logs in again and runs call once more.
+func (c *UserServiceClient) authenticated(ctx context.Context, call func(token string) error) error {
+	token, err := c.freshToken(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	err = call(token)
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != TokenExpired {
+		return err
+	}
+
+	if token, err = c.freshToken(ctx, true); err != nil {
+		return err
+	}
+
+	return call(token)
+}
+
+// freshToken returns the token of the current session, logging in again first if the token expires soon or force
+// is set.
+func (c *UserServiceClient) freshToken(ctx context.Context, force bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" {
+		return "", ErrNotLoggedIn
+	}
+
+	expiresSoon := c.session.ExpiresAt != nil && time.Until(*c.session.ExpiresAt) < refreshBefore
+	if !force && !expiresSoon {
+		return c.token, nil
+	}
+
+	if _, err := c.login(ctx, c.credentials); err != nil {
+		return "", err
+	}
+
+	return c.token, nil
+}
+
+// withToken returns a RequestEditorFn sending token in the Authorization header.
+func withToken(token string) RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// newIdempotencyKey returns a random Idempotency-Key, so POST calls can be retried without being processed twice.
+func newIdempotencyKey() *IdempotencyKey {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		// Without a key the call is simply not retried
+		return nil
+	}
+
+	encoded := hex.EncodeToString(key)
+	return &encoded
+}
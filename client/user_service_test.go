@@ -0,0 +1,306 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// exchange is a request expected by the fake service and the response it returns.
+type exchange struct {
+	method     string
+	path       string
+	wantHeader map[string]string
+
+	status int
+	header map[string]string
+	body   string
+}
+
+func TestUserServiceClient(t *testing.T) {
+	expiresAt := time.Now().Add(30 * time.Minute).UTC().Truncate(time.Second)
+	expiresSoon := time.Now().Add(30 * time.Second).UTC().Truncate(time.Second)
+
+	loginExchange := func(token string, expiresAt time.Time) exchange {
+		return exchange{
+			method: http.MethodPost,
+			path:   "/v2/sessions",
+			status: http.StatusCreated,
+			header: map[string]string{"Content-Type": mimeApplicationJSON, "Authorization": "Bearer " + token},
+			body:   fmt.Sprintf(`{"id":"session","user_id":123,"expires_at":%q}`, expiresAt.Format(time.RFC3339)),
+		}
+	}
+	userExchange := func(method, path, token string) exchange {
+		return exchange{
+			method:     method,
+			path:       path,
+			wantHeader: map[string]string{"Authorization": "Bearer " + token},
+			status:     http.StatusOK,
+			header:     map[string]string{"Content-Type": mimeApplicationJSON},
+			body:       `{"id":123,"full_name":"User","phone_number":"+628123456789"}`,
+		}
+	}
+	tokenExpiredExchange := exchange{
+		method: http.MethodGet,
+		path:   "/v2/users/me",
+		status: http.StatusUnauthorized,
+		header: map[string]string{"Content-Type": mimeApplicationProblemJSON},
+		body:   `{"type":"urn:user-service:problem:token_expired","title":"token has expired","status":401,"code":"token_expired"}`,
+	}
+	unavailableExchange := func(method, path string) exchange {
+		return exchange{method: method, path: path, status: http.StatusServiceUnavailable}
+	}
+
+	login := func(ctx context.Context, c *UserServiceClient) error {
+		_, err := c.Login(ctx, UserLoginRequest{PhoneNumber: ptr("+628123456789"), Password: ptr("P455w0rd!.")})
+		return err
+	}
+
+	user := User{Id: ptr(int64(123)), FullName: ptr("User"), PhoneNumber: ptr("+628123456789")}
+
+	tests := []struct {
+		name      string
+		exchanges []exchange
+		run       func(ctx context.Context, c *UserServiceClient) (interface{}, error)
+
+		wantResult interface{}
+		wantErr    error
+	}{
+		{
+			name: "register-retries-unavailable-service",
+			exchanges: []exchange{
+				unavailableExchange(http.MethodPost, "/v2/users"),
+				{
+					method: http.MethodPost,
+					path:   "/v2/users",
+					status: http.StatusCreated,
+					header: map[string]string{"Content-Type": mimeApplicationJSON},
+					body:   `{"id":123,"full_name":"User","phone_number":"+628123456789"}`,
+				},
+			},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				return c.Register(ctx, User{PhoneNumber: ptr("+628123456789"), FullName: ptr("User"), Password: ptr("P455w0rd!.")})
+			},
+			wantResult: user,
+		},
+		{
+			name: "register-fail-problem-is-returned-as-error",
+			exchanges: []exchange{{
+				method: http.MethodPost,
+				path:   "/v2/users",
+				status: http.StatusBadRequest,
+				header: map[string]string{"Content-Type": mimeApplicationProblemJSON},
+				body: `{"type":"urn:user-service:problem:validation_failed","title":"request validation failed","status":400,"code":"validation_failed",` +
+					`"invalid_params":[{"name":"phone_number","code":"phone_number_invalid_prefix","reason":"phone_number should start with +62"}]}`,
+			}},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				return c.Register(ctx, User{PhoneNumber: ptr("+658123456789")})
+			},
+			wantResult: User{},
+			wantErr: &Error{
+				StatusCode: http.StatusBadRequest,
+				Code:       ValidationFailed,
+				Message:    "request validation failed",
+				InvalidParams: []InvalidParam{
+					{Name: "phone_number", Code: PhoneNumberInvalidPrefix, Reason: "phone_number should start with +62"},
+				},
+			},
+		},
+		{
+			name: "register-fail-envelope-is-returned-as-error",
+			exchanges: []exchange{{
+				method: http.MethodPost,
+				path:   "/v2/users",
+				status: http.StatusConflict,
+				header: map[string]string{"Content-Type": mimeApplicationJSON},
+				body:   `{"header":{"success":false,"messages":["phone number is already registered"]}}`,
+			}},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				return c.Register(ctx, User{PhoneNumber: ptr("+628123456789")})
+			},
+			wantResult: User{},
+			wantErr:    &Error{StatusCode: http.StatusConflict, Message: "phone number is already registered"},
+		},
+		{
+			name:      "get-current-user-sends-token-of-login",
+			exchanges: []exchange{loginExchange("token-1", expiresAt), userExchange(http.MethodGet, "/v2/users/me", "token-1")},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				if err := login(ctx, c); err != nil {
+					return nil, err
+				}
+				return c.GetCurrentUser(ctx)
+			},
+			wantResult: user,
+		},
+		{
+			name: "get-current-user-logs-in-again-when-token-expired",
+			exchanges: []exchange{
+				loginExchange("token-1", expiresAt),
+				tokenExpiredExchange,
+				loginExchange("token-2", expiresAt),
+				userExchange(http.MethodGet, "/v2/users/me", "token-2"),
+			},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				if err := login(ctx, c); err != nil {
+					return nil, err
+				}
+				return c.GetCurrentUser(ctx)
+			},
+			wantResult: user,
+		},
+		{
+			name: "get-user-logs-in-again-when-token-expires-soon",
+			exchanges: []exchange{
+				loginExchange("token-1", expiresSoon),
+				loginExchange("token-2", expiresAt),
+				userExchange(http.MethodGet, "/v2/users/123", "token-2"),
+			},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				if err := login(ctx, c); err != nil {
+					return nil, err
+				}
+				return c.GetUser(ctx, 123)
+			},
+			wantResult: user,
+		},
+		{
+			name: "update-user-retries-unavailable-service",
+			exchanges: []exchange{
+				loginExchange("token-1", expiresAt),
+				unavailableExchange(http.MethodPut, "/v2/users/123"),
+				userExchange(http.MethodPut, "/v2/users/123", "token-1"),
+			},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				if err := login(ctx, c); err != nil {
+					return nil, err
+				}
+				return c.UpdateUser(ctx, 123, ReplaceUserRequest{PhoneNumber: "+628123456789", FullName: ptr("User")})
+			},
+			wantResult: user,
+		},
+		{
+			name: "logout-forgets-token",
+			exchanges: []exchange{
+				loginExchange("token-1", expiresAt),
+				{
+					method:     http.MethodDelete,
+					path:       "/v2/sessions/session",
+					wantHeader: map[string]string{"Authorization": "Bearer token-1"},
+					status:     http.StatusNoContent,
+				},
+			},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				if err := login(ctx, c); err != nil {
+					return nil, err
+				}
+				if err := c.Logout(ctx); err != nil {
+					return nil, err
+				}
+				return c.Token(), nil
+			},
+			wantResult: "",
+		},
+		{
+			name:      "login-does-not-retry-unavailable-service",
+			exchanges: []exchange{unavailableExchange(http.MethodPost, "/v2/sessions")},
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				if err := login(ctx, c); err != nil {
+					return nil, err
+				}
+				return c.Token(), nil
+			},
+			wantErr: &Error{StatusCode: http.StatusServiceUnavailable, Message: "Service Unavailable"},
+		},
+		{
+			name: "fail-not-logged-in",
+			run: func(ctx context.Context, c *UserServiceClient) (interface{}, error) {
+				return c.GetCurrentUser(ctx)
+			},
+			wantResult: User{},
+			wantErr:    ErrNotLoggedIn,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			idempotencyKeys := map[string]string{}
+			service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls >= len(test.exchanges) {
+					t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				exchange := test.exchanges[calls]
+				calls++
+
+				if r.Method != exchange.method || r.URL.Path != exchange.path {
+					t.Errorf("request = %s %s, want %s %s", r.Method, r.URL.Path, exchange.method, exchange.path)
+				}
+				for name, want := range exchange.wantHeader {
+					if got := r.Header.Get(name); got != want {
+						t.Errorf("request header %s = %v, want %v", name, got, want)
+					}
+				}
+
+				// Logins are not replayed by the service, so they are sent without an Idempotency-Key
+				if r.Method == http.MethodPost && r.URL.Path == "/v2/sessions" {
+					if key := r.Header.Get("Idempotency-Key"); key != "" {
+						t.Errorf("login request has Idempotency-Key %v", key)
+					}
+				}
+
+				// Retries of other POST requests must reuse their Idempotency-Key
+				if r.Method == http.MethodPost && r.URL.Path != "/v2/sessions" {
+					key := r.Header.Get("Idempotency-Key")
+					if key == "" {
+						t.Errorf("request %s %s is missing its Idempotency-Key", r.Method, r.URL.Path)
+					}
+					if previous, ok := idempotencyKeys[r.URL.Path]; ok && calls > 1 &&
+						test.exchanges[calls-2].status == http.StatusServiceUnavailable && previous != key {
+						t.Errorf("retry Idempotency-Key = %v, want %v", key, previous)
+					}
+					idempotencyKeys[r.URL.Path] = key
+				}
+
+				for name, value := range exchange.header {
+					w.Header().Set(name, value)
+				}
+				w.WriteHeader(exchange.status)
+				fmt.Fprint(w, exchange.body)
+			}))
+			defer service.Close()
+
+			c, err := NewUserServiceClient(NewUserServiceClientOptions{Server: service.URL, RetryBackoff: time.Millisecond})
+			if err != nil {
+				t.Fatalf("client.NewUserServiceClient() err = %v", err)
+			}
+
+			gotResult, gotErr := test.run(context.Background(), c)
+
+			var wantAPIErr *Error
+			if errors.As(test.wantErr, &wantAPIErr) {
+				if !reflect.DeepEqual(gotErr, test.wantErr) {
+					t.Errorf("client.UserServiceClient err = %v, wantErr %v", gotErr, test.wantErr)
+				}
+			} else if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("client.UserServiceClient err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(gotResult, test.wantResult) {
+				t.Errorf("client.UserServiceClient result = %v, wantResult %v", gotResult, test.wantResult)
+			}
+
+			if calls != len(test.exchanges) {
+				t.Errorf("client.UserServiceClient requests = %v, want %v", calls, len(test.exchanges))
+			}
+		})
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
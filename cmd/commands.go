@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/UserService/config"
+	"github.com/UserService/repository"
+)
+
+const (
+	commandVerifyAuditLog = "verify-audit-log"
+	commandPurge          = "purge"
+)
+
+// eraseBatchSize is the number of users listed at once to be erased
+const eraseBatchSize = 100
+
+// errAuditLogBroken is returned by verifyAuditLog when the hash chain of the audit log is broken
+var errAuditLogBroken = errors.New("audit log hash chain is broken")
+
+// runCommand runs the command given as argument, with the arguments that follow it, instead of starting the service
+func runCommand(ctx context.Context, repo repository.RepositoryInterface, cfg config.Config, command string, args []string, w io.Writer) error {
+	switch command {
+	case commandVerifyAuditLog:
+		if len(args) > 0 {
+			return fmt.Errorf("%s takes no arguments, got %q", commandVerifyAuditLog, args)
+		}
+		return verifyAuditLog(ctx, repo, w)
+	case commandPurge:
+		return purge(ctx, repo, cfg.Account.DeletionGracePeriod, args, w)
+	default:
+		return fmt.Errorf("unknown command %q, available commands: %s, %s", command, commandVerifyAuditLog, commandPurge)
+	}
+}
+
+// verifyAuditLog checks that the hash chain of the audit log is intact and prints the result. Record the head hash
+// it prints: a head hash that is no longer in the log shows that its last entries were deleted.
+func verifyAuditLog(ctx context.Context, repo repository.RepositoryInterface, w io.Writer) error {
+	verification, err := repo.VerifyAuditLog(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify audit log: %w", err)
+	}
+
+	if verification.BrokenEntryID != 0 {
+		fmt.Fprintf(w, "audit log broken at entry %d: %s\n", verification.BrokenEntryID, verification.Problem)
+		fmt.Fprintf(w, "%d entries verified before it, head hash %s\n", verification.Entries, verification.HeadHash)
+		return errAuditLogBroken
+	}
+
+	fmt.Fprintf(w, "audit log intact: %d entries verified, head hash %s\n", verification.Entries, verification.HeadHash)
+	return nil
+}
+
+// purge erases the users whose deletion grace period is over and prints them, as the service does every hour. With
+// --dry-run it only prints the users it would erase.
+func purge(ctx context.Context, repo repository.RepositoryInterface, gracePeriod time.Duration, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet(commandPurge, flag.ContinueOnError)
+	fs.SetOutput(w)
+	dryRun := fs.Bool("dry-run", false, "print the users that would be erased without erasing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%s takes no arguments, got %q", commandPurge, fs.Args())
+	}
+
+	verb := "erased"
+	if *dryRun {
+		verb = "would erase"
+	}
+
+	erased, err := eraseDeletedUsers(ctx, repo, time.Now().Add(-gracePeriod), *dryRun, func(user repository.User) {
+		fmt.Fprintf(w, "%s user %d, deleted at %s\n", verb, user.ID, user.DeletedTime.UTC().Format(time.RFC3339))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	if *dryRun {
+		fmt.Fprintf(w, "dry run: %d users would be erased\n", erased)
+		return nil
+	}
+	fmt.Fprintf(w, "%d users erased\n", erased)
+	return nil
+}
+
+// eraseDeletedUsers erases the users deleted before deletedBefore, see RepositoryInterface.EraseUser, and calls
+// onErased with every erased user. Users restored in the meantime are skipped. With dryRun, the users are listed but
+// not erased. It returns the number of erased users, and stops at the first failure.
+func eraseDeletedUsers(ctx context.Context, repo repository.RepositoryInterface, deletedBefore time.Time, dryRun bool, onErased func(repository.User)) (int, error) {
+	var (
+		erased  int
+		afterID int64
+	)
+
+	for {
+		users, err := repo.GetUsersToErase(ctx, deletedBefore, afterID, eraseBatchSize)
+		if err != nil {
+			return erased, err
+		}
+
+		for _, user := range users {
+			afterID = user.ID
+
+			if !dryRun {
+				err := repo.EraseUser(ctx, user.ID, deletedBefore, time.Now(), repository.AuditActorSystem)
+				if errors.Is(err, repository.ErrNotFound) {
+					continue
+				}
+				if err != nil {
+					return erased, fmt.Errorf("failed to erase user %d: %w", user.ID, err)
+				}
+			}
+
+			erased++
+			onErased(user)
+		}
+
+		if len(users) < eraseBatchSize {
+			return erased, nil
+		}
+	}
+}
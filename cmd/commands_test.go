@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UserService/config"
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_runCommand(t *testing.T) {
+	deletedTime := time.Date(2024, 1, 1, 19, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+	cfg := config.Default()
+
+	tests := []struct {
+		name     string
+		command  string
+		args     []string
+		mockRepo func(mock *repository.MockRepositoryInterface)
+
+		wantOutput       string
+		wantOutputSuffix string // instead of wantOutput, for long outputs
+		wantErr          string
+	}{
+		{
+			name:    "verify-audit-log-intact",
+			command: "verify-audit-log",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().VerifyAuditLog(gomock.Any()).Return(repository.AuditLogVerification{Entries: 3, HeadHash: "c3d4"}, nil)
+			},
+			wantOutput: "audit log intact: 3 entries verified, head hash c3d4\n",
+		},
+		{
+			name:    "verify-audit-log-broken",
+			command: "verify-audit-log",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().VerifyAuditLog(gomock.Any()).Return(repository.AuditLogVerification{
+					Entries:       1,
+					HeadHash:      "a1b2",
+					BrokenEntryID: 2,
+					Problem:       "hash \"c3d4\" does not match the content of the entry, the entry was edited",
+				}, nil)
+			},
+			wantOutput: "audit log broken at entry 2: hash \"c3d4\" does not match the content of the entry, the entry was edited\n" +
+				"1 entries verified before it, head hash a1b2\n",
+			wantErr: "audit log hash chain is broken",
+		},
+		{
+			name:    "verify-audit-log-fail-repository",
+			command: "verify-audit-log",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().VerifyAuditLog(gomock.Any()).Return(repository.AuditLogVerification{}, repository.ErrUnavailable)
+			},
+			wantErr: "failed to verify audit log",
+		},
+		{
+			name:     "verify-audit-log-fail-arguments",
+			command:  "verify-audit-log",
+			args:     []string{"--dry-run"},
+			mockRepo: func(mock *repository.MockRepositoryInterface) {},
+			wantErr:  "verify-audit-log takes no arguments",
+		},
+		{
+			name:    "purge-erases-users-past-grace-period",
+			command: "purge",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				deletedBefore := nearTime(time.Now().Add(-30 * 24 * time.Hour))
+				mock.EXPECT().GetUsersToErase(gomock.Any(), deletedBefore, int64(0), eraseBatchSize).Return([]repository.User{
+					{ID: 3, DeletedTime: &deletedTime},
+					{ID: 7, DeletedTime: &deletedTime},
+				}, nil)
+				mock.EXPECT().EraseUser(gomock.Any(), int64(3), deletedBefore, gomock.Any(), repository.AuditActorSystem).Return(nil)
+				mock.EXPECT().EraseUser(gomock.Any(), int64(7), deletedBefore, gomock.Any(), repository.AuditActorSystem).Return(repository.ErrNotFound)
+			},
+			wantOutput: "erased user 3, deleted at 2024-01-01T12:00:00Z\n" +
+				"1 users erased\n",
+		},
+		{
+			name:    "purge-dry-run-erases-nothing",
+			command: "purge",
+			args:    []string{"--dry-run"},
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsersToErase(gomock.Any(), gomock.Any(), int64(0), eraseBatchSize).Return([]repository.User{
+					{ID: 3, DeletedTime: &deletedTime},
+				}, nil)
+			},
+			wantOutput: "would erase user 3, deleted at 2024-01-01T12:00:00Z\n" +
+				"dry run: 1 users would be erased\n",
+		},
+		{
+			name:    "purge-pages-through-users",
+			command: "purge",
+			args:    []string{"--dry-run"},
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				users := make([]repository.User, eraseBatchSize)
+				for i := range users {
+					users[i] = repository.User{ID: int64(i + 1), DeletedTime: &deletedTime}
+				}
+				mock.EXPECT().GetUsersToErase(gomock.Any(), gomock.Any(), int64(0), eraseBatchSize).Return(users, nil)
+				mock.EXPECT().GetUsersToErase(gomock.Any(), gomock.Any(), int64(eraseBatchSize), eraseBatchSize).Return(nil, nil)
+			},
+			wantOutputSuffix: "dry run: 100 users would be erased\n",
+		},
+		{
+			name:    "purge-fail-erase-stops",
+			command: "purge",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsersToErase(gomock.Any(), gomock.Any(), int64(0), eraseBatchSize).Return([]repository.User{
+					{ID: 3, DeletedTime: &deletedTime},
+					{ID: 7, DeletedTime: &deletedTime},
+				}, nil)
+				mock.EXPECT().EraseUser(gomock.Any(), int64(3), gomock.Any(), gomock.Any(), repository.AuditActorSystem).Return(repository.ErrUnavailable)
+			},
+			wantErr: "failed to purge deleted users: failed to erase user 3",
+		},
+		{
+			name:             "purge-fail-unknown-flag",
+			command:          "purge",
+			args:             []string{"--force"},
+			mockRepo:         func(mock *repository.MockRepositoryInterface) {},
+			wantOutputSuffix: "print the users that would be erased without erasing them\n",
+			wantErr:          "flag provided but not defined: -force",
+		},
+		{
+			name:     "fail-unknown-command",
+			command:  "migrate",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {},
+			wantErr:  `unknown command "migrate"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			test.mockRepo(mock)
+
+			var output strings.Builder
+			err := runCommand(context.Background(), mock, cfg, test.command, test.args, &output)
+			if (err == nil) != (test.wantErr == "") || (err != nil && !strings.Contains(err.Error(), test.wantErr)) {
+				t.Errorf("runCommand() err = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if got := output.String(); test.wantOutputSuffix != "" {
+				if !strings.HasSuffix(got, test.wantOutputSuffix) {
+					t.Errorf("runCommand() output = %q, wantOutputSuffix %q", got, test.wantOutputSuffix)
+				}
+			} else if got != test.wantOutput {
+				t.Errorf("runCommand() output = %q, wantOutput %q", got, test.wantOutput)
+			}
+		})
+	}
+}
+
+// nearTime matches times within a minute of want, i.e. computed from time.Now
+func nearTime(want time.Time) gomock.Matcher {
+	return timeMatcher{want: want}
+}
+
+type timeMatcher struct {
+	want time.Time
+}
+
+func (m timeMatcher) Matches(x interface{}) bool {
+	got, ok := x.(time.Time)
+	if !ok {
+		return false
+	}
+
+	diff := got.Sub(m.want)
+	return diff > -time.Minute && diff < time.Minute
+}
+
+func (m timeMatcher) String() string {
+	return fmt.Sprintf("is within a minute of %v", m.want)
+}
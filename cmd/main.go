@@ -1,174 +1,498 @@
 package main
 
 import (
-	"errors"
+	"context"
+	"crypto/rsa"
 	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"syscall"
 	"time"
 
+	"github.com/UserService/apidocs"
+	"github.com/UserService/config"
+	"github.com/UserService/export"
 	"github.com/UserService/generated"
 	"github.com/UserService/handler"
+	"github.com/UserService/health"
+	"github.com/UserService/i18n"
+	"github.com/UserService/logging"
+	"github.com/UserService/metrics"
+	"github.com/UserService/outbox"
 	"github.com/UserService/repository"
+	"github.com/UserService/tracing"
 	"github.com/UserService/utils"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/UserService/webhook"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
 )
 
-const (
-	jwtExpiryDuration = time.Minute * 30 // Token expires in 30 minutes by default
-)
+// exportSigningKeyPurpose derives the key signing the download links of exports from the private key, see
+// utils.DeriveKey
+const exportSigningKeyPurpose = "user-export-download-link"
+
+// idempotencyFingerprintKeyPurpose derives the key of the fingerprints of idempotent requests from the private key,
+// see utils.DeriveKey
+const idempotencyFingerprintKeyPurpose = "idempotency-key-fingerprint"
 
 func main() {
-	e := echo.New()
-	e.Pre(AuthenticationMiddleware) // register pre-handler middleware
-	e.Use(AuthenticatedMiddleware)  // register post-handler middleware
+	cfg, flags, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
 
-	var server generated.ServerInterface = newServer()
+	if flags.PrintConfig {
+		if err := cfg.WriteRedacted(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	generated.RegisterHandlers(e, server)
-	e.Logger.Fatal(e.Start(":1323"))
-}
+	// Fail at startup on invalid settings instead of when they are used
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid config:\n%v", err)
+	}
+
+	// Logs are JSON lines with sensitive values redacted, the log package writes through the same logger
+	var logLevel slog.Level
+	_ = logLevel.UnmarshalText([]byte(cfg.Log.Level)) // validated above
+	logger := logging.NewLogger(logging.NewLoggerOptions{Level: logLevel})
+	slog.SetDefault(logger)
+
+	// Commands run against the database instead of starting the service, i.e. `main verify-audit-log`
+	if flags.Command != "" {
+		store := newRepository(cfg.Database, cfg.Auth)
+		err := runCommand(context.Background(), store, cfg, flags.Command, flags.CommandArgs, os.Stdout)
+		store.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	catalog, err := i18n.NewCatalog(cfg.I18n.DefaultLocale)
+	if err != nil {
+		panic(err)
+	}
 
-func newServer() *handler.Server {
-	dbDsn := os.Getenv("DATABASE_URL")
-	var repo repository.RepositoryInterface = repository.NewRepository(repository.NewRepositoryOptions{
-		Dsn: dbDsn,
+	swagger, err := generated.GetSwagger()
+	if err != nil {
+		panic(err)
+	}
+
+	// Keys are generated by the Dockerfile, endpoints that need them fail with an internal error when they are missing
+	privateKey, err := utils.LoadRSAPrivateKey(cfg.Auth.PrivateKeyPath)
+	if err != nil {
+		slog.Warn("failed to load private key, tokens cannot be issued", "error", err)
+	}
+	publicKey, err := utils.LoadRSAPublicKey(cfg.Auth.PublicKeyPath)
+	if err != nil {
+		slog.Warn("failed to load public key, tokens cannot be verified", "error", err)
+	}
+
+	// Spans are exported in batches, the last ones are flushed on shutdown
+	tracerProvider, err := tracing.NewTracerProvider(tracing.NewTracerProviderOptions{
+		Exporter:     tracing.Exporter(cfg.Tracing.Exporter),
+		ServiceName:  cfg.Tracing.ServiceName,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
 	})
-	opts := handler.NewServerOptions{
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %v", err)
+	}
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(tracing.Propagator())
+	tracer := tracing.NewTracing(tracing.NewTracingOptions{})
+
+	store := newRepository(cfg.Database, cfg.Auth)
+	var repo repository.RepositoryInterface = store
+
+	// Metrics are recorded by a middleware, a gRPC interceptor and a decorator of the repository
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = metrics.NewMetrics(metrics.NewMetricsOptions{
+			DB: store.Db,
+		})
+		repo = m.Repository(repo)
+		utils.PasswordObserver = m.ObservePassword
+	}
+	repo = tracer.Repository(repo)
+
+	// Logins of unknown phone numbers are compared against a hash with the cost of the stored ones to take as long
+	dummyPasswordHash, err := handler.NewDummyPasswordHash(cfg.Auth.BcryptCost)
+	if err != nil {
+		log.Fatalf("failed to hash the dummy password: %v", err)
+	}
+
+	server := handler.NewServer(handler.NewServerOptions{
+		Repository:          repo,
+		TokenExpiry:         cfg.Auth.TokenExpiry,
+		Validation:          handler.ValidationRules(cfg.Validation),
+		DeletionGracePeriod: cfg.Account.DeletionGracePeriod,
+		ExportLinkExpiry:    cfg.Export.LinkExpiry,
+		ExportSigningKey:    utils.DeriveKey(privateKey, exportSigningKeyPurpose),
+		DummyPasswordHash:   dummyPasswordHash,
+	})
+
+	// Idempotency keys are disabled without the private key, the fingerprints of their requests are keyed with it
+	idempotencyFingerprintKey := utils.DeriveKey(privateKey, idempotencyFingerprintKeyPurpose)
+
+	e := echo.New()
+	e.Pre(i18n.Middleware(catalog)) // negotiate locale before any middleware can write an error
+	e.Use(tracer.Middleware())      // start the span of requests, before the other middlewares to trace them
+	e.Use(logging.NewMiddleware(logging.NewMiddlewareOptions{
+		Logger: logger,
+	})) // scope a logger to the request ID and log requests, after tracing to log the trace ID
+	if m != nil {
+		e.Use(m.Middleware()) // record requests, before the other middlewares to see the errors they return
+		m.RegisterRoutes(e)
+	}
+	e.Use(tracer.WrapMiddleware("authenticated", NewAuthenticatedMiddleware(privateKey)))          // register post-handler middleware
+	e.Use(tracer.WrapMiddleware("authentication", NewAuthenticationMiddleware(server, publicKey))) // register pre-handler middleware, after routing to match route paths
+	e.Use(handler.NewAdminMiddleware(handler.NewAdminMiddlewareOptions{
+		Token: cfg.Admin.Token,
+	})) // authenticate the admin API, disabled without a token
+	e.Use(handler.NewIdempotencyMiddleware(handler.NewIdempotencyMiddlewareOptions{
+		Repository:     repo,
+		TTL:            cfg.Idempotency.TTL,
+		FingerprintKey: idempotencyFingerprintKey,
+	})) // replay responses of retried POST requests, after authentication to tell requesters apart
+	e.Use(handler.NewOpenAPIValidator(handler.NewOpenAPIValidatorOptions{
+		Swagger: swagger,
+		// Validating responses buffers every response, only enable it in development and tests
+		ValidateResponses: cfg.OpenAPI.ValidateResponses,
+	})) // validate requests against api.yml once the route is known
+	e.Use(tracer.HandlerMiddleware()) // trace the handler, after the other middlewares
+
+	generated.RegisterHandlers(e, server)
+
+	// API docs are served unless disabled, i.e. in production
+	if cfg.Docs.Enabled {
+		apidocs.NewHandler(apidocs.NewHandlerOptions{
+			Swagger:   swagger,
+			ServerURL: cfg.Docs.ServerURL,
+		}).RegisterRoutes(e)
+	}
+
+	// Probes are served without authentication, readiness fails once the service is shutting down
+	probes := health.NewHandler(health.NewHandlerOptions{
 		Repository: repo,
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+	})
+	probes.RegisterRoutes(e)
+
+	// Webhook deliveries are queued in the database, so every replica can dispatch them
+	dispatcher := webhook.NewDispatcher(webhook.NewDispatcherOptions{
+		Repository:   repo,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		PollInterval: cfg.Webhook.PollInterval,
+	})
+
+	// Domain events are written to the outbox with the user changes, and relayed from there by every replica to the
+	// publisher and to the webhook subscriptions
+	publisher := newEventPublisher(cfg.Outbox)
+	relay := outbox.NewRelay(outbox.NewRelayOptions{
+		Repository:   repo,
+		Publisher:    outbox.MultiPublisher{publisher, webhook.NewOutboxPublisher(webhook.NewOutboxPublisherOptions{Repository: repo})},
+		PollInterval: cfg.Outbox.PollInterval,
+	})
+
+	// Exports are queued in the database, so every replica can build them
+	exportWorker := export.NewWorker(export.NewWorkerOptions{
+		Repository:   repo,
+		Build:        server.BuildUserDataArchive,
+		PollInterval: cfg.Export.PollInterval,
+		Retention:    cfg.Export.Retention,
+	})
+
+	// The gRPC API is served on its own port for internal services
+	interceptors := []grpc.UnaryServerInterceptor{
+		tracer.UnaryServerInterceptor(),
+		logging.NewUnaryServerInterceptor(logging.NewMiddlewareOptions{Logger: logger}),
+	}
+	if m != nil {
+		interceptors = append(interceptors, m.UnaryServerInterceptor())
 	}
-	return handler.NewServer(opts)
+	grpcServer := handler.NewGRPCServer(handler.NewGRPCServerOptions{
+		Server:                    server,
+		Catalog:                   catalog,
+		PublicKey:                 publicKey,
+		PrivateKey:                privateKey,
+		InternalToken:             cfg.Auth.InternalToken,
+		IdempotencyFingerprintKey: idempotencyFingerprintKey,
+		IdempotencyKeyTTL:         cfg.Idempotency.TTL,
+		Interceptors:              interceptors,
+	})
+
+	httpListener, err := net.Listen("tcp", cfg.Server.HTTPAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+	grpcListener, err := net.Listen("tcp", cfg.Server.GRPCAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.Info("serving", "http_address", httpListener.Addr().String(), "grpc_address", grpcListener.Addr().String())
+
+	closers := []io.Closer{repo}
+	if closer, ok := publisher.(io.Closer); ok {
+		// The publisher is closed before the repository, which is used until the end
+		closers = []io.Closer{closer, repo}
+	}
+
+	err = serve(serveOptions{
+		HTTPServer:   &http.Server{Handler: e},
+		HTTPListener: httpListener,
+		GRPCServer:   grpcServer,
+		GRPCListener: grpcListener,
+		Workers: []func(ctx context.Context){
+			dispatcher.Run,
+			relay.Run,
+			exportWorker.Run,
+			func(ctx context.Context) { deleteExpiredIdempotencyKeys(ctx, repo) },
+			func(ctx context.Context) { deletePublishedOutboxEvents(ctx, repo, cfg.Outbox.Retention) },
+			func(ctx context.Context) { purgeDeletedUsers(ctx, repo, cfg.Account.DeletionGracePeriod) },
+			func(ctx context.Context) { expireUserExports(ctx, repo) },
+		},
+		// Login counters are updated within the login requests, so only the events of the last requests are left
+		Flushers: []func(ctx context.Context) error{
+			func(ctx context.Context) error {
+				_, err := relay.RelayPending(ctx)
+				return err
+			},
+			func(ctx context.Context) error {
+				_, err := dispatcher.DispatchDue(ctx)
+				return err
+			},
+			tracerProvider.Shutdown, // after the other flushers, to export their spans
+		},
+		Closers:         closers,
+		OnShutdown:      []func(){probes.Shutdown},
+		ShutdownDelay:   cfg.Server.ShutdownDelay,
+		ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		Signals:         []os.Signal{syscall.SIGTERM, os.Interrupt},
+	})
+	if err != nil {
+		log.Fatalf("failed to shut down gracefully: %v", err)
+	}
+	slog.Info("shut down gracefully")
 }
 
-// AuthenticationMiddleware validates incoming JWT (RS256 algorithm) using public key.
-// See command in Dockerfile: openssl rsa -in /tmp/rsa -pubout -out /tmp/rsa.pub
-func AuthenticationMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(ctx echo.Context) error {
-		// Add endpoints in the `whitelistedEndpoints` map to authenticate incoming JWT with RS256 algorithm
-		whitelistedEndpoints := map[string]bool{
-			"GET - /v1/user": true,
-			"PUT - /v1/user": true,
+// deleteExpiredIdempotencyKeys periodically deletes idempotency keys whose responses are no longer replayed, until ctx
+// is cancelled
+func deleteExpiredIdempotencyKeys(ctx context.Context, repo repository.RepositoryInterface) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 
-		endpoint := fmt.Sprintf("%s - %s", ctx.Request().Method, ctx.Request().URL.Path)
-		if whitelistedEndpoints[endpoint] {
-			claims, err := func() (*utils.CustomClaims, error) {
-				authHeader := ctx.Request().Header.Get("Authorization")
-				if authHeader == "" {
-					return nil, errors.New("missing authorization header")
-				}
+		deleted, err := repo.DeleteExpiredIdempotencyKeys(ctx, time.Now())
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to delete expired idempotency keys", "error", err)
+			continue
+		}
+		logging.FromContext(ctx).InfoContext(ctx, "deleted expired idempotency keys", "deleted", deleted)
+	}
+}
 
-				if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-					return nil, errors.New("token format is invalid")
-				}
+// deletePublishedOutboxEvents periodically deletes outbox events that were published longer than retention ago,
+// until ctx is cancelled
+func deletePublishedOutboxEvents(ctx context.Context, repo repository.RepositoryInterface, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
 
-				token := authHeader[7:]
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 
-				publicKeyData, err := os.ReadFile("../rsa.pub")
-				if err != nil {
-					return nil, err
-				}
+		deleted, err := repo.DeletePublishedOutboxEvents(ctx, time.Now().Add(-retention))
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to delete published outbox events", "error", err)
+			continue
+		}
+		logging.FromContext(ctx).InfoContext(ctx, "deleted published outbox events", "deleted", deleted)
+	}
+}
 
-				publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyData)
-				if err != nil {
-					return nil, err
-				}
+// purgeDeletedUsers periodically erases the users whose deletion grace period is over, until ctx is cancelled. The
+// purge command erases them on demand.
+func purgeDeletedUsers(ctx context.Context, repo repository.RepositoryInterface, gracePeriod time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
 
-				// Parse & validate token using publicKey
-				tok, err := jwt.ParseWithClaims(token, &utils.CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-					// Use publicKey for token verification
-					return publicKey, nil
-				})
-				if err != nil {
-					return nil, err
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		erased, err := eraseDeletedUsers(ctx, repo, time.Now().Add(-gracePeriod), false, func(repository.User) {})
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to erase deleted users", "erased", erased, "error", err)
+			continue
+		}
+		logging.FromContext(ctx).InfoContext(ctx, "erased deleted users", "erased", erased)
+	}
+}
+
+// expireUserExports periodically deletes the archives of the exports whose retention is over, until ctx is cancelled
+func expireUserExports(ctx context.Context, repo repository.RepositoryInterface) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := repo.ExpireUserExports(ctx, time.Now())
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to expire user exports", "error", err)
+			continue
+		}
+		logging.FromContext(ctx).InfoContext(ctx, "expired user exports", "expired", expired)
+	}
+}
+
+// newEventPublisher returns the publisher of the outbox events: `stdout` or `file`, which appends the events to the
+// outbox file. The publisher is checked by config.Validate.
+func newEventPublisher(cfg config.OutboxConfig) outbox.EventPublisher {
+	if cfg.Publisher == "file" {
+		filePublisher, err := outbox.NewFilePublisher(cfg.File)
+		if err != nil {
+			panic(err)
+		}
+		return filePublisher
+	}
+
+	return outbox.NewStdoutPublisher()
+}
+
+func newRepository(database config.DatabaseConfig, auth config.AuthConfig) *repository.Repository {
+	return repository.NewRepository(repository.NewRepositoryOptions{
+		Dsn:        database.URL,
+		BcryptCost: auth.BcryptCost,
+	})
+}
+
+// NewAuthenticationMiddleware returns a middleware that validates incoming JWT (RS256 algorithm) using public key,
+// and checks that the session of the JWT has not been revoked.
+// See command in Dockerfile: openssl rsa -in /tmp/rsa -pubout -out /tmp/rsa.pub
+func NewAuthenticationMiddleware(server *handler.Server, publicKey *rsa.PublicKey) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			// Add endpoints in the `whitelistedEndpoints` map to authenticate incoming JWT with RS256 algorithm.
+			// Endpoints are identified by their route path, i.e. `/v2/users/:id`
+			whitelistedEndpoints := map[string]bool{
+				"GET - /v1/user":            true,
+				"PUT - /v1/user":            true,
+				"PATCH - /v1/user":          true,
+				"DELETE - /v1/user":         true,
+				"POST - /v1/user/export":    true,
+				"GET - /v1/user/export/:id": true,
+				"GET - /v2/users/me":        true,
+				"GET - /v2/users/:id":       true,
+				"PUT - /v2/users/:id":       true,
+				"DELETE - /v2/sessions/:id": true,
+			}
+
+			endpoint := fmt.Sprintf("%s - %s", ctx.Request().Method, ctx.Path())
+			if whitelistedEndpoints[endpoint] {
+				if publicKey == nil {
+					return handler.WriteError(ctx, handler.NewError(http.StatusInternalServerError, generated.InternalError))
 				}
 
-				// Check if token is valid (has not expired)
-				claims, ok := tok.Claims.(*utils.CustomClaims)
-				if ok && tok.Valid {
-					if time.Now().Unix() >= claims.ExpiresAt {
-						return nil, errors.New("JWT has expired")
-					}
+				claims, err := server.AuthenticateToken(ctx.Request().Context(), publicKey, ctx.Request().Header.Get("Authorization"))
+				if err != nil {
+					return handler.WriteError(ctx, err)
 				}
 
-				return claims, nil
-			}()
-			if err != nil {
-				return ctx.JSON(http.StatusUnauthorized, utils.JWTResponse{
-					Header: generated.ResponseHeader{
-						Messages: []string{err.Error()},
-						Success:  false,
-					},
-				})
+				// Set custom claims to context so handler can use the values, i.e. authorization
+				ctx.Set(string(utils.JWTClaimUserID), claims.UserID)
+				ctx.Set(string(utils.JWTClaimPermissions), claims.Permissions)
 			}
 
-			// Set custom claims to context so handler can use the values, i.e. authorization
-			ctx.Set(string(utils.JWTClaimUserID), claims.UserID)
-			ctx.Set(string(utils.JWTClaimPermissions), claims.Permissions)
+			return next(ctx)
 		}
-
-		return next(ctx)
 	}
 }
 
-// AuthenticatedMiddleware generates JWT (RS256 algorithm) using private key.
+// NewAuthenticatedMiddleware returns a middleware that generates JWT (RS256 algorithm) using private key.
 // The JWT will contain userID in its claims.
 // The JWT will be included in the `Authentication` response header only if handler is returning status OK.
 // See command in Dockerfile: openssl genrsa -out /tmp/rsa 4096
-func AuthenticatedMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(ctx echo.Context) error {
-		// Add endpoints in the `whitelistedEndpoints` map to return JWT with RS256 algorithm
-		whitelistedEndpoints := map[string]bool{
-			"POST - /v1/user/login": true,
-		}
+func NewAuthenticatedMiddleware(privateKey *rsa.PrivateKey) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			// Add endpoints in the `whitelistedEndpoints` map to return JWT with RS256 algorithm, along with the status
+			// code the endpoint returns on success
+			whitelistedEndpoints := map[string]int{
+				"POST - /v1/user/login": http.StatusOK,
+				"POST - /v2/sessions":   http.StatusCreated,
+			}
 
-		endpoint := fmt.Sprintf("%s - %s", ctx.Request().Method, ctx.Request().URL.Path)
-		if whitelistedEndpoints[endpoint] {
-			// use `Before` hook so middleware can write token to the response header right before handler writes to response body
-			ctx.Response().Before(func() {
-				privateKeyData, err := os.ReadFile("../rsa")
-				if err != nil {
-					return
-				}
+			endpoint := fmt.Sprintf("%s - %s", ctx.Request().Method, ctx.Path())
+			if successStatus, ok := whitelistedEndpoints[endpoint]; ok && privateKey != nil {
+				// use `Before` hook so middleware can write token to the response header right before handler writes to response body
+				ctx.Response().Before(func() {
+					// Authorize JWT
+					permissions, ok := ctx.Get(string(utils.JWTClaimPermissions)).([]utils.JWTPermission)
+					if !ok {
+						return
+					}
 
-				privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyData)
-				if err != nil {
-					return
-				}
+					userID, ok := ctx.Get(string(utils.JWTClaimUserID)).(int64)
+					if !ok {
+						return
+					}
 
-				// Authorize JWT
-				permissions, ok := ctx.Get(string(utils.JWTClaimPermissions)).([]utils.JWTPermission)
-				if !ok {
-					return
-				}
+					sessionID, ok := ctx.Get(string(utils.JWTClaimSessionID)).(string)
+					if !ok {
+						return
+					}
 
-				userID, ok := ctx.Get(string(utils.JWTClaimUserID)).(int64)
-				if !ok {
-					return
-				}
+					expiresAt, ok := ctx.Get(string(utils.JWTClaimExpiresAt)).(time.Time)
+					if !ok {
+						return
+					}
 
-				claims := utils.CustomClaims{
-					UserID:      userID,
-					Permissions: permissions,
-					ExpiresAt:   time.Now().Add(jwtExpiryDuration).Unix(),
-				}
+					jwtToken, err := utils.NewToken(privateKey, utils.CustomClaims{
+						UserID:      userID,
+						Permissions: permissions,
+						ExpiresAt:   expiresAt.Unix(),
+						SessionID:   sessionID,
+					})
+					if err != nil {
+						return
+					}
 
-				token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-				jwtToken, err := token.SignedString(privateKey) // sign the token with the RSA private key
-				if err != nil {
-					return
-				}
+					bearerToken := fmt.Sprintf("Bearer %s", jwtToken)
 
-				bearerToken := fmt.Sprintf("Bearer %s", jwtToken)
+					// Only add JWT in `Authorization` response header if handler succeeds
+					if ctx.Response().Status == successStatus {
+						ctx.Response().Header().Set(echo.HeaderAuthorization, bearerToken)
+					}
+				})
+			}
 
-				// Only add JWT in `Authorization` response header if handler returns OK
-				if ctx.Response().Status == http.StatusOK {
-					ctx.Response().Header().Set(echo.HeaderAuthorization, bearerToken)
-				}
-			})
+			return next(ctx)
 		}
-
-		return next(ctx)
 	}
 }
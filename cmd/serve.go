@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type serveOptions struct {
+	// HTTPServer serves the HTTP API on HTTPListener
+	HTTPServer   *http.Server
+	HTTPListener net.Listener
+
+	// GRPCServer serves the gRPC API on GRPCListener
+	GRPCServer   *grpc.Server
+	GRPCListener net.Listener
+
+	// Workers run in the background until shutdown, when their context is cancelled
+	Workers []func(ctx context.Context)
+
+	// Flushers run once the servers and workers stopped, to handle the work left by the last requests
+	Flushers []func(ctx context.Context) error
+
+	// Closers are closed last, i.e. the database connection pool
+	Closers []io.Closer
+
+	// OnShutdown are called as soon as the shutdown starts, i.e. to make the readiness probe fail
+	OnShutdown []func()
+
+	// ShutdownDelay is how long the servers keep accepting connections after OnShutdown, so load balancers notice the
+	// failing readiness probe and stop sending requests first
+	ShutdownDelay time.Duration
+
+	// ShutdownTimeout bounds how long the servers, workers and flushers are drained for
+	ShutdownTimeout time.Duration
+
+	// Signals trigger the shutdown
+	Signals []os.Signal
+}
+
+// serve runs the servers and workers until one of the signals is received or a server fails, then shuts them down
+// gracefully: the servers stop accepting connections and in-flight requests are drained, workers are stopped and
+// flushed, and the closers are closed. Requests that are not done within the shutdown timeout are cut off.
+func serve(opts serveOptions) error {
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), opts.Signals...)
+	defer stopSignals()
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	for _, worker := range opts.Workers {
+		workers.Add(1)
+		go func(worker func(ctx context.Context)) {
+			defer workers.Done()
+			worker(workersCtx)
+		}(worker)
+	}
+
+	serveErrs := make(chan error, 2)
+	go func() {
+		if err := opts.HTTPServer.Serve(opts.HTTPListener); !errors.Is(err, http.ErrServerClosed) {
+			serveErrs <- fmt.Errorf("HTTP server failed: %w", err)
+		}
+	}()
+	go func() {
+		if err := opts.GRPCServer.Serve(opts.GRPCListener); err != nil {
+			serveErrs <- fmt.Errorf("gRPC server failed: %w", err)
+		}
+	}()
+
+	var errs []error
+	select {
+	case <-signalCtx.Done():
+		slog.Info("shutting down", "shutdown_timeout", opts.ShutdownTimeout.String())
+	case err := <-serveErrs:
+		errs = append(errs, err)
+		slog.Error("shutting down", "error", err)
+	}
+
+	// A second signal kills the process instead of waiting for the drain
+	stopSignals()
+
+	for _, onShutdown := range opts.OnShutdown {
+		onShutdown()
+	}
+	time.Sleep(opts.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	defer cancel()
+
+	// Workers stop while the requests drain, the work left by the requests is flushed afterwards
+	stopWorkers()
+
+	if err := opts.HTTPServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain HTTP requests: %w", err))
+		opts.HTTPServer.Close()
+	}
+	if err := stopGRPCServer(ctx, opts.GRPCServer); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain gRPC requests: %w", err))
+	}
+
+	if err := wait(ctx, &workers); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop workers: %w", err))
+	}
+
+	for _, flush := range opts.Flushers {
+		if err := flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush worker: %w", err))
+		}
+	}
+
+	for _, closer := range opts.Closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stopGRPCServer waits for in-flight RPCs until ctx is done, then cancels the remaining ones
+func stopGRPCServer(ctx context.Context, server *grpc.Server) error {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		return ctx.Err()
+	}
+}
+
+// wait waits for the group until ctx is done
+func wait(ctx context.Context, group *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// The group may be done already when ctx expired during an earlier step
+		select {
+		case <-done:
+			return nil
+		default:
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func Test_serve(t *testing.T) {
+	tests := []struct {
+		name            string
+		requestDuration time.Duration
+		shutdownTimeout time.Duration
+
+		wantStatus int
+		wantSteps  []string
+		wantErr    string
+	}{
+		{
+			name:            "success-drains-slow-request",
+			requestDuration: 300 * time.Millisecond,
+			shutdownTimeout: 5 * time.Second,
+			wantStatus:      http.StatusOK,
+			wantSteps:       []string{"not ready", "worker stopped", "request done", "flushed", "closed"},
+		},
+		{
+			name:            "fail-request-exceeds-shutdown-timeout",
+			requestDuration: 5 * time.Second,
+			shutdownTimeout: 100 * time.Millisecond,
+			wantSteps:       []string{"not ready", "worker stopped", "flushed", "closed"},
+			wantErr:         "failed to drain HTTP requests",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var steps []string
+			step := func(name string) {
+				mu.Lock()
+				defer mu.Unlock()
+				steps = append(steps, name)
+			}
+
+			started := make(chan struct{})
+			mux := http.NewServeMux()
+			mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(test.requestDuration):
+					step("request done")
+					w.Write([]byte("done"))
+				case <-r.Context().Done():
+				}
+			})
+
+			httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("net.Listen() err = %v", err)
+			}
+			grpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("net.Listen() err = %v", err)
+			}
+
+			serveErr := make(chan error, 1)
+			go func() {
+				serveErr <- serve(serveOptions{
+					HTTPServer:   &http.Server{Handler: mux},
+					HTTPListener: httpListener,
+					GRPCServer:   grpc.NewServer(),
+					GRPCListener: grpcListener,
+					Workers: []func(ctx context.Context){
+						func(ctx context.Context) {
+							<-ctx.Done()
+							step("worker stopped")
+						},
+					},
+					Flushers: []func(ctx context.Context) error{
+						func(ctx context.Context) error {
+							step("flushed")
+							return nil
+						},
+					},
+					Closers: []io.Closer{
+						closerFunc(func() error {
+							step("closed")
+							return nil
+						}),
+					},
+					OnShutdown: []func(){
+						func() { step("not ready") },
+					},
+					ShutdownDelay:   10 * time.Millisecond,
+					ShutdownTimeout: test.shutdownTimeout,
+					Signals:         []os.Signal{syscall.SIGTERM},
+				})
+			}()
+
+			type result struct {
+				status int
+				body   string
+				err    error
+			}
+			results := make(chan result, 1)
+			go func() {
+				response, err := http.Get("http://" + httpListener.Addr().String() + "/slow")
+				if err != nil {
+					results <- result{err: err}
+					return
+				}
+				defer response.Body.Close()
+
+				body, err := io.ReadAll(response.Body)
+				results <- result{status: response.StatusCode, body: string(body), err: err}
+			}()
+
+			// Signal once the request is in flight
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("request did not reach the handler")
+			}
+			if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+				t.Fatalf("syscall.Kill() err = %v", err)
+			}
+
+			var gotErr error
+			select {
+			case gotErr = <-serveErr:
+			case <-time.After(10 * time.Second):
+				t.Fatal("serve() did not return")
+			}
+			if (gotErr == nil) != (test.wantErr == "") || (gotErr != nil && !strings.Contains(gotErr.Error(), test.wantErr)) {
+				t.Errorf("serve() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			got := <-results
+			if test.wantStatus != 0 {
+				if got.err != nil || got.status != test.wantStatus || got.body != "done" {
+					t.Errorf("in-flight request got = %+v, want status %v", got, test.wantStatus)
+				}
+			} else if got.err == nil {
+				t.Errorf("in-flight request got = %+v, want it cut off", got)
+			}
+
+			// New connections are refused once shut down
+			if _, err := http.Get("http://" + httpListener.Addr().String() + "/slow"); err == nil {
+				t.Errorf("request after shutdown err = nil, want connection refused")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(steps, test.wantSteps) {
+				t.Errorf("serve() steps = %v, want %v", steps, test.wantSteps)
+			}
+		})
+	}
+}
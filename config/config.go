@@ -0,0 +1,484 @@
+// Package config loads the settings of the service. Settings are read from, in increasing precedence: the defaults,
+// a YAML file, environment variables and command line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/UserService/i18n"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the value of secret settings when the config is printed
+const redacted = "REDACTED"
+
+var phoneNumberPrefixPattern = regexp.MustCompile(`^\+[0-9]+$`)
+
+// Config is the typed configuration of the service, see config.example.yaml for the layout of the YAML file.
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Account     AccountConfig     `yaml:"account"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Validation  ValidationConfig  `yaml:"validation"`
+	I18n        I18nConfig        `yaml:"i18n"`
+	OpenAPI     OpenAPIConfig     `yaml:"openapi"`
+	Docs        DocsConfig        `yaml:"docs"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Log         LogConfig         `yaml:"log"`
+	Webhook     WebhookConfig     `yaml:"webhook"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Export      ExportConfig      `yaml:"export"`
+}
+
+type ServerConfig struct {
+	HTTPAddress string `yaml:"http_address"`
+	GRPCAddress string `yaml:"grpc_address"`
+
+	// ShutdownDelay is how long connections are still accepted on shutdown while the readiness probe fails, so load
+	// balancers stop sending requests first
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+
+	// ShutdownTimeout bounds how long in-flight requests and background workers are drained for on shutdown
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type DatabaseConfig struct {
+	URL string `yaml:"url"`
+}
+
+type AuthConfig struct {
+	PrivateKeyPath string        `yaml:"private_key_path"`
+	PublicKeyPath  string        `yaml:"public_key_path"`
+	TokenExpiry    time.Duration `yaml:"token_expiry"`
+	BcryptCost     int           `yaml:"bcrypt_cost"`
+
+	// InternalToken authenticates the internal-only gRPC RPCs, which are disabled when it is empty
+	InternalToken string `yaml:"internal_token"`
+}
+
+type AccountConfig struct {
+	// DeletionGracePeriod is how long deleted accounts can be restored for, before their personal data is erased
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period"`
+}
+
+type AdminConfig struct {
+	// Token authenticates the admin API, which is disabled when it is empty
+	Token string `yaml:"token"`
+}
+
+// ValidationConfig are the rules user input is validated against, lengths are in characters
+type ValidationConfig struct {
+	PhoneNumberPrefix    string `yaml:"phone_number_prefix"`
+	PhoneNumberMinLength int    `yaml:"phone_number_min_length"`
+	PhoneNumberMaxLength int    `yaml:"phone_number_max_length"`
+	FullNameMinLength    int    `yaml:"full_name_min_length"`
+	FullNameMaxLength    int    `yaml:"full_name_max_length"`
+	PasswordMinLength    int    `yaml:"password_min_length"`
+	PasswordMaxLength    int    `yaml:"password_max_length"`
+
+	// FullNameScripts are the comma separated Unicode scripts the letters of full names are written in, i.e.
+	// `Latin,Han`. Full names can be written in any script when it is empty.
+	FullNameScripts string `yaml:"full_name_scripts"`
+}
+
+type I18nConfig struct {
+	DefaultLocale string `yaml:"default_locale"`
+}
+
+type OpenAPIConfig struct {
+	ValidateResponses bool `yaml:"validate_responses"`
+}
+
+type DocsConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	ServerURL string `yaml:"server_url"`
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type MetricsConfig struct {
+	// Enabled serves the metrics at /metrics in the Prometheus text format
+	Enabled bool `yaml:"enabled"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp. Spans are not recorded with none, but the trace context is still propagated
+	Exporter    string `yaml:"exporter"`
+	ServiceName string `yaml:"service_name"`
+
+	// OTLPEndpoint is the host and port of the OTLP/HTTP collector, defaults to OTEL_EXPORTER_OTLP_ENDPOINT
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	OTLPInsecure bool   `yaml:"otlp_insecure"`
+}
+
+type LogConfig struct {
+	// Level is the minimum level of the logs: debug, info, warn or error
+	Level string `yaml:"level"`
+}
+
+type WebhookConfig struct {
+	MaxAttempts  int           `yaml:"max_attempts"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+type OutboxConfig struct {
+	Publisher    string        `yaml:"publisher"`
+	File         string        `yaml:"file"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Retention    time.Duration `yaml:"retention"`
+}
+
+type ExportConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+
+	// Retention is how long the archives of exports can be downloaded for before they are deleted
+	Retention time.Duration `yaml:"retention"`
+
+	// LinkExpiry is how long the signed download links of archives are valid for
+	LinkExpiry time.Duration `yaml:"link_expiry"`
+}
+
+// Flags are the command line flags that are not settings
+type Flags struct {
+	// File is the YAML file settings are read from, also set with CONFIG_FILE
+	File string
+
+	// PrintConfig prints the loaded config, with secrets redacted, instead of starting the service
+	PrintConfig bool
+
+	// Command is the first argument after the flags, i.e. verify-audit-log, run instead of starting the service
+	Command string
+
+	// CommandArgs are the arguments after the command, i.e. the flags of the command
+	CommandArgs []string
+}
+
+// Default returns the config used for settings that are not set
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			HTTPAddress:     ":1323",
+			GRPCAddress:     ":50051",
+			ShutdownDelay:   5 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Auth: AuthConfig{
+			// Keys are generated by the Dockerfile
+			PrivateKeyPath: "../rsa",
+			PublicKeyPath:  "../rsa.pub",
+			TokenExpiry:    30 * time.Minute,
+			BcryptCost:     12,
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: 30 * 24 * time.Hour,
+		},
+		Validation: ValidationConfig{
+			PhoneNumberPrefix:    "+62",
+			PhoneNumberMinLength: 10,
+			PhoneNumberMaxLength: 13,
+			FullNameMinLength:    3,
+			FullNameMaxLength:    60,
+			PasswordMinLength:    6,
+			PasswordMaxLength:    64,
+		},
+		I18n: I18nConfig{
+			DefaultLocale: i18n.DefaultLocale,
+		},
+		Docs: DocsConfig{
+			Enabled: true,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "user-service",
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:  8,
+			PollInterval: 5 * time.Second,
+		},
+		Outbox: OutboxConfig{
+			Publisher:    "stdout",
+			File:         "outbox.jsonl",
+			PollInterval: time.Second,
+			Retention:    7 * 24 * time.Hour,
+		},
+		Export: ExportConfig{
+			PollInterval: 5 * time.Second,
+			Retention:    24 * time.Hour,
+			LinkExpiry:   15 * time.Minute,
+		},
+	}
+}
+
+// Load loads the config from the YAML file, the environment variables returned by lookupEnv and the command line
+// flags in args, i.e. os.Args[1:]. The config is not validated, see Validate.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, Flags, error) {
+	var flags Flags
+
+	// Flags are parsed first to find the YAML file, and applied last as they take precedence
+	fs := flag.NewFlagSet("main", flag.ContinueOnError)
+	fs.StringVar(&flags.File, "config", "", "YAML file to read settings from, also set with CONFIG_FILE")
+	fs.BoolVar(&flags.PrintConfig, "print-config", false, "print the config, with secrets redacted, and exit")
+
+	parsed := Default()
+	for _, s := range settings(&parsed) {
+		fs.Var(s.value, s.flag, fmt.Sprintf("%s, also set with %s", s.usage, s.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, flags, err
+	}
+	flags.Command = fs.Arg(0)
+	if fs.NArg() > 1 {
+		flags.CommandArgs = fs.Args()[1:]
+	}
+
+	if flags.File == "" {
+		flags.File, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	cfg := Default()
+	if flags.File != "" {
+		if err := loadFile(&cfg, flags.File); err != nil {
+			return Config{}, flags, err
+		}
+	}
+
+	cfgSettings := settings(&cfg)
+	for _, s := range cfgSettings {
+		value, ok := lookupEnv(s.env)
+		if !ok {
+			continue
+		}
+
+		if err := s.value.Set(value); err != nil {
+			return Config{}, flags, fmt.Errorf("invalid value %q for %s: %w", value, s.env, err)
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range cfgSettings {
+			if s.flag == f.Name && flagErr == nil {
+				flagErr = s.value.Set(f.Value.String())
+			}
+		}
+	})
+	if flagErr != nil {
+		return Config{}, flags, flagErr
+	}
+
+	return cfg, flags, nil
+}
+
+// loadFile overrides the settings of cfg that are set in the YAML file at path
+func loadFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate returns an error listing every invalid setting, so the service fails at startup instead of when the
+// setting is used
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.HTTPAddress); err != nil {
+		invalid("server.http_address: %v", err)
+	}
+	if _, _, err := net.SplitHostPort(c.Server.GRPCAddress); err != nil {
+		invalid("server.grpc_address: %v", err)
+	}
+	// Port 0 picks a free port for each server
+	if _, port, _ := net.SplitHostPort(c.Server.HTTPAddress); c.Server.HTTPAddress == c.Server.GRPCAddress && port != "0" {
+		invalid("server.grpc_address: should differ from server.http_address")
+	}
+	if c.Server.ShutdownDelay < 0 {
+		invalid("server.shutdown_delay: should not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("server.shutdown_timeout: should be positive")
+	}
+
+	if c.Database.URL == "" {
+		invalid("database.url: is required")
+	}
+
+	if c.Auth.PrivateKeyPath == "" {
+		invalid("auth.private_key_path: is required")
+	}
+	if c.Auth.PublicKeyPath == "" {
+		invalid("auth.public_key_path: is required")
+	}
+	if c.Auth.TokenExpiry <= 0 {
+		invalid("auth.token_expiry: should be positive")
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		invalid("auth.bcrypt_cost: should be %d to %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if c.Account.DeletionGracePeriod <= 0 {
+		invalid("account.deletion_grace_period: should be positive")
+	}
+
+	if !phoneNumberPrefixPattern.MatchString(c.Validation.PhoneNumberPrefix) {
+		invalid("validation.phone_number_prefix: should be + followed by digits, got %q", c.Validation.PhoneNumberPrefix)
+	}
+	lengths := []struct {
+		name     string
+		min, max int
+	}{
+		{"phone_number", c.Validation.PhoneNumberMinLength, c.Validation.PhoneNumberMaxLength},
+		{"full_name", c.Validation.FullNameMinLength, c.Validation.FullNameMaxLength},
+		{"password", c.Validation.PasswordMinLength, c.Validation.PasswordMaxLength},
+	}
+	for _, length := range lengths {
+		if length.min <= 0 || length.min > length.max {
+			invalid("validation.%s_min_length: should be positive and at most %s_max_length", length.name, length.name)
+		}
+	}
+	if c.Validation.PhoneNumberMaxLength <= len(c.Validation.PhoneNumberPrefix) {
+		invalid("validation.phone_number_max_length: should leave room for digits after phone_number_prefix")
+	}
+	// bcrypt ignores the bytes of passwords after the 72nd
+	if c.Validation.PasswordMaxLength > 72 {
+		invalid("validation.password_max_length: should be at most 72")
+	}
+	if c.Validation.FullNameScripts != "" {
+		for _, script := range strings.Split(c.Validation.FullNameScripts, ",") {
+			if _, ok := unicode.Scripts[strings.TrimSpace(script)]; !ok {
+				invalid("validation.full_name_scripts: unknown Unicode script %q", strings.TrimSpace(script))
+			}
+		}
+	}
+
+	if _, err := i18n.NewCatalog(c.I18n.DefaultLocale); err != nil {
+		invalid("i18n.default_locale: %v", err)
+	}
+
+	if c.Docs.ServerURL != "" {
+		if u, err := url.Parse(c.Docs.ServerURL); err != nil || !u.IsAbs() {
+			invalid("docs.server_url: should be an absolute URL")
+		}
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		invalid("idempotency.ttl: should be positive")
+	}
+
+	if c.Webhook.MaxAttempts <= 0 {
+		invalid("webhook.max_attempts: should be positive")
+	}
+	if c.Webhook.PollInterval <= 0 {
+		invalid("webhook.poll_interval: should be positive")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		invalid("tracing.exporter: should be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.ServiceName == "" {
+		invalid("tracing.service_name: is required")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		invalid("log.level: should be debug, info, warn or error, got %q", c.Log.Level)
+	}
+
+	switch c.Outbox.Publisher {
+	case "stdout":
+	case "file":
+		if c.Outbox.File == "" {
+			invalid("outbox.file: is required by the file publisher")
+		}
+	default:
+		invalid("outbox.publisher: should be stdout or file, got %q", c.Outbox.Publisher)
+	}
+	if c.Outbox.PollInterval <= 0 {
+		invalid("outbox.poll_interval: should be positive")
+	}
+	if c.Outbox.Retention <= 0 {
+		invalid("outbox.retention: should be positive")
+	}
+
+	if c.Export.PollInterval <= 0 {
+		invalid("export.poll_interval: should be positive")
+	}
+	if c.Export.Retention <= 0 {
+		invalid("export.retention: should be positive")
+	}
+	// Links outliving the archive would only lead to export_expired
+	if c.Export.LinkExpiry <= 0 || c.Export.LinkExpiry > c.Export.Retention {
+		invalid("export.link_expiry: should be positive and at most export.retention")
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the config whose secrets are replaced, so it can be printed or logged
+func (c Config) Redacted() Config {
+	databaseURL := c.Database.URL
+	for _, s := range settings(&c) {
+		if s.secret && s.value.String() != "" {
+			_ = s.value.Set(redacted)
+		}
+	}
+
+	// Keep the non-secret parts of the database URL, they are useful to debug connection issues
+	if u, err := url.Parse(databaseURL); err == nil && u.User != nil && !u.Query().Has("password") {
+		c.Database.URL = u.Redacted()
+	}
+
+	return c
+}
+
+// WriteRedacted writes the config as YAML to w, with its secrets redacted
+func (c Config) WriteRedacted(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,404 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+server:
+  http_address: ":8080"
+  grpc_address: ":9090"
+auth:
+  token_expiry: 1h
+validation:
+  phone_number_prefix: "+65"
+outbox:
+  publisher: file
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() err = %v", err)
+	}
+
+	invalidPath := filepath.Join(t.TempDir(), "invalid.yaml")
+	if err := os.WriteFile(invalidPath, []byte("server:\n  port: 8080\n"), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() err = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		env       map[string]string
+		want      func(*Config)
+		wantFlags Flags
+		wantErr   string
+	}{
+		{
+			name: "defaults",
+			want: func(*Config) {},
+		},
+		{
+			name: "file overrides defaults",
+			args: []string{"--config", path},
+			want: func(cfg *Config) {
+				cfg.Server.HTTPAddress = ":8080"
+				cfg.Server.GRPCAddress = ":9090"
+				cfg.Auth.TokenExpiry = time.Hour
+				cfg.Validation.PhoneNumberPrefix = "+65"
+				cfg.Outbox.Publisher = "file"
+			},
+			wantFlags: Flags{File: path},
+		},
+		{
+			name: "file from environment",
+			env:  map[string]string{"CONFIG_FILE": path},
+			want: func(cfg *Config) {
+				cfg.Server.HTTPAddress = ":8080"
+				cfg.Server.GRPCAddress = ":9090"
+				cfg.Auth.TokenExpiry = time.Hour
+				cfg.Validation.PhoneNumberPrefix = "+65"
+				cfg.Outbox.Publisher = "file"
+			},
+			wantFlags: Flags{File: path},
+		},
+		{
+			name: "environment overrides file",
+			args: []string{"--config", path},
+			env: map[string]string{
+				"HTTP_ADDRESS":     ":8081",
+				"TOKEN_EXPIRY":     "2h",
+				"API_DOCS_ENABLED": "false",
+				"DATABASE_URL":     "postgres://postgres:postgres@db:5432/database",
+			},
+			want: func(cfg *Config) {
+				cfg.Server.HTTPAddress = ":8081"
+				cfg.Server.GRPCAddress = ":9090"
+				cfg.Auth.TokenExpiry = 2 * time.Hour
+				cfg.Validation.PhoneNumberPrefix = "+65"
+				cfg.Outbox.Publisher = "file"
+				cfg.Docs.Enabled = false
+				cfg.Database.URL = "postgres://postgres:postgres@db:5432/database"
+			},
+			wantFlags: Flags{File: path},
+		},
+		{
+			name: "flags override environment",
+			args: []string{"--config", path, "--http-address", ":8082", "--bcrypt-cost=10", "--api-docs-enabled", "--print-config", "verify-audit-log"},
+			env: map[string]string{
+				"HTTP_ADDRESS":     ":8081",
+				"BCRYPT_COST":      "4",
+				"API_DOCS_ENABLED": "false",
+			},
+			want: func(cfg *Config) {
+				cfg.Server.HTTPAddress = ":8082"
+				cfg.Server.GRPCAddress = ":9090"
+				cfg.Auth.TokenExpiry = time.Hour
+				cfg.Validation.PhoneNumberPrefix = "+65"
+				cfg.Auth.BcryptCost = 10
+				cfg.Outbox.Publisher = "file"
+			},
+			wantFlags: Flags{File: path, PrintConfig: true, Command: "verify-audit-log"},
+		},
+		{
+			name: "command arguments",
+			args: []string{"--config", path, "purge", "--dry-run"},
+			want: func(cfg *Config) {
+				cfg.Server.HTTPAddress = ":8080"
+				cfg.Server.GRPCAddress = ":9090"
+				cfg.Auth.TokenExpiry = time.Hour
+				cfg.Validation.PhoneNumberPrefix = "+65"
+				cfg.Outbox.Publisher = "file"
+			},
+			wantFlags: Flags{File: path, Command: "purge", CommandArgs: []string{"--dry-run"}},
+		},
+		{
+			name:    "missing file",
+			args:    []string{"--config", filepath.Join(t.TempDir(), "missing.yaml")},
+			wantErr: "failed to open config file",
+		},
+		{
+			name:    "unknown field in file",
+			args:    []string{"--config", invalidPath},
+			wantErr: "field port not found",
+		},
+		{
+			name:    "invalid environment variable",
+			env:     map[string]string{"TOKEN_EXPIRY": "30"},
+			wantErr: "TOKEN_EXPIRY",
+		},
+		{
+			name:    "invalid flag",
+			args:    []string{"--bcrypt-cost", "high"},
+			wantErr: "bcrypt-cost",
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"--port", "8080"},
+			wantErr: "flag provided but not defined",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lookupEnv := func(key string) (string, bool) {
+				value, ok := test.env[key]
+				return value, ok
+			}
+
+			got, gotFlags, err := Load(test.args, lookupEnv)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("config.Load() err = %v, wantErr %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("config.Load() err = %v", err)
+			}
+
+			want := Default()
+			test.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("config.Load() got = %+v, want %+v", got, want)
+			}
+			if !reflect.DeepEqual(gotFlags, test.wantFlags) {
+				t.Errorf("config.Load() gotFlags = %+v, wantFlags %+v", gotFlags, test.wantFlags)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Default()
+	valid.Database.URL = "postgres://postgres:postgres@db:5432/database"
+
+	tests := []struct {
+		name       string
+		modify     func(*Config)
+		wantErrors []string
+	}{
+		{
+			name:   "valid",
+			modify: func(*Config) {},
+		},
+		{
+			name: "file publisher",
+			modify: func(cfg *Config) {
+				cfg.Outbox.Publisher = "file"
+			},
+		},
+		{
+			name: "missing database",
+			modify: func(cfg *Config) {
+				cfg.Database.URL = ""
+			},
+			wantErrors: []string{"database.url"},
+		},
+		{
+			name: "invalid addresses",
+			modify: func(cfg *Config) {
+				cfg.Server.HTTPAddress = "1323"
+				cfg.Server.GRPCAddress = "1323"
+				cfg.Server.ShutdownDelay = -time.Second
+				cfg.Server.ShutdownTimeout = 0
+			},
+			wantErrors: []string{"server.http_address", "server.grpc_address", "should differ", "server.shutdown_delay", "server.shutdown_timeout"},
+		},
+		{
+			name: "invalid auth",
+			modify: func(cfg *Config) {
+				cfg.Auth.PrivateKeyPath = ""
+				cfg.Auth.TokenExpiry = 0
+				cfg.Auth.BcryptCost = 50
+			},
+			wantErrors: []string{"auth.private_key_path", "auth.token_expiry", "auth.bcrypt_cost"},
+		},
+		{
+			name: "invalid validation rules",
+			modify: func(cfg *Config) {
+				cfg.Validation.PhoneNumberPrefix = "62"
+				cfg.Validation.FullNameMinLength = 0
+				cfg.Validation.PasswordMinLength = 10
+				cfg.Validation.PasswordMaxLength = 8
+			},
+			wantErrors: []string{"validation.phone_number_prefix", "validation.full_name_min_length", "validation.password_min_length"},
+		},
+		{
+			name: "password longer than bcrypt supports",
+			modify: func(cfg *Config) {
+				cfg.Validation.PasswordMaxLength = 100
+			},
+			wantErrors: []string{"validation.password_max_length"},
+		},
+		{
+			name: "unknown full name script",
+			modify: func(cfg *Config) {
+				cfg.Validation.FullNameScripts = "Latin, Klingon"
+			},
+			wantErrors: []string{`validation.full_name_scripts: unknown Unicode script "Klingon"`},
+		},
+		{
+			name: "unsupported locale",
+			modify: func(cfg *Config) {
+				cfg.I18n.DefaultLocale = "xx"
+			},
+			wantErrors: []string{"i18n.default_locale"},
+		},
+		{
+			name: "relative server URL",
+			modify: func(cfg *Config) {
+				cfg.Docs.ServerURL = "/api"
+			},
+			wantErrors: []string{"docs.server_url"},
+		},
+		{
+			name: "invalid background jobs",
+			modify: func(cfg *Config) {
+				cfg.Account.DeletionGracePeriod = 0
+				cfg.Idempotency.TTL = -time.Hour
+				cfg.Webhook.MaxAttempts = 0
+				cfg.Outbox.Publisher = "kafka"
+				cfg.Outbox.Retention = 0
+			},
+			wantErrors: []string{"account.deletion_grace_period", "idempotency.ttl", "webhook.max_attempts", "outbox.publisher", "outbox.retention"},
+		},
+		{
+			name: "export links outliving archives",
+			modify: func(cfg *Config) {
+				cfg.Export.PollInterval = 0
+				cfg.Export.LinkExpiry = 2 * cfg.Export.Retention
+			},
+			wantErrors: []string{"export.poll_interval", "export.link_expiry"},
+		},
+		{
+			name: "invalid observability",
+			modify: func(cfg *Config) {
+				cfg.Tracing.Exporter = "jaeger"
+				cfg.Tracing.ServiceName = ""
+				cfg.Log.Level = "verbose"
+			},
+			wantErrors: []string{"tracing.exporter", "tracing.service_name", "log.level"},
+		},
+		{
+			name: "file publisher without file",
+			modify: func(cfg *Config) {
+				cfg.Outbox.Publisher = "file"
+				cfg.Outbox.File = ""
+			},
+			wantErrors: []string{"outbox.file"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := valid
+			test.modify(&cfg)
+
+			err := cfg.Validate()
+			if len(test.wantErrors) == 0 {
+				if err != nil {
+					t.Errorf("config.Validate() err = %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("config.Validate() err = nil, want %v", test.wantErrors)
+			}
+			for _, wantError := range test.wantErrors {
+				if !strings.Contains(err.Error(), wantError) {
+					t.Errorf("config.Validate() err = %v, want to contain %v", err, wantError)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	tests := []struct {
+		name            string
+		databaseURL     string
+		adminToken      string
+		wantDatabaseURL string
+		wantAdminToken  string
+	}{
+		{
+			name:            "password in URL",
+			databaseURL:     "postgres://postgres:secret@db:5432/database?sslmode=disable",
+			adminToken:      "admin",
+			wantDatabaseURL: "postgres://postgres:xxxxx@db:5432/database?sslmode=disable",
+			wantAdminToken:  redacted,
+		},
+		{
+			name:            "password in query",
+			databaseURL:     "postgres://postgres@db:5432/database?password=secret",
+			wantDatabaseURL: redacted,
+		},
+		{
+			name:            "key value DSN",
+			databaseURL:     "host=db user=postgres password=secret",
+			wantDatabaseURL: redacted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Database.URL = test.databaseURL
+			cfg.Admin.Token = test.adminToken
+
+			got := cfg.Redacted()
+			if got.Database.URL != test.wantDatabaseURL {
+				t.Errorf("config.Redacted() Database.URL = %v, want %v", got.Database.URL, test.wantDatabaseURL)
+			}
+			if got.Admin.Token != test.wantAdminToken {
+				t.Errorf("config.Redacted() Admin.Token = %v, want %v", got.Admin.Token, test.wantAdminToken)
+			}
+
+			// The config itself is left untouched
+			if cfg.Database.URL != test.databaseURL || cfg.Admin.Token != test.adminToken {
+				t.Errorf("config.Redacted() modified the config")
+			}
+		})
+	}
+}
+
+func TestConfig_WriteRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Admin.Token = "admin-token"
+
+	var buffer bytes.Buffer
+	if err := cfg.WriteRedacted(&buffer); err != nil {
+		t.Fatalf("config.WriteRedacted() err = %v", err)
+	}
+
+	output := buffer.String()
+	if strings.Contains(output, "admin-token") {
+		t.Errorf("config.WriteRedacted() output contains the admin token:\n%s", output)
+	}
+	for _, want := range []string{"token: " + redacted, "token_expiry: 30m0s", "http_address: :1323"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("config.WriteRedacted() output does not contain %q:\n%s", want, output)
+		}
+	}
+
+	// The printed config can be loaded back
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, buffer.Bytes(), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() err = %v", err)
+	}
+	loaded, _, err := Load([]string{"--config", path}, func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatalf("config.Load() err = %v", err)
+	}
+	if loaded.Auth != cfg.Auth || loaded.Outbox != cfg.Outbox {
+		t.Errorf("config.Load() got = %+v, want %+v", loaded, cfg)
+	}
+}
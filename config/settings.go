@@ -0,0 +1,114 @@
+package config
+
+import (
+	"flag"
+	"strconv"
+	"time"
+)
+
+// setting is a setting of Config that can be set with an environment variable and a command line flag
+type setting struct {
+	flag   string
+	env    string
+	usage  string
+	secret bool
+	value  flag.Value
+}
+
+// settings returns the settings of cfg, whose values point into cfg.
+// Add new fields of Config here so they can be set from the environment and the command line.
+func settings(cfg *Config) []setting {
+	return []setting{
+		{flag: "http-address", env: "HTTP_ADDRESS", usage: "address the HTTP API listens on", value: (*stringValue)(&cfg.Server.HTTPAddress)},
+		{flag: "grpc-address", env: "GRPC_ADDRESS", usage: "address the gRPC API listens on", value: (*stringValue)(&cfg.Server.GRPCAddress)},
+		{flag: "shutdown-delay", env: "SHUTDOWN_DELAY", usage: "how long connections are still accepted on shutdown while not ready", value: (*durationValue)(&cfg.Server.ShutdownDelay)},
+		{flag: "shutdown-timeout", env: "SHUTDOWN_TIMEOUT", usage: "how long in-flight requests are drained for on shutdown", value: (*durationValue)(&cfg.Server.ShutdownTimeout)},
+		{flag: "database-url", env: "DATABASE_URL", usage: "URL of the PostgreSQL database", secret: true, value: (*stringValue)(&cfg.Database.URL)},
+		{flag: "private-key", env: "PRIVATE_KEY_PATH", usage: "RSA private key signing tokens", value: (*stringValue)(&cfg.Auth.PrivateKeyPath)},
+		{flag: "public-key", env: "PUBLIC_KEY_PATH", usage: "RSA public key verifying tokens", value: (*stringValue)(&cfg.Auth.PublicKeyPath)},
+		{flag: "token-expiry", env: "TOKEN_EXPIRY", usage: "lifetime of sessions and their tokens", value: (*durationValue)(&cfg.Auth.TokenExpiry)},
+		{flag: "bcrypt-cost", env: "BCRYPT_COST", usage: "bcrypt cost of password hashes", value: (*intValue)(&cfg.Auth.BcryptCost)},
+		{flag: "internal-api-token", env: "INTERNAL_API_TOKEN", usage: "token of the internal-only gRPC RPCs, disabled when empty", secret: true, value: (*stringValue)(&cfg.Auth.InternalToken)},
+		{flag: "account-deletion-grace-period", env: "ACCOUNT_DELETION_GRACE_PERIOD", usage: "how long deleted accounts can be restored for, before their personal data is erased", value: (*durationValue)(&cfg.Account.DeletionGracePeriod)},
+		{flag: "admin-api-token", env: "ADMIN_API_TOKEN", usage: "token of the admin API, disabled when empty", secret: true, value: (*stringValue)(&cfg.Admin.Token)},
+		{flag: "phone-number-prefix", env: "PHONE_NUMBER_PREFIX", usage: "country code phone numbers should start with", value: (*stringValue)(&cfg.Validation.PhoneNumberPrefix)},
+		{flag: "phone-number-min-length", env: "PHONE_NUMBER_MIN_LENGTH", usage: "minimum length of phone numbers, including the prefix", value: (*intValue)(&cfg.Validation.PhoneNumberMinLength)},
+		{flag: "phone-number-max-length", env: "PHONE_NUMBER_MAX_LENGTH", usage: "maximum length of phone numbers, including the prefix", value: (*intValue)(&cfg.Validation.PhoneNumberMaxLength)},
+		{flag: "full-name-min-length", env: "FULL_NAME_MIN_LENGTH", usage: "minimum length of full names", value: (*intValue)(&cfg.Validation.FullNameMinLength)},
+		{flag: "full-name-max-length", env: "FULL_NAME_MAX_LENGTH", usage: "maximum length of full names", value: (*intValue)(&cfg.Validation.FullNameMaxLength)},
+		{flag: "full-name-scripts", env: "FULL_NAME_SCRIPTS", usage: "comma separated Unicode scripts full names should be written in, i.e. Latin,Han, any script when empty", value: (*stringValue)(&cfg.Validation.FullNameScripts)},
+		{flag: "password-min-length", env: "PASSWORD_MIN_LENGTH", usage: "minimum length of passwords", value: (*intValue)(&cfg.Validation.PasswordMinLength)},
+		{flag: "password-max-length", env: "PASSWORD_MAX_LENGTH", usage: "maximum length of passwords", value: (*intValue)(&cfg.Validation.PasswordMaxLength)},
+		{flag: "default-locale", env: "DEFAULT_LOCALE", usage: "locale of messages when the client accepts none of the supported locales", value: (*stringValue)(&cfg.I18n.DefaultLocale)},
+		{flag: "openapi-validate-responses", env: "OPENAPI_VALIDATE_RESPONSES", usage: "validate responses against api.yml, for development and tests only", value: (*boolValue)(&cfg.OpenAPI.ValidateResponses)},
+		{flag: "api-docs-enabled", env: "API_DOCS_ENABLED", usage: "serve the OpenAPI document and the API docs", value: (*boolValue)(&cfg.Docs.Enabled)},
+		{flag: "api-server-url", env: "API_SERVER_URL", usage: "public URL of the service in the OpenAPI document", value: (*stringValue)(&cfg.Docs.ServerURL)},
+		{flag: "idempotency-ttl", env: "IDEMPOTENCY_TTL", usage: "how long responses to an Idempotency-Key are replayed", value: (*durationValue)(&cfg.Idempotency.TTL)},
+		{flag: "metrics-enabled", env: "METRICS_ENABLED", usage: "serve Prometheus metrics at /metrics", value: (*boolValue)(&cfg.Metrics.Enabled)},
+		{flag: "tracing-exporter", env: "TRACING_EXPORTER", usage: "exporter of traces: none, stdout or otlp", value: (*stringValue)(&cfg.Tracing.Exporter)},
+		{flag: "tracing-service-name", env: "TRACING_SERVICE_NAME", usage: "service name of the exported spans", value: (*stringValue)(&cfg.Tracing.ServiceName)},
+		{flag: "tracing-otlp-endpoint", env: "TRACING_OTLP_ENDPOINT", usage: "host and port of the OTLP/HTTP collector", value: (*stringValue)(&cfg.Tracing.OTLPEndpoint)},
+		{flag: "tracing-otlp-insecure", env: "TRACING_OTLP_INSECURE", usage: "export spans to the OTLP collector over plain HTTP", value: (*boolValue)(&cfg.Tracing.OTLPInsecure)},
+		{flag: "log-level", env: "LOG_LEVEL", usage: "minimum level of the logs: debug, info, warn or error", value: (*stringValue)(&cfg.Log.Level)},
+		{flag: "webhook-max-attempts", env: "WEBHOOK_MAX_ATTEMPTS", usage: "attempts before a webhook delivery is dead", value: (*intValue)(&cfg.Webhook.MaxAttempts)},
+		{flag: "webhook-poll-interval", env: "WEBHOOK_POLL_INTERVAL", usage: "interval between polls for due webhook deliveries", value: (*durationValue)(&cfg.Webhook.PollInterval)},
+		{flag: "outbox-publisher", env: "OUTBOX_PUBLISHER", usage: "publisher of outbox events: stdout or file", value: (*stringValue)(&cfg.Outbox.Publisher)},
+		{flag: "outbox-file", env: "OUTBOX_FILE", usage: "file the file publisher appends outbox events to", value: (*stringValue)(&cfg.Outbox.File)},
+		{flag: "outbox-poll-interval", env: "OUTBOX_POLL_INTERVAL", usage: "interval between polls for pending outbox events", value: (*durationValue)(&cfg.Outbox.PollInterval)},
+		{flag: "outbox-retention", env: "OUTBOX_RETENTION", usage: "how long published outbox events are kept", value: (*durationValue)(&cfg.Outbox.Retention)},
+		{flag: "export-poll-interval", env: "EXPORT_POLL_INTERVAL", usage: "interval between polls for pending exports of personal data", value: (*durationValue)(&cfg.Export.PollInterval)},
+		{flag: "export-retention", env: "EXPORT_RETENTION", usage: "how long archives of exports can be downloaded for", value: (*durationValue)(&cfg.Export.Retention)},
+		{flag: "export-link-expiry", env: "EXPORT_LINK_EXPIRY", usage: "how long signed download links of archives are valid for", value: (*durationValue)(&cfg.Export.LinkExpiry)},
+	}
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string { return string(*v) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+// IsBoolFlag allows the flag to be set without a value, i.e. `--api-docs-enabled`
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(i)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
@@ -7,16 +7,173 @@
   In this assignment we will use PostgreSQL as the database.
   */
 
+-- The version of this schema, checked by the readiness probe. Bump it together with repository.SchemaVersion whenever
+-- the schema changes, so instances are not ready until the database is migrated.
+CREATE TABLE schema_version (
+  version int NOT NULL
+);
+
+INSERT INTO schema_version (version) VALUES (8);
+
 CREATE TABLE "user" (
   id serial PRIMARY KEY,
-  full_name text NOT NULL,
+  full_name text, -- NULL when the user removed it, it is required at registration
   phone_number text NOT NULL,
   "password" text not null,
   created_time timestamp NOT NULL default now(),
   updated_time timestamp,
   successful_login_count int not null default 0,
+  last_login_time timestamp, -- set with successful_login_count
+  version bigint NOT NULL default 1, -- incremented by every update, returned as the ETag of the user
+  deleted_time timestamp, -- set when the user deletes their account, which can be restored for a grace period
+  suspended_time timestamp, -- set while the account is suspended by an admin
+  suspension_reason text,
+  -- set when the personal data of the deleted user is erased after the grace period. The row is kept for the rows
+  -- that reference it, with a placeholder phone number so the number can be registered again.
+  erased_time timestamp,
   CONSTRAINT user_phone_number_uniquekey UNIQUE (phone_number)
 );
 
+-- Deleted users are looked up by the time they were deleted, to be restored or erased
+CREATE INDEX user_deleted_time_idx ON "user" (deleted_time) WHERE deleted_time IS NOT NULL;
+
+-- A session is created on login, its id is the `jti` claim of the JWT. Revoking a session logs the user out.
+CREATE TABLE "session" (
+  id text PRIMARY KEY,
+  user_id int NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+  created_time timestamp NOT NULL default now(),
+  expires_time timestamp NOT NULL,
+  revoked_time timestamp
+);
+
+CREATE INDEX session_user_id_idx ON "session" (user_id);
+
+-- Exports of the personal data of users, built by the export worker. The archive is deleted once it expires, the
+-- row is kept so polling the export tells it expired. A user has at most one pending export.
+CREATE TABLE user_export (
+  id text PRIMARY KEY,
+  user_id int NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+  status text NOT NULL default 'pending',
+  attempts int NOT NULL default 0,
+  next_attempt_time timestamp NOT NULL, -- claims of the workers building the archive last until then
+  last_error text,
+  archive bytea, -- JSON, set while completed
+  created_time timestamp NOT NULL default now(),
+  completed_time timestamp,
+  expires_time timestamp, -- when the archive is deleted
+  CONSTRAINT user_export_status_check CHECK (status IN ('pending', 'completed', 'failed', 'expired'))
+);
+
+CREATE UNIQUE INDEX user_export_pending_uniquekey ON user_export (user_id) WHERE status = 'pending';
+CREATE INDEX user_export_next_attempt_time_idx ON user_export (next_attempt_time) WHERE status = 'pending';
+CREATE INDEX user_export_expires_time_idx ON user_export (expires_time) WHERE status = 'completed';
+
+-- Responses of POST requests sent with an Idempotency-Key header, replayed when the request is retried.
+CREATE TABLE idempotency_key (
+  id text PRIMARY KEY,
+  fingerprint text NOT NULL,
+  status_code int, -- NULL while the request is in progress
+  response_header jsonb,
+  response_body bytea,
+  created_time timestamp NOT NULL default now(),
+  expires_time timestamp NOT NULL
+);
+
+CREATE INDEX idempotency_key_expires_time_idx ON idempotency_key (expires_time);
+
+-- Subscriptions of other systems to user lifecycle events, managed through the admin API. Deleted subscriptions are
+-- kept so their delivery log remains available.
+CREATE TABLE webhook_subscription (
+  id serial PRIMARY KEY,
+  url text NOT NULL,
+  secret text NOT NULL, -- key of the HMAC signature of the deliveries
+  event_types text[] NOT NULL,
+  created_time timestamp NOT NULL default now(),
+  deleted_time timestamp
+);
+
+-- User lifecycle events, delivered to every subscription of their type. The id is sent to receivers to deduplicate
+-- retried deliveries.
+CREATE TABLE webhook_event (
+  id text PRIMARY KEY,
+  event_type text NOT NULL,
+  payload jsonb NOT NULL,
+  created_time timestamp NOT NULL default now()
+);
+
+-- Events of a user are redacted when the user is erased
+CREATE INDEX webhook_event_user_id_idx ON webhook_event ((payload->'data'->'user_id'));
+
+-- Queue and log of the deliveries of events to subscriptions. Pending deliveries of a subscription are sent in id
+-- order, retried with exponential backoff, and marked as dead once their attempts are exhausted.
+CREATE TABLE webhook_delivery (
+  id bigserial PRIMARY KEY,
+  subscription_id int NOT NULL REFERENCES webhook_subscription (id) ON DELETE CASCADE,
+  event_id text NOT NULL REFERENCES webhook_event (id) ON DELETE CASCADE,
+  status text NOT NULL default 'pending',
+  attempts int NOT NULL default 0,
+  next_attempt_time timestamp NOT NULL,
+  last_attempt_time timestamp,
+  last_status_code int,
+  last_error text,
+  delivered_time timestamp,
+  created_time timestamp NOT NULL default now(),
+  CONSTRAINT webhook_delivery_status_check CHECK (status IN ('pending', 'delivered', 'dead'))
+);
+
+CREATE INDEX webhook_delivery_pending_idx ON webhook_delivery (subscription_id, id) WHERE status = 'pending';
+CREATE INDEX webhook_delivery_subscription_id_idx ON webhook_delivery (subscription_id, id DESC);
+
+-- Domain events written in the same transaction as the user change they are about, and published by the outbox
+-- relay at least once. event_id is sent to consumers so they can discard duplicates.
+CREATE TABLE outbox (
+  id bigserial PRIMARY KEY,
+  event_id text NOT NULL,
+  event_type text NOT NULL,
+  aggregate_id int NOT NULL, -- ID of the user
+  payload jsonb NOT NULL,
+  attempts int NOT NULL default 0,
+  next_attempt_time timestamp NOT NULL default now(),
+  last_error text,
+  created_time timestamp NOT NULL default now(),
+  published_time timestamp,
+  CONSTRAINT outbox_event_id_uniquekey UNIQUE (event_id)
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (id) WHERE published_time IS NULL;
+
+-- Append-only log of the changes to accounts, written in the same transaction as the change. Each entry is chained
+-- to the previous one: hash is the SHA-256 of the entry and prev_hash, see repository.VerifyAuditLog. target_user_id
+-- has no foreign key so the log outlives the user.
+CREATE TABLE audit_log (
+  id bigserial PRIMARY KEY,
+  actor text NOT NULL, -- i.e. user:123 or admin
+  action text NOT NULL,
+  target_user_id int NOT NULL,
+  changes jsonb NOT NULL, -- before and after values of the changed fields, encrypted with the key of the user
+  created_time timestamp NOT NULL,
+  prev_hash text NOT NULL, -- empty for the first entry
+  hash text NOT NULL
+);
+
+CREATE INDEX audit_log_target_user_id_idx ON audit_log (target_user_id, id DESC);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+  FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+-- Keys the values of the audit log changes of a user are encrypted with. Erasing a user deletes its key, which
+-- erases its values from the append-only audit log without breaking the hash chain.
+CREATE TABLE audit_log_key (
+  user_id int PRIMARY KEY, -- no foreign key, like audit_log.target_user_id
+  key bytea NOT NULL, -- AES-256 key
+  created_time timestamp NOT NULL default now()
+);
+
 INSERT INTO "user" (full_name, phone_number, "password") VALUES ('name1', '+6281234567890', 'password1');
 INSERT INTO "user" (full_name, phone_number, "password") VALUES ('name2', '+6289876543210', 'password2');
\ No newline at end of file
@@ -0,0 +1,172 @@
+// Package export builds the archives of the exports of personal data started with POST /v1/user/export.
+//
+// Exports are queued in the database by the handler. The Worker claims them, builds their archive with a Builder and
+// stores it until it expires, along with the user.export_completed event of the outbox that notifies the webhook
+// subscribers. An export the builder failed on is retried a few times before it is marked as failed, so the user can
+// start another one.
+package export
+
+import (
+	"context"
+	"time"
+
+	"github.com/UserService/logging"
+	"github.com/UserService/repository"
+)
+
+const (
+	defaultBatchSize    = 10
+	defaultPollInterval = 5 * time.Second
+	defaultRetention    = 24 * time.Hour
+	defaultMaxAttempts  = 3
+	defaultBackoff      = time.Minute
+	defaultLease        = 5 * time.Minute
+)
+
+var (
+	//define function wrappers so we can inject dummy function in UT
+	fnTimeNow func() time.Time = time.Now
+)
+
+// Builder returns the archive of the personal data of the user, i.e. handler.Server.BuildUserDataArchive.
+type Builder func(ctx context.Context, userID int64) ([]byte, error)
+
+// Worker builds the pending exports. Several workers, i.e. one per replica of the service, can run against the same
+// database: exports are claimed so each attempt is made by a single worker.
+type Worker struct {
+	repository   repository.RepositoryInterface
+	build        Builder
+	batchSize    int
+	pollInterval time.Duration
+	retention    time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	lease        time.Duration
+}
+
+type NewWorkerOptions struct {
+	Repository repository.RepositoryInterface
+	Build      Builder
+
+	// BatchSize is how many exports are claimed at once, defaults to 10.
+	BatchSize int
+
+	// PollInterval is how long Run waits for new exports once none are pending, defaults to 5s.
+	PollInterval time.Duration
+
+	// Retention is how long archives are kept once they are built, defaults to 24h.
+	Retention time.Duration
+
+	// MaxAttempts is how many times an export is attempted before it is marked as failed, defaults to 3, and Backoff
+	// how long to wait between attempts, defaults to 1m.
+	MaxAttempts int
+	Backoff     time.Duration
+
+	// Lease is how long claimed exports are reserved for the worker, defaults to 5m. It must exceed the time the
+	// builder takes to build a batch, or exports are built twice.
+	Lease time.Duration
+}
+
+func NewWorker(opts NewWorkerOptions) *Worker {
+	worker := &Worker{
+		repository:   opts.Repository,
+		build:        opts.Build,
+		batchSize:    opts.BatchSize,
+		pollInterval: opts.PollInterval,
+		retention:    opts.Retention,
+		maxAttempts:  opts.MaxAttempts,
+		backoff:      opts.Backoff,
+		lease:        opts.Lease,
+	}
+
+	if worker.batchSize <= 0 {
+		worker.batchSize = defaultBatchSize
+	}
+	if worker.pollInterval <= 0 {
+		worker.pollInterval = defaultPollInterval
+	}
+	if worker.retention <= 0 {
+		worker.retention = defaultRetention
+	}
+	if worker.maxAttempts <= 0 {
+		worker.maxAttempts = defaultMaxAttempts
+	}
+	if worker.backoff <= 0 {
+		worker.backoff = defaultBackoff
+	}
+	if worker.lease <= 0 {
+		worker.lease = defaultLease
+	}
+
+	return worker
+}
+
+// Run builds the pending exports until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		built, err := w.BuildPending(ctx)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to build user exports", "error", err)
+		}
+
+		// A full batch means more exports are probably pending
+		if err == nil && built == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// BuildPending claims the exports that are due and attempts to build them once, oldest first. It returns how many
+// exports were attempted.
+func (w *Worker) BuildPending(ctx context.Context) (int, error) {
+	now := fnTimeNow()
+
+	exports, err := w.repository.ClaimUserExports(ctx, now, w.batchSize, now.Add(w.lease))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, export := range exports {
+		export = w.attempt(ctx, export)
+
+		if err := w.repository.UpdateUserExport(ctx, export); err != nil {
+			// The export is built again once its claim expires
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to update user export", "export_id", export.ID, "error", err)
+		}
+	}
+
+	return len(exports), nil
+}
+
+// attempt builds the archive of the export and returns the export updated with the outcome. Attempts counts this
+// attempt, see RepositoryInterface.ClaimUserExports.
+func (w *Worker) attempt(ctx context.Context, export repository.UserExport) repository.UserExport {
+	archive, err := w.build(ctx, export.UserID)
+
+	now := fnTimeNow()
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to build user export", "export_id", export.ID, "attempts", export.Attempts, "error", err)
+
+		export.LastError = err.Error()
+		export.NextAttemptTime = now.Add(w.backoff)
+		if export.Attempts >= w.maxAttempts {
+			export.Status = repository.UserExportFailed
+		}
+		return export
+	}
+
+	expiresTime := now.Add(w.retention)
+	export.Status = repository.UserExportCompleted
+	export.LastError = ""
+	export.NextAttemptTime = now
+	export.Archive = archive
+	export.CompletedTime = &now
+	export.ExpiresTime = &expiresTime
+	return export
+}
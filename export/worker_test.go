@@ -0,0 +1,154 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+func TestWorker_BuildPending(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	retention := 24 * time.Hour
+	expiresTime := now.Add(retention)
+	lease := now.Add(defaultLease)
+
+	claimed := repository.UserExport{
+		ID:              "a1b2",
+		UserID:          123,
+		Status:          repository.UserExportPending,
+		Attempts:        1,
+		NextAttemptTime: lease,
+		CreatedTime:     now.Add(-time.Minute),
+	}
+
+	tests := []struct {
+		name           string
+		build          Builder
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+
+		wantBuilt int
+		wantErr   error
+	}{
+		{
+			name: "success-stores-archive",
+			build: func(_ context.Context, userID int64) ([]byte, error) {
+				return []byte(`{"profile":{"id":123}}`), nil
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				completed := claimed
+				completed.Status = repository.UserExportCompleted
+				completed.NextAttemptTime = now
+				completed.Archive = []byte(`{"profile":{"id":123}}`)
+				completed.CompletedTime = &now
+				completed.ExpiresTime = &expiresTime
+
+				gomock.InOrder(
+					mock.EXPECT().ClaimUserExports(gomock.Any(), now, defaultBatchSize, lease).Return([]repository.UserExport{claimed}, nil),
+					mock.EXPECT().UpdateUserExport(gomock.Any(), completed).Return(nil),
+				)
+
+				return mock
+			},
+			wantBuilt: 1,
+		},
+		{
+			name: "success-retries-failed-build",
+			build: func(_ context.Context, userID int64) ([]byte, error) {
+				return nil, errors.New("connection reset")
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				retried := claimed
+				retried.LastError = "connection reset"
+				retried.NextAttemptTime = now.Add(defaultBackoff)
+
+				mock.EXPECT().ClaimUserExports(gomock.Any(), now, defaultBatchSize, lease).Return([]repository.UserExport{claimed}, nil)
+				mock.EXPECT().UpdateUserExport(gomock.Any(), retried).Return(nil)
+
+				return mock
+			},
+			wantBuilt: 1,
+		},
+		{
+			name: "success-fails-export-after-max-attempts",
+			build: func(_ context.Context, userID int64) ([]byte, error) {
+				return nil, errors.New("connection reset")
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				lastAttempt := claimed
+				lastAttempt.Attempts = defaultMaxAttempts
+
+				failed := lastAttempt
+				failed.Status = repository.UserExportFailed
+				failed.LastError = "connection reset"
+				failed.NextAttemptTime = now.Add(defaultBackoff)
+
+				mock.EXPECT().ClaimUserExports(gomock.Any(), now, defaultBatchSize, lease).Return([]repository.UserExport{lastAttempt}, nil)
+				mock.EXPECT().UpdateUserExport(gomock.Any(), failed).Return(nil)
+
+				return mock
+			},
+			wantBuilt: 1,
+		},
+		{
+			name: "success-logs-failed-update",
+			build: func(_ context.Context, userID int64) ([]byte, error) {
+				return []byte(`{}`), nil
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().ClaimUserExports(gomock.Any(), now, defaultBatchSize, lease).Return([]repository.UserExport{claimed}, nil)
+				mock.EXPECT().UpdateUserExport(gomock.Any(), gomock.Any()).Return(repository.ErrUnavailable)
+
+				return mock
+			},
+			wantBuilt: 1,
+		},
+		{
+			name: "fail-claim",
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().ClaimUserExports(gomock.Any(), now, defaultBatchSize, lease).Return(nil, repository.ErrUnavailable)
+
+				return mock
+			},
+			wantErr: repository.ErrUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			fnTimeNow = func() time.Time { return now }
+			defer func() { fnTimeNow = time.Now }()
+
+			worker := NewWorker(NewWorkerOptions{
+				Repository: test.mockRepository(controller),
+				Build:      test.build,
+				Retention:  retention,
+			})
+
+			gotBuilt, gotErr := worker.BuildPending(context.Background())
+
+			if !reflect.DeepEqual(gotErr, test.wantErr) {
+				t.Errorf("worker.BuildPending() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if gotBuilt != test.wantBuilt {
+				t.Errorf("worker.BuildPending() built = %v, want %v", gotBuilt, test.wantBuilt)
+			}
+		})
+	}
+}
@@ -8,26 +8,194 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/labstack/echo/v4"
+	"github.com/oapi-codegen/runtime"
 )
 
+// Defines values for ErrorCode.
+const (
+	AccountDeleted                  ErrorCode = "account_deleted"
+	AccountNotDeleted               ErrorCode = "account_not_deleted"
+	AccountSuspended                ErrorCode = "account_suspended"
+	AdminApiDisabled                ErrorCode = "admin_api_disabled"
+	AlreadyRegistered               ErrorCode = "already_registered"
+	DownloadLinkInvalid             ErrorCode = "download_link_invalid"
+	ExportExpired                   ErrorCode = "export_expired"
+	ExportNotFound                  ErrorCode = "export_not_found"
+	FullNameInvalidCharacter        ErrorCode = "full_name_invalid_character"
+	FullNameInvalidLength           ErrorCode = "full_name_invalid_length"
+	FullNameInvalidScript           ErrorCode = "full_name_invalid_script"
+	IdempotencyKeyReused            ErrorCode = "idempotency_key_reused"
+	IdempotencyRequestInProgress    ErrorCode = "idempotency_request_in_progress"
+	InternalError                   ErrorCode = "internal_error"
+	InvalidAuthorizationHeader      ErrorCode = "invalid_authorization_header"
+	InvalidCredentials              ErrorCode = "invalid_credentials"
+	InvalidField                    ErrorCode = "invalid_field"
+	InvalidFieldType                ErrorCode = "invalid_field_type"
+	InvalidRequestBody              ErrorCode = "invalid_request_body"
+	InvalidToken                    ErrorCode = "invalid_token"
+	InvalidValue                    ErrorCode = "invalid_value"
+	MissingAuthorizationHeader      ErrorCode = "missing_authorization_header"
+	MissingUserId                   ErrorCode = "missing_user_id"
+	PasswordInvalidLength           ErrorCode = "password_invalid_length"
+	PasswordMissingCapitalLetter    ErrorCode = "password_missing_capital_letter"
+	PasswordMissingNumber           ErrorCode = "password_missing_number"
+	PasswordMissingSpecialCharacter ErrorCode = "password_missing_special_character"
+	PermissionDenied                ErrorCode = "permission_denied"
+	PhoneNumberAlreadyRegistered    ErrorCode = "phone_number_already_registered"
+	PhoneNumberInvalidLength        ErrorCode = "phone_number_invalid_length"
+	PhoneNumberInvalidPrefix        ErrorCode = "phone_number_invalid_prefix"
+	PhoneNumberNotNumeric           ErrorCode = "phone_number_not_numeric"
+	PreconditionFailed              ErrorCode = "precondition_failed"
+	ReadOnlyField                   ErrorCode = "read_only_field"
+	RequiredFieldMissing            ErrorCode = "required_field_missing"
+	ResponseValidationFailed        ErrorCode = "response_validation_failed"
+	ServiceUnavailable              ErrorCode = "service_unavailable"
+	SessionNotFound                 ErrorCode = "session_not_found"
+	SessionRevoked                  ErrorCode = "session_revoked"
+	TokenExpired                    ErrorCode = "token_expired"
+	UnknownField                    ErrorCode = "unknown_field"
+	UnsupportedMediaType            ErrorCode = "unsupported_media_type"
+	UserNotFound                    ErrorCode = "user_not_found"
+	ValidationFailed                ErrorCode = "validation_failed"
+	WebhookDeliveryNotDead          ErrorCode = "webhook_delivery_not_dead"
+	WebhookDeliveryNotFound         ErrorCode = "webhook_delivery_not_found"
+	WebhookSubscriptionNotFound     ErrorCode = "webhook_subscription_not_found"
+	WebhookUrlInvalid               ErrorCode = "webhook_url_invalid"
+)
+
+// Defines values for UserExportStatus.
+const (
+	UserExportStatusCompleted UserExportStatus = "completed"
+	UserExportStatusExpired   UserExportStatus = "expired"
+	UserExportStatusFailed    UserExportStatus = "failed"
+	UserExportStatusPending   UserExportStatus = "pending"
+)
+
+// Defines values for WebhookDeliveryStatus.
+const (
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "dead"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+)
+
+// Defines values for WebhookEventType.
+const (
+	UserExportCompleted    WebhookEventType = "user.export_completed"
+	UserFullNameChanged    WebhookEventType = "user.full_name_changed"
+	UserLoggedIn           WebhookEventType = "user.logged_in"
+	UserPhoneNumberChanged WebhookEventType = "user.phone_number_changed"
+	UserRegistered         WebhookEventType = "user.registered"
+)
+
+// AuditChange Value of a field before and after a change, `before` is unset for fields set on registration.
+type AuditChange struct {
+	After  *string `json:"after,omitempty"`
+	Before *string `json:"before,omitempty"`
+}
+
+// AuditLogEntry Change to an account in the audit log. Each entry is chained to the previous one: `hash` is the SHA-256 of
+// the entry and `prev_hash`, so editing or deleting an entry breaks the chain.
+// The hash covers the values as stored, encrypted with the key of the user.
+type AuditLogEntry struct {
+	// Action What was done, i.e. `user.registered`, `user.updated`, `user.deleted`, `user.suspended` or `user.erased`,
+	// the receipt of the erasure of a deleted user after its grace period.
+	Action string `json:"action"`
+
+	// Actor Who made the change, `user:<id>` for users, `admin` or `system` for the erasure of users.
+	Actor string `json:"actor"`
+
+	// Changes The changed fields, by name. The values of erased users are `[erased]`.
+	Changes   map[string]AuditChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+	Hash      string                 `json:"hash"`
+	Id        int64                  `json:"id"`
+
+	// PrevHash Hash of the previous entry, empty for the first entry.
+	PrevHash     string `json:"prev_hash"`
+	TargetUserId int64  `json:"target_user_id"`
+}
+
+// DeleteUserResponse defines model for DeleteUserResponse.
+type DeleteUserResponse struct {
+	Header ResponseHeader `json:"header"`
+
+	// RestorableUntil End of the grace period, until which the user can be restored with `POST /v1/user/restore`.
+	RestorableUntil time.Time `json:"restorable_until"`
+}
+
+// ErrorCode Stable machine readable error code.
+type ErrorCode string
+
+// ErrorResponse Response envelope returned for failed requests.
+type ErrorResponse struct {
+	Header ResponseHeader `json:"header"`
+}
+
 // GetUserResponse defines model for GetUserResponse.
 type GetUserResponse struct {
 	Header ResponseHeader `json:"header"`
 	User   User           `json:"user"`
 }
 
+// InvalidParam defines model for InvalidParam.
+type InvalidParam struct {
+	// Code Stable machine readable error code.
+	Code ErrorCode `json:"code"`
+
+	// Name Name of the rejected field.
+	Name string `json:"name"`
+
+	// Reason Why the field was rejected.
+	Reason string `json:"reason"`
+}
+
+// Problem RFC 7807 problem details.
+type Problem struct {
+	// Code Stable machine readable error code.
+	Code ErrorCode `json:"code"`
+
+	// Detail Explanation specific to this occurrence of the problem.
+	Detail *string `json:"detail,omitempty"`
+
+	// Instance Request path where the problem occurred.
+	Instance *string `json:"instance,omitempty"`
+
+	// InvalidParams Request fields that were rejected.
+	InvalidParams *[]InvalidParam `json:"invalid_params,omitempty"`
+
+	// Status HTTP status code of the response.
+	Status int `json:"status"`
+
+	// Title Short summary of the problem type.
+	Title string `json:"title"`
+
+	// Type URI reference identifying the problem type.
+	Type string `json:"type"`
+}
+
 // RegisterUserResponse defines model for RegisterUserResponse.
 type RegisterUserResponse struct {
 	Header ResponseHeader `json:"header"`
 	User   User           `json:"user"`
 }
 
+// ReplaceUserRequest Profile of the user, replacing the current one.
+type ReplaceUserRequest struct {
+	// FullName User's full name, `null` removes it.
+	FullName *string `json:"full_name"`
+
+	// PhoneNumber User's phone number.
+	PhoneNumber string `json:"phone_number"`
+}
+
 // ResponseHeader defines model for ResponseHeader.
 type ResponseHeader struct {
 	// Messages Array of error message(s).
@@ -37,6 +205,21 @@ type ResponseHeader struct {
 	Success bool `json:"success"`
 }
 
+// RestoreUserResponse defines model for RestoreUserResponse.
+type RestoreUserResponse struct {
+	Header ResponseHeader `json:"header"`
+}
+
+// Session defines model for Session.
+type Session struct {
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Id ID of the session, the `jti` claim of its JWT.
+	Id     *string `json:"id,omitempty"`
+	UserId *int64  `json:"user_id,omitempty"`
+}
+
 // UpdateUserResponse defines model for UpdateUserResponse.
 type UpdateUserResponse struct {
 	Header ResponseHeader `json:"header"`
@@ -44,10 +227,100 @@ type UpdateUserResponse struct {
 
 // User defines model for User.
 type User struct {
-	// FullName User's full name.
+	// CreatedAt When the user registered.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// FullName User's full name, required at registration. It is absent when the user removed it.
 	FullName *string `json:"full_name,omitempty"`
 	Id       *int64  `json:"id,omitempty"`
 
+	// LastLoginAt When the user last logged in, absent until they do.
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+
+	// Password User's password.
+	Password *string `json:"password,omitempty"`
+
+	// PhoneNumber User's phone number.
+	PhoneNumber *string `json:"phone_number,omitempty"`
+
+	// SuccessfulLoginCount How many times the user logged in.
+	SuccessfulLoginCount *int64 `json:"successful_login_count,omitempty"`
+
+	// UpdatedAt When the profile was last updated, absent until it is.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// UserDataArchive Everything the service holds about a user: the profile, the login history and the changes to the account in
+// the audit log. The service records no consents, so there are none to export.
+type UserDataArchive struct {
+	// AuditLog The audit log entries about the user, newest first.
+	AuditLog   []AuditLogEntry `json:"audit_log"`
+	ExportedAt time.Time       `json:"exported_at"`
+
+	// LoginHistory The sessions of the user, newest first.
+	LoginHistory []UserDataLogin `json:"login_history"`
+	Profile      UserDataProfile `json:"profile"`
+}
+
+// UserDataLogin defines model for UserDataLogin.
+type UserDataLogin struct {
+	// ExpiresAt When the session expired or expires.
+	ExpiresAt  time.Time `json:"expires_at"`
+	LoggedInAt time.Time `json:"logged_in_at"`
+
+	// RevokedAt When the user logged out of the session, if they did.
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// UserDataProfile defines model for UserDataProfile.
+type UserDataProfile struct {
+	CreatedAt time.Time `json:"created_at"`
+
+	// FullName Absent when the user removed it.
+	FullName             *string    `json:"full_name,omitempty"`
+	Id                   int64      `json:"id"`
+	LastLoginAt          *time.Time `json:"last_login_at,omitempty"`
+	PhoneNumber          string     `json:"phone_number"`
+	SuccessfulLoginCount int64      `json:"successful_login_count"`
+	UpdatedAt            *time.Time `json:"updated_at,omitempty"`
+}
+
+// UserExport Export of the personal data of the user. The status is:
+//   - `pending` - the archive is being built
+//   - `completed` - the archive can be downloaded at `download_url` until `expires_at`
+//   - `failed` - the archive could not be built, start another export
+//   - `expired` - the archive was deleted, start another export
+type UserExport struct {
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// DownloadUrl Signed link to download the archive, relative to the server, set while the export is completed. It
+	// expires at `download_url_expires_at`, poll the export again for a new link.
+	DownloadUrl          *string    `json:"download_url,omitempty"`
+	DownloadUrlExpiresAt *time.Time `json:"download_url_expires_at,omitempty"`
+
+	// ExpiresAt When the archive is deleted, set once the export is completed.
+	ExpiresAt *time.Time       `json:"expires_at,omitempty"`
+	Id        string           `json:"id"`
+	Status    UserExportStatus `json:"status"`
+}
+
+// UserExportStatus defines model for UserExport.Status.
+type UserExportStatus string
+
+// UserExportResponse defines model for UserExportResponse.
+type UserExportResponse struct {
+	// Export Export of the personal data of the user. The status is:
+	//   * `pending` - the archive is being built
+	//   * `completed` - the archive can be downloaded at `download_url` until `expires_at`
+	//   * `failed` - the archive could not be built, start another export
+	//   * `expired` - the archive was deleted, start another export
+	Export UserExport     `json:"export"`
+	Header ResponseHeader `json:"header"`
+}
+
+// UserLoginRequest defines model for UserLoginRequest.
+type UserLoginRequest struct {
 	// Password User's password.
 	Password *string `json:"password,omitempty"`
 
@@ -61,29 +334,407 @@ type UserLoginResponse struct {
 	User   User           `json:"user"`
 }
 
+// UserMergePatch JSON Merge Patch of the user profile: absent fields are unchanged. `null` removes the full name, which is
+// optional, and is rejected with `required_field_missing` for the phone number, which is required.
+type UserMergePatch struct {
+	// FullName User's full name, `null` removes it.
+	FullName *string `json:"full_name"`
+
+	// PhoneNumber User's phone number, required.
+	PhoneNumber *string `json:"phone_number"`
+}
+
+// UserSuspension defines model for UserSuspension.
+type UserSuspension struct {
+	// Reason Why the user is suspended, recorded in the audit log. It is not shown to the user.
+	Reason string `json:"reason"`
+}
+
+// WebhookDelivery Delivery of an event to a subscription, as recorded in the delivery log.
+type WebhookDelivery struct {
+	// Attempts Number of attempts made so far.
+	Attempts    *int       `json:"attempts,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	EventId     *string    `json:"event_id,omitempty"`
+
+	// EventType Type of a user lifecycle event:
+	//   * `user.registered` - a user was created
+	//   * `user.phone_number_changed` - a user updated their phone number
+	//   * `user.full_name_changed` - a user updated or removed their full name
+	//   * `user.logged_in` - a user created a session
+	//   * `user.export_completed` - the export of the personal data of a user is ready to download
+	EventType     WebhookEventType `json:"event_type"`
+	Id            *int64           `json:"id,omitempty"`
+	LastAttemptAt *time.Time       `json:"last_attempt_at,omitempty"`
+
+	// LastError Why the last attempt failed.
+	LastError *string `json:"last_error,omitempty"`
+
+	// LastStatusCode Status code of the response to the last attempt, unset when no response was received.
+	LastStatusCode *int `json:"last_status_code,omitempty"`
+
+	// NextAttemptAt When the next attempt is due, only set for pending deliveries.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+
+	// Status Status of a webhook delivery:
+	//   * `pending` - the delivery is queued or waiting for its next attempt
+	//   * `delivered` - the receiver answered with a `2xx` status
+	//   * `dead` - every attempt failed, the delivery is no longer retried unless it is retried explicitly
+	Status         WebhookDeliveryStatus `json:"status"`
+	SubscriptionId *int64                `json:"subscription_id,omitempty"`
+}
+
+// WebhookDeliveryStatus Status of a webhook delivery:
+//   - `pending` - the delivery is queued or waiting for its next attempt
+//   - `delivered` - the receiver answered with a `2xx` status
+//   - `dead` - every attempt failed, the delivery is no longer retried unless it is retried explicitly
+type WebhookDeliveryStatus string
+
+// WebhookEventType Type of a user lifecycle event:
+//   - `user.registered` - a user was created
+//   - `user.phone_number_changed` - a user updated their phone number
+//   - `user.full_name_changed` - a user updated or removed their full name
+//   - `user.logged_in` - a user created a session
+//   - `user.export_completed` - the export of the personal data of a user is ready to download
+type WebhookEventType string
+
+// WebhookSubscription Events are sent to `url` in a `POST` request whose JSON body is the `WebhookEvent`. Receivers must verify the
+// `X-Webhook-Signature: t=<unix timestamp>,v1=<signature>` header, where the signature is the hex encoded
+// HMAC-SHA256 of `<unix timestamp>.<request body>` keyed with `secret`, and should reject old timestamps.
+//
+// Events of a subscription are delivered in the order they occurred, at least once: the `id` of the event is
+// stable across retries so receivers can discard duplicates. Deliveries that are not answered with a `2xx`
+// status are retried with exponential backoff, and marked as `dead` once their attempts are exhausted.
+type WebhookSubscription struct {
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// Events Types of the events sent to the subscription.
+	Events []WebhookEventType `json:"events"`
+	Id     *int64             `json:"id,omitempty"`
+
+	// Secret Key of the signature of the deliveries. It is never returned.
+	Secret *string `json:"secret,omitempty"`
+
+	// Url Absolute `http` or `https` URL the events are sent to.
+	Url string `json:"url"`
+}
+
+// IdempotencyKey defines model for IdempotencyKey.
+type IdempotencyKey = string
+
+// IfMatch defines model for IfMatch.
+type IfMatch = string
+
+// IfNoneMatch defines model for IfNoneMatch.
+type IfNoneMatch = string
+
+// SessionID defines model for SessionID.
+type SessionID = string
+
+// UserExportID defines model for UserExportID.
+type UserExportID = string
+
+// UserID defines model for UserID.
+type UserID = int64
+
+// WebhookDeliveryID defines model for WebhookDeliveryID.
+type WebhookDeliveryID = int64
+
+// WebhookSubscriptionID defines model for WebhookSubscriptionID.
+type WebhookSubscriptionID = int64
+
+// AccountInactiveApplicationJSON Response envelope returned for failed requests.
+type AccountInactiveApplicationJSON = ErrorResponse
+
+// AccountInactiveApplicationProblemPlusJSON RFC 7807 problem details.
+type AccountInactiveApplicationProblemPlusJSON = Problem
+
+// BadRequestApplicationJSON Response envelope returned for failed requests.
+type BadRequestApplicationJSON = ErrorResponse
+
+// BadRequestApplicationProblemPlusJSON RFC 7807 problem details.
+type BadRequestApplicationProblemPlusJSON = Problem
+
+// ConflictApplicationJSON Response envelope returned for failed requests.
+type ConflictApplicationJSON = ErrorResponse
+
+// ConflictApplicationProblemPlusJSON RFC 7807 problem details.
+type ConflictApplicationProblemPlusJSON = Problem
+
+// ForbiddenApplicationJSON Response envelope returned for failed requests.
+type ForbiddenApplicationJSON = ErrorResponse
+
+// ForbiddenApplicationProblemPlusJSON RFC 7807 problem details.
+type ForbiddenApplicationProblemPlusJSON = Problem
+
+// GoneApplicationJSON Response envelope returned for failed requests.
+type GoneApplicationJSON = ErrorResponse
+
+// GoneApplicationProblemPlusJSON RFC 7807 problem details.
+type GoneApplicationProblemPlusJSON = Problem
+
+// InternalServerErrorApplicationJSON Response envelope returned for failed requests.
+type InternalServerErrorApplicationJSON = ErrorResponse
+
+// InternalServerErrorApplicationProblemPlusJSON RFC 7807 problem details.
+type InternalServerErrorApplicationProblemPlusJSON = Problem
+
+// NotFoundApplicationJSON Response envelope returned for failed requests.
+type NotFoundApplicationJSON = ErrorResponse
+
+// NotFoundApplicationProblemPlusJSON RFC 7807 problem details.
+type NotFoundApplicationProblemPlusJSON = Problem
+
+// PreconditionFailedApplicationJSON Response envelope returned for failed requests.
+type PreconditionFailedApplicationJSON = ErrorResponse
+
+// PreconditionFailedApplicationProblemPlusJSON RFC 7807 problem details.
+type PreconditionFailedApplicationProblemPlusJSON = Problem
+
+// ServiceUnavailableApplicationJSON Response envelope returned for failed requests.
+type ServiceUnavailableApplicationJSON = ErrorResponse
+
+// ServiceUnavailableApplicationProblemPlusJSON RFC 7807 problem details.
+type ServiceUnavailableApplicationProblemPlusJSON = Problem
+
+// UnauthorizedApplicationJSON Response envelope returned for failed requests.
+type UnauthorizedApplicationJSON = ErrorResponse
+
+// UnauthorizedApplicationProblemPlusJSON RFC 7807 problem details.
+type UnauthorizedApplicationProblemPlusJSON = Problem
+
+// UnprocessableEntityApplicationJSON Response envelope returned for failed requests.
+type UnprocessableEntityApplicationJSON = ErrorResponse
+
+// UnprocessableEntityApplicationProblemPlusJSON RFC 7807 problem details.
+type UnprocessableEntityApplicationProblemPlusJSON = Problem
+
+// UnsupportedMediaTypeApplicationJSON Response envelope returned for failed requests.
+type UnsupportedMediaTypeApplicationJSON = ErrorResponse
+
+// UnsupportedMediaTypeApplicationProblemPlusJSON RFC 7807 problem details.
+type UnsupportedMediaTypeApplicationProblemPlusJSON = Problem
+
+// ListAuditLogParams defines parameters for ListAuditLog.
+type ListAuditLogParams struct {
+	// UserId Only return the changes to this user.
+	UserId *int64 `form:"user_id,omitempty" json:"user_id,omitempty"`
+
+	// BeforeId Only return entries older than this entry.
+	BeforeId *int64 `form:"before_id,omitempty" json:"before_id,omitempty"`
+
+	// Limit Maximum number of entries to return.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// ListWebhookDeliveriesParams defines parameters for ListWebhookDeliveries.
+type ListWebhookDeliveriesParams struct {
+	// Status Only return deliveries with this status, i.e. `dead` for the dead letters.
+	Status *WebhookDeliveryStatus `form:"status,omitempty" json:"status,omitempty"`
+
+	// Limit Maximum number of deliveries to return.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetUserParams defines parameters for GetUser.
+type GetUserParams struct {
+	// IfNoneMatch ETags of the versions of the user the client has, or `*`. `304` is returned without a body when the
+	// current version is one of them.
+	IfNoneMatch *IfNoneMatch `json:"If-None-Match,omitempty"`
+}
+
+// PatchUserParams defines parameters for PatchUser.
+type PatchUserParams struct {
+	// IfMatch ETag of the version of the user the update is based on, as returned by `GET /v1/user` or
+	// `GET /v2/users/{id}`. The update is rejected with `412` when the user was changed since, i.e. from another
+	// device, so changes are not silently overwritten. Without the header the update is applied to the current
+	// version.
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
+}
+
+// RegisterUserParams defines parameters for RegisterUser.
+type RegisterUserParams struct {
+	// IdempotencyKey Unique key of the request, i.e. a UUID, so it can be retried safely. The response of the first
+	// request is stored for 24 hours and replayed, with an `Idempotent-Replayed: true` header, to
+	// retries with the same key. Reusing a key with a different request returns `422`.
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// UpdateUserParams defines parameters for UpdateUser.
+type UpdateUserParams struct {
+	// IfMatch ETag of the version of the user the update is based on, as returned by `GET /v1/user` or
+	// `GET /v2/users/{id}`. The update is rejected with `412` when the user was changed since, i.e. from another
+	// device, so changes are not silently overwritten. Without the header the update is applied to the current
+	// version.
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
+}
+
+// ExportUserParams defines parameters for ExportUser.
+type ExportUserParams struct {
+	// IdempotencyKey Unique key of the request, i.e. a UUID, so it can be retried safely. The response of the first
+	// request is stored for 24 hours and replayed, with an `Idempotent-Replayed: true` header, to
+	// retries with the same key. Reusing a key with a different request returns `422`.
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// DownloadUserExportParams defines parameters for DownloadUserExport.
+type DownloadUserExportParams struct {
+	// Expires Unix time the link expires at.
+	Expires int64 `form:"expires" json:"expires"`
+
+	// Signature Signature of the export ID and `expires`.
+	Signature string `form:"signature" json:"signature"`
+}
+
+// UserLoginParams defines parameters for UserLogin.
+type UserLoginParams struct {
+	// IdempotencyKey Unique key of the request, i.e. a UUID, so it can be retried safely. The response of the first
+	// request is stored for 24 hours and replayed, with an `Idempotent-Replayed: true` header, to
+	// retries with the same key. Reusing a key with a different request returns `422`.
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// RestoreUserParams defines parameters for RestoreUser.
+type RestoreUserParams struct {
+	// IdempotencyKey Unique key of the request, i.e. a UUID, so it can be retried safely. The response of the first
+	// request is stored for 24 hours and replayed, with an `Idempotent-Replayed: true` header, to
+	// retries with the same key. Reusing a key with a different request returns `422`.
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// CreateSessionParams defines parameters for CreateSession.
+type CreateSessionParams struct {
+	// IdempotencyKey Unique key of the request, i.e. a UUID, so it can be retried safely. The response of the first
+	// request is stored for 24 hours and replayed, with an `Idempotent-Replayed: true` header, to
+	// retries with the same key. Reusing a key with a different request returns `422`.
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// CreateUserParams defines parameters for CreateUser.
+type CreateUserParams struct {
+	// IdempotencyKey Unique key of the request, i.e. a UUID, so it can be retried safely. The response of the first
+	// request is stored for 24 hours and replayed, with an `Idempotent-Replayed: true` header, to
+	// retries with the same key. Reusing a key with a different request returns `422`.
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// UpdateUserByIDParams defines parameters for UpdateUserByID.
+type UpdateUserByIDParams struct {
+	// IfMatch ETag of the version of the user the update is based on, as returned by `GET /v1/user` or
+	// `GET /v2/users/{id}`. The update is rejected with `412` when the user was changed since, i.e. from another
+	// device, so changes are not silently overwritten. Without the header the update is applied to the current
+	// version.
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
+}
+
+// SuspendUserJSONRequestBody defines body for SuspendUser for application/json ContentType.
+type SuspendUserJSONRequestBody = UserSuspension
+
+// CreateWebhookSubscriptionJSONRequestBody defines body for CreateWebhookSubscription for application/json ContentType.
+type CreateWebhookSubscriptionJSONRequestBody = WebhookSubscription
+
+// PatchUserApplicationMergePatchPlusJSONRequestBody defines body for PatchUser for application/merge-patch+json ContentType.
+type PatchUserApplicationMergePatchPlusJSONRequestBody = UserMergePatch
+
 // RegisterUserJSONRequestBody defines body for RegisterUser for application/json ContentType.
 type RegisterUserJSONRequestBody = User
 
 // UpdateUserJSONRequestBody defines body for UpdateUser for application/json ContentType.
-type UpdateUserJSONRequestBody = User
+type UpdateUserJSONRequestBody = ReplaceUserRequest
 
 // UserLoginJSONRequestBody defines body for UserLogin for application/json ContentType.
-type UserLoginJSONRequestBody = User
+type UserLoginJSONRequestBody = UserLoginRequest
+
+// RestoreUserJSONRequestBody defines body for RestoreUser for application/json ContentType.
+type RestoreUserJSONRequestBody = UserLoginRequest
+
+// CreateSessionJSONRequestBody defines body for CreateSession for application/json ContentType.
+type CreateSessionJSONRequestBody = UserLoginRequest
+
+// CreateUserJSONRequestBody defines body for CreateUser for application/json ContentType.
+type CreateUserJSONRequestBody = User
+
+// UpdateUserByIDJSONRequestBody defines body for UpdateUserByID for application/json ContentType.
+type UpdateUserByIDJSONRequestBody = ReplaceUserRequest
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Get the audit log of account changes
+	// (GET /admin/audit-log)
+	ListAuditLog(ctx echo.Context, params ListAuditLogParams) error
+	// Suspend a user
+	// (POST /admin/users/{id}/suspend)
+	SuspendUser(ctx echo.Context, id UserID) error
+	// Lift the suspension of a user
+	// (POST /admin/users/{id}/unsuspend)
+	UnsuspendUser(ctx echo.Context, id UserID) error
+	// List webhook subscriptions
+	// (GET /admin/webhooks)
+	ListWebhookSubscriptions(ctx echo.Context) error
+	// Subscribe to user lifecycle events
+	// (POST /admin/webhooks)
+	CreateWebhookSubscription(ctx echo.Context) error
+	// Unsubscribe
+	// (DELETE /admin/webhooks/{id})
+	DeleteWebhookSubscription(ctx echo.Context, id WebhookSubscriptionID) error
+	// Get a webhook subscription
+	// (GET /admin/webhooks/{id})
+	GetWebhookSubscription(ctx echo.Context, id WebhookSubscriptionID) error
+	// Get the delivery log of a webhook subscription
+	// (GET /admin/webhooks/{id}/deliveries)
+	ListWebhookDeliveries(ctx echo.Context, id WebhookSubscriptionID, params ListWebhookDeliveriesParams) error
+	// Retry a dead delivery
+	// (POST /admin/webhooks/{id}/deliveries/{delivery_id}/retry)
+	RetryWebhookDelivery(ctx echo.Context, id WebhookSubscriptionID, deliveryId WebhookDeliveryID) error
+	// Delete the account of the user
+	// (DELETE /v1/user)
+	DeleteUser(ctx echo.Context) error
 	// Get an existing new user
 	// (GET /v1/user)
-	GetUser(ctx echo.Context) error
+	GetUser(ctx echo.Context, params GetUserParams) error
+	// Change some fields of an existing user
+	// (PATCH /v1/user)
+	PatchUser(ctx echo.Context, params PatchUserParams) error
 	// Create a new user
 	// (POST /v1/user)
-	RegisterUser(ctx echo.Context) error
-	// Update an existing user
+	RegisterUser(ctx echo.Context, params RegisterUserParams) error
+	// Replace the profile of an existing user
 	// (PUT /v1/user)
-	UpdateUser(ctx echo.Context) error
+	UpdateUser(ctx echo.Context, params UpdateUserParams) error
+	// Start an export of the personal data of the user
+	// (POST /v1/user/export)
+	ExportUser(ctx echo.Context, params ExportUserParams) error
+	// Get an export of the personal data of the user
+	// (GET /v1/user/export/{id})
+	GetUserExport(ctx echo.Context, id UserExportID) error
+	// Download the archive of an export
+	// (GET /v1/user/export/{id}/download)
+	DownloadUserExport(ctx echo.Context, id UserExportID, params DownloadUserExportParams) error
 	// Existing user login
 	// (POST /v1/user/login)
-	UserLogin(ctx echo.Context) error
+	UserLogin(ctx echo.Context, params UserLoginParams) error
+	// Restore a deleted user
+	// (POST /v1/user/restore)
+	RestoreUser(ctx echo.Context, params RestoreUserParams) error
+	// Log in
+	// (POST /v2/sessions)
+	CreateSession(ctx echo.Context, params CreateSessionParams) error
+	// Log out
+	// (DELETE /v2/sessions/{id})
+	DeleteSession(ctx echo.Context, id SessionID) error
+	// Create a new user
+	// (POST /v2/users)
+	CreateUser(ctx echo.Context, params CreateUserParams) error
+	// Get the authenticated user
+	// (GET /v2/users/me)
+	GetCurrentUser(ctx echo.Context) error
+	// Get a user
+	// (GET /v2/users/{id})
+	GetUserByID(ctx echo.Context, id UserID) error
+	// Replace a user
+	// (PUT /v2/users/{id})
+	UpdateUserByID(ctx echo.Context, id UserID, params UpdateUserByIDParams) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -91,12 +742,240 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
+// ListAuditLog converts echo context to params.
+func (w *ServerInterfaceWrapper) ListAuditLog(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListAuditLogParams
+	// ------------- Optional query parameter "user_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "user_id", ctx.QueryParams(), &params.UserId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// ------------- Optional query parameter "before_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "before_id", ctx.QueryParams(), &params.BeforeId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter before_id: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListAuditLog(ctx, params)
+	return err
+}
+
+// SuspendUser converts echo context to params.
+func (w *ServerInterfaceWrapper) SuspendUser(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id UserID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SuspendUser(ctx, id)
+	return err
+}
+
+// UnsuspendUser converts echo context to params.
+func (w *ServerInterfaceWrapper) UnsuspendUser(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id UserID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UnsuspendUser(ctx, id)
+	return err
+}
+
+// ListWebhookSubscriptions converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWebhookSubscriptions(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWebhookSubscriptions(ctx)
+	return err
+}
+
+// CreateWebhookSubscription converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateWebhookSubscription(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateWebhookSubscription(ctx)
+	return err
+}
+
+// DeleteWebhookSubscription converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteWebhookSubscription(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id WebhookSubscriptionID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteWebhookSubscription(ctx, id)
+	return err
+}
+
+// GetWebhookSubscription converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWebhookSubscription(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id WebhookSubscriptionID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetWebhookSubscription(ctx, id)
+	return err
+}
+
+// ListWebhookDeliveries converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWebhookDeliveries(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id WebhookSubscriptionID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListWebhookDeliveriesParams
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", ctx.QueryParams(), &params.Status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter status: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWebhookDeliveries(ctx, id, params)
+	return err
+}
+
+// RetryWebhookDelivery converts echo context to params.
+func (w *ServerInterfaceWrapper) RetryWebhookDelivery(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id WebhookSubscriptionID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// ------------- Path parameter "delivery_id" -------------
+	var deliveryId WebhookDeliveryID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "delivery_id", runtime.ParamLocationPath, ctx.Param("delivery_id"), &deliveryId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter delivery_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RetryWebhookDelivery(ctx, id, deliveryId)
+	return err
+}
+
+// DeleteUser converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteUser(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteUser(ctx)
+	return err
+}
+
 // GetUser converts echo context to params.
 func (w *ServerInterfaceWrapper) GetUser(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetUserParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-None-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-None-Match")]; found {
+		var IfNoneMatch IfNoneMatch
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-None-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "If-None-Match", runtime.ParamLocationHeader, valueList[0], &IfNoneMatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-None-Match: %s", err))
+		}
+
+		params.IfNoneMatch = &IfNoneMatch
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetUser(ctx)
+	err = w.Handler.GetUser(ctx, params)
+	return err
+}
+
+// PatchUser converts echo context to params.
+func (w *ServerInterfaceWrapper) PatchUser(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PatchUserParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "If-Match", runtime.ParamLocationHeader, valueList[0], &IfMatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PatchUser(ctx, params)
 	return err
 }
 
@@ -104,8 +983,28 @@ func (w *ServerInterfaceWrapper) GetUser(ctx echo.Context) error {
 func (w *ServerInterfaceWrapper) RegisterUser(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params RegisterUserParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.RegisterUser(ctx)
+	err = w.Handler.RegisterUser(ctx, params)
 	return err
 }
 
@@ -113,8 +1012,105 @@ func (w *ServerInterfaceWrapper) RegisterUser(ctx echo.Context) error {
 func (w *ServerInterfaceWrapper) UpdateUser(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UpdateUserParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "If-Match", runtime.ParamLocationHeader, valueList[0], &IfMatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UpdateUser(ctx, params)
+	return err
+}
+
+// ExportUser converts echo context to params.
+func (w *ServerInterfaceWrapper) ExportUser(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ExportUserParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ExportUser(ctx, params)
+	return err
+}
+
+// GetUserExport converts echo context to params.
+func (w *ServerInterfaceWrapper) GetUserExport(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id UserExportID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.UpdateUser(ctx)
+	err = w.Handler.GetUserExport(ctx, id)
+	return err
+}
+
+// DownloadUserExport converts echo context to params.
+func (w *ServerInterfaceWrapper) DownloadUserExport(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id UserExportID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DownloadUserExportParams
+	// ------------- Required query parameter "expires" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "expires", ctx.QueryParams(), &params.Expires)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter expires: %s", err))
+	}
+
+	// ------------- Required query parameter "signature" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "signature", ctx.QueryParams(), &params.Signature)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter signature: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DownloadUserExport(ctx, id, params)
 	return err
 }
 
@@ -122,8 +1118,192 @@ func (w *ServerInterfaceWrapper) UpdateUser(ctx echo.Context) error {
 func (w *ServerInterfaceWrapper) UserLogin(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UserLoginParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UserLogin(ctx, params)
+	return err
+}
+
+// RestoreUser converts echo context to params.
+func (w *ServerInterfaceWrapper) RestoreUser(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params RestoreUserParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RestoreUser(ctx, params)
+	return err
+}
+
+// CreateSession converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateSession(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params CreateSessionParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateSession(ctx, params)
+	return err
+}
+
+// DeleteSession converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteSession(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id SessionID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteSession(ctx, id)
+	return err
+}
+
+// CreateUser converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateUser(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params CreateUserParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateUser(ctx, params)
+	return err
+}
+
+// GetCurrentUser converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCurrentUser(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetCurrentUser(ctx)
+	return err
+}
+
+// GetUserByID converts echo context to params.
+func (w *ServerInterfaceWrapper) GetUserByID(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id UserID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetUserByID(ctx, id)
+	return err
+}
+
+// UpdateUserByID converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateUserByID(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id UserID
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UpdateUserByIDParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "If-Match", runtime.ParamLocationHeader, valueList[0], &IfMatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.UserLogin(ctx)
+	err = w.Handler.UpdateUserByID(ctx, id, params)
 	return err
 }
 
@@ -155,28 +1335,162 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.GET(baseURL+"/admin/audit-log", wrapper.ListAuditLog)
+	router.POST(baseURL+"/admin/users/:id/suspend", wrapper.SuspendUser)
+	router.POST(baseURL+"/admin/users/:id/unsuspend", wrapper.UnsuspendUser)
+	router.GET(baseURL+"/admin/webhooks", wrapper.ListWebhookSubscriptions)
+	router.POST(baseURL+"/admin/webhooks", wrapper.CreateWebhookSubscription)
+	router.DELETE(baseURL+"/admin/webhooks/:id", wrapper.DeleteWebhookSubscription)
+	router.GET(baseURL+"/admin/webhooks/:id", wrapper.GetWebhookSubscription)
+	router.GET(baseURL+"/admin/webhooks/:id/deliveries", wrapper.ListWebhookDeliveries)
+	router.POST(baseURL+"/admin/webhooks/:id/deliveries/:delivery_id/retry", wrapper.RetryWebhookDelivery)
+	router.DELETE(baseURL+"/v1/user", wrapper.DeleteUser)
 	router.GET(baseURL+"/v1/user", wrapper.GetUser)
+	router.PATCH(baseURL+"/v1/user", wrapper.PatchUser)
 	router.POST(baseURL+"/v1/user", wrapper.RegisterUser)
 	router.PUT(baseURL+"/v1/user", wrapper.UpdateUser)
+	router.POST(baseURL+"/v1/user/export", wrapper.ExportUser)
+	router.GET(baseURL+"/v1/user/export/:id", wrapper.GetUserExport)
+	router.GET(baseURL+"/v1/user/export/:id/download", wrapper.DownloadUserExport)
 	router.POST(baseURL+"/v1/user/login", wrapper.UserLogin)
+	router.POST(baseURL+"/v1/user/restore", wrapper.RestoreUser)
+	router.POST(baseURL+"/v2/sessions", wrapper.CreateSession)
+	router.DELETE(baseURL+"/v2/sessions/:id", wrapper.DeleteSession)
+	router.POST(baseURL+"/v2/users", wrapper.CreateUser)
+	router.GET(baseURL+"/v2/users/me", wrapper.GetCurrentUser)
+	router.GET(baseURL+"/v2/users/:id", wrapper.GetUserByID)
+	router.PUT(baseURL+"/v2/users/:id", wrapper.UpdateUserByID)
 
 }
 
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/9xVXWsbOxD9K2LuhXsv+GadJi1035rQD9OWQto8hRDk3bFXYVdSR7NOTdj/XiTt1nZX",
-	"IdQklPTJa3HOfJyjGd1CYRprNGp2kN+CKypsZPh8i3zukM7QWaMd+iNLxiKxwgCoUJZI/utvwgXk8Fe2",
-	"CZb1kbKB/y6iuwm07n6WzwxdNwHCr60iLCG/GBL2ES4nwGuLkIOZX2PBPvQZLpVjpCdY+E6yUckNOieX",
-	"8btEV5CyrIyGHF4RybUwC4FEhkQP/Nf9dwATUIxN4PQJHZPSS5+wP5Ce7f+7tijQJeKfGFOj1IKNKFEb",
-	"RnFTIVdIgvqahXLi03thSGjDPmsfex6ZIzWGVJNNVylJzm0pGR/DybQ7yRpcyoxFW9dXWjY4VssT/nHC",
-	"I4RHbMmx0V6VIYqhRjLkoDS/ON7glGZcxgtnpXM3hso78wyAZBpbGY1Xum3msYl0BA8SEZSI0t0hygez",
-	"VPoJTZjHK70wPnitCuyrjibCx9mXMBOKa+ylEZ+RVqpAmMAKyUXNDg+mB1OPNBa1tApyOApH3iquQuvZ",
-	"6jAb2lgi+x+vjPSyz0rIh70KvoHYf+A9m079T2E0ow40aW2tikDMrp0vYNjP90n08+oO7Y/tFwWhZCxF",
-	"P5H+1oZlcDw9Gl+YN4bmqixRe8TzWO0uYqYZSctaOKQVUtxIwSrXNo2kdexeSC3wm3Ks9FJovBFBLn9h",
-	"jUvotb3TIbqOjk9MuX4wvfortXOnmFrsRh4dPljO5FP160YlbDiRpehVEv+LmV7JWpVCadty5Lwcc06N",
-	"XtQqzvfe3p6GKoXcNbVNeLrZ7b/d0YebusSDdZefbYDuM3iP514sf2c422HVDkstq/3eD/s+Oas/noY/",
-	"ydbRc5dwNQC27Nx3OPd27/W2ZyLaFOqMHAf5xS20VEMOFbPNs6w2hawrb2N32X0PAAD//0qC0fUIDAAA",
+	"H4sIAAAAAAAC/+x9a3PcNrLoX0Hx3qq7D2okK072rrbOB8d2Em3sxMeWTk7VjmsIkRgNYg4wAUDJc1L6",
+	"76e6GyDB1zxkKbGy+iQNiWej3+hu/prkernSSihnk5Nfk4XghTD478szfgl/C2FzI1dOapWcJP8ljJVa",
+	"MT1nbiFYZYVJmRWqYNIxqVh2Oj94zV2+yJjTrFoV3AnmFtKyq9DTYKMftBJRS63KNbsUjilxLUxobCdJ",
+	"mth8IZYcluLWK5GcJNYZqS6Tm5ubNFlxw5fC+TWfFmK50k6ofP29WPdXf67kL5VgH8Q6bMCIXyphXcrk",
+	"REwYZ+fnpy9SZjVsJ+eKXUATZ6QomOVzUa4n7Ay72ZVWVoRh5tJYN1V+NCYts04bUbC5Nuz4KVvoyljG",
+	"VcGMWJV8LYqUXUu3YBxAFlbtDt76tyfMmUpkjM4jZU5PFa3DUj+Y1PIl7mXC3orKSnXJOG6NBmaFnM+F",
+	"EcqFTcJOKqMsy54eH2eTqUrSRAJYaJYkTRRfiuQkBuMBwDE+gyX/+EqoS7dITo6//DJNllKF30/S3gml",
+	"yekcD7l/FoBfAXxXfayifwh/pGUX3IqCaZUybv0+RMEu1iz79uUZO7x6cgidMqbNVPlHx/jIHv4qi5uM",
+	"jq0Zz4ifRe5EQcDKnj45ztj1Qqhm/mtuWb7g6hKOXqpceByZG71kXGm3EGaqCnEl4ZXVvrFl3AimtGNW",
+	"lkK5cs30lTDXRjon1IT9JN1CVw7nIbh3dspXqxLQzWl8kVcGznCqPIw2HZsnvY00AycCtLfhVGznWGzv",
+	"XPJSAl4tuE2Rnv+STVj2xdHTjMnodK79Vjm70MW6Bu9U+T3Vxy4t0yrQ0nLzDhu+sWWb74SFwU9f9Dd5",
+	"+iLsyFKjFH9kPzuZsbzkcgnvpbPsnz+dTcJiVtwtmqXIIkkTICxpRJGcALluXs+5Feblx5U2bvOSBLYJ",
+	"v1bCWK14yQrueHwOd7mszQu6/WxzbZbcQTvlvnqa1OxBKicuhcH5fxIXC60/vBClvBJmvXkp19SYFb71",
+	"yLLC69ndre9ddVEvabc12qjHfYHvJk2CJEL59yzPdaXcqeK5k1cCHuVaOaEc/IucJeewoMOfLSz712i2",
+	"/2vEPDlJ/s9hoxEc0lt7+NIYbd76mRAs8Vgroy9KsfzrfmO+oV60izYwv9HmQhaFUOwA2TanbaFYrexK",
+	"qAJkgQE0EE4UyU2afM2LtyTlHuqmv+ZFLagP2Km64qUsmFSrysEGn2s1L2X+YLdXr/8mbc73wSMo7OZb",
+	"rR4spcHaPZEZYXVlchRAwI9QWwUlKKKyU+WEUbx8J8yVMLjWh7rzsBVmcS9M4GZu0uQH7b7RlSoe6sZA",
+	"oKP+Ocdd3KTJGyNyrQoJDb7hshQPdm/xTtgct9LF3p7a3jIwWhZqglqiAQX+XPErLkt+UT5YUvY7YVW0",
+	"FdDvFK/cQhv5Pw/32OM9sAP2Wlq0drVh0otJnufCWub0B+LJ52plNDwCMLxUTrr1w918tBUmcC+gIDQ2",
+	"Ohn93IKmTh6HAeOfoGKrFVgXongtCsnPUJ98qGCp98KWsBkG2jE7YF4NJJtTWmSFvV3BeH4S1JurQrrn",
+	"yDUGXF68rNA45WwuRVmwCzHXRqB85HMnANzEcVKW0Tu0gytlhcPjwG6WwU+tmBGX0jrDg2GwMnoljJOk",
+	"wuOIA6ZamtDQw1acf6IvwKsBjXFHr/TlS+XMgCOM9go+Bq4a9Zq8Hxy6slJfTthLni8A5QxCMl9wqRrP",
+	"xMqIK6krtN1PWLbgdoH7hnfvvnt2cPzlV0zPpwp+0xgAsQy6zbAxek0EcHMiZlQ04H+ufIcLI/gHGhEn",
+	"n0wVMHvozXJwq9C7Kzghy3jwuqVMqNysV7V/BxpFTj+0aKeqD/ucwNOF1k8L7kgV0iq4gTIchM5SGFFk",
+	"qX9Enpzmt1ef6t+1BZOh6wSfCcMtNCFgGZELuapdAPCyMh4D/Wi4BY990ll2aXiOzgKpC9pZD3147rQZ",
+	"2ptmS16IAGRCYxj+ZFodHX2RywL/igxRGV7YlGW8WEpFO7Br68SSXnfWi60nQ6uhmQjqBQl0Xr5pncYm",
+	"xhATbI81nNU7KTzppeApBKOb3IAeX/ScEeBpnei4y/5Fj95nk2SArHIj4HBn3LUMdDjxAyeXYmirgK2D",
+	"BC2Lnaz8NKlJpn983wEp6HmbIJF4UiaWK7eujwVd1PRq8EQcN5fCzQAUsx1XdhN7L/5F7gxCszTQUm/c",
+	"5uhb0Iw36UH2fgD+LxD9QcWtJc3Jrx0q9k7DLRgU+n9HrXErwD1Ays4q5WQ54BxVRYB1THApw/bseiHz",
+	"ReMora8P/FUAeZrf/Piu8Vcf+peIbLtgUwfitX+0t/Yh4KGEfq6LARn3zkFftuT5QipYMy/wAVpFLNeF",
+	"gBUKVS3xoEnrmnnVYgaiNkmTqhHJMxTJM1xCmmBrlHczUtqx8Qelr9UMCRR3wIsZ3AJFT2ij9GC2JK0P",
+	"PWk0PT33c7QeAjottBIzVS0vAO/8y5URc/lx7G1J9xidt0o7+FcYmcMhVWU5A07S79V/lS+44bkTZvAt",
+	"wR+m49Zea1MMLCS88Zuf5XwlHS9npXA0bK8FrXrojV2JXPKytap6pUYUQjnJS6DL0CNo3XR0NbKFTlte",
+	"kzKeJvh35r0KSZp4n/vMiCv9AZ+shMEptZoVQkl8FtbQ8I3K+tMgw7YZKH7mva+z2Ps62KD2EW99WQge",
+	"v6tMGQ4K2BzIwRlfyVkhUUOHh7LRzWcfxHpmRGV7LwLxSDVbGX1phLVd1OMlUMV61mgZMOPQw1VkGDc0",
+	"5hW7Wa1yRM+CV6d5onT8lK4hWtDxj5qTLPS1KjUvZqVUHyKgBAxAOYsHhabpLDZN00R6D8yMXC+NK3vW",
+	"5xfvBwRW2+DosbTwhgl1JUq9Es3FFOrkOHCwjmxfEb+dGBlm0EPc+Fvh7keOVXZ7r3M7vlg/wtCavVf6",
+	"DVy69xece9Gy1UpEGXQTrkG6B/cDXGvX1/P+mhbZ+qDeYgS3w0r72us9YLNd8+bOd7JVruLCUtpQPcMQ",
+	"QIKB2se+b56zv/3/o78xb/iyQjguywE82xtqNNKAdvJxVXKFZMOQ189lTrYa3K/mdOmai0ZbxIUNwlQq",
+	"67jKB6mKrGu4yIIbXSPiwcI0xcioXgoD+tjxsb257NDqghnig5NOLLeaBy08bcxjbgxfw2/ruKsGVvDd",
+	"2dkbRi9R6WnQkIhsMqieO+nKIZ1qoY1jtlouuVl3oI7OimE1fL0aGOv87SkzAj06uWASZfV8DbbyDoN2",
+	"kNsrTLTqGhYe24eQ/K2XNA+OXWE0Te7NhfpycNjenPPSir6/Wc9lKdrRThjBkwfYh1gGrUSftmulb+BE",
+	"rTD/zzJogZZpyjJVlWXGjFjqK2GZdDAePCOvNN0O9/AlVhhGZ8FGjBptR5DWkJHmOgLj1oH20GIprOXe",
+	"0m+v7RlQIxnhYGT4hn+yf26R+QiBRLRcoe+3P/7XWpeCK2CBhVDaCeBXbiFME70lLfvxe3BiKO0iuFxQ",
+	"zx5gwlRps6sRkDht7sVK3V298OEvWxG+I4y2+zeM4MWPqlyPYiTph/aTxpBF/zz3jtrZOssmX8dI542+",
+	"j8jHEXs2IoAMndQ5Ogx/X2w590z41qjS1b3iSLrGUhl1c2w9qr04adg0467tbWenGETCL6xQrhPvR3y3",
+	"8Hx3V2fdNjRJk5JbNyv1pVQ7gAoag+sdXJdSpWGl5F9yC7Fmhb49EINLYFxO+AZ9CKQJRDCKZvg7Ej01",
+	"/55XpYcSWqMDupm+Zkuu1gx2ayOQBWi14LL7+Xh//ebDWXlFAMwIPCPfq3NCEtDrtudzM0KXL7jjz0y+",
+	"8AFdHaUf3BRuEbQRb2mzhQYNml9Q+CV68+N9EOdEcLOFBGlFlzPNHYANtzzN5dBUdW6HzqIJjci1KSxT",
+	"muVaAUgsXu84NBEoJFbhhRN5EQavX2BoQIL+Ns/imdGJLUXYX6OZKXFN9oOxbmdboX1TNqBg0IL3dPoT",
+	"KnvYDu/HSzHb1i5vtYeAJq9g1qE9+HPfdRyv9/YkSAyKZtDubtPoJN9vQGpabU/WtfWHEYL0wKuDlbTx",
+	"/9pdXem46ksBXte9Tta7LXdh5sSZAEe7iouce24ui1u6/luL365jdA6277u5xcXWBpn87O4k7A4Sdbfl",
+	"dsXVHpJoh0W1xcgtDlR2by26auTI8saOm2LNB91EO8WXE3snX4i0J1PF2F9YthKqkOoyYwfYkpNcwgwN",
+	"AULoopKl822BwdDdd6e1vxkL7mNS1LLwEzzsmReoWYPXmR+VHLe9IXVVFhjrcSFoESks3riQquHljh/E",
+	"s43uKFG441j3Af+d3+VeyHgbeosBNOBukpdKFAxc8Wjy+sbx/kAzLrmDnXrpTsGPKYamXC9Aw4lSEKRl",
+	"9eZAdZ4qfxq945pFx5SylS7LeBx+yaXycUlKXOMSR4IURgbdHUY7CY8Ia5vTxuCcfBwAO4sV4mO9x43P",
+	"MVyjelpC15ufBSYJtzceR5P3O3GOxo3XYNZm1jBubYqadWxTFmgkGPcuLdSgc41uADWHXV167b19gg10",
+	"d962TZt6QP5V6PZamEvxJiST7eFb/ee7H39g2Jth91aSmVctT4J55a8EuBGsUj62Z9L1mELvyAtA4RjS",
+	"TpVe0ZpSNHL62X/DkQZNRFN8oM24tZthyJb5HD2/jWNkh/HHsPQd3iXfwrW47Z4Ozz3O8Em9UYnGfTcy",
+	"kRw5mGa50NcqyLOQqxZlqX55dLQ9SzXG+w3XfZ1ktf5mwhsM1lNMXAHyOs14Kx3MJ7G2NxdiDnB/fdvY",
+	"ObFcuQE39w94tjihb0PxfFazOTeTnXwgd+H29ev/xFEQYt4nu2PjcGO2icH5g3sJHTDq+ZM9eh7Yn7RZ",
+	"HEiELJphsoA24WR90MJk57FJKZjlYzFfY/ecgZziyVMf0Iw2ndJNW7paz4W82ri0CIBKfOwCcERTg5b1",
+	"7kFdq0RKtQJCbLVXogL5SGFv7x9tVLQdcCmQOoERu8cxR3d4rdAdN6KSFg1ESmDNLrbqg8O7GUMWjEHu",
+	"ZuGO2IbhNZzbL5WoyEtzzSneG85OOts6YT9OzUnCSB67DOPKXos6lpKz7Pjjx8wbqXVnjv0ETt0mnLS3",
+	"LqVZqdUlOieoykOlSmEteXLrh+Ij5A9IV65R1PcV+HrJ+D8fDljqMaG+WxBSGBDG5ECSc5Gv81KQIAlw",
+	"7kaes4PQAZOf6Lzjtq2gMq89Rb286wKAI01LY4gHaQIYx0fQjZOHBquVnHik2nUVjeBXzXjwkcUdfOBZ",
+	"z5+wJVWe1zoFRszFVnHrGDvw9BrvINTCux4wwot6b+FBd+2bMCNOMt9Tm0akIgXZep0jQz+KVIxT1HFW",
+	"pxdfL7QVDBXwkCWDN6kxgmZQVYTIzrJlZbFag5yvqYJD9t8HvvEB+B24q4w4Ye4/KGWgUvIjXdM4vlzh",
+	"M5FePfFvbegQ8gpCkZMmnKhuEpa2EB+ZUCCqiqn67vWz5wfvvntGCSYsG590Qq9MlBIUJv0g1rX+b0Vu",
+	"hMvIPLALdCWRicB0WTSD2slUTZUHNSJYzJoR+DUjCGqdNr7Ex7oOjkrBf1IKbsnjQDczmSyygMmkNoLl",
+	"Yikum+dG28COLKh2pj4bcKYV0ubcFKyoKM9J2Al7UQtEiqQKZUkGeSjOBAweWgWuhw3ER5J/kpfsgucf",
+	"9HxOcFpy8wEI1gaeG7wn0jSaKAwnPi54Zd2wnXQnAQdXoXxRn5vaFkxtTRzwqFehYZerliFNcinVKfV9",
+	"0rl6SZMKSw751/7i9NaaJyFqf6ffN7lNDen4B5FiFKwmkI11NGzHXOoV9flql6vgQY/kswury8oJli2c",
+	"W1GyEPxnM3b+9lV8KhHb2h6eBHPVh17DpK/bQD+p5nr49u3qmD17c0rCwacOayOFcqI4ifKBfKidpGJD",
+	"hN2nLwJxQxQkVcBZitpdMMVsVeiW85CqRSQDwgF0H+DJii6GCP+pNBT5Im0jA3220hNcqRFLLpWdNjHU",
+	"nl1xgz4D8MAv+JXUhgj36pi4lTHa1HSNJx5Wn7UiqLMmUPpizQox51XpJuw5lvrxPMQKVUxV9izPxcqd",
+	"sLEEzyywJzYWBsuksk7wugpWzel6GSgZMP2svp1uBwRbTOhux5VmuO3XPkALN17qnJeYPIzFm3DvtIeD",
+	"V1xdVvxSNMKRpNGEvavTS7E7DTVVmVC0GmDY//BGyqV2kjdNW1WQArCfU5Jta0ZvQfkpYd3eqUnr9nHw",
+	"oiAXunUUyxuCexHwIRsVJQx0WnBVgJHIzinDBhY7VQDPAzScIldatwjW0VGWgpDsyYysm0Cb1XWzviR4",
+	"A6Jmhz4rENB1yRXCf6gcjk2Dl8cGUlOoZGsr7LCnp01SdVIn5pawXKu5vKwaqQs4+uL16Q+zZ29OZ2c/",
+	"fv/yB8RuabRaYuEpbiRgV0pcB2TYsziZ5YR9LbgRhpH+gLN4xYEoOSR71NYwLQREdrOYCfupbSlJj48W",
+	"r2pg8kbpinU/X5utlLnwrmBfMOj16VkUcEylHnzef5ImvshBcpI8mRxNjqClXgnFVzI5Sb7ARylWIEIh",
+	"R4d1iJA+8EEXl0PC5a2vGhfyeGUjVPkKDpHwqj6yTgwDe8MvAT2Nri4XIe4EWMyKUxo/PLJLXpYCSuih",
+	"HlQTD5wMIfhMFh4seiUonOu0SE6SV9K6EMKRtOsB/qu7ExBZfux+qIu07UpXv1QCYxk86JvYvr3qR21a",
+	"Qg3MklRErmgZdY7m0DpqaHzqSl7zj3JZLb2lByca1uO0X+HYGkq5lK41vxcX4G1FbQJGTk6eeNer/zWw",
+	"qvedAlbHR0d7FSS4iwifm8EEYg+MNjJD56dHR2MT1ls5jMpRYZcn27u0CnVgpy+2d2oVIvpyl5UNlfDB",
+	"vjvMNlAs5Qb9XpjiAOWEhGuzb7SSfASZpzbs4plPU6Dx0AsEuqJr0fDQmpomh76AHaDSStsB/vUuiJo4",
+	"nM2rY1EcoQ/TIb9RrYC9q+uNkaDKuVKatobxmaoIQnKqiGhqTRHEYD0AVee0rdF8JoEXeXTtMMTi/BCw",
+	"UZ8WK6z7GnJu76p2R+di56atcnsdv0OoT4fvnKI9NgEr5frzppynR0+396gLQ0GHJ1/usq6BciufBZ16",
+	"jPIeshGKhHTu+6LJszhJnoiJdNwJO7fCdNTPBqXgSSnmqLIFjXfSI5jzsPKaZHbC3Er9W+Du749+r+Tc",
+	"eQ9M4DmNvzbGRm882Eg37Wt/Awq0TX4LvWJg4l21i441BBpgW8f4N1EY4PyGTcTkpuEd7TN/ji6TIeDf",
+	"j3AcPOZdJOST+19CV9Np3tc3Ki1OlsYV3l9pWsxQuumrYOI1w7SdpRuqsn/e7PKhS248hgu8oh+6JLRD",
+	"/BMFOh1zKdzAxeOb3i167U/u3nA0V6ZWKOdDqlz7MrWU1odMtkmXyveMke42Id1C71AI61FQ3yO2AR14",
+	"fINBB0Xwt8LtdKBHvzU37MrZR+y4B2ufD0rv5Cbd02IYLrN+836Elx02jGoX3bC5C93HRdjMERzO+F0P",
+	"uCcNRQDp5jPEicIvRqWZ7JjvrA7R2QvXu8FGu7jzouX/0Tx6HajsqnU3EHlIbr0Hyhm6Ma3t2LHfi1sc",
+	"/hp9HOLm0AhfHvVO5k937Rh97WLcR/KflcCqpg0U0SlSh1PgnW8TaQHek3CdyuZG2AXltDRxwX197C1s",
+	"v0tM9y+5G7odplMfLVhE5P1H8h0e/X17h/hzCb8/PSOeICby+FiA3Hwly03GBWn9t/O/x5//GCqoOVUj",
+	"FTVDAmG3MiZc4GLZXJ/G4WwncFBaXxV2yBXf1B+9TzIZqHI6Vuh/3Ax6lE3DuEzAbSFjlHm0yczxx76f",
+	"qIi/tvXJytEmpOnWExzDmO1+ofDlv6HZfLNDbINTfDFkqtf+/UJSVi5d/o1/kSH6HOCnLOYR7Tcaa1Av",
+	"QVqMv4dE3IDwq+FvwT0DXEQNpJer9ycM7Pri71/9uakG3yuVtjF7DyVAUxh/qjBLCiuGYsM9k/xYyPFr",
+	"knib4O+6/BCKE4zrnipvKEnLFvq6VbTBhgA9DHvm5TVfW7bgVyIsECub8LCyOGC+l1w4VaPZhYa7RYj7",
+	"8B/9m7Bvaoh4wEpTl5hrgNO9AgulVqYqA1rI4lOgdBkCpyV3XVDCo+hLbEQOPQxpCt864LmreNkMMCQU",
+	"ESVuyRxjxriL634JSHiACPvX/e+4o2TVnTz4d8edB4p8jTHokEzxR74+31cFfvrkeHuHge8cPXyHv/9S",
+	"h9VLEdilnrc4ec3FB2/L4uKd+9Nn+3u6u5Pp/qT5W1+pDRY13Utnui1J7o36n4C/T4+Pd+nc/1jS54H7",
+	"CHZfNaRG82owRjWKpBpURciuJKGGcv1CRBdYtTCvVYs4Gx9iUlj25tnZ8+8y/BRzJFJjyozEt3LkitlL",
+	"grcry/ptTBUOJJ0V5bwOWVUai74NieNG1PwG8nhfousV5H2Uw49y+CHIYY+6XQbTF8SRU+ywKaQzEprq",
+	"uAEFnP2sL1iuy1LkOJLYXtWxsXDIckITra7zZAXYAp3qkdmEvYHSTK1Ppvs10lfSm0KjUQmkqI7XP1h9",
+	"H2xChMBUjSXptsyMEMxHuVQcEkPKcsJ+wqJTCMU4lzfYbj50NqUIgyjE1ucPQXus00WfDaNSXVqJIb5I",
+	"tYruTg9qcajjO1WGOgWaBjgUtaC9Y/bxvnE9BO8UrEyo17VLSM9nysMetIrzzheZ2/Wz70P8pY7uGUzf",
+	"eR4I0k9RuzLaBfdSxn1eki8hB3dN0lIOmi/eNhfXbClV5YQ9QazplnabKuyLLhjpQnFO78UPRddIkWlq",
+	"r2E1uaiA3BDteucmYf3e5Nv69P69OmF3I96zmv4eXfU7+Sw/jTQOA6KP0ghmL7bIgW6r8w7tECYjjvdy",
+	"Ei/WeJkUlS5oRBR9ZFNYC27OD0Khti/rGy29Ej5thLMLo6/rhOF+qbLBTy5lPYoburryPe+MjnohKOeh",
+	"AgNzAUpNwcixyBPfIukaAZ+Y3faumwbvkej0BSXv+mmzsWXVx7hxYV05ed+sJVLmxviKZ7NtjSDkHr+Q",
+	"dqWtHFYO3vHgXw+smlv0A5diF+3gN8i52YEf4cf4P497xoECrLXFQLw/5ldlXQx70FIIydzxZQOlTV8b",
+	"rS7rqpWWXWi3YJfCUT63ZxsD3/mjz+/itKzQ5FJgTpTlVNGlSmcqI1o3KZSmQ5Vk6hrxUfW+sIQvwhJ6",
+	"H6PL/CAwn66VAV9AxI84NohvnbGiMsFKan+SE0AD7NXHAwBz3BgvMOhJCVU5P2fHaasW6m/tTemVLR3g",
+	"Stgg8qLc2oeyA1U+I/w4VTx3yCR/G6fIv62H9mXs+yBm0mZrnrzGGZv/WFI7OigwAyL6Fy9fvTx7WZNs",
+	"lnY0r+AfZRF3wxKbkI3gnazEHaaK2EOoHaqvhJmwV1H+Ya1JUWEYp0e0qegbT4/MYbQUce8zWGO+1gDq",
+	"u3G2PhL8PbpBSZy2v0Tvaf74MHzTZJzen4eCR/UnPEL0fPPVgX/+dNb5YsZgRZ1WyZZsqsI2fD2dYEGR",
+	"B6PxbLY/HWKZNpEzYojWacnhg2p/KGq/u/vUAJ6htEQP8O2RZ60THfiiHxXl6aPHZvsk3TPTcZcxbx6V",
+	"mD8MTyP53+NhW5Mm3+KXgGznyz70/cGW6yZUHQPtIlhOYJvgNXJRx6NKw/S1mqqwgJTRfUb47W0wgj/j",
+	"pMLgx7nHQ5UbrrVDfqWn0zuOKX545QkgWaVy+6eleADWqShXx1RPI5aGQ6LlMTZnbM5bxS/vxe1Dza97",
+	"YPWPHPgTAn1i+jlcish137sZek7RNfedlTGGlfRtwm7Fz8co9vstMNaDdxtjNl6JdiQwXc00EthXbCXh",
+	"25SCHZa8Y1eVX0Ny3++EjZ+Of4+5sHecJd+kWty2jtZtwh5ZKT9A5OJ54/DOxkMhe6opRcARYUzVvpSx",
+	"MTTRk8djeOIeVO3jER+p+zF+8fbxi3WRNXiLExHpYQX3ZOHc6uTwEMtYLzQc/vub/x0AtTIhnySoAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
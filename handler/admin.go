@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/UserService/generated"
+	"github.com/labstack/echo/v4"
+)
+
+// prefixAdminRoutes is the path prefix of the admin API
+const prefixAdminRoutes = "/admin/"
+
+type NewAdminMiddlewareOptions struct {
+	// Token authenticates the admin API, sent as `Authorization: Bearer <token>`. The admin API is disabled when it
+	// is empty.
+	Token string
+}
+
+// NewAdminMiddleware returns a middleware that authenticates requests to the admin API with a static token. Other
+// routes are passed through.
+//
+// The middleware needs the matched route, so register it with echo.Use rather than echo.Pre.
+func NewAdminMiddleware(opts NewAdminMiddlewareOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if !strings.HasPrefix(ctx.Path(), prefixAdminRoutes) {
+				return next(ctx)
+			}
+
+			if opts.Token == "" {
+				return WriteError(ctx, NewError(http.StatusForbidden, generated.AdminApiDisabled))
+			}
+
+			authHeader := ctx.Request().Header.Get(echo.HeaderAuthorization)
+			if authHeader == "" {
+				return WriteError(ctx, NewError(http.StatusUnauthorized, generated.MissingAuthorizationHeader))
+			}
+
+			token, ok := strings.CutPrefix(authHeader, "Bearer ")
+			if !ok {
+				return WriteError(ctx, NewError(http.StatusUnauthorized, generated.InvalidAuthorizationHeader))
+			}
+
+			if subtle.ConstantTimeCompare([]byte(token), []byte(opts.Token)) != 1 {
+				return WriteError(ctx, NewError(http.StatusUnauthorized, generated.InvalidToken))
+			}
+
+			return next(ctx)
+		}
+	}
+}
@@ -1,29 +1,35 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/UserService/generated"
+	"github.com/UserService/i18n"
 	"github.com/UserService/repository"
 	"github.com/UserService/utils"
 	"github.com/labstack/echo/v4"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	//define function wrappers so we can inject dummy function in UT
-	fnConvertRegisterUserRequestToUser func(generated.User) (repository.User, []string)        = convertRegisterUserRequestToUser
-	fnConvertUpdateUserRequestToUser   func(int64, generated.User) (repository.User, []string) = convertUpdateUserRequestToUser
+	fnConvertRegisterUserRequestToUser func(ValidationRules, generated.User) (repository.User, []FieldError)    = convertRegisterUserRequestToUser
+	fnConvertUpdateUserRequestToUser   func(ValidationRules, int64, userUpdate) (repository.User, []FieldError) = convertUpdateUserRequestToUser
 )
 
-func (s *Server) RegisterUser(ctx echo.Context) error {
-	return ctx.JSON(s.registerUser(ctx))
+// NOTE: Idempotency-Key header is handled by NewIdempotencyMiddleware
+func (s *Server) RegisterUser(ctx echo.Context, _ generated.RegisterUserParams) error {
+	response, err := s.registerUser(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, response)
 }
-func (s *Server) registerUser(ctx echo.Context) (int, generated.RegisterUserResponse) {
+func (s *Server) registerUser(ctx echo.Context) (generated.RegisterUserResponse, *Error) {
 	var (
-		context = context.Background()
+		context = ctx.Request().Context()
 
 		response = generated.RegisterUserResponse{
 			Header: generated.ResponseHeader{}, //success is false by default
@@ -33,175 +39,337 @@ func (s *Server) registerUser(ctx echo.Context) (int, generated.RegisterUserResp
 	request := generated.User{}
 	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
 	if err != nil {
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusBadRequest, response
+		return response, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
 	}
 
-	user, errorList := fnConvertRegisterUserRequestToUser(request)
-	if len(errorList) > 0 {
-		response.Header.Messages = errorList
-		return http.StatusBadRequest, response
+	user, createErr := s.createUser(context, request)
+	if createErr != nil {
+		return response, createErr
 	}
 
-	userID, err := s.Repository.InsertUser(context, user)
+	response.Header.Success = true
+	response.Header.Messages = []string{i18n.FromContext(ctx).Message(messageRequestSuccessful, nil)}
+	response.User.Id = &user.ID
+	return response, nil
+}
+
+// NOTE: Check Authenticated cmd/main.go that returns JWT token after successful login
+// NOTE: Idempotency-Key header is handled by NewIdempotencyMiddleware
+func (s *Server) UserLogin(ctx echo.Context, _ generated.UserLoginParams) error {
+	response, err := s.userLogin(ctx)
 	if err != nil {
-		if utils.IsUniqueConstraintViolation(err) {
-			response.Header.Messages = []string{duplicatePhoneNumberErrorMsg}
-			return http.StatusConflict, response
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) userLogin(ctx echo.Context) (generated.UserLoginResponse, *Error) {
+	var (
+		context = ctx.Request().Context()
+
+		response = generated.UserLoginResponse{
+			Header: generated.ResponseHeader{}, //success is false by default
 		}
+	)
+
+	request := generated.UserLoginRequest{}
+	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
+	if err != nil {
+		return response, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+	}
 
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusInternalServerError, response
+	session, loginErr := s.login(context, request)
+	if loginErr != nil {
+		return response, loginErr
 	}
 
+	// Set data to Echo context so we can rely on AuthenticatedMiddleware to generate and return JWT in the Authorization header
+	setSessionClaims(ctx, session)
+
 	response.Header.Success = true
-	response.Header.Messages = []string{successMsg}
-	response.User.Id = &userID
-	return http.StatusCreated, response
+	response.Header.Messages = []string{i18n.FromContext(ctx).Message(messageRequestSuccessful, nil)}
+	response.User.Id = &session.UserID
+	return response, nil
 }
 
-// NOTE: Check Authenticated cmd/main.go that returns JWT token after successful login
-func (s *Server) UserLogin(ctx echo.Context) error {
-	return ctx.JSON(s.userLogin(ctx))
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+func (s *Server) GetUser(ctx echo.Context, params generated.GetUserParams) error {
+	response, err := s.getUser(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	// The client already has the version of the user in the ETag set by getUser
+	if params.IfNoneMatch != nil && etagMatches(*params.IfNoneMatch, ctx.Response().Header().Get(headerETag), true) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
 }
-func (s *Server) userLogin(ctx echo.Context) (int, generated.UserLoginResponse) {
+func (s *Server) getUser(ctx echo.Context) (generated.GetUserResponse, *Error) {
 	var (
-		context = context.Background()
+		context = ctx.Request().Context()
 
-		response = generated.UserLoginResponse{
+		response = generated.GetUserResponse{
 			Header: generated.ResponseHeader{}, //success is false by default
 		}
 	)
 
-	request := generated.User{}
-	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
+	// Authorize and get userID of the requester
+	userID, authErr := authorize(ctx, utils.JWTPermissionGetUser)
+	if authErr != nil {
+		return response, authErr
+	}
+
+	// Get data for the userID
+	user, getErr := s.getUserByID(context, userID)
+	if getErr != nil {
+		return response, getErr
+	}
+
+	response.Header.Success = true
+	response.Header.Messages = []string{i18n.FromContext(ctx).Message(messageRequestSuccessful, nil)}
+	response.User = newUserResource(user)
+	ctx.Response().Header().Set(headerETag, userETag(user))
+
+	return response, nil
+}
+
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+func (s *Server) UpdateUser(ctx echo.Context, params generated.UpdateUserParams) error {
+	response, err := s.updateUser(ctx, params)
 	if err != nil {
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusBadRequest, response
+		return WriteError(ctx, err)
 	}
 
-	// Get user's phone number from request body
-	validPhoneNumber, errorList := validatePhoneNumber(request.PhoneNumber)
-	if len(errorList) > 0 {
-		response.Header.Messages = errorList
-		return http.StatusBadRequest, response
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) updateUser(ctx echo.Context, params generated.UpdateUserParams) (generated.UpdateUserResponse, *Error) {
+	response := generated.UpdateUserResponse{
+		Header: generated.ResponseHeader{}, //success is false by default
 	}
 
-	// Get user data
-	user, err := s.getSingleUser(context, repository.UserFilter{PhoneNumber: validPhoneNumber})
+	// Authenticate and get userID of the requester
+	userID, authErr := authorize(ctx, utils.JWTPermissionUpdateUser)
+	if authErr != nil {
+		return response, authErr
+	}
+
+	// Replace user data, every field is required by the spec and a null full name removes it
+	request := generated.ReplaceUserRequest{}
+	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
 	if err != nil {
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusInternalServerError, response
+		return response, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
 	}
 
-	// Validate password format is valid
-	inputPassword, errorList := validatePassword(request.Password)
+	return s.applyUserUpdate(ctx, userID, params.IfMatch, replaceUserUpdate(request))
+}
+
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+func (s *Server) PatchUser(ctx echo.Context, params generated.PatchUserParams) error {
+	response, err := s.patchUser(ctx, params)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) patchUser(ctx echo.Context, params generated.PatchUserParams) (generated.UpdateUserResponse, *Error) {
+	// Authenticate and get userID of the requester
+	userID, authErr := authorize(ctx, utils.JWTPermissionUpdateUser)
+	if authErr != nil {
+		return generated.UpdateUserResponse{}, authErr
+	}
+
+	request, errorList, err := convertUserMergePatch(ctx.Request().Body)
+	if err != nil {
+		return generated.UpdateUserResponse{}, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+	}
 	if len(errorList) > 0 {
-		response.Header.Messages = errorList
-		return http.StatusBadRequest, response
+		return generated.UpdateUserResponse{}, newValidationError(errorList)
 	}
 
-	// Validate input password (plain) matches user's password (hashed and salted)
-	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(inputPassword)) != nil {
-		response.Header.Messages = []string{"invalid password"}
-		return http.StatusBadRequest, response
+	return s.applyUserUpdate(ctx, userID, params.IfMatch, request)
+}
+
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+func (s *Server) DeleteUser(ctx echo.Context) error {
+	response, err := s.deleteUser(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
 	}
 
-	// Increment successful login count for the users
-	s.Repository.IncrementSuccessfulLoginCount(context, user.ID)
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) deleteUser(ctx echo.Context) (generated.DeleteUserResponse, *Error) {
+	var (
+		context = ctx.Request().Context()
 
-	// Set data to Echo context so we can rely on AuthenticatedMiddleware to generate and return JWT in the Authorization header
-	ctx.Set(string(utils.JWTClaimUserID), user.ID)
-	ctx.Set(string(utils.JWTClaimPermissions), []utils.JWTPermission{utils.JWTPermissionGetUser, utils.JWTPermissionUpdateUser})
+		response = generated.DeleteUserResponse{
+			Header: generated.ResponseHeader{}, //success is false by default
+		}
+	)
+
+	// Deleting the account is an update of the user, sessions are not granted another permission
+	userID, authErr := authorize(ctx, utils.JWTPermissionUpdateUser)
+	if authErr != nil {
+		return response, authErr
+	}
+
+	restorableUntil, deleteErr := s.deleteUserByID(context, userID)
+	if deleteErr != nil {
+		return response, deleteErr
+	}
 
 	response.Header.Success = true
-	response.Header.Messages = []string{successMsg}
-	response.User.Id = &user.ID
-	return http.StatusOK, response
+	response.Header.Messages = []string{i18n.FromContext(ctx).Message(messageRequestSuccessful, nil)}
+	response.RestorableUntil = restorableUntil
+	return response, nil
 }
 
-// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
-func (s *Server) GetUser(ctx echo.Context) error {
-	return ctx.JSON(s.getUser(ctx))
+// NOTE: Idempotency-Key header is handled by NewIdempotencyMiddleware
+func (s *Server) RestoreUser(ctx echo.Context, _ generated.RestoreUserParams) error {
+	response, err := s.restoreUser(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
 }
-func (s *Server) getUser(ctx echo.Context) (int, generated.GetUserResponse) {
+func (s *Server) restoreUser(ctx echo.Context) (generated.RestoreUserResponse, *Error) {
 	var (
-		context = context.Background()
+		context = ctx.Request().Context()
 
-		response = generated.GetUserResponse{
+		response = generated.RestoreUserResponse{
 			Header: generated.ResponseHeader{}, //success is false by default
 		}
 	)
 
-	// Authorize and get userID of the requester
-	userID, err := authorize(ctx, utils.JWTPermissionGetUser)
+	// Sessions of deleted users are revoked, they authenticate with their credentials instead
+	request := generated.UserLoginRequest{}
+	err := json.NewDecoder(ctx.Request().Body).Decode(&request)
 	if err != nil {
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusForbidden, response
+		return response, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
 	}
 
-	// Get data for the userID
-	user, err := s.getSingleUser(context, repository.UserFilter{UserID: userID})
-	if err != nil {
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusInternalServerError, response
+	if restoreErr := s.restoreDeletedUser(context, request); restoreErr != nil {
+		return response, restoreErr
 	}
 
 	response.Header.Success = true
-	response.Header.Messages = []string{successMsg}
-	response.User = generated.User{
-		FullName:    &user.FullName,
-		PhoneNumber: &user.PhoneNumber,
+	response.Header.Messages = []string{i18n.FromContext(ctx).Message(messageRequestSuccessful, nil)}
+	return response, nil
+}
+
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+// NOTE: Idempotency-Key header is handled by NewIdempotencyMiddleware
+func (s *Server) ExportUser(ctx echo.Context, _ generated.ExportUserParams) error {
+	response, err := s.exportUser(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
 	}
 
-	return http.StatusOK, response
+	ctx.Response().Header().Set(echo.HeaderLocation, exportURL(response.Export.Id))
+	return ctx.JSON(http.StatusAccepted, response)
+}
+func (s *Server) exportUser(ctx echo.Context) (generated.UserExportResponse, *Error) {
+	var (
+		context = ctx.Request().Context()
+
+		response = generated.UserExportResponse{
+			Header: generated.ResponseHeader{}, //success is false by default
+		}
+	)
+
+	// Exporting reads the user, sessions are not granted another permission
+	userID, authErr := authorize(ctx, utils.JWTPermissionGetUser)
+	if authErr != nil {
+		return response, authErr
+	}
+
+	export, startErr := s.startUserExport(context, userID)
+	if startErr != nil {
+		return response, startErr
+	}
+
+	response.Header.Success = true
+	response.Header.Messages = []string{i18n.FromContext(ctx).Message(messageRequestSuccessful, nil)}
+	response.Export = s.newUserExportResource(export)
+	return response, nil
 }
 
 // NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
-func (s *Server) UpdateUser(ctx echo.Context) error {
-	return ctx.JSON(s.updateUser(ctx))
+func (s *Server) GetUserExport(ctx echo.Context, id generated.UserExportID) error {
+	response, err := s.getUserExport(ctx, id)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
 }
-func (s *Server) updateUser(ctx echo.Context) (int, generated.UpdateUserResponse) {
+func (s *Server) getUserExport(ctx echo.Context, id string) (generated.UserExportResponse, *Error) {
 	var (
-		context = context.Background()
+		context = ctx.Request().Context()
 
-		response = generated.UpdateUserResponse{
+		response = generated.UserExportResponse{
 			Header: generated.ResponseHeader{}, //success is false by default
 		}
 	)
 
-	// Authenticate and get userID of the requester
-	userID, err := authorize(ctx, utils.JWTPermissionUpdateUser)
-	if err != nil {
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusForbidden, response
+	userID, authErr := authorize(ctx, utils.JWTPermissionGetUser)
+	if authErr != nil {
+		return response, authErr
 	}
 
-	// Update user data
-	request := generated.User{}
-	err = json.NewDecoder(ctx.Request().Body).Decode(&request)
-	if err != nil {
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusBadRequest, response
+	export, getErr := s.getUserExportByID(context, userID, id)
+	if getErr != nil {
+		return response, getErr
 	}
 
-	updateRequest, errorList := fnConvertUpdateUserRequestToUser(userID, request)
-	if len(errorList) > 0 {
-		response.Header.Messages = errorList
-		return http.StatusBadRequest, response
+	response.Header.Success = true
+	response.Header.Messages = []string{i18n.FromContext(ctx).Message(messageRequestSuccessful, nil)}
+	response.Export = s.newUserExportResource(export)
+	return response, nil
+}
+
+// NOTE: The download link is authenticated by its signature, the endpoint is not in the AuthenticationMiddleware
+// whitelist in cmd/main.go
+func (s *Server) DownloadUserExport(ctx echo.Context, id generated.UserExportID, params generated.DownloadUserExportParams) error {
+	archive, err := s.getUserExportArchive(ctx.Request().Context(), id, params.Expires, params.Signature)
+	if err != nil {
+		return WriteError(ctx, err)
 	}
 
-	if err := s.Repository.UpdateUser(context, updateRequest); err != nil {
-		if utils.IsUniqueConstraintViolation(err) {
-			response.Header.Messages = []string{duplicatePhoneNumberErrorMsg}
-			return http.StatusConflict, response
+	// The archive holds personal data, do not let it be cached
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="user-export-%s.json"`, id))
+	ctx.Response().Header().Set("Cache-Control", "no-store")
+	return ctx.Blob(http.StatusOK, echo.MIMEApplicationJSON, archive)
+}
+
+// applyUserUpdate updates the fields of the user set in request, if the user is at the version in ifMatch.
+func (s *Server) applyUserUpdate(ctx echo.Context, userID int64, ifMatch *string, request userUpdate) (generated.UpdateUserResponse, *Error) {
+	var (
+		context = ctx.Request().Context()
+
+		response = generated.UpdateUserResponse{
+			Header: generated.ResponseHeader{}, //success is false by default
 		}
+	)
 
-		response.Header.Messages = []string{err.Error()}
-		return http.StatusInternalServerError, response
+	// Only update the version the client based the update on, so updates from other devices are not overwritten
+	version, matchErr := s.ifMatchVersion(context, userID, ifMatch)
+	if matchErr != nil {
+		return response, matchErr
+	}
+
+	// An empty merge patch changes nothing
+	if request.PhoneNumber != nil || request.FullName != nil || request.ClearFullName {
+		if updateErr := s.updateUserByID(context, userID, version, request); updateErr != nil {
+			return response, updateErr
+		}
 	}
 
 	response.Header.Success = true
-	response.Header.Messages = []string{successMsg}
-	return http.StatusOK, response
+	response.Header.Messages = []string{i18n.FromContext(ctx).Message(messageRequestSuccessful, nil)}
+	return response, nil
 }
@@ -0,0 +1,355 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	locationWebhookSubscriptionF = "/admin/webhooks/%d"
+)
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) CreateWebhookSubscription(ctx echo.Context) error {
+	response, err := s.createWebhookSubscription(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf(locationWebhookSubscriptionF, *response.Id))
+	return ctx.JSON(http.StatusCreated, response)
+}
+func (s *Server) createWebhookSubscription(ctx echo.Context) (generated.WebhookSubscription, *Error) {
+	request := generated.WebhookSubscription{}
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&request); err != nil {
+		return generated.WebhookSubscription{}, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+	}
+
+	subscription, errorList := convertWebhookSubscriptionRequest(request)
+	if len(errorList) > 0 {
+		return generated.WebhookSubscription{}, newValidationError(errorList)
+	}
+
+	subscriptionID, err := s.Repository.InsertWebhookSubscription(ctx.Request().Context(), subscription)
+	if err != nil {
+		return generated.WebhookSubscription{}, repositoryError(err)
+	}
+
+	subscription.ID = subscriptionID
+	return newWebhookSubscriptionResource(subscription), nil
+}
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) ListWebhookSubscriptions(ctx echo.Context) error {
+	subscriptions, err := s.Repository.GetWebhookSubscriptions(ctx.Request().Context(), repository.WebhookSubscriptionFilter{})
+	if err != nil {
+		return WriteError(ctx, repositoryError(err))
+	}
+
+	response := make([]generated.WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		response = append(response, newWebhookSubscriptionResource(subscription))
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) GetWebhookSubscription(ctx echo.Context, id generated.WebhookSubscriptionID) error {
+	subscription, err := s.getWebhookSubscription(ctx, id)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newWebhookSubscriptionResource(subscription))
+}
+func (s *Server) getWebhookSubscription(ctx echo.Context, id int64) (repository.WebhookSubscription, *Error) {
+	subscriptions, err := s.Repository.GetWebhookSubscriptions(ctx.Request().Context(), repository.WebhookSubscriptionFilter{SubscriptionID: id})
+	if err != nil {
+		return repository.WebhookSubscription{}, repositoryError(err)
+	}
+
+	if len(subscriptions) == 0 {
+		return repository.WebhookSubscription{}, NewError(http.StatusNotFound, generated.WebhookSubscriptionNotFound)
+	}
+
+	return subscriptions[0], nil
+}
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) DeleteWebhookSubscription(ctx echo.Context, id generated.WebhookSubscriptionID) error {
+	err := s.Repository.DeleteWebhookSubscription(ctx.Request().Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return WriteError(ctx, NewError(http.StatusNotFound, generated.WebhookSubscriptionNotFound))
+	}
+	if err != nil {
+		return WriteError(ctx, repositoryError(err))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) ListWebhookDeliveries(ctx echo.Context, id generated.WebhookSubscriptionID, params generated.ListWebhookDeliveriesParams) error {
+	response, err := s.listWebhookDeliveries(ctx, id, params)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) listWebhookDeliveries(ctx echo.Context, id int64, params generated.ListWebhookDeliveriesParams) ([]generated.WebhookDelivery, *Error) {
+	if _, err := s.getWebhookSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	filter := repository.WebhookDeliveryFilter{SubscriptionID: id}
+	if params.Status != nil {
+		filter.Status = repository.WebhookDeliveryStatus(*params.Status)
+	}
+	if params.Limit != nil {
+		filter.Limit = *params.Limit
+	}
+
+	deliveries, err := s.Repository.GetWebhookDeliveries(ctx.Request().Context(), filter)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	response := make([]generated.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, newWebhookDeliveryResource(delivery))
+	}
+
+	return response, nil
+}
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) RetryWebhookDelivery(ctx echo.Context, id generated.WebhookSubscriptionID, deliveryID generated.WebhookDeliveryID) error {
+	response, err := s.retryWebhookDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) retryWebhookDelivery(ctx echo.Context, id, deliveryID int64) (generated.WebhookDelivery, *Error) {
+	if _, err := s.getWebhookSubscription(ctx, id); err != nil {
+		return generated.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.getWebhookDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return generated.WebhookDelivery{}, err
+	}
+
+	if delivery.Status != repository.WebhookDeliveryDead {
+		return generated.WebhookDelivery{}, NewError(http.StatusConflict, generated.WebhookDeliveryNotDead)
+	}
+
+	// Not found means the delivery is no longer dead, i.e. it was retried concurrently
+	retryErr := s.Repository.RetryWebhookDelivery(ctx.Request().Context(), deliveryID)
+	if errors.Is(retryErr, repository.ErrNotFound) {
+		return generated.WebhookDelivery{}, NewError(http.StatusConflict, generated.WebhookDeliveryNotDead)
+	}
+	if retryErr != nil {
+		return generated.WebhookDelivery{}, repositoryError(retryErr)
+	}
+
+	delivery, err = s.getWebhookDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return generated.WebhookDelivery{}, err
+	}
+
+	return newWebhookDeliveryResource(delivery), nil
+}
+func (s *Server) getWebhookDelivery(ctx echo.Context, id, deliveryID int64) (repository.WebhookDelivery, *Error) {
+	deliveries, err := s.Repository.GetWebhookDeliveries(ctx.Request().Context(), repository.WebhookDeliveryFilter{
+		SubscriptionID: id,
+		DeliveryID:     deliveryID,
+	})
+	if err != nil {
+		return repository.WebhookDelivery{}, repositoryError(err)
+	}
+
+	if len(deliveries) == 0 {
+		return repository.WebhookDelivery{}, NewError(http.StatusNotFound, generated.WebhookDeliveryNotFound)
+	}
+
+	return deliveries[0], nil
+}
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) SuspendUser(ctx echo.Context, id generated.UserID) error {
+	if err := s.suspendUser(ctx, id); err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+func (s *Server) suspendUser(ctx echo.Context, id int64) *Error {
+	request := generated.UserSuspension{}
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&request); err != nil {
+		return NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+	}
+
+	// The length of the reason is validated against the spec by NewOpenAPIValidator
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return newValidationError([]FieldError{newSpecFieldError("reason", generated.RequiredFieldMissing)})
+	}
+
+	if err := s.Repository.SuspendUser(ctx.Request().Context(), id, reason, repository.AuditActorAdmin); err != nil {
+		return repositoryError(err)
+	}
+
+	return nil
+}
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) UnsuspendUser(ctx echo.Context, id generated.UserID) error {
+	if err := s.Repository.UnsuspendUser(ctx.Request().Context(), id, repository.AuditActorAdmin); err != nil {
+		return WriteError(ctx, repositoryError(err))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// NOTE: Check NewAdminMiddleware that authenticates the admin token
+func (s *Server) ListAuditLog(ctx echo.Context, params generated.ListAuditLogParams) error {
+	filter := repository.AuditLogFilter{}
+	if params.UserId != nil {
+		filter.TargetUserID = *params.UserId
+	}
+	if params.BeforeId != nil {
+		filter.BeforeID = *params.BeforeId
+	}
+	if params.Limit != nil {
+		filter.Limit = *params.Limit
+	}
+
+	entries, err := s.Repository.GetAuditLog(ctx.Request().Context(), filter)
+	if err != nil {
+		return WriteError(ctx, repositoryError(err))
+	}
+
+	response := make([]generated.AuditLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, newAuditLogEntryResource(entry))
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// convertWebhookSubscriptionRequest validates the subscription request. The events and the secret are validated
+// against the spec by NewOpenAPIValidator.
+func convertWebhookSubscriptionRequest(request generated.WebhookSubscription) (repository.WebhookSubscription, []FieldError) {
+	var errorList []FieldError
+
+	parsedURL, err := url.Parse(request.Url)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		errorList = append(errorList, newSpecFieldError("url", generated.WebhookUrlInvalid))
+	}
+
+	if len(request.Events) == 0 {
+		errorList = append(errorList, newSpecFieldError("events", generated.RequiredFieldMissing))
+	}
+
+	if request.Secret == nil || *request.Secret == "" {
+		errorList = append(errorList, newSpecFieldError("secret", generated.RequiredFieldMissing))
+	}
+
+	if len(errorList) > 0 {
+		return repository.WebhookSubscription{}, errorList
+	}
+
+	eventTypes := make([]string, 0, len(request.Events))
+	for _, event := range request.Events {
+		eventTypes = append(eventTypes, string(event))
+	}
+
+	return repository.WebhookSubscription{
+		URL:         request.Url,
+		Secret:      *request.Secret,
+		EventTypes:  eventTypes,
+		CreatedTime: fnTimeNow(),
+	}, nil
+}
+
+// newWebhookSubscriptionResource returns the subscription as exposed by the API, without its secret.
+func newWebhookSubscriptionResource(subscription repository.WebhookSubscription) generated.WebhookSubscription {
+	events := make([]generated.WebhookEventType, 0, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		events = append(events, generated.WebhookEventType(eventType))
+	}
+
+	return generated.WebhookSubscription{
+		Id:        &subscription.ID,
+		Url:       subscription.URL,
+		Events:    events,
+		CreatedAt: &subscription.CreatedTime,
+	}
+}
+
+// newWebhookDeliveryResource returns the delivery as exposed by the API.
+func newWebhookDeliveryResource(delivery repository.WebhookDelivery) generated.WebhookDelivery {
+	resource := generated.WebhookDelivery{
+		Id:             &delivery.ID,
+		SubscriptionId: &delivery.SubscriptionID,
+		EventId:        &delivery.EventID,
+		EventType:      generated.WebhookEventType(delivery.EventType),
+		Status:         generated.WebhookDeliveryStatus(delivery.Status),
+		Attempts:       &delivery.Attempts,
+		LastAttemptAt:  delivery.LastAttemptTime,
+		DeliveredAt:    delivery.DeliveredTime,
+		CreatedAt:      &delivery.CreatedTime,
+	}
+
+	if delivery.Status == repository.WebhookDeliveryPending {
+		resource.NextAttemptAt = &delivery.NextAttemptTime
+	}
+	if delivery.LastStatusCode != 0 {
+		resource.LastStatusCode = &delivery.LastStatusCode
+	}
+	if delivery.LastError != "" {
+		resource.LastError = &delivery.LastError
+	}
+
+	return resource
+}
+
+// newAuditLogEntryResource returns the audit log entry as exposed by the API.
+func newAuditLogEntryResource(entry repository.AuditLogEntry) generated.AuditLogEntry {
+	changes := make(map[string]generated.AuditChange, len(entry.Changes))
+	for field, change := range entry.Changes {
+		before, after := change.Before, change.After
+
+		resource := generated.AuditChange{}
+		if before != "" {
+			resource.Before = &before
+		}
+		if after != "" {
+			resource.After = &after
+		}
+		changes[field] = resource
+	}
+
+	return generated.AuditLogEntry{
+		Id:           entry.ID,
+		Actor:        entry.Actor,
+		Action:       entry.Action,
+		TargetUserId: entry.TargetUserID,
+		Changes:      changes,
+		CreatedAt:    entry.CreatedTime,
+		PrevHash:     entry.PrevHash,
+		Hash:         entry.Hash,
+	}
+}
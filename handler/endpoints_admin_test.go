@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestServerAdmin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	subscription := repository.WebhookSubscription{
+		ID:          1,
+		URL:         "https://example.com/webhooks",
+		Secret:      "0123456789abcdef",
+		EventTypes:  []string{"user.registered", "user.logged_in"},
+		CreatedTime: now,
+	}
+
+	deadDelivery := repository.WebhookDelivery{
+		ID:              7,
+		SubscriptionID:  1,
+		EventID:         "event",
+		EventType:       "user.registered",
+		Status:          repository.WebhookDeliveryDead,
+		Attempts:        8,
+		NextAttemptTime: now,
+		LastAttemptTime: &now,
+		LastStatusCode:  http.StatusInternalServerError,
+		LastError:       "unexpected status 500: ",
+		CreatedTime:     now,
+	}
+	retriedDelivery := deadDelivery
+	retriedDelivery.Status = repository.WebhookDeliveryPending
+	retriedDelivery.Attempts = 0
+
+	auditLogEntry := repository.AuditLogEntry{
+		ID:           12,
+		Actor:        "user:123",
+		Action:       repository.AuditActionUserUpdated,
+		TargetUserID: 123,
+		Changes:      map[string]repository.AuditChange{"full_name": {Before: "User", After: "New User"}},
+		CreatedTime:  now,
+		PrevHash:     "a1b2",
+		Hash:         "c3d4",
+	}
+
+	tests := []struct {
+		name           string
+		adminToken     string
+		method         string
+		path           string
+		authorization  string
+		requestBody    string
+		mockRepository func(mock *repository.MockRepositoryInterface)
+
+		wantHttpStatusCode int
+		wantLocation       string
+		wantBody           string
+	}{
+		{
+			name:          "create-subscription",
+			adminToken:    "admin",
+			method:        http.MethodPost,
+			path:          "/admin/webhooks",
+			authorization: "Bearer admin",
+			requestBody:   `{"url":"https://example.com/webhooks","events":["user.registered","user.logged_in"],"secret":"0123456789abcdef"}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				request := subscription
+				request.ID = 0
+				mock.EXPECT().InsertWebhookSubscription(gomock.Any(), request).Return(int64(1), nil)
+			},
+			wantHttpStatusCode: http.StatusCreated,
+			wantLocation:       "/admin/webhooks/1",
+			wantBody:           `{"created_at":"2024-01-01T12:00:00Z","events":["user.registered","user.logged_in"],"id":1,"url":"https://example.com/webhooks"}`,
+		},
+		{
+			name:               "create-subscription-fail-invalid-url",
+			adminToken:         "admin",
+			method:             http.MethodPost,
+			path:               "/admin/webhooks",
+			authorization:      "Bearer admin",
+			requestBody:        `{"url":"example.com/webhooks","events":["user.registered"],"secret":"0123456789abcdef"}`,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["url should be an absolute http or https URL"],"success":false}}`,
+		},
+		{
+			name:               "create-subscription-fail-unknown-event",
+			adminToken:         "admin",
+			method:             http.MethodPost,
+			path:               "/admin/webhooks",
+			authorization:      "Bearer admin",
+			requestBody:        `{"url":"https://example.com/webhooks","events":["user.deleted"],"secret":"0123456789abcdef"}`,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["events.0 has an invalid value"],"success":false}}`,
+		},
+		{
+			name:               "fail-admin-api-disabled",
+			method:             http.MethodGet,
+			path:               "/admin/webhooks",
+			authorization:      "Bearer ",
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusForbidden,
+			wantBody:           `{"header":{"messages":["the admin API is disabled, configure ADMIN_API_TOKEN to enable it"],"success":false}}`,
+		},
+		{
+			name:               "fail-missing-admin-token",
+			adminToken:         "admin",
+			method:             http.MethodGet,
+			path:               "/admin/webhooks",
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusUnauthorized,
+			wantBody:           `{"header":{"messages":["missing authorization header"],"success":false}}`,
+		},
+		{
+			name:               "fail-wrong-admin-token",
+			adminToken:         "admin",
+			method:             http.MethodGet,
+			path:               "/admin/webhooks",
+			authorization:      "Bearer user",
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusUnauthorized,
+			wantBody:           `{"header":{"messages":["token is invalid"],"success":false}}`,
+		},
+		{
+			name:          "list-subscriptions",
+			adminToken:    "admin",
+			method:        http.MethodGet,
+			path:          "/admin/webhooks",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetWebhookSubscriptions(gomock.Any(), repository.WebhookSubscriptionFilter{}).Return([]repository.WebhookSubscription{subscription}, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantBody:           `[{"created_at":"2024-01-01T12:00:00Z","events":["user.registered","user.logged_in"],"id":1,"url":"https://example.com/webhooks"}]`,
+		},
+		{
+			name:          "get-subscription-fail-not-found",
+			adminToken:    "admin",
+			method:        http.MethodGet,
+			path:          "/admin/webhooks/2",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetWebhookSubscriptions(gomock.Any(), repository.WebhookSubscriptionFilter{SubscriptionID: 2}).Return(nil, nil)
+			},
+			wantHttpStatusCode: http.StatusNotFound,
+			wantBody:           `{"header":{"messages":["webhook subscription not found"],"success":false}}`,
+		},
+		{
+			name:          "delete-subscription",
+			adminToken:    "admin",
+			method:        http.MethodDelete,
+			path:          "/admin/webhooks/1",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().DeleteWebhookSubscription(gomock.Any(), int64(1)).Return(nil)
+			},
+			wantHttpStatusCode: http.StatusNoContent,
+		},
+		{
+			name:          "list-dead-deliveries",
+			adminToken:    "admin",
+			method:        http.MethodGet,
+			path:          "/admin/webhooks/1/deliveries?status=dead&limit=10",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetWebhookSubscriptions(gomock.Any(), repository.WebhookSubscriptionFilter{SubscriptionID: 1}).Return([]repository.WebhookSubscription{subscription}, nil)
+				mock.EXPECT().GetWebhookDeliveries(gomock.Any(), repository.WebhookDeliveryFilter{
+					SubscriptionID: 1,
+					Status:         repository.WebhookDeliveryDead,
+					Limit:          10,
+				}).Return([]repository.WebhookDelivery{deadDelivery}, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantBody: `[{"attempts":8,"created_at":"2024-01-01T12:00:00Z","event_id":"event","event_type":"user.registered","id":7,` +
+				`"last_attempt_at":"2024-01-01T12:00:00Z","last_error":"unexpected status 500: ","last_status_code":500,"status":"dead","subscription_id":1}]`,
+		},
+		{
+			name:          "retry-dead-delivery",
+			adminToken:    "admin",
+			method:        http.MethodPost,
+			path:          "/admin/webhooks/1/deliveries/7/retry",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				filter := repository.WebhookDeliveryFilter{SubscriptionID: 1, DeliveryID: 7}
+				gomock.InOrder(
+					mock.EXPECT().GetWebhookSubscriptions(gomock.Any(), repository.WebhookSubscriptionFilter{SubscriptionID: 1}).Return([]repository.WebhookSubscription{subscription}, nil),
+					mock.EXPECT().GetWebhookDeliveries(gomock.Any(), filter).Return([]repository.WebhookDelivery{deadDelivery}, nil),
+					mock.EXPECT().RetryWebhookDelivery(gomock.Any(), int64(7)).Return(nil),
+					mock.EXPECT().GetWebhookDeliveries(gomock.Any(), filter).Return([]repository.WebhookDelivery{retriedDelivery}, nil),
+				)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantBody: `{"attempts":0,"created_at":"2024-01-01T12:00:00Z","event_id":"event","event_type":"user.registered","id":7,` +
+				`"last_attempt_at":"2024-01-01T12:00:00Z","last_error":"unexpected status 500: ","last_status_code":500,` +
+				`"next_attempt_at":"2024-01-01T12:00:00Z","status":"pending","subscription_id":1}`,
+		},
+		{
+			name:          "retry-delivery-fail-not-dead",
+			adminToken:    "admin",
+			method:        http.MethodPost,
+			path:          "/admin/webhooks/1/deliveries/7/retry",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetWebhookSubscriptions(gomock.Any(), repository.WebhookSubscriptionFilter{SubscriptionID: 1}).Return([]repository.WebhookSubscription{subscription}, nil)
+				mock.EXPECT().GetWebhookDeliveries(gomock.Any(), repository.WebhookDeliveryFilter{SubscriptionID: 1, DeliveryID: 7}).Return([]repository.WebhookDelivery{retriedDelivery}, nil)
+			},
+			wantHttpStatusCode: http.StatusConflict,
+			wantBody:           `{"header":{"messages":["only dead webhook deliveries can be retried"],"success":false}}`,
+		},
+		{
+			name:          "list-audit-log-of-user",
+			adminToken:    "admin",
+			method:        http.MethodGet,
+			path:          "/admin/audit-log?user_id=123&before_id=13&limit=1",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetAuditLog(gomock.Any(), repository.AuditLogFilter{
+					TargetUserID: 123,
+					BeforeID:     13,
+					Limit:        1,
+				}).Return([]repository.AuditLogEntry{auditLogEntry}, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantBody: `[{"action":"user.updated","actor":"user:123","changes":{"full_name":{"after":"New User","before":"User"}},` +
+				`"created_at":"2024-01-01T12:00:00Z","hash":"c3d4","id":12,"prev_hash":"a1b2","target_user_id":123}]`,
+		},
+		{
+			name:          "list-audit-log-empty",
+			adminToken:    "admin",
+			method:        http.MethodGet,
+			path:          "/admin/audit-log",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetAuditLog(gomock.Any(), repository.AuditLogFilter{Limit: 50}).Return(nil, nil) // default of the spec
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantBody:           `[]`,
+		},
+		{
+			name:               "list-audit-log-fail-invalid-limit",
+			adminToken:         "admin",
+			method:             http.MethodGet,
+			path:               "/admin/audit-log?limit=500",
+			authorization:      "Bearer admin",
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["limit has an invalid value"],"success":false}}`,
+		},
+		{
+			name:          "suspend-user",
+			adminToken:    "admin",
+			method:        http.MethodPost,
+			path:          "/admin/users/123/suspend",
+			authorization: "Bearer admin",
+			requestBody:   `{"reason":" Fraud "}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().SuspendUser(gomock.Any(), int64(123), "Fraud", repository.AuditActorAdmin).Return(nil)
+			},
+			wantHttpStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "suspend-user-fail-blank-reason",
+			adminToken:         "admin",
+			method:             http.MethodPost,
+			path:               "/admin/users/123/suspend",
+			authorization:      "Bearer admin",
+			requestBody:        `{"reason":" "}`,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["reason is required"],"success":false}}`,
+		},
+		{
+			name:          "suspend-user-fail-not-found",
+			adminToken:    "admin",
+			method:        http.MethodPost,
+			path:          "/admin/users/123/suspend",
+			authorization: "Bearer admin",
+			requestBody:   `{"reason":"Fraud"}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().SuspendUser(gomock.Any(), int64(123), "Fraud", repository.AuditActorAdmin).Return(repository.ErrNotFound)
+			},
+			wantHttpStatusCode: http.StatusNotFound,
+			wantBody:           `{"header":{"messages":["user not found"],"success":false}}`,
+		},
+		{
+			name:          "unsuspend-user",
+			adminToken:    "admin",
+			method:        http.MethodPost,
+			path:          "/admin/users/123/unsuspend",
+			authorization: "Bearer admin",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().UnsuspendUser(gomock.Any(), int64(123), repository.AuditActorAdmin).Return(nil)
+			},
+			wantHttpStatusCode: http.StatusNoContent,
+		},
+	}
+
+	swagger, err := generated.GetSwagger()
+	if err != nil {
+		t.Fatalf("generated.GetSwagger() err = %v", err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			test.mockRepository(mock)
+
+			fnTimeNow = func() time.Time { return now }
+			defer func() {
+				fnTimeNow = time.Now
+			}()
+
+			e := echo.New()
+			e.Use(NewAdminMiddleware(NewAdminMiddlewareOptions{Token: test.adminToken}))
+			e.Use(NewOpenAPIValidator(NewOpenAPIValidatorOptions{Swagger: swagger, ValidateResponses: true}))
+			generated.RegisterHandlers(e, NewServer(NewServerOptions{Repository: mock}))
+
+			request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.requestBody))
+			if test.requestBody != "" {
+				request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			}
+			if test.authorization != "" {
+				request.Header.Set(echo.HeaderAuthorization, test.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantHttpStatusCode {
+				t.Errorf("handler.Server httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, test.wantHttpStatusCode)
+			}
+
+			if gotLocation := recorder.Header().Get(echo.HeaderLocation); gotLocation != test.wantLocation {
+				t.Errorf("handler.Server location = %v, wantLocation %v", gotLocation, test.wantLocation)
+			}
+
+			if gotBody := strings.TrimSuffix(recorder.Body.String(), "\n"); gotBody != test.wantBody {
+				t.Errorf("handler.Server body = %v, wantBody %v", gotBody, test.wantBody)
+			}
+		})
+	}
+}
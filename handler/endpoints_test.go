@@ -2,19 +2,24 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/UserService/generated"
+	"github.com/UserService/logging"
 	"github.com/UserService/repository"
 	"github.com/UserService/utils"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
-	"github.com/lib/pq"
 )
 
 func TestRegisterUser(t *testing.T) {
@@ -26,17 +31,17 @@ func TestRegisterUser(t *testing.T) {
 		return &in
 	}
 
-	errorConflictUserPhoneNumber := pq.Error{
-		Code: "23505",
+	errorConflictUserPhoneNumber := repository.ErrConflict{
+		Field: "phone_number",
 	}
 
 	tests := []struct {
 		name                               string
 		mockRepository                     func(controller *gomock.Controller) *repository.MockRepositoryInterface
 		requestBody                        generated.User
-		fnConvertRegisterUserRequestToUser func(generated.User) (repository.User, []string)
+		fnConvertRegisterUserRequestToUser func(ValidationRules, generated.User) (repository.User, []FieldError)
 		wantResponse                       generated.RegisterUserResponse
-		wantHttpStatusCode                 int
+		wantErr                            *Error
 	}{
 		{
 			name: "success",
@@ -45,16 +50,16 @@ func TestRegisterUser(t *testing.T) {
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("P455w0rd!."),
 			},
-			fnConvertRegisterUserRequestToUser: func(generated.User) (repository.User, []string) {
+			fnConvertRegisterUserRequestToUser: func(ValidationRules, generated.User) (repository.User, []FieldError) {
 				user := repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 					Password:    "P455w0rd!.",
 				}
 
-				errorMsgs := []string{}
+				errorList := []FieldError{}
 
-				return user, errorMsgs
+				return user, errorList
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
@@ -70,13 +75,12 @@ func TestRegisterUser(t *testing.T) {
 			wantResponse: generated.RegisterUserResponse{
 				Header: generated.ResponseHeader{
 					Success:  true,
-					Messages: []string{successMsg},
+					Messages: []string{"request successful"},
 				},
 				User: generated.User{
 					Id: int64Ptr(123),
 				},
 			},
-			wantHttpStatusCode: http.StatusCreated,
 		},
 		{
 			name: "fail-insert-user",
@@ -85,16 +89,16 @@ func TestRegisterUser(t *testing.T) {
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("P455w0rd!."),
 			},
-			fnConvertRegisterUserRequestToUser: func(generated.User) (repository.User, []string) {
+			fnConvertRegisterUserRequestToUser: func(ValidationRules, generated.User) (repository.User, []FieldError) {
 				user := repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 					Password:    "P455w0rd!.",
 				}
 
-				errorMsgs := []string{}
+				errorList := []FieldError{}
 
-				return user, errorMsgs
+				return user, errorList
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
@@ -107,13 +111,7 @@ func TestRegisterUser(t *testing.T) {
 
 				return mock
 			},
-			wantResponse: generated.RegisterUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"error-insert-user"},
-				},
-			},
-			wantHttpStatusCode: http.StatusInternalServerError,
+			wantErr: &Error{Status: http.StatusInternalServerError, Code: generated.InternalError},
 		},
 		{
 			name: "fail-insert-user-conflict-phone-number",
@@ -122,16 +120,16 @@ func TestRegisterUser(t *testing.T) {
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("P455w0rd!."),
 			},
-			fnConvertRegisterUserRequestToUser: func(generated.User) (repository.User, []string) {
+			fnConvertRegisterUserRequestToUser: func(ValidationRules, generated.User) (repository.User, []FieldError) {
 				user := repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 					Password:    "P455w0rd!.",
 				}
 
-				errorMsgs := []string{}
+				errorList := []FieldError{}
 
-				return user, errorMsgs
+				return user, errorList
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
@@ -140,17 +138,11 @@ func TestRegisterUser(t *testing.T) {
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 					Password:    "P455w0rd!.",
-				}).Return(int64(0), &errorConflictUserPhoneNumber)
+				}).Return(int64(0), errorConflictUserPhoneNumber)
 
 				return mock
 			},
-			wantResponse: generated.RegisterUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"phone number is already registered to an existing user"},
-				},
-			},
-			wantHttpStatusCode: http.StatusConflict,
+			wantErr: &Error{Status: http.StatusConflict, Code: generated.PhoneNumberAlreadyRegistered},
 		},
 		{
 			name: "fail-insert-user-conflict-phone-number",
@@ -159,16 +151,16 @@ func TestRegisterUser(t *testing.T) {
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("P455w0rd!."),
 			},
-			fnConvertRegisterUserRequestToUser: func(generated.User) (repository.User, []string) {
+			fnConvertRegisterUserRequestToUser: func(ValidationRules, generated.User) (repository.User, []FieldError) {
 				user := repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 					Password:    "P455w0rd!.",
 				}
 
-				errorMsgs := []string{}
+				errorList := []FieldError{}
 
-				return user, errorMsgs
+				return user, errorList
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
@@ -177,17 +169,11 @@ func TestRegisterUser(t *testing.T) {
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 					Password:    "P455w0rd!.",
-				}).Return(int64(0), &errorConflictUserPhoneNumber)
+				}).Return(int64(0), errorConflictUserPhoneNumber)
 
 				return mock
 			},
-			wantResponse: generated.RegisterUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{duplicatePhoneNumberErrorMsg},
-				},
-			},
-			wantHttpStatusCode: http.StatusConflict,
+			wantErr: &Error{Status: http.StatusConflict, Code: generated.PhoneNumberAlreadyRegistered},
 		},
 		{
 			name: "fail-invalid-input",
@@ -196,20 +182,26 @@ func TestRegisterUser(t *testing.T) {
 				PhoneNumber: stringPtr("+62812"),
 				Password:    stringPtr("P455w"),
 			},
-			fnConvertRegisterUserRequestToUser: func(generated.User) (repository.User, []string) {
-				return repository.User{}, []string{"invalid full name", "invalid phone number", "invalid password"}
+			fnConvertRegisterUserRequestToUser: func(ValidationRules, generated.User) (repository.User, []FieldError) {
+				return repository.User{}, []FieldError{
+					{Field: "full_name", Code: generated.FullNameInvalidLength},
+					{Field: "phone_number", Code: generated.PhoneNumberInvalidLength},
+					{Field: "password", Code: generated.PasswordInvalidLength},
+				}
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
 				return mock
 			},
-			wantResponse: generated.RegisterUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"invalid full name", "invalid phone number", "invalid password"},
+			wantErr: &Error{
+				Status: http.StatusBadRequest,
+				Code:   generated.ValidationFailed,
+				InvalidParams: []FieldError{
+					{Field: "full_name", Code: generated.FullNameInvalidLength},
+					{Field: "phone_number", Code: generated.PhoneNumberInvalidLength},
+					{Field: "password", Code: generated.PasswordInvalidLength},
 				},
 			},
-			wantHttpStatusCode: http.StatusBadRequest,
 		},
 	}
 
@@ -230,11 +222,12 @@ func TestRegisterUser(t *testing.T) {
 			ctx := e.NewContext(request, recorder)
 
 			fnConvertRegisterUserRequestToUser = test.fnConvertRegisterUserRequestToUser
+			defer func() { fnConvertRegisterUserRequestToUser = convertRegisterUserRequestToUser }()
 
-			gotHttpStatusCode, gotResponse := handler.registerUser(ctx)
+			gotResponse, gotErr := handler.registerUser(ctx)
 
-			if gotHttpStatusCode != test.wantHttpStatusCode {
-				t.Errorf("handler.RegisterUser() httpStatusCode = %v, wantHttpStatusCode %v", gotHttpStatusCode, test.wantHttpStatusCode)
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.RegisterUser() err = %v, wantErr %v", gotErr, test.wantErr)
 			}
 
 			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
@@ -254,17 +247,25 @@ func TestUserLogin(t *testing.T) {
 		return &in
 	}
 
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	session := repository.Session{
+		ID:          "f8065ac4677043303e57ebe063ac292f",
+		UserID:      123,
+		CreatedTime: now,
+		ExpiresTime: now.Add(utils.JWTExpiryDuration),
+	}
+
 	tests := []struct {
-		name               string
-		mockRepository     func(controller *gomock.Controller) *repository.MockRepositoryInterface
-		requestBody        generated.User
-		wantResponse       generated.UserLoginResponse
-		wantCtxUserID      int64
-		wantHttpStatusCode int
+		name           string
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+		requestBody    generated.UserLoginRequest
+		wantResponse   generated.UserLoginResponse
+		wantCtxUserID  int64
+		wantErr        *Error
 	}{
 		{
 			name: "success",
-			requestBody: generated.User{
+			requestBody: generated.UserLoginRequest{
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("Password123!."),
 			},
@@ -272,7 +273,8 @@ func TestUserLogin(t *testing.T) {
 				mock := repository.NewMockRepositoryInterface(controller)
 
 				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
-					PhoneNumber: "+628123456789",
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
 				}).Return([]repository.User{
 					{
 						ID:          123,
@@ -282,25 +284,55 @@ func TestUserLogin(t *testing.T) {
 					},
 				}, nil)
 
-				mock.EXPECT().IncrementSuccessfulLoginCount(gomock.Any(), int64(123)).Return(nil)
+				mock.EXPECT().IncrementSuccessfulLoginCount(gomock.Any(), int64(123), session.CreatedTime).Return(nil)
+
+				mock.EXPECT().InsertSession(gomock.Any(), session).Return(nil)
 
 				return mock
 			},
 			wantResponse: generated.UserLoginResponse{
 				Header: generated.ResponseHeader{
 					Success:  true,
-					Messages: []string{successMsg},
+					Messages: []string{"request successful"},
 				},
 				User: generated.User{
 					Id: int64Ptr(123),
 				},
 			},
-			wantCtxUserID:      123,
-			wantHttpStatusCode: http.StatusOK,
+			wantCtxUserID: 123,
+		},
+		{
+			name: "fail-insert-session",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password123!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
+				}).Return([]repository.User{
+					{
+						ID:          123,
+						FullName:    "User",
+						PhoneNumber: "+628123456789",
+						Password:    "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+					},
+				}, nil)
+
+				mock.EXPECT().IncrementSuccessfulLoginCount(gomock.Any(), int64(123), session.CreatedTime).Return(nil)
+
+				mock.EXPECT().InsertSession(gomock.Any(), session).Return(repository.ErrUnavailable)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusServiceUnavailable, Code: generated.ServiceUnavailable},
 		},
 		{
 			name: "fail-invalid-password",
-			requestBody: generated.User{
+			requestBody: generated.UserLoginRequest{
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("Password123.!"),
 			},
@@ -308,7 +340,8 @@ func TestUserLogin(t *testing.T) {
 				mock := repository.NewMockRepositoryInterface(controller)
 
 				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
-					PhoneNumber: "+628123456789",
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
 				}).Return([]repository.User{
 					{
 						ID:          123,
@@ -320,17 +353,11 @@ func TestUserLogin(t *testing.T) {
 
 				return mock
 			},
-			wantResponse: generated.UserLoginResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"invalid password"},
-				},
-			},
-			wantHttpStatusCode: http.StatusBadRequest,
+			wantErr: &Error{Status: http.StatusBadRequest, Code: generated.InvalidCredentials},
 		},
 		{
 			name: "fail-get-user",
-			requestBody: generated.User{
+			requestBody: generated.UserLoginRequest{
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("Password123.!"),
 			},
@@ -338,22 +365,17 @@ func TestUserLogin(t *testing.T) {
 				mock := repository.NewMockRepositoryInterface(controller)
 
 				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
-					PhoneNumber: "+628123456789",
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
 				}).Return([]repository.User{}, errors.New("error-get-users"))
 
 				return mock
 			},
-			wantResponse: generated.UserLoginResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"error-get-users"},
-				},
-			},
-			wantHttpStatusCode: http.StatusInternalServerError,
+			wantErr: &Error{Status: http.StatusInternalServerError, Code: generated.InternalError},
 		},
 		{
 			name: "fail-user-does-not-exist",
-			requestBody: generated.User{
+			requestBody: generated.UserLoginRequest{
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("Password123!."),
 			},
@@ -361,24 +383,131 @@ func TestUserLogin(t *testing.T) {
 				mock := repository.NewMockRepositoryInterface(controller)
 
 				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
-					PhoneNumber: "+628123456789",
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
 				}).Return([]repository.User{}, nil)
 
 				return mock
 			},
-			wantResponse: generated.UserLoginResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"user not found"},
-				},
+			wantErr: &Error{Status: http.StatusBadRequest, Code: generated.InvalidCredentials},
+		},
+		{
+			name: "fail-account-suspended",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password123!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				suspendedTime := now.Add(-time.Hour)
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
+				}).Return([]repository.User{
+					{
+						ID:               123,
+						Password:         "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+						SuspendedTime:    &suspendedTime,
+						SuspensionReason: "Fraud",
+					},
+				}, nil)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusForbidden, Code: generated.AccountSuspended},
+		},
+		{
+			name: "fail-account-deleted",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password123!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				deletedTime := now.Add(-24 * time.Hour)
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
+				}).Return([]repository.User{
+					{
+						ID:          123,
+						Password:    "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+						DeletedTime: &deletedTime,
+					},
+				}, nil)
+
+				return mock
+			},
+			wantErr: &Error{
+				Status: http.StatusForbidden,
+				Code:   generated.AccountDeleted,
+				Params: map[string]interface{}{"restorable_until": "2024-01-30T12:00:00Z"},
+			},
+		},
+		{
+			name: "fail-account-deleted-before-grace-period",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password123!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				deletedTime := now.Add(-DefaultDeletionGracePeriod)
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
+				}).Return([]repository.User{
+					{
+						ID:          123,
+						Password:    "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+						DeletedTime: &deletedTime,
+					},
+				}, nil)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusNotFound, Code: generated.UserNotFound},
+		},
+		{
+			name: "fail-inactive-account-wrong-password",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password321!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				suspendedTime := now.Add(-time.Hour)
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
+					PhoneNumber:     "+628123456789",
+					IncludeInactive: true,
+				}).Return([]repository.User{
+					{
+						ID:            123,
+						Password:      "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+						SuspendedTime: &suspendedTime,
+					},
+				}, nil)
+
+				return mock
 			},
-			wantHttpStatusCode: http.StatusInternalServerError,
+			wantErr: &Error{Status: http.StatusBadRequest, Code: generated.InvalidCredentials},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			controller := gomock.NewController(t)
+			fnNewSessionID = func() (string, error) { return session.ID, nil }
+			fnTimeNow = func() time.Time { return now }
+			defer func() {
+				fnNewSessionID = newSessionID
+				fnTimeNow = time.Now
+			}()
+
 			handler := &Server{
 				Repository: test.mockRepository(controller),
 			}
@@ -392,17 +521,17 @@ func TestUserLogin(t *testing.T) {
 			recorder := httptest.NewRecorder()
 			ctx := e.NewContext(request, recorder)
 
-			gotHttpStatusCode, gotResponse := handler.userLogin(ctx)
+			gotResponse, gotErr := handler.userLogin(ctx)
 
-			if gotHttpStatusCode != test.wantHttpStatusCode {
-				t.Errorf("handler.UserLogin() httpStatusCode = %v, wantHttpStatusCode %v", gotHttpStatusCode, test.wantHttpStatusCode)
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.UserLogin() err = %v, wantErr %v", gotErr, test.wantErr)
 			}
 
 			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
 				t.Errorf("handler.UserLogin() response = %v, wantResponse %v", gotResponse, test.wantResponse)
 			}
 
-			if test.wantResponse.Header.Success {
+			if test.wantErr == nil {
 				gotCtxUserID, _ := ctx.Get(string(utils.JWTClaimUserID)).(int64)
 				if gotCtxUserID != test.wantCtxUserID {
 					t.Errorf("handler.UserLogin() gotCtxUserID = %v, wantCtxUserID %v", gotCtxUserID, test.wantCtxUserID)
@@ -413,15 +542,68 @@ func TestUserLogin(t *testing.T) {
 				if !reflect.DeepEqual(gotCtxPermissions, wantCtxPermissions) {
 					t.Errorf("handler.UserLogin() gotCtxPermissions = %v, wantCtxPermissions %v", gotCtxPermissions, wantCtxPermissions)
 				}
+
+				gotCtxSessionID, _ := ctx.Get(string(utils.JWTClaimSessionID)).(string)
+				if gotCtxSessionID != session.ID {
+					t.Errorf("handler.UserLogin() gotCtxSessionID = %v, wantCtxSessionID %v", gotCtxSessionID, session.ID)
+				}
+
+				gotCtxExpiresAt, _ := ctx.Get(string(utils.JWTClaimExpiresAt)).(time.Time)
+				if !gotCtxExpiresAt.Equal(session.ExpiresTime) {
+					t.Errorf("handler.UserLogin() gotCtxExpiresAt = %v, wantCtxExpiresAt %v", gotCtxExpiresAt, session.ExpiresTime)
+				}
 			}
 		})
 	}
 }
 
+// TestUserLogin_LogsUserID checks the user who logged in is recorded in the logger of the request, so the request
+// is logged with their ID.
+func TestUserLogin_LogsUserID(t *testing.T) {
+	controller := gomock.NewController(t)
+	mock := repository.NewMockRepositoryInterface(controller)
+	mock.EXPECT().GetUsers(gomock.Any(), gomock.Any()).Return([]repository.User{
+		{ID: 123, PhoneNumber: "+628123456789", Password: "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq"},
+	}, nil)
+	mock.EXPECT().IncrementSuccessfulLoginCount(gomock.Any(), int64(123), gomock.Any()).Return(nil)
+	mock.EXPECT().InsertSession(gomock.Any(), gomock.Any()).Return(nil)
+	handler := &Server{Repository: mock}
+
+	output := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(output, nil)).With("request_id", "r1")
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodPost, "/v1/user/login", strings.NewReader(`{"phone_number":"+628123456789","password":"Password123!."}`))
+	request = request.WithContext(logging.NewContext(request.Context(), logger))
+	recorder := httptest.NewRecorder()
+	ctx := e.NewContext(request, recorder)
+
+	if err := handler.UserLogin(ctx, generated.UserLoginParams{}); err != nil {
+		t.Fatalf("handler.UserLogin() err = %v", err)
+	}
+
+	logging.FromContext(request.Context()).Info("request")
+
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatalf("json.Unmarshal() err = %v", err)
+	}
+	if entry["request_id"] != "r1" || entry["user_id"] != float64(123) {
+		t.Errorf("handler.UserLogin() log = %v, want request_id r1 and user_id 123", entry)
+	}
+}
+
 func TestGetUser(t *testing.T) {
 	stringPtr := func(in string) *string {
 		return &in
 	}
+	int64Ptr := func(in int64) *int64 {
+		return &in
+	}
+
+	createdTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	updatedTime := createdTime.Add(time.Hour)
+	lastLoginTime := createdTime.Add(2 * time.Hour)
 
 	tests := []struct {
 		name           string
@@ -429,8 +611,9 @@ func TestGetUser(t *testing.T) {
 		ctxPermissions []utils.JWTPermission
 		ctxUserID      int64
 
-		wantResponse       generated.GetUserResponse
-		wantHttpStatusCode int
+		wantResponse generated.GetUserResponse
+		wantETag     string
+		wantErr      *Error
 	}{
 		{
 			name: "success",
@@ -441,6 +624,50 @@ func TestGetUser(t *testing.T) {
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
 
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
+					UserID: 123,
+				}).Return([]repository.User{
+					{
+						ID:                   123,
+						FullName:             "User",
+						PhoneNumber:          "+628123456789",
+						Password:             "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+						CreatedTime:          createdTime,
+						UpdatedTime:          &updatedTime,
+						SuccessfulLoginCount: 7,
+						LastLoginTime:        &lastLoginTime,
+						Version:              4,
+					},
+				}, nil)
+
+				return mock
+			},
+			wantResponse: generated.GetUserResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+				User: generated.User{
+					Id:                   int64Ptr(123),
+					FullName:             stringPtr("User"),
+					PhoneNumber:          stringPtr("+628123456789"),
+					SuccessfulLoginCount: int64Ptr(7),
+					LastLoginAt:          &lastLoginTime,
+					CreatedAt:            &createdTime,
+					UpdatedAt:            &updatedTime,
+				},
+			},
+			wantETag: `"4"`,
+		},
+		{
+			name: "success-never-logged-in-or-updated",
+			ctxPermissions: []utils.JWTPermission{
+				utils.JWTPermissionGetUser,
+			},
+			ctxUserID: 123,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
 				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{
 					UserID: 123,
 				}).Return([]repository.User{
@@ -448,7 +675,8 @@ func TestGetUser(t *testing.T) {
 						ID:          123,
 						FullName:    "User",
 						PhoneNumber: "+628123456789",
-						Password:    "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+						CreatedTime: createdTime,
+						Version:     1,
 					},
 				}, nil)
 
@@ -457,14 +685,17 @@ func TestGetUser(t *testing.T) {
 			wantResponse: generated.GetUserResponse{
 				Header: generated.ResponseHeader{
 					Success:  true,
-					Messages: []string{successMsg},
+					Messages: []string{"request successful"},
 				},
 				User: generated.User{
-					FullName:    stringPtr("User"),
-					PhoneNumber: stringPtr("+628123456789"),
+					Id:                   int64Ptr(123),
+					FullName:             stringPtr("User"),
+					PhoneNumber:          stringPtr("+628123456789"),
+					SuccessfulLoginCount: int64Ptr(0),
+					CreatedAt:            &createdTime,
 				},
 			},
-			wantHttpStatusCode: http.StatusOK,
+			wantETag: `"1"`,
 		},
 		{
 			name:           "fail-not-authorized-no-permission",
@@ -474,13 +705,7 @@ func TestGetUser(t *testing.T) {
 				mock := repository.NewMockRepositoryInterface(controller)
 				return mock
 			},
-			wantResponse: generated.GetUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"not authorized: missing required permission"},
-				},
-			},
-			wantHttpStatusCode: http.StatusForbidden,
+			wantErr: &Error{Status: http.StatusForbidden, Code: generated.PermissionDenied},
 		},
 		{
 			name:           "fail-not-authorized-wrong-permission",
@@ -490,13 +715,7 @@ func TestGetUser(t *testing.T) {
 				mock := repository.NewMockRepositoryInterface(controller)
 				return mock
 			},
-			wantResponse: generated.GetUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"not authorized: missing required permission"},
-				},
-			},
-			wantHttpStatusCode: http.StatusForbidden,
+			wantErr: &Error{Status: http.StatusForbidden, Code: generated.PermissionDenied},
 		},
 		{
 			name:           "fail-not-authorized-no-user-id",
@@ -506,13 +725,7 @@ func TestGetUser(t *testing.T) {
 				mock := repository.NewMockRepositoryInterface(controller)
 				return mock
 			},
-			wantResponse: generated.GetUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"missing user_id"},
-				},
-			},
-			wantHttpStatusCode: http.StatusForbidden,
+			wantErr: &Error{Status: http.StatusForbidden, Code: generated.MissingUserId},
 		},
 		{
 			name: "fail-get-user",
@@ -529,13 +742,7 @@ func TestGetUser(t *testing.T) {
 
 				return mock
 			},
-			wantResponse: generated.GetUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"error-get-user"},
-				},
-			},
-			wantHttpStatusCode: http.StatusInternalServerError,
+			wantErr: &Error{Status: http.StatusInternalServerError, Code: generated.InternalError},
 		},
 		{
 			name: "fail-user-not-found",
@@ -552,13 +759,7 @@ func TestGetUser(t *testing.T) {
 
 				return mock
 			},
-			wantResponse: generated.GetUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"user not found"},
-				},
-			},
-			wantHttpStatusCode: http.StatusInternalServerError,
+			wantErr: &Error{Status: http.StatusNotFound, Code: generated.UserNotFound},
 		},
 	}
 
@@ -581,15 +782,96 @@ func TestGetUser(t *testing.T) {
 				ctx.Set(string(utils.JWTClaimPermissions), test.ctxPermissions)
 			}
 
-			gotHttpStatusCode, gotResponse := handler.getUser(ctx)
+			gotResponse, gotErr := handler.getUser(ctx)
 
-			if gotHttpStatusCode != test.wantHttpStatusCode {
-				t.Errorf("handler.GetUser() httpStatusCode = %v, wantHttpStatusCode %v", gotHttpStatusCode, test.wantHttpStatusCode)
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.GetUser() err = %v, wantErr %v", gotErr, test.wantErr)
 			}
 
 			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
 				t.Errorf("handler.GetUser() response = %v, wantResponse %v", gotResponse, test.wantResponse)
 			}
+
+			if gotETag := recorder.Header().Get(headerETag); gotETag != test.wantETag {
+				t.Errorf("handler.GetUser() etag = %v, wantETag %v", gotETag, test.wantETag)
+			}
+		})
+	}
+}
+
+func TestGetUser_IfNoneMatch(t *testing.T) {
+	stringPtr := func(in string) *string {
+		return &in
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch *string
+
+		wantHttpStatusCode int
+	}{
+		{
+			name:               "no-header",
+			wantHttpStatusCode: http.StatusOK,
+		},
+		{
+			name:               "current-version-not-modified",
+			ifNoneMatch:        stringPtr(`"4"`),
+			wantHttpStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "weak-current-version-not-modified",
+			ifNoneMatch:        stringPtr(`W/"4"`),
+			wantHttpStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "list-with-current-version-not-modified",
+			ifNoneMatch:        stringPtr(`"2", "4"`),
+			wantHttpStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "wildcard-not-modified",
+			ifNoneMatch:        stringPtr(`*`),
+			wantHttpStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "older-version",
+			ifNoneMatch:        stringPtr(`"3"`),
+			wantHttpStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{
+				{ID: 123, FullName: "User", PhoneNumber: "+628123456789", Version: 4},
+			}, nil)
+			handler := &Server{Repository: mock}
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodGet, "/v1/user", nil)
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+			ctx.Set(string(utils.JWTClaimUserID), int64(123))
+			ctx.Set(string(utils.JWTClaimPermissions), []utils.JWTPermission{utils.JWTPermissionGetUser})
+
+			if err := handler.GetUser(ctx, generated.GetUserParams{IfNoneMatch: test.ifNoneMatch}); err != nil {
+				t.Fatalf("handler.GetUser() err = %v", err)
+			}
+
+			if recorder.Code != test.wantHttpStatusCode {
+				t.Errorf("handler.GetUser() httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, test.wantHttpStatusCode)
+			}
+
+			if gotETag := recorder.Header().Get(headerETag); gotETag != `"4"` {
+				t.Errorf("handler.GetUser() etag = %v, wantETag %v", gotETag, `"4"`)
+			}
+
+			if test.wantHttpStatusCode == http.StatusNotModified && recorder.Body.Len() != 0 {
+				t.Errorf("handler.GetUser() body = %v, want no body", recorder.Body.String())
+			}
 		})
 	}
 }
@@ -599,8 +881,8 @@ func TestUpdateUser(t *testing.T) {
 		return &in
 	}
 
-	errorConflictUserPhoneNumber := pq.Error{
-		Code: "23505",
+	errorConflictUserPhoneNumber := repository.ErrConflict{
+		Field: "phone_number",
 	}
 
 	tests := []struct {
@@ -608,11 +890,12 @@ func TestUpdateUser(t *testing.T) {
 		mockRepository                   func(controller *gomock.Controller) *repository.MockRepositoryInterface
 		ctxPermissions                   []utils.JWTPermission
 		ctxUserID                        int64
-		requestBody                      generated.User
-		fnConvertUpdateUserRequestToUser func(int64, generated.User) (repository.User, []string)
+		params                           generated.UpdateUserParams
+		requestBody                      generated.ReplaceUserRequest
+		fnConvertUpdateUserRequestToUser func(ValidationRules, int64, userUpdate) (repository.User, []FieldError)
 
-		wantResponse       generated.UpdateUserResponse
-		wantHttpStatusCode int
+		wantResponse generated.UpdateUserResponse
+		wantErr      *Error
 	}{
 		{
 			name: "success",
@@ -620,19 +903,19 @@ func TestUpdateUser(t *testing.T) {
 				utils.JWTPermissionUpdateUser,
 			},
 			ctxUserID: 123,
-			requestBody: generated.User{
+			requestBody: generated.ReplaceUserRequest{
 				FullName:    stringPtr("User"),
-				PhoneNumber: stringPtr("+628123456789"),
+				PhoneNumber: "+628123456789",
 			},
-			fnConvertUpdateUserRequestToUser: func(int64, generated.User) (repository.User, []string) {
+			fnConvertUpdateUserRequestToUser: func(ValidationRules, int64, userUpdate) (repository.User, []FieldError) {
 				user := repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 				}
 
-				errorMsgs := []string{}
+				errorList := []FieldError{}
 
-				return user, errorMsgs
+				return user, errorList
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
@@ -640,17 +923,16 @@ func TestUpdateUser(t *testing.T) {
 				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
-				}).Return(nil)
+				}, "user:123").Return(nil)
 
 				return mock
 			},
 			wantResponse: generated.UpdateUserResponse{
 				Header: generated.ResponseHeader{
 					Success:  true,
-					Messages: []string{successMsg},
+					Messages: []string{"request successful"},
 				},
 			},
-			wantHttpStatusCode: http.StatusOK,
 		},
 		{
 			name: "fail-not-authorized-permission",
@@ -658,21 +940,15 @@ func TestUpdateUser(t *testing.T) {
 				utils.JWTPermissionGetUser,
 			},
 			ctxUserID: 123,
-			requestBody: generated.User{
+			requestBody: generated.ReplaceUserRequest{
 				FullName:    stringPtr("User"),
-				PhoneNumber: stringPtr("+628123456789"),
+				PhoneNumber: "+628123456789",
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
 				return mock
 			},
-			wantResponse: generated.UpdateUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"not authorized: missing required permission"},
-				},
-			},
-			wantHttpStatusCode: http.StatusForbidden,
+			wantErr: &Error{Status: http.StatusForbidden, Code: generated.PermissionDenied},
 		},
 		{
 			name: "fail-update-user",
@@ -680,19 +956,19 @@ func TestUpdateUser(t *testing.T) {
 				utils.JWTPermissionUpdateUser,
 			},
 			ctxUserID: 123,
-			requestBody: generated.User{
+			requestBody: generated.ReplaceUserRequest{
 				FullName:    stringPtr("User"),
-				PhoneNumber: stringPtr("+628123456789"),
+				PhoneNumber: "+628123456789",
 			},
-			fnConvertUpdateUserRequestToUser: func(int64, generated.User) (repository.User, []string) {
+			fnConvertUpdateUserRequestToUser: func(ValidationRules, int64, userUpdate) (repository.User, []FieldError) {
 				user := repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 				}
 
-				errorMsgs := []string{}
+				errorList := []FieldError{}
 
-				return user, errorMsgs
+				return user, errorList
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
@@ -700,17 +976,11 @@ func TestUpdateUser(t *testing.T) {
 				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
-				}).Return(errors.New("error-update-user"))
+				}, "user:123").Return(errors.New("error-update-user"))
 
 				return mock
 			},
-			wantResponse: generated.UpdateUserResponse{
-				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{"error-update-user"},
-				},
-			},
-			wantHttpStatusCode: http.StatusInternalServerError,
+			wantErr: &Error{Status: http.StatusInternalServerError, Code: generated.InternalError},
 		},
 		{
 			name: "fail-update-user-conflict-phone-number",
@@ -718,19 +988,19 @@ func TestUpdateUser(t *testing.T) {
 				utils.JWTPermissionUpdateUser,
 			},
 			ctxUserID: 123,
-			requestBody: generated.User{
+			requestBody: generated.ReplaceUserRequest{
 				FullName:    stringPtr("User"),
-				PhoneNumber: stringPtr("+628123456789"),
+				PhoneNumber: "+628123456789",
 			},
-			fnConvertUpdateUserRequestToUser: func(int64, generated.User) (repository.User, []string) {
+			fnConvertUpdateUserRequestToUser: func(ValidationRules, int64, userUpdate) (repository.User, []FieldError) {
 				user := repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
 				}
 
-				errorMsgs := []string{}
+				errorList := []FieldError{}
 
-				return user, errorMsgs
+				return user, errorList
 			},
 			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
 				mock := repository.NewMockRepositoryInterface(controller)
@@ -738,17 +1008,110 @@ func TestUpdateUser(t *testing.T) {
 				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{
 					FullName:    "User",
 					PhoneNumber: "+628123456789",
-				}).Return(&errorConflictUserPhoneNumber)
+				}, "user:123").Return(errorConflictUserPhoneNumber)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusConflict, Code: generated.PhoneNumberAlreadyRegistered},
+		},
+		{
+			name:                             "success-null-full-name-clears-it",
+			ctxPermissions:                   []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			ctxUserID:                        123,
+			requestBody:                      generated.ReplaceUserRequest{PhoneNumber: "+628123456789"},
+			fnConvertUpdateUserRequestToUser: convertUpdateUserRequestToUser,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, PhoneNumber: "+628123456789", ClearFullName: true}, "user:123").Return(nil)
+
+				return mock
+			},
+			wantResponse: generated.UpdateUserResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+			},
+		},
+		{
+			name:           "success-if-match-current-version",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			ctxUserID:      123,
+			params:         generated.UpdateUserParams{IfMatch: stringPtr(`"4"`)},
+			requestBody:    generated.ReplaceUserRequest{FullName: stringPtr("User"), PhoneNumber: "+628123456789"},
+			fnConvertUpdateUserRequestToUser: func(ValidationRules, int64, userUpdate) (repository.User, []FieldError) {
+				return repository.User{ID: 123, FullName: "User"}, []FieldError{}
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{{ID: 123, Version: 4}}, nil)
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, FullName: "User", Version: 4}, "user:123").Return(nil)
 
 				return mock
 			},
 			wantResponse: generated.UpdateUserResponse{
 				Header: generated.ResponseHeader{
-					Success:  false,
-					Messages: []string{duplicatePhoneNumberErrorMsg},
+					Success:  true,
+					Messages: []string{"request successful"},
 				},
 			},
-			wantHttpStatusCode: http.StatusConflict,
+		},
+		{
+			name:           "fail-if-match-stale-version",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			ctxUserID:      123,
+			params:         generated.UpdateUserParams{IfMatch: stringPtr(`"3"`)},
+			requestBody:    generated.ReplaceUserRequest{FullName: stringPtr("User"), PhoneNumber: "+628123456789"},
+			fnConvertUpdateUserRequestToUser: func(ValidationRules, int64, userUpdate) (repository.User, []FieldError) {
+				return repository.User{ID: 123, FullName: "User"}, []FieldError{}
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{{ID: 123, Version: 4}}, nil)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusPreconditionFailed, Code: generated.PreconditionFailed},
+		},
+		{
+			name:           "fail-if-match-weak-etag",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			ctxUserID:      123,
+			params:         generated.UpdateUserParams{IfMatch: stringPtr(`W/"4"`)},
+			requestBody:    generated.ReplaceUserRequest{FullName: stringPtr("User"), PhoneNumber: "+628123456789"},
+			fnConvertUpdateUserRequestToUser: func(ValidationRules, int64, userUpdate) (repository.User, []FieldError) {
+				return repository.User{ID: 123, FullName: "User"}, []FieldError{}
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{{ID: 123, Version: 4}}, nil)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusPreconditionFailed, Code: generated.PreconditionFailed},
+		},
+		{
+			name:           "fail-if-match-updated-concurrently",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			ctxUserID:      123,
+			params:         generated.UpdateUserParams{IfMatch: stringPtr(`*`)},
+			requestBody:    generated.ReplaceUserRequest{FullName: stringPtr("User"), PhoneNumber: "+628123456789"},
+			fnConvertUpdateUserRequestToUser: func(ValidationRules, int64, userUpdate) (repository.User, []FieldError) {
+				return repository.User{ID: 123, FullName: "User"}, []FieldError{}
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{{ID: 123, Version: 4}}, nil)
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, FullName: "User", Version: 4}, "user:123").Return(repository.ErrVersionMismatch)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusPreconditionFailed, Code: generated.PreconditionFailed},
 		},
 	}
 
@@ -775,11 +1138,12 @@ func TestUpdateUser(t *testing.T) {
 			}
 
 			fnConvertUpdateUserRequestToUser = test.fnConvertUpdateUserRequestToUser
+			defer func() { fnConvertUpdateUserRequestToUser = convertUpdateUserRequestToUser }()
 
-			gotHttpStatusCode, gotResponse := handler.updateUser(ctx)
+			gotResponse, gotErr := handler.updateUser(ctx, test.params)
 
-			if gotHttpStatusCode != test.wantHttpStatusCode {
-				t.Errorf("handler.UpdateUser() httpStatusCode = %v, wantHttpStatusCode %v", gotHttpStatusCode, test.wantHttpStatusCode)
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.UpdateUser() err = %v, wantErr %v", gotErr, test.wantErr)
 			}
 
 			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
@@ -788,3 +1152,910 @@ func TestUpdateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchUser(t *testing.T) {
+	stringPtr := func(in string) *string {
+		return &in
+	}
+
+	tests := []struct {
+		name           string
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+		ctxPermissions []utils.JWTPermission
+		params         generated.PatchUserParams
+		patch          string
+
+		wantResponse generated.UpdateUserResponse
+		wantErr      *Error
+	}{
+		{
+			name:           "success-patched-field-only",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			patch:          `{"full_name":"User"}`,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, FullName: "User"}, "user:123").Return(nil)
+
+				return mock
+			},
+			wantResponse: generated.UpdateUserResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+			},
+		},
+		{
+			name:           "success-empty-patch",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			patch:          `{}`,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantResponse: generated.UpdateUserResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+			},
+		},
+		{
+			name:           "fail-not-authorized-permission",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionGetUser},
+			patch:          `{"full_name":"User"}`,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantErr: &Error{Status: http.StatusForbidden, Code: generated.PermissionDenied},
+		},
+		{
+			name:           "success-clear-full-name",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			patch:          `{"full_name":null}`,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, ClearFullName: true}, "user:123").Return(nil)
+
+				return mock
+			},
+			wantResponse: generated.UpdateUserResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+			},
+		},
+		{
+			name:           "fail-clear-required-field",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			patch:          `{"phone_number":null,"full_name":null}`,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantErr: newValidationError([]FieldError{newSpecFieldError("phone_number", generated.RequiredFieldMissing)}),
+		},
+		{
+			name:           "fail-invalid-field",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			patch:          `{"full_name":"U"}`,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantErr: newValidationError([]FieldError{{
+				Field:  "full_name",
+				Code:   generated.FullNameInvalidLength,
+				Params: map[string]interface{}{"min": DefaultValidationRules.FullNameMinLength, "max": DefaultValidationRules.FullNameMaxLength},
+			}}),
+		},
+		{
+			name:           "fail-if-match-stale-version",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			params:         generated.PatchUserParams{IfMatch: stringPtr(`"3"`)},
+			patch:          `{"full_name":"User"}`,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{{ID: 123, Version: 4}}, nil)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusPreconditionFailed, Code: generated.PreconditionFailed},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			handler := &Server{
+				Repository: test.mockRepository(controller),
+			}
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodPatch, "/v1/user", strings.NewReader(test.patch))
+			request.Header.Set(echo.HeaderContentType, mimeApplicationMergePatchJSON)
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+			ctx.Set(string(utils.JWTClaimUserID), int64(123))
+			ctx.Set(string(utils.JWTClaimPermissions), test.ctxPermissions)
+
+			gotResponse, gotErr := handler.patchUser(ctx, test.params)
+
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.PatchUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
+				t.Errorf("handler.PatchUser() response = %v, wantResponse %v", gotResponse, test.wantResponse)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+		ctxPermissions []utils.JWTPermission
+
+		wantResponse generated.DeleteUserResponse
+		wantErr      *Error
+	}{
+		{
+			name:           "success",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().DeleteUser(gomock.Any(), int64(123), now, "user:123").Return(nil)
+
+				return mock
+			},
+			wantResponse: generated.DeleteUserResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+				RestorableUntil: now.Add(DefaultDeletionGracePeriod),
+			},
+		},
+		{
+			name:           "fail-not-authorized-permission",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionGetUser},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantErr: &Error{Status: http.StatusForbidden, Code: generated.PermissionDenied},
+		},
+		{
+			name:           "fail-delete-user",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().DeleteUser(gomock.Any(), int64(123), now, "user:123").Return(repository.ErrNotFound)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusNotFound, Code: generated.UserNotFound},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			fnTimeNow = func() time.Time { return now }
+			defer func() { fnTimeNow = time.Now }()
+
+			handler := &Server{
+				Repository: test.mockRepository(controller),
+			}
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodDelete, "/v1/user", nil)
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+			ctx.Set(string(utils.JWTClaimUserID), int64(123))
+			ctx.Set(string(utils.JWTClaimPermissions), test.ctxPermissions)
+
+			gotResponse, gotErr := handler.deleteUser(ctx)
+
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.DeleteUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
+				t.Errorf("handler.DeleteUser() response = %v, wantResponse %v", gotResponse, test.wantResponse)
+			}
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	stringPtr := func(in string) *string {
+		return &in
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	deletedTime := now.Add(-24 * time.Hour)
+	user := repository.User{
+		ID:       123,
+		Password: "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+	}
+	deletedUser := user
+	deletedUser.DeletedTime = &deletedTime
+
+	tests := []struct {
+		name           string
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+		requestBody    generated.UserLoginRequest
+
+		wantResponse generated.RestoreUserResponse
+		wantErr      *Error
+	}{
+		{
+			name: "success",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password123!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{deletedUser}, nil)
+				mock.EXPECT().RestoreUser(gomock.Any(), int64(123), now.Add(-DefaultDeletionGracePeriod), "user:123").Return(nil)
+
+				return mock
+			},
+			wantResponse: generated.RestoreUserResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+			},
+		},
+		{
+			name: "fail-invalid-password",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password321!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{deletedUser}, nil)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusBadRequest, Code: generated.InvalidCredentials},
+		},
+		{
+			name: "fail-account-not-deleted",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password123!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{user}, nil)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusConflict, Code: generated.AccountNotDeleted},
+		},
+		{
+			name: "fail-grace-period-over",
+			requestBody: generated.UserLoginRequest{
+				PhoneNumber: stringPtr("+628123456789"),
+				Password:    stringPtr("Password123!."),
+			},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{deletedUser}, nil)
+				mock.EXPECT().RestoreUser(gomock.Any(), int64(123), now.Add(-DefaultDeletionGracePeriod), "user:123").Return(repository.ErrNotFound)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusNotFound, Code: generated.UserNotFound},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			fnTimeNow = func() time.Time { return now }
+			defer func() { fnTimeNow = time.Now }()
+
+			handler := &Server{
+				Repository: test.mockRepository(controller),
+			}
+
+			requestBodyJSON, _ := json.Marshal(test.requestBody)
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodPost, "/v1/user/restore", bytes.NewBuffer(requestBodyJSON))
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+
+			gotResponse, gotErr := handler.restoreUser(ctx)
+
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.RestoreUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
+				t.Errorf("handler.RestoreUser() response = %v, wantResponse %v", gotResponse, test.wantResponse)
+			}
+		})
+	}
+}
+
+func TestExportUser(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	export := repository.UserExport{
+		ID:          "a1b2",
+		UserID:      123,
+		Status:      repository.UserExportPending,
+		CreatedTime: now,
+	}
+
+	tests := []struct {
+		name           string
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+		ctxPermissions []utils.JWTPermission
+
+		wantResponse generated.UserExportResponse
+		wantErr      *Error
+	}{
+		{
+			name:           "success",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionGetUser},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().InsertUserExport(gomock.Any(), repository.UserExport{ID: "a1b2", UserID: 123, CreatedTime: now}).Return(export, nil)
+
+				return mock
+			},
+			wantResponse: generated.UserExportResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+				Export: generated.UserExport{
+					Id:        "a1b2",
+					Status:    generated.UserExportStatusPending,
+					CreatedAt: now,
+				},
+			},
+		},
+		{
+			name:           "success-returns-pending-export",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionGetUser},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				pending := export
+				pending.ID = "c3d4"
+				pending.CreatedTime = now.Add(-time.Minute)
+				mock.EXPECT().InsertUserExport(gomock.Any(), repository.UserExport{ID: "a1b2", UserID: 123, CreatedTime: now}).Return(pending, nil)
+
+				return mock
+			},
+			wantResponse: generated.UserExportResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+				Export: generated.UserExport{
+					Id:        "c3d4",
+					Status:    generated.UserExportStatusPending,
+					CreatedAt: now.Add(-time.Minute),
+				},
+			},
+		},
+		{
+			name:           "fail-not-authorized-permission",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionUpdateUser},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantErr: &Error{Status: http.StatusForbidden, Code: generated.PermissionDenied},
+		},
+		{
+			name:           "fail-user-not-active",
+			ctxPermissions: []utils.JWTPermission{utils.JWTPermissionGetUser},
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().InsertUserExport(gomock.Any(), gomock.Any()).Return(repository.UserExport{}, repository.ErrNotFound)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusNotFound, Code: generated.UserNotFound},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			fnTimeNow = func() time.Time { return now }
+			fnNewExportID = func() (string, error) { return "a1b2", nil }
+			defer func() {
+				fnTimeNow = time.Now
+				fnNewExportID = newExportID
+			}()
+
+			handler := &Server{
+				Repository: test.mockRepository(controller),
+			}
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodPost, "/v1/user/export", nil)
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+			ctx.Set(string(utils.JWTClaimUserID), int64(123))
+			ctx.Set(string(utils.JWTClaimPermissions), test.ctxPermissions)
+
+			gotResponse, gotErr := handler.exportUser(ctx)
+
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.ExportUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
+				t.Errorf("handler.ExportUser() response = %v, wantResponse %v", gotResponse, test.wantResponse)
+			}
+		})
+	}
+}
+
+func TestGetUserExport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	completedTime := now.Add(-time.Hour)
+	expiresTime := now.Add(23 * time.Hour)
+	soonExpiresTime := now.Add(5 * time.Minute)
+	signingKey := []byte("export-signing-key")
+
+	export := repository.UserExport{
+		ID:            "a1b2",
+		UserID:        123,
+		Status:        repository.UserExportCompleted,
+		CreatedTime:   completedTime,
+		CompletedTime: &completedTime,
+		ExpiresTime:   &expiresTime,
+	}
+	linkExpiresAt := now.Add(DefaultExportLinkExpiry)
+	downloadURL := fmt.Sprintf("/v1/user/export/a1b2/download?expires=%d&signature=%s", linkExpiresAt.Unix(), signExportLink(signingKey, "a1b2", linkExpiresAt.Unix()))
+	soonDownloadURL := fmt.Sprintf("/v1/user/export/a1b2/download?expires=%d&signature=%s", soonExpiresTime.Unix(), signExportLink(signingKey, "a1b2", soonExpiresTime.Unix()))
+
+	tests := []struct {
+		name           string
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+		signingKey     []byte
+
+		wantResponse generated.UserExportResponse
+		wantErr      *Error
+	}{
+		{
+			name:       "success-completed-has-download-url",
+			signingKey: signingKey,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(export, nil)
+
+				return mock
+			},
+			wantResponse: generated.UserExportResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+				Export: generated.UserExport{
+					Id:                   "a1b2",
+					Status:               generated.UserExportStatusCompleted,
+					CreatedAt:            completedTime,
+					CompletedAt:          &completedTime,
+					ExpiresAt:            &expiresTime,
+					DownloadUrl:          &downloadURL,
+					DownloadUrlExpiresAt: &linkExpiresAt,
+				},
+			},
+		},
+		{
+			name:       "success-download-url-expires-with-archive",
+			signingKey: signingKey,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				expiringExport := export
+				expiringExport.ExpiresTime = &soonExpiresTime
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(expiringExport, nil)
+
+				return mock
+			},
+			wantResponse: generated.UserExportResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+				Export: generated.UserExport{
+					Id:                   "a1b2",
+					Status:               generated.UserExportStatusCompleted,
+					CreatedAt:            completedTime,
+					CompletedAt:          &completedTime,
+					ExpiresAt:            &soonExpiresTime,
+					DownloadUrl:          &soonDownloadURL,
+					DownloadUrlExpiresAt: &soonExpiresTime,
+				},
+			},
+		},
+		{
+			name:       "success-pending-has-no-download-url",
+			signingKey: signingKey,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(repository.UserExport{
+					ID:          "a1b2",
+					UserID:      123,
+					Status:      repository.UserExportPending,
+					CreatedTime: now,
+				}, nil)
+
+				return mock
+			},
+			wantResponse: generated.UserExportResponse{
+				Header: generated.ResponseHeader{
+					Success:  true,
+					Messages: []string{"request successful"},
+				},
+				Export: generated.UserExport{
+					Id:        "a1b2",
+					Status:    generated.UserExportStatusPending,
+					CreatedAt: now,
+				},
+			},
+		},
+		{
+			name: "fail-export-of-other-user",
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				otherExport := export
+				otherExport.UserID = 456
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(otherExport, nil)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusNotFound, Code: generated.ExportNotFound},
+		},
+		{
+			name: "fail-not-found",
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(repository.UserExport{}, repository.ErrNotFound)
+
+				return mock
+			},
+			wantErr: &Error{Status: http.StatusNotFound, Code: generated.ExportNotFound},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			fnTimeNow = func() time.Time { return now }
+			defer func() { fnTimeNow = time.Now }()
+
+			handler := &Server{
+				Repository:       test.mockRepository(controller),
+				ExportSigningKey: test.signingKey,
+			}
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodGet, "/v1/user/export/a1b2", nil)
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+			ctx.Set(string(utils.JWTClaimUserID), int64(123))
+			ctx.Set(string(utils.JWTClaimPermissions), []utils.JWTPermission{utils.JWTPermissionGetUser})
+
+			gotResponse, gotErr := handler.getUserExport(ctx, "a1b2")
+
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("handler.GetUserExport() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(test.wantResponse, gotResponse) {
+				t.Errorf("handler.GetUserExport() response = %v, wantResponse %v", gotResponse, test.wantResponse)
+			}
+		})
+	}
+}
+
+func TestDownloadUserExport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	signingKey := []byte("export-signing-key")
+	expires := now.Add(time.Minute).Unix()
+	signature := signExportLink(signingKey, "a1b2", expires)
+	archive := []byte(`{"profile":{"id":123}}`)
+
+	tests := []struct {
+		name           string
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+		expires        int64
+		signature      string
+
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:      "success",
+			expires:   expires,
+			signature: signature,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(repository.UserExport{ID: "a1b2", UserID: 123, Status: repository.UserExportCompleted}, nil)
+				mock.EXPECT().GetUserExportArchive(gomock.Any(), "a1b2").Return(archive, nil)
+
+				return mock
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   string(archive),
+		},
+		{
+			name:      "fail-invalid-signature",
+			expires:   expires,
+			signature: signExportLink([]byte("other-key"), "a1b2", expires),
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:      "fail-tampered-expiry",
+			expires:   expires + 3600,
+			signature: signature,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:      "fail-link-expired",
+			expires:   now.Unix(),
+			signature: signExportLink(signingKey, "a1b2", now.Unix()),
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				return repository.NewMockRepositoryInterface(controller)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:      "fail-export-expired",
+			expires:   expires,
+			signature: signature,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(repository.UserExport{ID: "a1b2", UserID: 123, Status: repository.UserExportExpired}, nil)
+
+				return mock
+			},
+			wantStatus: http.StatusGone,
+		},
+		{
+			name:      "fail-archive-expired-since-read",
+			expires:   expires,
+			signature: signature,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(repository.UserExport{ID: "a1b2", UserID: 123, Status: repository.UserExportCompleted}, nil)
+				mock.EXPECT().GetUserExportArchive(gomock.Any(), "a1b2").Return(nil, repository.ErrNotFound)
+
+				return mock
+			},
+			wantStatus: http.StatusGone,
+		},
+		{
+			name:      "fail-user-not-active",
+			expires:   expires,
+			signature: signature,
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUserExport(gomock.Any(), "a1b2").Return(repository.UserExport{}, repository.ErrNotFound)
+
+				return mock
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			fnTimeNow = func() time.Time { return now }
+			defer func() { fnTimeNow = time.Now }()
+
+			handler := &Server{
+				Repository:       test.mockRepository(controller),
+				ExportSigningKey: signingKey,
+			}
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodGet, "/v1/user/export/a1b2/download", nil)
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+
+			err := handler.DownloadUserExport(ctx, "a1b2", generated.DownloadUserExportParams{
+				Expires:   test.expires,
+				Signature: test.signature,
+			})
+			if err != nil {
+				t.Fatalf("handler.DownloadUserExport() err = %v", err)
+			}
+
+			if recorder.Code != test.wantStatus {
+				t.Errorf("handler.DownloadUserExport() status = %v, wantStatus %v", recorder.Code, test.wantStatus)
+			}
+
+			if test.wantBody != "" {
+				if recorder.Body.String() != test.wantBody {
+					t.Errorf("handler.DownloadUserExport() body = %s, wantBody %s", recorder.Body.String(), test.wantBody)
+				}
+				if got := recorder.Header().Get(echo.HeaderContentDisposition); got != `attachment; filename="user-export-a1b2.json"` {
+					t.Errorf("handler.DownloadUserExport() Content-Disposition = %s", got)
+				}
+			}
+		})
+	}
+}
+
+// requestContextKey marks the context of the test requests, see requestContextMatcher
+type requestContextKey struct{}
+
+// requestContextMatcher matches the context of the test request, or a context derived from it
+type requestContextMatcher struct{}
+
+func (requestContextMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && ctx.Value(requestContextKey{}) == "request"
+}
+
+func (requestContextMatcher) String() string {
+	return "is the context of the request"
+}
+
+// TestV1Endpoints_RequestContext checks the v1 endpoints call the repository with the context of the request, so the
+// repository spans and logs belong to the request like in v2.
+func TestV1Endpoints_RequestContext(t *testing.T) {
+	signingKey := []byte("export-signing-key")
+	expires := time.Now().Add(time.Minute).Unix()
+	credentials := `{"phone_number":"+628123456789","password":"Password123!."}`
+
+	tests := []struct {
+		name           string
+		body           string
+		mockRepository func(mock *repository.MockRepositoryInterface)
+		call           func(s *Server, ctx echo.Context) error
+	}{
+		{
+			name: "RegisterUser",
+			body: `{"full_name":"User","phone_number":"+628123456789","password":"Password123!."}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertUser(requestContextMatcher{}, gomock.Any()).Return(int64(0), repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.RegisterUser(ctx, generated.RegisterUserParams{}) },
+		},
+		{
+			name: "UserLogin",
+			body: credentials,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(requestContextMatcher{}, gomock.Any()).Return(nil, repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.UserLogin(ctx, generated.UserLoginParams{}) },
+		},
+		{
+			name: "GetUser",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(requestContextMatcher{}, gomock.Any()).Return(nil, repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.GetUser(ctx, generated.GetUserParams{}) },
+		},
+		{
+			name: "UpdateUser",
+			body: `{"full_name":"User","phone_number":"+628123456789"}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().UpdateUser(requestContextMatcher{}, gomock.Any(), gomock.Any()).Return(repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.UpdateUser(ctx, generated.UpdateUserParams{}) },
+		},
+		{
+			name: "PatchUser",
+			body: `{"full_name":"User"}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().UpdateUser(requestContextMatcher{}, gomock.Any(), gomock.Any()).Return(repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.PatchUser(ctx, generated.PatchUserParams{}) },
+		},
+		{
+			name: "DeleteUser",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().DeleteUser(requestContextMatcher{}, int64(123), gomock.Any(), gomock.Any()).Return(repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.DeleteUser(ctx) },
+		},
+		{
+			name: "RestoreUser",
+			body: credentials,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(requestContextMatcher{}, gomock.Any()).Return(nil, repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.RestoreUser(ctx, generated.RestoreUserParams{}) },
+		},
+		{
+			name: "ExportUser",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertUserExport(requestContextMatcher{}, gomock.Any()).Return(repository.UserExport{}, repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.ExportUser(ctx, generated.ExportUserParams{}) },
+		},
+		{
+			name: "GetUserExport",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUserExport(requestContextMatcher{}, "a1b2").Return(repository.UserExport{}, repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error { return s.GetUserExport(ctx, "a1b2") },
+		},
+		{
+			name: "DownloadUserExport",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUserExport(requestContextMatcher{}, "a1b2").Return(repository.UserExport{}, repository.ErrUnavailable)
+			},
+			call: func(s *Server, ctx echo.Context) error {
+				return s.DownloadUserExport(ctx, "a1b2", generated.DownloadUserExportParams{
+					Expires:   expires,
+					Signature: signExportLink(signingKey, "a1b2", expires),
+				})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			test.mockRepository(mock)
+
+			handler := &Server{
+				Repository:       mock,
+				ExportSigningKey: signingKey,
+			}
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodPost, "/v1/user", strings.NewReader(test.body))
+			request = request.WithContext(context.WithValue(request.Context(), requestContextKey{}, "request"))
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+			ctx.Set(string(utils.JWTClaimUserID), int64(123))
+			ctx.Set(string(utils.JWTClaimPermissions), sessionPermissions)
+
+			if err := test.call(handler, ctx); err != nil {
+				t.Fatalf("handler.%s() err = %v", test.name, err)
+			}
+
+			if recorder.Code != http.StatusServiceUnavailable {
+				t.Errorf("handler.%s() httpStatusCode = %v, wantHttpStatusCode %v", test.name, recorder.Code, http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
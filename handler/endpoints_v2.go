@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	locationUserF    = "/v2/users/%d"
+	locationSessionF = "/v2/sessions/%s"
+)
+
+// NOTE: Idempotency-Key header is handled by NewIdempotencyMiddleware
+func (s *Server) CreateUser(ctx echo.Context, _ generated.CreateUserParams) error {
+	response, err := s.createUserV2(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf(locationUserF, *response.Id))
+	return ctx.JSON(http.StatusCreated, response)
+}
+func (s *Server) createUserV2(ctx echo.Context) (generated.User, *Error) {
+	request := generated.User{}
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&request); err != nil {
+		return generated.User{}, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+	}
+
+	user, err := s.createUser(ctx.Request().Context(), request)
+	if err != nil {
+		return generated.User{}, err
+	}
+
+	return newUserResource(user), nil
+}
+
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+func (s *Server) GetCurrentUser(ctx echo.Context) error {
+	response, err := s.getCurrentUser(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) getCurrentUser(ctx echo.Context) (generated.User, *Error) {
+	userID, authErr := authorize(ctx, utils.JWTPermissionGetUser)
+	if authErr != nil {
+		return generated.User{}, authErr
+	}
+
+	user, err := s.getUserByID(ctx.Request().Context(), userID)
+	if err != nil {
+		return generated.User{}, err
+	}
+	ctx.Response().Header().Set(headerETag, userETag(user))
+
+	return newUserResource(user), nil
+}
+
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+func (s *Server) GetUserByID(ctx echo.Context, id generated.UserID) error {
+	response, err := s.getUserByIDV2(ctx, id)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) getUserByIDV2(ctx echo.Context, id int64) (generated.User, *Error) {
+	userID, authErr := authorize(ctx, utils.JWTPermissionGetUser)
+	if authErr != nil {
+		return generated.User{}, authErr
+	}
+
+	// Users can only access themselves, do not reveal whether other users exist
+	if id != userID {
+		return generated.User{}, NewError(http.StatusNotFound, generated.UserNotFound)
+	}
+
+	user, err := s.getUserByID(ctx.Request().Context(), userID)
+	if err != nil {
+		return generated.User{}, err
+	}
+	ctx.Response().Header().Set(headerETag, userETag(user))
+
+	return newUserResource(user), nil
+}
+
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+func (s *Server) UpdateUserByID(ctx echo.Context, id generated.UserID, params generated.UpdateUserByIDParams) error {
+	response, err := s.updateUserByIDV2(ctx, id, params)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+func (s *Server) updateUserByIDV2(ctx echo.Context, id int64, params generated.UpdateUserByIDParams) (generated.User, *Error) {
+	userID, authErr := authorize(ctx, utils.JWTPermissionUpdateUser)
+	if authErr != nil {
+		return generated.User{}, authErr
+	}
+
+	// Users can only update themselves, do not reveal whether other users exist
+	if id != userID {
+		return generated.User{}, NewError(http.StatusNotFound, generated.UserNotFound)
+	}
+
+	// Replace user data, every field is required by the spec like PUT /v1/user
+	request := generated.ReplaceUserRequest{}
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&request); err != nil {
+		return generated.User{}, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+	}
+
+	// Only update the version the client based the update on, like PUT /v1/user
+	version, matchErr := s.ifMatchVersion(ctx.Request().Context(), userID, params.IfMatch)
+	if matchErr != nil {
+		return generated.User{}, matchErr
+	}
+
+	if err := s.updateUserByID(ctx.Request().Context(), userID, version, replaceUserUpdate(request)); err != nil {
+		return generated.User{}, err
+	}
+
+	user, err := s.getUserByID(ctx.Request().Context(), userID)
+	if err != nil {
+		return generated.User{}, err
+	}
+	ctx.Response().Header().Set(headerETag, userETag(user))
+
+	return newUserResource(user), nil
+}
+
+// NOTE: Check AuthenticatedMiddleware cmd/main.go that returns JWT token of the session
+// NOTE: Idempotency-Key header is handled by NewIdempotencyMiddleware
+func (s *Server) CreateSession(ctx echo.Context, _ generated.CreateSessionParams) error {
+	response, err := s.createSession(ctx)
+	if err != nil {
+		return WriteError(ctx, err)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf(locationSessionF, *response.Id))
+	return ctx.JSON(http.StatusCreated, response)
+}
+func (s *Server) createSession(ctx echo.Context) (generated.Session, *Error) {
+	request := generated.UserLoginRequest{}
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&request); err != nil {
+		return generated.Session{}, NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+	}
+
+	session, err := s.login(ctx.Request().Context(), request)
+	if err != nil {
+		return generated.Session{}, err
+	}
+
+	// Set data to Echo context so we can rely on AuthenticatedMiddleware to generate and return JWT in the Authorization header
+	setSessionClaims(ctx, session)
+
+	return newSessionResource(session), nil
+}
+
+// NOTE: Check AuthenticationMiddleware cmd/main.go that authenticates the JWT token
+func (s *Server) DeleteSession(ctx echo.Context, id generated.SessionID) error {
+	if err := s.deleteSession(ctx, id); err != nil {
+		return WriteError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+func (s *Server) deleteSession(ctx echo.Context, id string) *Error {
+	userID, authErr := authenticatedUserID(ctx)
+	if authErr != nil {
+		return authErr
+	}
+
+	return s.revokeSession(ctx.Request().Context(), userID, id)
+}
+
+// newSessionResource returns the session as exposed by the API.
+func newSessionResource(session repository.Session) generated.Session {
+	return generated.Session{
+		Id:        &session.ID,
+		UserId:    &session.UserID,
+		CreatedAt: &session.CreatedTime,
+		ExpiresAt: &session.ExpiresTime,
+	}
+}
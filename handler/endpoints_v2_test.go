@@ -0,0 +1,398 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestServerV2(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	session := repository.Session{
+		ID:          "f8065ac4677043303e57ebe063ac292f",
+		UserID:      123,
+		CreatedTime: now,
+		ExpiresTime: now.Add(utils.JWTExpiryDuration),
+	}
+
+	createdTime := now.Add(-48 * time.Hour)
+	updatedTime := now.Add(-24 * time.Hour)
+	lastLoginTime := now.Add(-time.Hour)
+	user := repository.User{
+		ID:                   123,
+		FullName:             "User",
+		PhoneNumber:          "+628123456789",
+		Password:             "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+		CreatedTime:          createdTime,
+		UpdatedTime:          &updatedTime,
+		SuccessfulLoginCount: 7,
+		LastLoginTime:        &lastLoginTime,
+		Version:              4,
+	}
+
+	allPermissions := []utils.JWTPermission{utils.JWTPermissionGetUser, utils.JWTPermissionUpdateUser}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		requestBody    string
+		ifMatch        string
+		ctxUserID      int64
+		ctxPermissions []utils.JWTPermission
+		mockRepository func(mock *repository.MockRepositoryInterface)
+		// validateResponses checks the response against the spec, as OPENAPI_VALIDATE_RESPONSES does
+		validateResponses bool
+
+		wantHttpStatusCode int
+		wantLocation       string
+		wantETag           string
+		wantBody           string
+	}{
+		{
+			name:        "create-user",
+			method:      http.MethodPost,
+			path:        "/v2/users",
+			requestBody: `{"phone_number":"+628123456789","full_name":"User","password":"P455w0rd!."}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(int64(123), nil)
+			},
+			wantHttpStatusCode: http.StatusCreated,
+			wantLocation:       "/v2/users/123",
+			wantBody:           `{"full_name":"User","id":123,"phone_number":"+628123456789","successful_login_count":0}`,
+		},
+		{
+			name:               "create-user-fail-validation",
+			method:             http.MethodPost,
+			path:               "/v2/users",
+			requestBody:        `{"phone_number":"+658123456789","full_name":"User","password":"P455w0rd!."}`,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["phone_number should start with +62 (rule 2)"],"success":false}}`,
+		},
+		{
+			name:           "get-current-user",
+			method:         http.MethodGet,
+			path:           "/v2/users/me",
+			ctxUserID:      123,
+			ctxPermissions: allPermissions,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantETag:           `"4"`,
+			wantBody:           `{"created_at":"2023-12-30T12:00:00Z","full_name":"User","id":123,"last_login_at":"2024-01-01T11:00:00Z","phone_number":"+628123456789","successful_login_count":7,"updated_at":"2023-12-31T12:00:00Z"}`,
+		},
+		{
+			name:               "get-current-user-fail-permission-denied",
+			method:             http.MethodGet,
+			path:               "/v2/users/me",
+			ctxUserID:          123,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusForbidden,
+			wantBody:           `{"header":{"messages":["not authorized: missing required permission"],"success":false}}`,
+		},
+		{
+			name:           "get-user-by-id",
+			method:         http.MethodGet,
+			path:           "/v2/users/123",
+			ctxUserID:      123,
+			ctxPermissions: allPermissions,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantETag:           `"4"`,
+			wantBody:           `{"created_at":"2023-12-30T12:00:00Z","full_name":"User","id":123,"last_login_at":"2024-01-01T11:00:00Z","phone_number":"+628123456789","successful_login_count":7,"updated_at":"2023-12-31T12:00:00Z"}`,
+		},
+		{
+			name:               "get-user-by-id-fail-other-user-not-found",
+			method:             http.MethodGet,
+			path:               "/v2/users/456",
+			ctxUserID:          123,
+			ctxPermissions:     allPermissions,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusNotFound,
+			wantBody:           `{"header":{"messages":["user not found"],"success":false}}`,
+		},
+		{
+			name:           "get-user-by-id-fail-deleted-user-not-found",
+			method:         http.MethodGet,
+			path:           "/v2/users/123",
+			ctxUserID:      123,
+			ctxPermissions: allPermissions,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return(nil, nil)
+			},
+			wantHttpStatusCode: http.StatusNotFound,
+			wantBody:           `{"header":{"messages":["user not found"],"success":false}}`,
+		},
+		{
+			name:           "update-user-by-id",
+			method:         http.MethodPut,
+			path:           "/v2/users/123",
+			requestBody:    `{"phone_number":"+628123456789","full_name":"New User"}`,
+			ctxUserID:      123,
+			ctxPermissions: allPermissions,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, PhoneNumber: "+628123456789", FullName: "New User"}, "user:123").Return(nil)
+
+				updatedUser := user
+				updatedUser.FullName = "New User"
+				updatedUser.Version = 5
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{updatedUser}, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantETag:           `"5"`,
+			wantBody:           `{"created_at":"2023-12-30T12:00:00Z","full_name":"New User","id":123,"last_login_at":"2024-01-01T11:00:00Z","phone_number":"+628123456789","successful_login_count":7,"updated_at":"2023-12-31T12:00:00Z"}`,
+		},
+		{
+			name:              "update-user-by-id-null-full-name-removes-it",
+			validateResponses: true,
+			method:            http.MethodPut,
+			path:              "/v2/users/123",
+			requestBody:       `{"phone_number":"+628123456789","full_name":null}`,
+			ctxUserID:         123,
+			ctxPermissions:    allPermissions,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, PhoneNumber: "+628123456789", ClearFullName: true}, "user:123").Return(nil)
+
+				updatedUser := user
+				updatedUser.FullName = ""
+				updatedUser.Version = 5
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{updatedUser}, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantETag:           `"5"`,
+			wantBody:           `{"created_at":"2023-12-30T12:00:00Z","id":123,"last_login_at":"2024-01-01T11:00:00Z","phone_number":"+628123456789","successful_login_count":7,"updated_at":"2023-12-31T12:00:00Z"}`,
+		},
+		{
+			name:           "update-user-by-id-if-match",
+			method:         http.MethodPut,
+			path:           "/v2/users/123",
+			requestBody:    `{"phone_number":"+628123456789","full_name":"New User"}`,
+			ifMatch:        `"4"`,
+			ctxUserID:      123,
+			ctxPermissions: allPermissions,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				updatedUser := user
+				updatedUser.FullName = "New User"
+				updatedUser.Version = 5
+				gomock.InOrder(
+					mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil),
+					mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, PhoneNumber: "+628123456789", FullName: "New User", Version: 4}, "user:123").Return(nil),
+					mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{updatedUser}, nil),
+				)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantETag:           `"5"`,
+			wantBody:           `{"created_at":"2023-12-30T12:00:00Z","full_name":"New User","id":123,"last_login_at":"2024-01-01T11:00:00Z","phone_number":"+628123456789","successful_login_count":7,"updated_at":"2023-12-31T12:00:00Z"}`,
+		},
+		{
+			name:           "update-user-by-id-fail-if-match-stale-version",
+			method:         http.MethodPut,
+			path:           "/v2/users/123",
+			requestBody:    `{"phone_number":"+628123456789","full_name":"New User"}`,
+			ifMatch:        `"3"`,
+			ctxUserID:      123,
+			ctxPermissions: allPermissions,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil)
+			},
+			wantHttpStatusCode: http.StatusPreconditionFailed,
+			wantBody:           `{"header":{"messages":["the user was changed since the version in If-Match, get it again and retry"],"success":false}}`,
+		},
+		{
+			name:           "update-user-by-id-fail-changed-during-update",
+			method:         http.MethodPut,
+			path:           "/v2/users/123",
+			requestBody:    `{"phone_number":"+628123456789","full_name":"New User"}`,
+			ifMatch:        `"4"`,
+			ctxUserID:      123,
+			ctxPermissions: allPermissions,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil)
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, PhoneNumber: "+628123456789", FullName: "New User", Version: 4}, "user:123").Return(repository.ErrVersionMismatch)
+			},
+			wantHttpStatusCode: http.StatusPreconditionFailed,
+			wantBody:           `{"header":{"messages":["the user was changed since the version in If-Match, get it again and retry"],"success":false}}`,
+		},
+		{
+			name:               "update-user-by-id-fail-missing-field",
+			method:             http.MethodPut,
+			path:               "/v2/users/123",
+			requestBody:        `{"full_name":"New User"}`,
+			ctxUserID:          123,
+			ctxPermissions:     allPermissions,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["phone_number should start with +62 (rule 2)","phone_number should be 10 to 13 digits (rule 1)"],"success":false}}`,
+		},
+		{
+			name:               "update-user-by-id-fail-other-user-not-found",
+			method:             http.MethodPut,
+			path:               "/v2/users/456",
+			requestBody:        `{"phone_number":"+628123456789","full_name":"New User"}`,
+			ctxUserID:          123,
+			ctxPermissions:     allPermissions,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusNotFound,
+			wantBody:           `{"header":{"messages":["user not found"],"success":false}}`,
+		},
+		{
+			name:        "create-session",
+			method:      http.MethodPost,
+			path:        "/v2/sessions",
+			requestBody: `{"phone_number":"+628123456789","password":"Password123!."}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{user}, nil)
+				mock.EXPECT().IncrementSuccessfulLoginCount(gomock.Any(), int64(123), session.CreatedTime).Return(nil)
+				mock.EXPECT().InsertSession(gomock.Any(), session).Return(nil)
+			},
+			wantHttpStatusCode: http.StatusCreated,
+			wantLocation:       "/v2/sessions/f8065ac4677043303e57ebe063ac292f",
+			wantBody:           `{"created_at":"2024-01-01T12:00:00Z","expires_at":"2024-01-01T12:30:00Z","id":"f8065ac4677043303e57ebe063ac292f","user_id":123}`,
+		},
+		{
+			name:        "create-session-fail-invalid-password",
+			method:      http.MethodPost,
+			path:        "/v2/sessions",
+			requestBody: `{"phone_number":"+628123456789","password":"Password123.!"}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{user}, nil)
+			},
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["invalid phone number or password"],"success":false}}`,
+		},
+		{
+			name:        "create-session-fail-account-suspended",
+			method:      http.MethodPost,
+			path:        "/v2/sessions",
+			requestBody: `{"phone_number":"+628123456789","password":"Password123!."}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				suspendedUser := user
+				suspendedTime := now.Add(-time.Hour)
+				suspendedUser.SuspendedTime = &suspendedTime
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{suspendedUser}, nil)
+			},
+			validateResponses:  true,
+			wantHttpStatusCode: http.StatusForbidden,
+			wantBody:           `{"header":{"messages":["account has been suspended"],"success":false}}`,
+		},
+		{
+			name:        "create-session-fail-account-deleted",
+			method:      http.MethodPost,
+			path:        "/v2/sessions",
+			requestBody: `{"phone_number":"+628123456789","password":"Password123!."}`,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				deletedUser := user
+				deletedTime := now.Add(-24 * time.Hour)
+				deletedUser.DeletedTime = &deletedTime
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{deletedUser}, nil)
+			},
+			validateResponses:  true,
+			wantHttpStatusCode: http.StatusForbidden,
+			wantBody:           `{"header":{"messages":["account has been deleted, it can be restored until 2024-01-30T12:00:00Z"],"success":false}}`,
+		},
+		{
+			name:      "delete-session",
+			method:    http.MethodDelete,
+			path:      "/v2/sessions/f8065ac4677043303e57ebe063ac292f",
+			ctxUserID: 123,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				mock.EXPECT().RevokeSession(gomock.Any(), session.ID).Return(nil)
+			},
+			wantHttpStatusCode: http.StatusNoContent,
+		},
+		{
+			name:      "delete-session-fail-other-user-not-found",
+			method:    http.MethodDelete,
+			path:      "/v2/sessions/f8065ac4677043303e57ebe063ac292f",
+			ctxUserID: 456,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+			},
+			wantHttpStatusCode: http.StatusNotFound,
+			wantBody:           `{"header":{"messages":["session not found"],"success":false}}`,
+		},
+		{
+			name:      "delete-session-fail-not-found",
+			method:    http.MethodDelete,
+			path:      "/v2/sessions/unknown",
+			ctxUserID: 123,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "unknown").Return(repository.Session{}, repository.ErrNotFound)
+			},
+			wantHttpStatusCode: http.StatusNotFound,
+			wantBody:           `{"header":{"messages":["session not found"],"success":false}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			test.mockRepository(mock)
+
+			fnNewSessionID = func() (string, error) { return session.ID, nil }
+			fnTimeNow = func() time.Time { return now }
+			defer func() {
+				fnNewSessionID = newSessionID
+				fnTimeNow = time.Now
+			}()
+
+			e := echo.New()
+			if test.validateResponses {
+				swagger, err := generated.GetSwagger()
+				if err != nil {
+					t.Fatalf("generated.GetSwagger() err = %v", err)
+				}
+				e.Use(NewOpenAPIValidator(NewOpenAPIValidatorOptions{Swagger: swagger, ValidateResponses: true}))
+			}
+			// Stand-in for AuthenticationMiddleware in cmd/main.go
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(ctx echo.Context) error {
+					if test.ctxUserID != 0 {
+						ctx.Set(string(utils.JWTClaimUserID), test.ctxUserID)
+						ctx.Set(string(utils.JWTClaimPermissions), test.ctxPermissions)
+					}
+					return next(ctx)
+				}
+			})
+			generated.RegisterHandlers(e, NewServer(NewServerOptions{Repository: mock}))
+
+			request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.requestBody))
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if test.ifMatch != "" {
+				request.Header.Set("If-Match", test.ifMatch)
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantHttpStatusCode {
+				t.Errorf("handler.Server httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, test.wantHttpStatusCode)
+			}
+
+			if gotLocation := recorder.Header().Get(echo.HeaderLocation); gotLocation != test.wantLocation {
+				t.Errorf("handler.Server location = %v, wantLocation %v", gotLocation, test.wantLocation)
+			}
+
+			if gotETag := recorder.Header().Get(headerETag); gotETag != test.wantETag {
+				t.Errorf("handler.Server etag = %v, wantETag %v", gotETag, test.wantETag)
+			}
+
+			if gotBody := strings.TrimSuffix(recorder.Body.String(), "\n"); gotBody != test.wantBody {
+				t.Errorf("handler.Server body = %v, wantBody %v", gotBody, test.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/i18n"
+	"github.com/UserService/repository"
+)
+
+// FieldError describes why the value of a single request field was rejected.
+// Params are interpolated into the localized message of Code, see the i18n package.
+type FieldError struct {
+	Field  string
+	Code   generated.ErrorCode
+	Params map[string]interface{}
+}
+
+// Error is a failure reported to clients. Its error code is stable, so clients and tests should rely on
+// the code rather than on the message. See WriteError for how it is rendered.
+type Error struct {
+	Status        int
+	Code          generated.ErrorCode
+	Params        map[string]interface{}
+	InvalidParams []FieldError
+}
+
+// NewError creates an Error with the given HTTP status code and error code.
+func NewError(status int, code generated.ErrorCode, invalidParams ...FieldError) *Error {
+	return &Error{
+		Status:        status,
+		Code:          code,
+		InvalidParams: invalidParams,
+	}
+}
+
+// newValidationError creates the Error returned when one or more request fields are invalid.
+func newValidationError(fieldErrors []FieldError) *Error {
+	return NewError(http.StatusBadRequest, generated.ValidationFailed, fieldErrors...)
+}
+
+// Error returns the message of the error in the default locale.
+func (e *Error) Error() string {
+	return e.title(i18n.Default())
+}
+
+func (e *Error) title(localizer *i18n.Localizer) string {
+	return localizer.Message(string(e.Code), e.Params)
+}
+
+// messages returns the messages of the v1 response envelope: one per rejected field for validation errors,
+// otherwise the message of the error code.
+func (e *Error) messages(localizer *i18n.Localizer) []string {
+	if len(e.InvalidParams) == 0 {
+		return []string{e.title(localizer)}
+	}
+
+	messages := make([]string, 0, len(e.InvalidParams))
+	for _, param := range e.InvalidParams {
+		messages = append(messages, localizer.Message(string(param.Code), param.Params))
+	}
+
+	return messages
+}
+
+// repositoryError maps an error returned by the repository layer to an Error that is safe to return to clients.
+// Errors that are unknown to the domain are reported as internal server errors so raw database errors never
+// leave the service.
+func repositoryError(err error) *Error {
+	var conflict repository.ErrConflict
+
+	switch {
+	case errors.As(err, &conflict):
+		if conflict.Field == "phone_number" {
+			return NewError(http.StatusConflict, generated.PhoneNumberAlreadyRegistered)
+		}
+
+		params := map[string]interface{}{"field": conflict.Field}
+		return &Error{
+			Status:        http.StatusConflict,
+			Code:          generated.AlreadyRegistered,
+			Params:        params,
+			InvalidParams: []FieldError{{Field: conflict.Field, Code: generated.AlreadyRegistered, Params: params}},
+		}
+	case errors.Is(err, repository.ErrNotFound):
+		return NewError(http.StatusNotFound, generated.UserNotFound)
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return NewError(http.StatusPreconditionFailed, generated.PreconditionFailed)
+	case errors.Is(err, repository.ErrValidation):
+		return NewError(http.StatusBadRequest, generated.InvalidValue)
+	case errors.Is(err, repository.ErrUnavailable):
+		return NewError(http.StatusServiceUnavailable, generated.ServiceUnavailable)
+	default:
+		return NewError(http.StatusInternalServerError, generated.InternalError)
+	}
+}
+
+// sessionRepositoryError is repositoryError for session lookups, where not found refers to the session
+func sessionRepositoryError(err error) *Error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return NewError(http.StatusNotFound, generated.SessionNotFound)
+	}
+
+	return repositoryError(err)
+}
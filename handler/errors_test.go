@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/i18n"
+	"github.com/UserService/repository"
+)
+
+func Test_repositoryError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input error
+
+		wantErr *Error
+	}{
+		{
+			name:    "conflict-phone-number",
+			input:   repository.ErrConflict{Field: "phone_number"},
+			wantErr: &Error{Status: http.StatusConflict, Code: generated.PhoneNumberAlreadyRegistered},
+		},
+		{
+			name:  "conflict-other-field",
+			input: repository.ErrConflict{Field: "email"},
+			wantErr: &Error{
+				Status: http.StatusConflict,
+				Code:   generated.AlreadyRegistered,
+				Params: map[string]interface{}{"field": "email"},
+				InvalidParams: []FieldError{
+					{Field: "email", Code: generated.AlreadyRegistered, Params: map[string]interface{}{"field": "email"}},
+				},
+			},
+		},
+		{
+			name:    "not-found",
+			input:   repository.ErrNotFound,
+			wantErr: &Error{Status: http.StatusNotFound, Code: generated.UserNotFound},
+		},
+		{
+			name:    "validation-wrapped",
+			input:   fmt.Errorf("%w: pq: value too long for type character varying(60)", repository.ErrValidation),
+			wantErr: &Error{Status: http.StatusBadRequest, Code: generated.InvalidValue},
+		},
+		{
+			name:    "unavailable-wrapped",
+			input:   fmt.Errorf("%w: dial tcp 127.0.0.1:5432: connect: connection refused", repository.ErrUnavailable),
+			wantErr: &Error{Status: http.StatusServiceUnavailable, Code: generated.ServiceUnavailable},
+		},
+		{
+			name:    "unknown-error-is-not-exposed",
+			input:   errors.New(`pq: relation "user" does not exist`),
+			wantErr: &Error{Status: http.StatusInternalServerError, Code: generated.InternalError},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotErr := repositoryError(test.input)
+			if !reflect.DeepEqual(gotErr, test.wantErr) {
+				t.Errorf("handler.repositoryError() gotErr = %v, wantErr %v", gotErr, test.wantErr)
+			}
+		})
+	}
+}
+
+func Test_errorMessages(t *testing.T) {
+	swagger, err := generated.GetSwagger()
+	if err != nil {
+		t.Fatalf("generated.GetSwagger() err = %v", err)
+	}
+
+	catalog, err := i18n.NewCatalog(i18n.DefaultLocale)
+	if err != nil {
+		t.Fatalf("i18n.NewCatalog() err = %v", err)
+	}
+
+	// Every error code documented in api.yml needs a message in every locale
+	for _, locale := range catalog.Locales() {
+		localizer := catalog.Negotiate(locale)
+		for _, code := range swagger.Components.Schemas["ErrorCode"].Value.Enum {
+			if message := localizer.Message(code.(string), nil); message == code {
+				t.Errorf("locale %v is missing a message for error code %v", locale, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+)
+
+const (
+	headerETag = "ETag"
+
+	// etagWildcard matches any version in If-Match and If-None-Match headers
+	etagWildcard = "*"
+)
+
+// userETag returns the ETag of the version of the user, see repository.User.Version
+func userETag(user repository.User) string {
+	return fmt.Sprintf(`"%d"`, user.Version)
+}
+
+// etagMatches reports whether header, an If-Match or If-None-Match header, lists etag or is the wildcard. Weak ETags
+// only match with weak comparison, which If-None-Match uses and If-Match does not.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == etagWildcard {
+			return true
+		}
+
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatchVersion returns the version of the user matching the If-Match header, so the update can be made conditional
+// on it, or 0 without the header so the update applies to the current version.
+func (s *Server) ifMatchVersion(ctx context.Context, userID int64, ifMatch *string) (int64, *Error) {
+	if ifMatch == nil {
+		return 0, nil
+	}
+
+	return s.matchUserVersion(ctx, userID, *ifMatch)
+}
+
+// matchUserVersion returns the version of the user if it matches the If-Match header, so the update can be made
+// conditional on it.
+func (s *Server) matchUserVersion(ctx context.Context, userID int64, ifMatch string) (int64, *Error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !etagMatches(ifMatch, userETag(user), false) {
+		return 0, NewError(http.StatusPreconditionFailed, generated.PreconditionFailed)
+	}
+
+	return user.Version, nil
+}
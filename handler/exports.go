@@ -0,0 +1,235 @@
+package handler
+
+// This file contains the logic of the exports of the personal data of users, built by export.Worker.
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+)
+
+const (
+	// exportAuditLogPageSize is how many audit log entries BuildUserDataArchive reads at once
+	exportAuditLogPageSize = 100
+
+	exportURLF         = "/v1/user/export/%s"
+	exportDownloadURLF = "/v1/user/export/%s/download?%s"
+)
+
+var (
+	//define function wrappers so we can inject dummy function in UT
+	fnNewExportID func() (string, error) = newExportID
+)
+
+// startUserExport queues an export of the personal data of the user, or returns its pending export.
+func (s *Server) startUserExport(ctx context.Context, userID int64) (repository.UserExport, *Error) {
+	exportID, err := fnNewExportID()
+	if err != nil {
+		return repository.UserExport{}, NewError(http.StatusInternalServerError, generated.InternalError)
+	}
+
+	// Not found means the user is no longer active
+	export, err := s.Repository.InsertUserExport(ctx, repository.UserExport{
+		ID:          exportID,
+		UserID:      userID,
+		CreatedTime: fnTimeNow(),
+	})
+	if err != nil {
+		return repository.UserExport{}, repositoryError(err)
+	}
+
+	return export, nil
+}
+
+// getUserExportByID returns the export with the given ID, if it belongs to the user.
+func (s *Server) getUserExportByID(ctx context.Context, userID int64, exportID string) (repository.UserExport, *Error) {
+	export, err := s.Repository.GetUserExport(ctx, exportID)
+	if err != nil {
+		return repository.UserExport{}, exportRepositoryError(err)
+	}
+
+	// Do not tell users whether the exports of other users exist
+	if export.UserID != userID {
+		return repository.UserExport{}, NewError(http.StatusNotFound, generated.ExportNotFound)
+	}
+
+	return export, nil
+}
+
+// getUserExportArchive checks the signed download link of the export and returns its archive. The link replaces the
+// access token, so it is checked before anything is read.
+func (s *Server) getUserExportArchive(ctx context.Context, exportID string, expires int64, signature string) ([]byte, *Error) {
+	if !s.validExportLink(exportID, expires, signature) {
+		return nil, NewError(http.StatusForbidden, generated.DownloadLinkInvalid)
+	}
+
+	export, err := s.Repository.GetUserExport(ctx, exportID)
+	if err != nil {
+		return nil, exportRepositoryError(err)
+	}
+
+	switch export.Status {
+	case repository.UserExportCompleted:
+	case repository.UserExportExpired:
+		return nil, NewError(http.StatusGone, generated.ExportExpired)
+	default:
+		return nil, NewError(http.StatusNotFound, generated.ExportNotFound)
+	}
+
+	// Not found means the export expired since it was read
+	archive, err := s.Repository.GetUserExportArchive(ctx, exportID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, NewError(http.StatusGone, generated.ExportExpired)
+	}
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	return archive, nil
+}
+
+// BuildUserDataArchive returns the JSON archive of everything the service holds about the active user, see
+// UserDataArchive in api.yml. It is the export.Builder of the exports started with POST /v1/user/export.
+func (s *Server) BuildUserDataArchive(ctx context.Context, userID int64) ([]byte, error) {
+	user, err := s.getSingleUser(ctx, repository.UserFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.Repository.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := generated.UserDataArchive{
+		ExportedAt: fnTimeNow().UTC(),
+		Profile: generated.UserDataProfile{
+			Id:                   user.ID,
+			PhoneNumber:          user.PhoneNumber,
+			CreatedAt:            user.CreatedTime,
+			UpdatedAt:            user.UpdatedTime,
+			SuccessfulLoginCount: user.SuccessfulLoginCount,
+			LastLoginAt:          user.LastLoginTime,
+		},
+		LoginHistory: make([]generated.UserDataLogin, 0, len(sessions)),
+		AuditLog:     []generated.AuditLogEntry{},
+	}
+	if user.FullName != "" {
+		archive.Profile.FullName = &user.FullName
+	}
+
+	for _, session := range sessions {
+		archive.LoginHistory = append(archive.LoginHistory, generated.UserDataLogin{
+			LoggedInAt: session.CreatedTime,
+			ExpiresAt:  session.ExpiresTime,
+			RevokedAt:  session.RevokedTime,
+		})
+	}
+
+	// Page through the audit log of the user, newest first
+	filter := repository.AuditLogFilter{TargetUserID: userID, Limit: exportAuditLogPageSize}
+	for {
+		entries, err := s.Repository.GetAuditLog(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			archive.AuditLog = append(archive.AuditLog, newAuditLogEntryResource(entry))
+		}
+
+		if len(entries) < filter.Limit {
+			break
+		}
+		filter.BeforeID = entries[len(entries)-1].ID
+	}
+
+	return json.Marshal(archive)
+}
+
+// newUserExportResource returns the export as exposed by the API. Completed exports get a download link that is
+// valid for exportLinkExpiry, or until the archive expires if that is sooner.
+func (s *Server) newUserExportResource(export repository.UserExport) generated.UserExport {
+	resource := generated.UserExport{
+		Id:          export.ID,
+		Status:      generated.UserExportStatus(export.Status),
+		CreatedAt:   export.CreatedTime,
+		CompletedAt: export.CompletedTime,
+		ExpiresAt:   export.ExpiresTime,
+	}
+
+	if export.Status != repository.UserExportCompleted || len(s.ExportSigningKey) == 0 {
+		return resource
+	}
+
+	linkExpiresAt := fnTimeNow().Add(s.exportLinkExpiry()).Truncate(time.Second)
+	if export.ExpiresTime != nil && export.ExpiresTime.Before(linkExpiresAt) {
+		linkExpiresAt = export.ExpiresTime.Truncate(time.Second)
+	}
+
+	expires := linkExpiresAt.Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", signExportLink(s.ExportSigningKey, export.ID, expires))
+
+	downloadURL := fmt.Sprintf(exportDownloadURLF, url.PathEscape(export.ID), query.Encode())
+	resource.DownloadUrl = &downloadURL
+	resource.DownloadUrlExpiresAt = &linkExpiresAt
+	return resource
+}
+
+// validExportLink checks the signature of a download link and that it has not expired.
+func (s *Server) validExportLink(exportID string, expires int64, signature string) bool {
+	if len(s.ExportSigningKey) == 0 || fnTimeNow().Unix() >= expires {
+		return false
+	}
+
+	expected := signExportLink(s.ExportSigningKey, exportID, expires)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// signExportLink returns the signature of a download link: the hex encoded HMAC-SHA256 of `<export ID>.<expires>`.
+func signExportLink(key []byte, exportID string, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(exportID))
+	mac.Write([]byte("."))
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// exportURL returns the URL of the export, to poll
+func exportURL(exportID string) string {
+	return fmt.Sprintf(exportURLF, url.PathEscape(exportID))
+}
+
+// exportRepositoryError is repositoryError for export lookups, where not found refers to the export
+func exportRepositoryError(err error) *Error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return NewError(http.StatusNotFound, generated.ExportNotFound)
+	}
+
+	return repositoryError(err)
+}
+
+// newExportID returns a random 128-bit export ID.
+func newExportID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
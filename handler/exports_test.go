@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+func TestServer_BuildUserDataArchive(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	createdTime := now.Add(-48 * time.Hour)
+	revokedTime := now.Add(-time.Hour)
+
+	user := repository.User{ID: 123, FullName: "User", PhoneNumber: "+628123456789", Password: "hash", CreatedTime: createdTime}
+	fullName := "User"
+	profile := generated.UserDataProfile{Id: 123, FullName: &fullName, PhoneNumber: "+628123456789", CreatedAt: createdTime}
+	session := repository.Session{ID: "s1", UserID: 123, CreatedTime: now.Add(-2 * time.Hour), ExpiresTime: now.Add(-90 * time.Minute), RevokedTime: &revokedTime}
+
+	// A full page of entries, so the next page is read
+	firstPage := make([]repository.AuditLogEntry, exportAuditLogPageSize)
+	for i := range firstPage {
+		firstPage[i] = repository.AuditLogEntry{ID: int64(200 - i), TargetUserID: 123, Action: repository.AuditActionUserUpdated}
+	}
+	secondPage := []repository.AuditLogEntry{{
+		ID:           1,
+		TargetUserID: 123,
+		Actor:        "user:123",
+		Action:       repository.AuditActionUserRegistered,
+		Changes:      map[string]repository.AuditChange{"full_name": {After: "User"}},
+	}}
+
+	tests := []struct {
+		name           string
+		mockRepository func(controller *gomock.Controller) *repository.MockRepositoryInterface
+
+		wantProfile    generated.UserDataProfile
+		wantLogins     []generated.UserDataLogin
+		wantAuditLogID []int64
+		wantErr        error
+	}{
+		{
+			name: "success-pages-through-audit-log",
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil)
+				mock.EXPECT().GetUserSessions(gomock.Any(), int64(123)).Return([]repository.Session{session}, nil)
+				mock.EXPECT().GetAuditLog(gomock.Any(), repository.AuditLogFilter{TargetUserID: 123, Limit: exportAuditLogPageSize}).Return(firstPage, nil)
+				mock.EXPECT().GetAuditLog(gomock.Any(), repository.AuditLogFilter{TargetUserID: 123, BeforeID: 101, Limit: exportAuditLogPageSize}).Return(secondPage, nil)
+
+				return mock
+			},
+			wantProfile: profile,
+			wantLogins: []generated.UserDataLogin{{
+				LoggedInAt: session.CreatedTime,
+				ExpiresAt:  session.ExpiresTime,
+				RevokedAt:  &revokedTime,
+			}},
+			wantAuditLogID: append(auditLogIDs(firstPage), 1),
+		},
+		{
+			name: "success-empty-history",
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil)
+				mock.EXPECT().GetUserSessions(gomock.Any(), int64(123)).Return(nil, nil)
+				mock.EXPECT().GetAuditLog(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+				return mock
+			},
+			wantProfile:    profile,
+			wantLogins:     []generated.UserDataLogin{},
+			wantAuditLogID: []int64{},
+		},
+		{
+			name: "success-profile-without-full-name",
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{{ID: 123, PhoneNumber: "+628123456789", CreatedTime: createdTime}}, nil)
+				mock.EXPECT().GetUserSessions(gomock.Any(), int64(123)).Return(nil, nil)
+				mock.EXPECT().GetAuditLog(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+				return mock
+			},
+			wantProfile:    generated.UserDataProfile{Id: 123, PhoneNumber: "+628123456789", CreatedAt: createdTime},
+			wantLogins:     []generated.UserDataLogin{},
+			wantAuditLogID: []int64{},
+		},
+		{
+			name: "fail-user-not-active",
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return(nil, nil)
+
+				return mock
+			},
+			wantErr: repository.ErrNotFound,
+		},
+		{
+			name: "fail-get-audit-log",
+			mockRepository: func(controller *gomock.Controller) *repository.MockRepositoryInterface {
+				mock := repository.NewMockRepositoryInterface(controller)
+
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil)
+				mock.EXPECT().GetUserSessions(gomock.Any(), int64(123)).Return(nil, nil)
+				mock.EXPECT().GetAuditLog(gomock.Any(), gomock.Any()).Return(nil, repository.ErrUnavailable)
+
+				return mock
+			},
+			wantErr: repository.ErrUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			fnTimeNow = func() time.Time { return now }
+			defer func() { fnTimeNow = time.Now }()
+
+			handler := &Server{
+				Repository: test.mockRepository(controller),
+			}
+
+			gotArchive, gotErr := handler.BuildUserDataArchive(context.Background(), 123)
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Fatalf("handler.BuildUserDataArchive() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+
+			archive := generated.UserDataArchive{}
+			if err := json.Unmarshal(gotArchive, &archive); err != nil {
+				t.Fatalf("json.Unmarshal() err = %v", err)
+			}
+
+			if !reflect.DeepEqual(archive.Profile, test.wantProfile) {
+				t.Errorf("handler.BuildUserDataArchive() profile = %v, want %v", archive.Profile, test.wantProfile)
+			}
+			if !archive.ExportedAt.Equal(now) {
+				t.Errorf("handler.BuildUserDataArchive() exported_at = %v, want %v", archive.ExportedAt, now)
+			}
+			if !reflect.DeepEqual(archive.LoginHistory, test.wantLogins) {
+				t.Errorf("handler.BuildUserDataArchive() login_history = %v, want %v", archive.LoginHistory, test.wantLogins)
+			}
+
+			gotAuditLogIDs := []int64{}
+			for _, entry := range archive.AuditLog {
+				gotAuditLogIDs = append(gotAuditLogIDs, entry.Id)
+			}
+			if !reflect.DeepEqual(gotAuditLogIDs, test.wantAuditLogID) {
+				t.Errorf("handler.BuildUserDataArchive() audit_log IDs = %v, want %v", gotAuditLogIDs, test.wantAuditLogID)
+			}
+		})
+	}
+}
+
+func TestServer_validExportLink(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := []byte("export-signing-key")
+	expires := now.Add(time.Minute).Unix()
+
+	tests := []struct {
+		name      string
+		key       []byte
+		exportID  string
+		expires   int64
+		signature string
+
+		want bool
+	}{
+		{
+			name:      "valid",
+			key:       key,
+			exportID:  "a1b2",
+			expires:   expires,
+			signature: signExportLink(key, "a1b2", expires),
+			want:      true,
+		},
+		{
+			name:      "invalid-other-export",
+			key:       key,
+			exportID:  "c3d4",
+			expires:   expires,
+			signature: signExportLink(key, "a1b2", expires),
+		},
+		{
+			name:      "invalid-expired",
+			key:       key,
+			exportID:  "a1b2",
+			expires:   now.Unix(),
+			signature: signExportLink(key, "a1b2", now.Unix()),
+		},
+		{
+			name:      "invalid-without-signing-key",
+			exportID:  "a1b2",
+			expires:   expires,
+			signature: signExportLink(nil, "a1b2", expires),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fnTimeNow = func() time.Time { return now }
+			defer func() { fnTimeNow = time.Now }()
+
+			handler := &Server{ExportSigningKey: test.key}
+
+			if got := handler.validExportLink(test.exportID, test.expires, test.signature); got != test.want {
+				t.Errorf("handler.validExportLink() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func auditLogIDs(entries []repository.AuditLogEntry) []int64 {
+	ids := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+
+	return ids
+}
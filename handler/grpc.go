@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/generated/userpb"
+	"github.com/UserService/i18n"
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// errorDomain is the domain of the errdetails.ErrorInfo attached to gRPC errors, its reason is the error code
+	errorDomain = "user-service"
+
+	getUsersByIDsMaxLength = 100
+)
+
+// grpcService implements the gRPC API defined in proto/user.proto with the logic shared with the HTTP endpoints,
+// see users.go.
+type grpcService struct {
+	userpb.UnimplementedUserServiceServer
+
+	server        *Server
+	publicKey     *rsa.PublicKey
+	privateKey    *rsa.PrivateKey
+	internalToken string
+}
+
+type NewGRPCServerOptions struct {
+	Server  *Server
+	Catalog *i18n.Catalog
+
+	// PublicKey verifies the tokens of authenticated calls, PrivateKey signs the tokens of new sessions
+	PublicKey  *rsa.PublicKey
+	PrivateKey *rsa.PrivateKey
+
+	// InternalToken authenticates the internal-only RPCs, sent as `authorization: Bearer <token>` metadata. The
+	// internal RPCs are disabled when it is empty.
+	InternalToken string
+
+	// IdempotencyFingerprintKey keys the fingerprints of calls sent with an idempotency key, see
+	// NewIdempotencyMiddlewareOptions.FingerprintKey. Idempotency keys are ignored when it is empty.
+	IdempotencyFingerprintKey []byte
+
+	// IdempotencyKeyTTL is how long responses of calls sent with an idempotency key are replayed for, defaults to
+	// 24 hours like NewIdempotencyMiddlewareOptions.TTL
+	IdempotencyKeyTTL time.Duration
+
+	// Interceptors run before the interceptors of the API, i.e. to instrument every call
+	Interceptors []grpc.UnaryServerInterceptor
+}
+
+// NewGRPCServer returns a gRPC server serving the gRPC API, with interceptors equivalent to the Echo middlewares
+// of the HTTP API registered in cmd/main.go.
+func NewGRPCServer(opts NewGRPCServerOptions) *grpc.Server {
+	if opts.IdempotencyKeyTTL == 0 {
+		opts.IdempotencyKeyTTL = defaultIdempotencyKeyTTL
+	}
+
+	service := &grpcService{
+		server:        opts.Server,
+		publicKey:     opts.PublicKey,
+		privateKey:    opts.PrivateKey,
+		internalToken: opts.InternalToken,
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(append(opts.Interceptors,
+		i18n.UnaryServerInterceptor(opts.Catalog), // negotiate locale before any interceptor can return an error
+		service.authenticate,                      // like AuthenticationMiddleware in cmd/main.go
+		newGRPCIdempotencyInterceptor(opts.Server.Repository, opts.IdempotencyKeyTTL, opts.IdempotencyFingerprintKey), // like NewIdempotencyMiddleware
+	)...))
+	userpb.RegisterUserServiceServer(server, service)
+
+	return server
+}
+
+func (g *grpcService) CreateUser(ctx context.Context, request *userpb.CreateUserRequest) (*userpb.User, error) {
+	user, err := g.server.createUser(ctx, generated.User{
+		PhoneNumber: &request.PhoneNumber,
+		FullName:    &request.FullName,
+		Password:    &request.Password,
+	})
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newUserMessage(user), nil
+}
+
+func (g *grpcService) CreateSession(ctx context.Context, request *userpb.CreateSessionRequest) (*userpb.CreateSessionResponse, error) {
+	if g.privateKey == nil {
+		return nil, grpcError(ctx, NewError(http.StatusInternalServerError, generated.InternalError))
+	}
+
+	session, err := g.server.login(ctx, generated.UserLoginRequest{
+		PhoneNumber: &request.PhoneNumber,
+		Password:    &request.Password,
+	})
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	token, tokenErr := utils.NewToken(g.privateKey, newSessionClaims(session))
+	if tokenErr != nil {
+		return nil, grpcError(ctx, NewError(http.StatusInternalServerError, generated.InternalError))
+	}
+
+	// Like AuthenticatedMiddleware in cmd/main.go, also return the token in the Authorization header
+	_ = grpc.SetHeader(ctx, metadata.Pairs(metadataAuthorization, fmt.Sprintf("Bearer %s", token)))
+
+	return &userpb.CreateSessionResponse{
+		Session: newSessionMessage(session),
+		Token:   token,
+	}, nil
+}
+
+func (g *grpcService) DeleteSession(ctx context.Context, request *userpb.DeleteSessionRequest) (*userpb.DeleteSessionResponse, error) {
+	claims, ok := grpcClaims(ctx)
+	if !ok {
+		return nil, grpcError(ctx, NewError(http.StatusForbidden, generated.MissingUserId))
+	}
+
+	if err := g.server.revokeSession(ctx, claims.UserID, request.Id); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return &userpb.DeleteSessionResponse{}, nil
+}
+
+func (g *grpcService) GetCurrentUser(ctx context.Context, _ *userpb.GetCurrentUserRequest) (*userpb.User, error) {
+	userID, authErr := authorizeGRPC(ctx, utils.JWTPermissionGetUser)
+	if authErr != nil {
+		return nil, grpcError(ctx, authErr)
+	}
+
+	user, err := g.server.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newUserMessage(user), nil
+}
+
+func (g *grpcService) GetUser(ctx context.Context, request *userpb.GetUserRequest) (*userpb.User, error) {
+	userID, authErr := authorizeGRPC(ctx, utils.JWTPermissionGetUser)
+	if authErr != nil {
+		return nil, grpcError(ctx, authErr)
+	}
+
+	// Users can only access themselves, do not reveal whether other users exist
+	if request.Id != userID {
+		return nil, grpcError(ctx, NewError(http.StatusNotFound, generated.UserNotFound))
+	}
+
+	user, err := g.server.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newUserMessage(user), nil
+}
+
+func (g *grpcService) UpdateUser(ctx context.Context, request *userpb.UpdateUserRequest) (*userpb.User, error) {
+	userID, authErr := authorizeGRPC(ctx, utils.JWTPermissionUpdateUser)
+	if authErr != nil {
+		return nil, grpcError(ctx, authErr)
+	}
+
+	// Users can only update themselves, do not reveal whether other users exist
+	if request.Id != userID {
+		return nil, grpcError(ctx, NewError(http.StatusNotFound, generated.UserNotFound))
+	}
+
+	// An empty full name removes it, like null in PATCH /v1/user
+	update := userUpdate{PhoneNumber: request.PhoneNumber, FullName: request.FullName}
+	if request.FullName != nil && *request.FullName == "" {
+		update.FullName, update.ClearFullName = nil, true
+	}
+
+	// Only update the version the client based the update on, like If-Match, version 0 updates the current version
+	if err := g.server.updateUserByID(ctx, userID, request.Version, update); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	user, err := g.server.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return newUserMessage(user), nil
+}
+
+func (g *grpcService) GetUsersByIDs(ctx context.Context, request *userpb.GetUsersByIDsRequest) (*userpb.GetUsersByIDsResponse, error) {
+	if len(request.Ids) > getUsersByIDsMaxLength {
+		return nil, grpcError(ctx, newValidationError([]FieldError{newSpecFieldError("ids", generated.InvalidField)}))
+	}
+
+	response := &userpb.GetUsersByIDsResponse{}
+	if len(request.Ids) == 0 {
+		return response, nil
+	}
+
+	users, err := g.server.Repository.GetUsersByIDs(ctx, request.Ids)
+	if err != nil {
+		return nil, grpcError(ctx, repositoryError(err))
+	}
+
+	for _, user := range users {
+		response.Users = append(response.Users, newUserMessage(user))
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) ValidateToken(ctx context.Context, request *userpb.ValidateTokenRequest) (*userpb.ValidateTokenResponse, error) {
+	if g.publicKey == nil {
+		return nil, grpcError(ctx, NewError(http.StatusInternalServerError, generated.InternalError))
+	}
+
+	claims, err := g.server.validateToken(ctx, g.publicKey, request.Token)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	permissions := make([]string, 0, len(claims.Permissions))
+	for _, permission := range claims.Permissions {
+		permissions = append(permissions, string(permission))
+	}
+
+	return &userpb.ValidateTokenResponse{
+		UserId:      claims.UserID,
+		SessionId:   claims.SessionID,
+		Permissions: permissions,
+		ExpiresAt:   timestamppb.New(time.Unix(claims.ExpiresAt, 0)),
+	}, nil
+}
+
+// grpcError converts err to a gRPC status. Its message is localized in the locale negotiated by
+// i18n.UnaryServerInterceptor, its error code is attached as the reason of an errdetails.ErrorInfo and rejected
+// fields as an errdetails.BadRequest.
+func grpcError(ctx context.Context, err *Error) error {
+	localizer := i18n.FromGoContext(ctx)
+
+	st, detailsErr := status.New(grpcCode(err), err.title(localizer)).WithDetails(&errdetails.ErrorInfo{
+		Reason: string(err.Code),
+		Domain: errorDomain,
+	})
+	if detailsErr != nil {
+		return status.Error(grpcCode(err), err.title(localizer))
+	}
+
+	if len(err.InvalidParams) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, param := range err.InvalidParams {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       param.Field,
+				Description: localizer.Message(string(param.Code), param.Params),
+			})
+		}
+
+		if withBadRequest, detailsErr := st.WithDetails(badRequest); detailsErr == nil {
+			st = withBadRequest
+		}
+	}
+
+	return st.Err()
+}
+
+// grpcCode returns the gRPC code matching the HTTP status of err.
+func grpcCode(err *Error) codes.Code {
+	switch err.Status {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		if err.Code == generated.IdempotencyRequestInProgress {
+			return codes.Aborted
+		}
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// newUserMessage returns the user as exposed by the gRPC API, without its password, like newUserResource.
+func newUserMessage(user repository.User) *userpb.User {
+	message := &userpb.User{
+		Id:                   user.ID,
+		PhoneNumber:          user.PhoneNumber,
+		FullName:             user.FullName,
+		Version:              user.Version,
+		SuccessfulLoginCount: user.SuccessfulLoginCount,
+	}
+	if user.LastLoginTime != nil {
+		message.LastLoginAt = timestamppb.New(*user.LastLoginTime)
+	}
+	if !user.CreatedTime.IsZero() {
+		message.CreatedAt = timestamppb.New(user.CreatedTime)
+	}
+	if user.UpdatedTime != nil {
+		message.UpdatedAt = timestamppb.New(*user.UpdatedTime)
+	}
+
+	return message
+}
+
+// newSessionMessage returns the session as exposed by the gRPC API.
+func newSessionMessage(session repository.Session) *userpb.Session {
+	return &userpb.Session{
+		Id:        session.ID,
+		UserId:    session.UserID,
+		CreatedAt: timestamppb.New(session.CreatedTime),
+		ExpiresAt: timestamppb.New(session.ExpiresTime),
+	}
+}
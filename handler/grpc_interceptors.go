@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/generated/userpb"
+	"github.com/UserService/logging"
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	metadataAuthorization      = "authorization"
+	metadataIdempotencyKey     = "idempotency-key"
+	metadataIdempotentReplayed = "idempotent-replayed"
+)
+
+type claimsContextKey struct{}
+
+// grpcAuthenticatedMethods are the RPCs that require a token, like the endpoints of AuthenticationMiddleware
+// in cmd/main.go
+var grpcAuthenticatedMethods = map[string]bool{
+	userpb.UserService_DeleteSession_FullMethodName:  true,
+	userpb.UserService_GetCurrentUser_FullMethodName: true,
+	userpb.UserService_GetUser_FullMethodName:        true,
+	userpb.UserService_UpdateUser_FullMethodName:     true,
+}
+
+// grpcInternalMethods are the internal-only RPCs, which require the internal token of the service rather than the
+// token of a user
+var grpcInternalMethods = map[string]bool{
+	userpb.UserService_GetUsersByIDs_FullMethodName: true,
+	userpb.UserService_ValidateToken_FullMethodName: true,
+}
+
+// grpcIdempotentMethods are the RPCs whose responses are replayed to retries sent with the same `idempotency-key`
+// metadata, along with the type of their response. Like NewIdempotencyMiddleware, sessions are left out so tokens
+// are never persisted.
+var grpcIdempotentMethods = map[string]func() proto.Message{
+	userpb.UserService_CreateUser_FullMethodName: func() proto.Message { return &userpb.User{} },
+}
+
+// authenticate verifies the token of the `authorization: Bearer <token>` metadata of calls to
+// grpcAuthenticatedMethods and stores its claims in the context of the call. Calls to grpcInternalMethods are
+// authenticated with the internal token instead.
+func (g *grpcService) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if grpcInternalMethods[info.FullMethod] {
+		if err := g.authenticateInternal(ctx); err != nil {
+			return nil, grpcError(ctx, err)
+		}
+		return handler(ctx, req)
+	}
+
+	if !grpcAuthenticatedMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	if g.publicKey == nil {
+		return nil, grpcError(ctx, NewError(http.StatusInternalServerError, generated.InternalError))
+	}
+
+	claims, err := g.server.AuthenticateToken(ctx, g.publicKey, metadataValue(ctx, metadataAuthorization))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return handler(context.WithValue(ctx, claimsContextKey{}, claims), req)
+}
+
+// authenticateInternal checks that the `authorization: Bearer <token>` metadata holds the internal token, like
+// NewAdminMiddleware does for the admin API. No token matches when the internal token is not set, so internal RPCs
+// are disabled.
+func (g *grpcService) authenticateInternal(ctx context.Context) *Error {
+	authHeader := metadataValue(ctx, metadataAuthorization)
+	if authHeader == "" {
+		return NewError(http.StatusUnauthorized, generated.MissingAuthorizationHeader)
+	}
+
+	token, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok {
+		return NewError(http.StatusUnauthorized, generated.InvalidAuthorizationHeader)
+	}
+
+	if g.internalToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(g.internalToken)) != 1 {
+		return NewError(http.StatusUnauthorized, generated.InvalidToken)
+	}
+
+	return nil
+}
+
+// grpcClaims returns the claims of the token stored by grpcService.authenticate.
+func grpcClaims(ctx context.Context) (utils.CustomClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(utils.CustomClaims)
+	return claims, ok
+}
+
+// authorizeGRPC is authorize for gRPC calls.
+func authorizeGRPC(ctx context.Context, requiredPermission utils.JWTPermission) (userID int64, err *Error) {
+	claims, ok := grpcClaims(ctx)
+	if !hasPermission(claims.Permissions, requiredPermission) {
+		return userID, NewError(http.StatusForbidden, generated.PermissionDenied)
+	}
+
+	if !ok {
+		return userID, NewError(http.StatusForbidden, generated.MissingUserId)
+	}
+
+	return claims.UserID, nil
+}
+
+// newGRPCIdempotencyInterceptor is NewIdempotencyMiddleware for gRPC calls. Only successful responses are stored,
+// failed calls release the key so they can be retried.
+func newGRPCIdempotencyInterceptor(repo repository.RepositoryInterface, ttl time.Duration, fingerprintKey []byte) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		newResponse, ok := grpcIdempotentMethods[info.FullMethod]
+		keyID := metadataValue(ctx, metadataIdempotencyKey)
+		if !ok || keyID == "" || len(fingerprintKey) == 0 {
+			return handler(ctx, req)
+		}
+
+		if len(keyID) > idempotencyKeyMaxLength {
+			return nil, grpcError(ctx, newValidationError([]FieldError{newSpecFieldError(metadataIdempotencyKey, generated.InvalidField)}))
+		}
+
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req.(proto.Message))
+		if err != nil {
+			return nil, grpcError(ctx, NewError(http.StatusBadRequest, generated.InvalidRequestBody))
+		}
+
+		claims, _ := grpcClaims(ctx)
+		now := fnTimeNow()
+		key := repository.IdempotencyKey{
+			ID:          keyID,
+			Fingerprint: idempotencyFingerprint(fingerprintKey, "RPC", info.FullMethod, claims.UserID, body),
+			CreatedTime: now,
+			ExpiresTime: now.Add(ttl),
+		}
+
+		reserved, err := repo.ReserveIdempotencyKey(ctx, key)
+		if err != nil {
+			return nil, grpcError(ctx, repositoryError(err))
+		}
+
+		if !reserved {
+			return replayGRPCResponse(ctx, repo, key, newResponse())
+		}
+
+		response, handlerErr := handler(ctx, req)
+
+		// The response is already computed, store it even if the call has been cancelled in the meantime
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+		defer cancel()
+
+		if handlerErr == nil {
+			key.StatusCode = http.StatusOK // the column holds HTTP statuses, where 0 means in progress
+			key.ResponseBody, err = proto.Marshal(response.(proto.Message))
+			if err == nil {
+				err = repo.CompleteIdempotencyKey(storeCtx, key)
+			}
+			if err == nil {
+				return response, nil
+			}
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to store response of idempotency key", "error", err)
+		}
+
+		if err := repo.DeleteIdempotencyKey(storeCtx, key.ID); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to release idempotency key", "error", err)
+		}
+
+		return response, handlerErr
+	}
+}
+
+// replayGRPCResponse returns the stored response of a key that is already used, unmarshalled into response.
+func replayGRPCResponse(ctx context.Context, repo repository.RepositoryInterface, key repository.IdempotencyKey, response proto.Message) (interface{}, error) {
+	stored, err := repo.GetIdempotencyKey(ctx, key.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// The first call failed and released the key in the meantime
+		return nil, grpcError(ctx, NewError(http.StatusConflict, generated.IdempotencyRequestInProgress))
+	}
+	if err != nil {
+		return nil, grpcError(ctx, repositoryError(err))
+	}
+
+	if stored.Fingerprint != key.Fingerprint {
+		return nil, grpcError(ctx, NewError(http.StatusUnprocessableEntity, generated.IdempotencyKeyReused))
+	}
+
+	if stored.StatusCode == 0 {
+		return nil, grpcError(ctx, NewError(http.StatusConflict, generated.IdempotencyRequestInProgress))
+	}
+
+	if err := proto.Unmarshal(stored.ResponseBody, response); err != nil {
+		return nil, grpcError(ctx, NewError(http.StatusInternalServerError, generated.InternalError))
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(metadataIdempotentReplayed, "true"))
+
+	return response, nil
+}
+
+// metadataValue returns the first value of the incoming metadata key, or an empty string.
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
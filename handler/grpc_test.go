@@ -0,0 +1,463 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/generated/userpb"
+	"github.com/UserService/i18n"
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+	"github.com/golang/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestNewGRPCServer(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v", err)
+	}
+
+	catalog, err := i18n.NewCatalog(i18n.DefaultLocale)
+	if err != nil {
+		t.Fatalf("i18n.NewCatalog() err = %v", err)
+	}
+
+	// Tokens are checked against the current time
+	now := time.Now().UTC().Truncate(time.Second)
+	session := repository.Session{
+		ID:          "f8065ac4677043303e57ebe063ac292f",
+		UserID:      123,
+		CreatedTime: now,
+		ExpiresTime: now.Add(utils.JWTExpiryDuration),
+	}
+	revokedSession := session
+	revokedSession.RevokedTime = &now
+
+	token, err := utils.NewToken(privateKey, newSessionClaims(session))
+	if err != nil {
+		t.Fatalf("utils.NewToken() err = %v", err)
+	}
+
+	expiredClaims := newSessionClaims(session)
+	expiredClaims.ExpiresAt = now.Add(-time.Minute).Unix()
+	expiredToken, err := utils.NewToken(privateKey, expiredClaims)
+	if err != nil {
+		t.Fatalf("utils.NewToken() err = %v", err)
+	}
+
+	fingerprintKey := []byte("fingerprint-key")
+	idempotencyKeyTTL := time.Hour // not the default, to check that the configured TTL is used
+
+	// Internal RPCs are authenticated with the internal token of the service
+	const internalToken = "internal-token"
+	internalMetadata := metadata.Pairs(metadataAuthorization, "Bearer "+internalToken)
+
+	createdTime := now.Add(-48 * time.Hour)
+	updatedTime := now.Add(-24 * time.Hour)
+	lastLoginTime := now.Add(-time.Hour)
+	user := repository.User{
+		ID:                   123,
+		FullName:             "User",
+		PhoneNumber:          "+628123456789",
+		Password:             "$2a$12$41bm0d9VyLDKALovox4S9.FoNezvO9tB8ck94/0fEyKcYIFmV8guq",
+		CreatedTime:          createdTime,
+		UpdatedTime:          &updatedTime,
+		SuccessfulLoginCount: 7,
+		LastLoginTime:        &lastLoginTime,
+		Version:              4,
+	}
+	userMessage := &userpb.User{
+		Id:                   123,
+		FullName:             "User",
+		PhoneNumber:          "+628123456789",
+		Version:              4,
+		SuccessfulLoginCount: 7,
+		LastLoginAt:          timestamppb.New(lastLoginTime),
+		CreatedAt:            timestamppb.New(createdTime),
+		UpdatedAt:            timestamppb.New(updatedTime),
+	}
+	updatedUserMessage := proto.Clone(userMessage).(*userpb.User)
+	updatedUserMessage.FullName = "New User"
+	updatedUserMessage.Version = 5
+	clearedUserMessage := proto.Clone(userMessage).(*userpb.User)
+	clearedUserMessage.FullName = ""
+	clearedUserMessage.Version = 5
+
+	createUserRequest := &userpb.CreateUserRequest{PhoneNumber: "+628123456789", FullName: "User", Password: "P455w0rd!."}
+	createUserBody, err := proto.MarshalOptions{Deterministic: true}.Marshal(createUserRequest)
+	if err != nil {
+		t.Fatalf("proto.Marshal() err = %v", err)
+	}
+	userMessageBody, err := proto.Marshal(userMessage)
+	if err != nil {
+		t.Fatalf("proto.Marshal() err = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		metadata       metadata.MD
+		call           func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error)
+		mockRepository func(mock *repository.MockRepositoryInterface)
+
+		wantResponse proto.Message
+		wantCode     codes.Code
+		wantReason   generated.ErrorCode
+	}{
+		{
+			name: "create-user",
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.CreateUser(ctx, createUserRequest)
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(int64(123), nil)
+			},
+			wantResponse: &userpb.User{Id: 123, FullName: "User", PhoneNumber: "+628123456789"},
+		},
+		{
+			name: "create-user-fail-validation",
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.CreateUser(ctx, &userpb.CreateUserRequest{PhoneNumber: "+658123456789", FullName: "User", Password: "P455w0rd!."})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.InvalidArgument,
+			wantReason:     generated.ValidationFailed,
+		},
+		{
+			name:     "create-user-replays-idempotent-response",
+			metadata: metadata.Pairs(metadataIdempotencyKey, "key"),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.CreateUser(ctx, createUserRequest)
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				key := repository.IdempotencyKey{
+					ID:          "key",
+					Fingerprint: idempotencyFingerprint(fingerprintKey, "RPC", userpb.UserService_CreateUser_FullMethodName, 0, createUserBody),
+					CreatedTime: now,
+					ExpiresTime: now.Add(idempotencyKeyTTL),
+				}
+				mock.EXPECT().ReserveIdempotencyKey(gomock.Any(), key).Return(false, nil)
+
+				key.StatusCode = 200
+				key.ResponseBody = userMessageBody
+				mock.EXPECT().GetIdempotencyKey(gomock.Any(), "key").Return(key, nil)
+			},
+			wantResponse: userMessage,
+		},
+		{
+			name: "create-session",
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.CreateSession(ctx, &userpb.CreateSessionRequest{PhoneNumber: "+628123456789", Password: "Password123!."})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{PhoneNumber: "+628123456789", IncludeInactive: true}).Return([]repository.User{user}, nil)
+				mock.EXPECT().IncrementSuccessfulLoginCount(gomock.Any(), int64(123), session.CreatedTime).Return(nil)
+				mock.EXPECT().InsertSession(gomock.Any(), session).Return(nil)
+			},
+			wantResponse: &userpb.CreateSessionResponse{
+				Session: &userpb.Session{
+					Id:        session.ID,
+					UserId:    123,
+					CreatedAt: timestamppb.New(session.CreatedTime),
+					ExpiresAt: timestamppb.New(session.ExpiresTime),
+				},
+				Token: token,
+			},
+		},
+		{
+			name:     "get-current-user",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetCurrentUser(ctx, &userpb.GetCurrentUserRequest{})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{user}, nil)
+			},
+			wantResponse: userMessage,
+		},
+		{
+			name: "get-current-user-fail-missing-token",
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetCurrentUser(ctx, &userpb.GetCurrentUserRequest{})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.Unauthenticated,
+			wantReason:     generated.MissingAuthorizationHeader,
+		},
+		{
+			name:     "get-current-user-fail-expired-token",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+expiredToken),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetCurrentUser(ctx, &userpb.GetCurrentUserRequest{})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.Unauthenticated,
+			wantReason:     generated.TokenExpired,
+		},
+		{
+			name:     "get-current-user-fail-revoked-session",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetCurrentUser(ctx, &userpb.GetCurrentUserRequest{})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(revokedSession, nil)
+			},
+			wantCode:   codes.Unauthenticated,
+			wantReason: generated.SessionRevoked,
+		},
+		{
+			name:     "get-user-fail-other-user-not-found",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetUser(ctx, &userpb.GetUserRequest{Id: 456})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+			},
+			wantCode:   codes.NotFound,
+			wantReason: generated.UserNotFound,
+		},
+		{
+			name:     "update-user",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.UpdateUser(ctx, &userpb.UpdateUserRequest{Id: 123, FullName: proto.String("New User")})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, FullName: "New User"}, "user:123").Return(nil)
+
+				updatedUser := user
+				updatedUser.FullName = "New User"
+				updatedUser.Version = 5
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{updatedUser}, nil)
+			},
+			wantResponse: updatedUserMessage,
+		},
+		{
+			name:     "update-user-clear-full-name",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.UpdateUser(ctx, &userpb.UpdateUserRequest{Id: 123, FullName: proto.String("")})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, ClearFullName: true}, "user:123").Return(nil)
+
+				updatedUser := user
+				updatedUser.FullName = ""
+				updatedUser.Version = 5
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{updatedUser}, nil)
+			},
+			wantResponse: clearedUserMessage,
+		},
+		{
+			name:     "update-user-version",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.UpdateUser(ctx, &userpb.UpdateUserRequest{Id: 123, FullName: proto.String("New User"), Version: 4})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, FullName: "New User", Version: 4}, "user:123").Return(nil)
+
+				updatedUser := user
+				updatedUser.FullName = "New User"
+				updatedUser.Version = 5
+				mock.EXPECT().GetUsers(gomock.Any(), repository.UserFilter{UserID: 123}).Return([]repository.User{updatedUser}, nil)
+			},
+			wantResponse: updatedUserMessage,
+		},
+		{
+			name:     "update-user-fail-version-mismatch",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.UpdateUser(ctx, &userpb.UpdateUserRequest{Id: 123, FullName: proto.String("New User"), Version: 3})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+				mock.EXPECT().UpdateUser(gomock.Any(), repository.User{ID: 123, FullName: "New User", Version: 3}, "user:123").Return(repository.ErrVersionMismatch)
+			},
+			wantCode:   codes.FailedPrecondition,
+			wantReason: generated.PreconditionFailed,
+		},
+		{
+			name:     "delete-session",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.DeleteSession(ctx, &userpb.DeleteSessionRequest{Id: session.ID})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil).Times(2)
+				mock.EXPECT().RevokeSession(gomock.Any(), session.ID).Return(nil)
+			},
+			wantResponse: &userpb.DeleteSessionResponse{},
+		},
+		{
+			name:     "get-users-by-ids",
+			metadata: internalMetadata,
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetUsersByIDs(ctx, &userpb.GetUsersByIDsRequest{Ids: []int64{123, 456}})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetUsersByIDs(gomock.Any(), []int64{123, 456}).Return([]repository.User{user}, nil)
+			},
+			wantResponse: &userpb.GetUsersByIDsResponse{Users: []*userpb.User{userMessage}},
+		},
+		{
+			name: "get-users-by-ids-fail-missing-internal-token",
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetUsersByIDs(ctx, &userpb.GetUsersByIDsRequest{Ids: []int64{123, 456}})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.Unauthenticated,
+			wantReason:     generated.MissingAuthorizationHeader,
+		},
+		{
+			name:     "get-users-by-ids-fail-user-token",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer "+token),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetUsersByIDs(ctx, &userpb.GetUsersByIDsRequest{Ids: []int64{123, 456}})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.Unauthenticated,
+			wantReason:     generated.InvalidToken,
+		},
+		{
+			name:     "get-users-by-ids-fail-too-many-ids",
+			metadata: internalMetadata,
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.GetUsersByIDs(ctx, &userpb.GetUsersByIDsRequest{Ids: make([]int64, getUsersByIDsMaxLength+1)})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.InvalidArgument,
+			wantReason:     generated.ValidationFailed,
+		},
+		{
+			name:     "validate-token",
+			metadata: internalMetadata,
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.ValidateToken(ctx, &userpb.ValidateTokenRequest{Token: token})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), session.ID).Return(session, nil)
+			},
+			wantResponse: &userpb.ValidateTokenResponse{
+				UserId:      123,
+				SessionId:   session.ID,
+				Permissions: []string{string(utils.JWTPermissionGetUser), string(utils.JWTPermissionUpdateUser)},
+				ExpiresAt:   timestamppb.New(session.ExpiresTime),
+			},
+		},
+		{
+			name: "validate-token-fail-missing-internal-token",
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.ValidateToken(ctx, &userpb.ValidateTokenRequest{Token: token})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.Unauthenticated,
+			wantReason:     generated.MissingAuthorizationHeader,
+		},
+		{
+			name:     "validate-token-fail-invalid-internal-token",
+			metadata: metadata.Pairs(metadataAuthorization, "Bearer internal"),
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.ValidateToken(ctx, &userpb.ValidateTokenRequest{Token: token})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.Unauthenticated,
+			wantReason:     generated.InvalidToken,
+		},
+		{
+			name:     "validate-token-fail-invalid-token",
+			metadata: internalMetadata,
+			call: func(ctx context.Context, client userpb.UserServiceClient) (proto.Message, error) {
+				return client.ValidateToken(ctx, &userpb.ValidateTokenRequest{Token: "token"})
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantCode:       codes.Unauthenticated,
+			wantReason:     generated.InvalidToken,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			test.mockRepository(mock)
+
+			fnNewSessionID = func() (string, error) { return session.ID, nil }
+			fnTimeNow = func() time.Time { return now }
+			defer func() {
+				fnNewSessionID = newSessionID
+				fnTimeNow = time.Now
+			}()
+
+			server := NewGRPCServer(NewGRPCServerOptions{
+				Server:                    NewServer(NewServerOptions{Repository: mock}),
+				Catalog:                   catalog,
+				PublicKey:                 &privateKey.PublicKey,
+				PrivateKey:                privateKey,
+				InternalToken:             internalToken,
+				IdempotencyFingerprintKey: fingerprintKey,
+				IdempotencyKeyTTL:         idempotencyKeyTTL,
+			})
+			listener := bufconn.Listen(1024 * 1024)
+			go server.Serve(listener)
+			defer server.Stop()
+
+			conn, err := grpc.Dial("bufnet",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+			if err != nil {
+				t.Fatalf("grpc.Dial() err = %v", err)
+			}
+			defer conn.Close()
+
+			ctx := metadata.NewOutgoingContext(context.Background(), test.metadata)
+			gotResponse, gotErr := test.call(ctx, userpb.NewUserServiceClient(conn))
+
+			st := status.Convert(gotErr)
+			if st.Code() != test.wantCode {
+				t.Fatalf("handler.NewGRPCServer() code = %v, wantCode %v (%v)", st.Code(), test.wantCode, st.Message())
+			}
+
+			if gotErr != nil {
+				if gotReason := errorReason(st); gotReason != string(test.wantReason) {
+					t.Errorf("handler.NewGRPCServer() reason = %v, wantReason %v", gotReason, test.wantReason)
+				}
+				return
+			}
+
+			if !proto.Equal(gotResponse, test.wantResponse) {
+				t.Errorf("handler.NewGRPCServer() response = %v, wantResponse %v", gotResponse, test.wantResponse)
+			}
+		})
+	}
+}
+
+func errorReason(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/logging"
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+	idempotencyKeyMaxLength  = 255
+	idempotencyStoreTimeout  = 5 * time.Second
+)
+
+// replayedHeaders are the response headers stored along with the response body and replayed to retries
+var replayedHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, "Content-Language"}
+
+type NewIdempotencyMiddlewareOptions struct {
+	Repository repository.RepositoryInterface
+
+	// TTL is how long responses are replayed for, defaults to 24 hours
+	TTL time.Duration
+
+	// FingerprintKey keys the fingerprints of requests, see utils.DeriveKey, so the stored fingerprints cannot be
+	// matched against guessed request bodies, i.e. to find passwords. Keys are ignored when it is empty.
+	FingerprintKey []byte
+}
+
+// NewIdempotencyMiddleware returns a middleware that makes POST requests sent with an Idempotency-Key header safe to
+// retry. The response of the first request is stored and replayed to retries with the same key and request, so a
+// client that lost the response of a registration gets the original 201 instead of a 409. Reusing a key for a
+// different request is rejected with 422, and retries sent while the first request is still running with 409.
+//
+// Server errors are not stored so the request can be retried. Neither are responses carrying an Authorization
+// header, so tokens are never persisted; retrying a login simply logs in again.
+//
+// Requests are identified by their route, requester and body, stored as an HMAC so the passwords in the bodies of
+// registrations and logins cannot be brute-forced from the stored fingerprints. Routes and requesters are only known
+// after routing and authentication, so register the middleware with echo.Use, after AuthenticationMiddleware in
+// cmd/main.go.
+func NewIdempotencyMiddleware(opts NewIdempotencyMiddlewareOptions) echo.MiddlewareFunc {
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			keyID := ctx.Request().Header.Get(headerIdempotencyKey)
+			if ctx.Request().Method != http.MethodPost || keyID == "" || len(opts.FingerprintKey) == 0 {
+				return next(ctx)
+			}
+
+			if len(keyID) > idempotencyKeyMaxLength {
+				return WriteError(ctx, newValidationError([]FieldError{newSpecFieldError(headerIdempotencyKey, generated.InvalidField)}))
+			}
+
+			fingerprint, err := requestFingerprint(ctx, opts.FingerprintKey)
+			if err != nil {
+				return WriteError(ctx, NewError(http.StatusBadRequest, generated.InvalidRequestBody))
+			}
+
+			now := fnTimeNow()
+			key := repository.IdempotencyKey{
+				ID:          keyID,
+				Fingerprint: fingerprint,
+				CreatedTime: now,
+				ExpiresTime: now.Add(ttl),
+			}
+
+			reserved, err := opts.Repository.ReserveIdempotencyKey(ctx.Request().Context(), key)
+			if err != nil {
+				return WriteError(ctx, repositoryError(err))
+			}
+
+			if !reserved {
+				return replayResponse(ctx, opts.Repository, key)
+			}
+
+			return recordResponse(ctx, next, opts.Repository, key)
+		}
+	}
+}
+
+// requestFingerprint hashes what identifies a request: its route, requester and body.
+func requestFingerprint(ctx echo.Context, key []byte) (string, error) {
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return "", err
+	}
+	ctx.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+	userID, _ := ctx.Get(string(utils.JWTClaimUserID)).(int64)
+
+	return idempotencyFingerprint(key, ctx.Request().Method, ctx.Path(), userID, body), nil
+}
+
+// idempotencyFingerprint returns the HMAC with key of the parts of a request, separated so they cannot run into each
+// other. The body holds the password of registrations and logins, which a plain hash would expose to brute force.
+func idempotencyFingerprint(key []byte, method, route string, userID int64, body []byte) string {
+	hash := hmac.New(sha256.New, key)
+	for _, part := range []string{method, route, strconv.FormatInt(userID, 10)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replayResponse writes the stored response of a key that is already used.
+func replayResponse(ctx echo.Context, repo repository.RepositoryInterface, key repository.IdempotencyKey) error {
+	stored, err := repo.GetIdempotencyKey(ctx.Request().Context(), key.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// The first request failed and released the key in the meantime
+		return WriteError(ctx, NewError(http.StatusConflict, generated.IdempotencyRequestInProgress))
+	}
+	if err != nil {
+		return WriteError(ctx, repositoryError(err))
+	}
+
+	if stored.Fingerprint != key.Fingerprint {
+		return WriteError(ctx, NewError(http.StatusUnprocessableEntity, generated.IdempotencyKeyReused))
+	}
+
+	if stored.StatusCode == 0 {
+		return WriteError(ctx, NewError(http.StatusConflict, generated.IdempotencyRequestInProgress))
+	}
+
+	for name, values := range stored.ResponseHeader {
+		for _, value := range values {
+			ctx.Response().Header().Add(name, value)
+		}
+	}
+	ctx.Response().Header().Set(headerIdempotentReplayed, "true")
+
+	ctx.Response().WriteHeader(stored.StatusCode)
+	_, err = ctx.Response().Write(stored.ResponseBody)
+	return err
+}
+
+// recordResponse runs the request and stores its response for the reserved key.
+func recordResponse(ctx echo.Context, next echo.HandlerFunc, repo repository.RepositoryInterface, key repository.IdempotencyKey) error {
+	response := ctx.Response()
+	recorder := &recordingResponseWriter{ResponseWriter: response.Writer}
+	response.Writer = recorder
+	err := next(ctx)
+	response.Writer = recorder.ResponseWriter
+
+	// The response is already sent, store it even if the request has been cancelled in the meantime. The context
+	// keeps the values of the request, so the queries are still traced and logged with it
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx.Request().Context()), idempotencyStoreTimeout)
+	defer cancel()
+
+	// Errors returned by the handler are written by echo's HTTPErrorHandler after this middleware, treat them as
+	// server errors
+	if err != nil || !response.Committed || response.Status >= http.StatusInternalServerError ||
+		response.Header().Get(echo.HeaderAuthorization) != "" {
+		if releaseErr := repo.DeleteIdempotencyKey(storeCtx, key.ID); releaseErr != nil {
+			logging.FromContext(ctx.Request().Context()).Error("failed to release idempotency key", "error", releaseErr)
+		}
+		return err
+	}
+
+	key.StatusCode = response.Status
+	key.ResponseBody = recorder.body.Bytes()
+	key.ResponseHeader = map[string][]string{}
+	for _, name := range replayedHeaders {
+		if values := response.Header().Values(name); len(values) > 0 {
+			key.ResponseHeader[name] = values
+		}
+	}
+
+	if completeErr := repo.CompleteIdempotencyKey(storeCtx, key); completeErr != nil {
+		logging.FromContext(ctx.Request().Context()).Error("failed to store response of idempotency key", "error", completeErr)
+		// Do not leave the key in progress until it expires
+		if releaseErr := repo.DeleteIdempotencyKey(storeCtx, key.ID); releaseErr != nil {
+			logging.FromContext(ctx.Request().Context()).Error("failed to release idempotency key", "error", releaseErr)
+		}
+	}
+
+	return nil
+}
+
+// recordingResponseWriter keeps a copy of the response body while it is written to the client.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestNewIdempotencyMiddleware(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	requestBody := `{"phone_number":"+628123456789","full_name":"User","password":"P455w0rd!."}`
+	responseBody := `{"header":{"messages":["request successful"],"success":true},"user":{"id":123}}` + "\n"
+
+	// Fingerprint of an unauthenticated POST /v1/user with requestBody
+	fingerprintKey := []byte("fingerprint-key")
+	mac := hmac.New(sha256.New, fingerprintKey)
+	mac.Write([]byte("POST\x00/v1/user\x000\x00" + requestBody))
+	fingerprint := hex.EncodeToString(mac.Sum(nil))
+
+	reservedKey := repository.IdempotencyKey{
+		ID:          "key",
+		Fingerprint: fingerprint,
+		CreatedTime: now,
+		ExpiresTime: now.Add(24 * time.Hour),
+	}
+
+	completedKey := reservedKey
+	completedKey.StatusCode = http.StatusCreated
+	completedKey.ResponseHeader = map[string][]string{
+		echo.HeaderContentType: {echo.MIMEApplicationJSONCharsetUTF8},
+		echo.HeaderLocation:    {"/v2/users/123"},
+	}
+	completedKey.ResponseBody = []byte(responseBody)
+
+	created := func(ctx echo.Context) error {
+		ctx.Response().Header().Set(echo.HeaderLocation, "/v2/users/123")
+		return ctx.JSONBlob(http.StatusCreated, []byte(responseBody))
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		idempotencyKey string
+		handler        echo.HandlerFunc
+		mockRepository func(mock *repository.MockRepositoryInterface)
+
+		wantHttpStatusCode int
+		wantReplayed       bool
+		wantBody           string
+	}{
+		{
+			name:               "request-without-key-is-not-stored",
+			method:             http.MethodPost,
+			handler:            created,
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusCreated,
+			wantBody:           responseBody,
+		},
+		{
+			name:           "non-post-request-is-not-stored",
+			method:         http.MethodPut,
+			idempotencyKey: "key",
+			handler: func(ctx echo.Context) error {
+				return ctx.JSONBlob(http.StatusOK, []byte(responseBody))
+			},
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusOK,
+			wantBody:           responseBody,
+		},
+		{
+			name:           "first-request-stores-response",
+			method:         http.MethodPost,
+			idempotencyKey: "key",
+			handler:        created,
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().ReserveIdempotencyKey(gomock.Any(), reservedKey).Return(true, nil)
+				mock.EXPECT().CompleteIdempotencyKey(gomock.Any(), completedKey).Return(nil)
+			},
+			wantHttpStatusCode: http.StatusCreated,
+			wantBody:           responseBody,
+		},
+		{
+			name:           "retry-replays-stored-response",
+			method:         http.MethodPost,
+			idempotencyKey: "key",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().ReserveIdempotencyKey(gomock.Any(), reservedKey).Return(false, nil)
+				mock.EXPECT().GetIdempotencyKey(gomock.Any(), "key").Return(completedKey, nil)
+			},
+			wantHttpStatusCode: http.StatusCreated,
+			wantReplayed:       true,
+			wantBody:           responseBody,
+		},
+		{
+			name:           "fail-key-reused-for-different-request",
+			method:         http.MethodPost,
+			idempotencyKey: "key",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				otherKey := completedKey
+				otherKey.Fingerprint = "other"
+				mock.EXPECT().ReserveIdempotencyKey(gomock.Any(), reservedKey).Return(false, nil)
+				mock.EXPECT().GetIdempotencyKey(gomock.Any(), "key").Return(otherKey, nil)
+			},
+			wantHttpStatusCode: http.StatusUnprocessableEntity,
+			wantBody:           `{"header":{"messages":["Idempotency-Key was already used for a different request"],"success":false}}` + "\n",
+		},
+		{
+			name:           "fail-request-in-progress",
+			method:         http.MethodPost,
+			idempotencyKey: "key",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().ReserveIdempotencyKey(gomock.Any(), reservedKey).Return(false, nil)
+				mock.EXPECT().GetIdempotencyKey(gomock.Any(), "key").Return(reservedKey, nil)
+			},
+			wantHttpStatusCode: http.StatusConflict,
+			wantBody:           `{"header":{"messages":["a request with this Idempotency-Key is still in progress, retry later"],"success":false}}` + "\n",
+		},
+		{
+			name:               "fail-key-too-long",
+			method:             http.MethodPost,
+			idempotencyKey:     strings.Repeat("k", 256),
+			mockRepository:     func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["Idempotency-Key has an invalid value"],"success":false}}` + "\n",
+		},
+		{
+			name:           "fail-repository-unavailable",
+			method:         http.MethodPost,
+			idempotencyKey: "key",
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().ReserveIdempotencyKey(gomock.Any(), reservedKey).Return(false, repository.ErrUnavailable)
+			},
+			wantHttpStatusCode: http.StatusServiceUnavailable,
+			wantBody:           `{"header":{"messages":["service is temporarily unavailable, please try again later"],"success":false}}` + "\n",
+		},
+		{
+			name:           "server-error-releases-key",
+			method:         http.MethodPost,
+			idempotencyKey: "key",
+			handler: func(ctx echo.Context) error {
+				return WriteError(ctx, NewError(http.StatusServiceUnavailable, generated.ServiceUnavailable))
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().ReserveIdempotencyKey(gomock.Any(), reservedKey).Return(true, nil)
+				mock.EXPECT().DeleteIdempotencyKey(gomock.Any(), "key").Return(nil)
+			},
+			wantHttpStatusCode: http.StatusServiceUnavailable,
+			wantBody:           `{"header":{"messages":["service is temporarily unavailable, please try again later"],"success":false}}` + "\n",
+		},
+		{
+			name:           "response-with-token-releases-key",
+			method:         http.MethodPost,
+			idempotencyKey: "key",
+			handler: func(ctx echo.Context) error {
+				ctx.Response().Header().Set(echo.HeaderAuthorization, "Bearer token")
+				return ctx.JSONBlob(http.StatusOK, []byte(responseBody))
+			},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().ReserveIdempotencyKey(gomock.Any(), reservedKey).Return(true, nil)
+				mock.EXPECT().DeleteIdempotencyKey(gomock.Any(), "key").Return(nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantBody:           responseBody,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			test.mockRepository(mock)
+
+			fnTimeNow = func() time.Time { return now }
+			defer func() { fnTimeNow = time.Now }()
+
+			e := echo.New()
+			e.Logger.SetOutput(io.Discard)
+			e.Use(NewIdempotencyMiddleware(NewIdempotencyMiddlewareOptions{Repository: mock, FingerprintKey: fingerprintKey}))
+			e.Add(test.method, "/v1/user", func(ctx echo.Context) error {
+				if test.handler == nil {
+					t.Fatalf("handler should not be called for a replayed or rejected request")
+				}
+
+				// The handler still reads the whole body
+				if body, _ := io.ReadAll(ctx.Request().Body); string(body) != requestBody {
+					t.Errorf("handler.NewIdempotencyMiddleware() request body = %s, want %s", body, requestBody)
+				}
+				return test.handler(ctx)
+			})
+
+			request := httptest.NewRequest(test.method, "/v1/user", strings.NewReader(requestBody))
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if test.idempotencyKey != "" {
+				request.Header.Set(headerIdempotencyKey, test.idempotencyKey)
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantHttpStatusCode {
+				t.Errorf("handler.NewIdempotencyMiddleware() httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, test.wantHttpStatusCode)
+			}
+
+			if gotReplayed := recorder.Header().Get(headerIdempotentReplayed) == "true"; gotReplayed != test.wantReplayed {
+				t.Errorf("handler.NewIdempotencyMiddleware() replayed = %v, wantReplayed %v", gotReplayed, test.wantReplayed)
+			}
+
+			if gotBody := recorder.Body.String(); gotBody != test.wantBody {
+				t.Errorf("handler.NewIdempotencyMiddleware() body = %v, wantBody %v", gotBody, test.wantBody)
+			}
+		})
+	}
+}
+
+func TestIdempotencyFingerprint(t *testing.T) {
+	body := []byte(`{"phone_number":"+628123456789","password":"P455w0rd!."}`)
+	fingerprint := idempotencyFingerprint([]byte("fingerprint-key"), http.MethodPost, "/v1/user/login", 0, body)
+
+	// Without the key, the stored fingerprint cannot be rebuilt from a guessed body to find the password
+	hash := sha256.Sum256([]byte("POST\x00/v1/user/login\x000\x00" + string(body)))
+	if fingerprint == hex.EncodeToString(hash[:]) {
+		t.Errorf("handler.idempotencyFingerprint() = %v, the hash of the request without the key", fingerprint)
+	}
+	if otherKey := idempotencyFingerprint([]byte("other-key"), http.MethodPost, "/v1/user/login", 0, body); fingerprint == otherKey {
+		t.Errorf("handler.idempotencyFingerprint() = %v with another key", otherKey)
+	}
+
+	if again := idempotencyFingerprint([]byte("fingerprint-key"), http.MethodPost, "/v1/user/login", 0, body); fingerprint != again {
+		t.Errorf("handler.idempotencyFingerprint() = %v, want %v for the same request", again, fingerprint)
+	}
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/logging"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// prefixInvalidContentType is the reason openapi3filter reports for a request body whose Content-Type is not in the spec
+	prefixInvalidContentType = "header Content-Type has unexpected value"
+
+	mimeApplicationMergePatchJSON = "application/merge-patch+json"
+)
+
+var (
+	// propertyPattern extracts the property name from openapi3 errors that do not carry it in their JSON pointer
+	propertyPattern = regexp.MustCompile(`property "([^"]+)"`)
+
+	// echoPathParamPattern matches echo path parameters, i.e. `:id`
+	echoPathParamPattern = regexp.MustCompile(`:([^/]+)`)
+)
+
+type NewOpenAPIValidatorOptions struct {
+	Swagger *openapi3.T
+
+	// ValidateResponses also validates outgoing responses against the spec so drift between the handlers and
+	// api.yml is caught early. Responses are buffered to do so, hence it is meant for development and tests only.
+	ValidateResponses bool
+}
+
+// NewOpenAPIValidator returns a middleware that validates requests against the spec before they reach the handler.
+// Invalid requests are rejected with a 400 validation error listing every rejected field, or a 415 error when the
+// body is not sent with a content type documented in the spec. Routes that are not in the spec are not validated.
+//
+// The middleware needs the matched route, so register it with echo.Use rather than echo.Pre.
+func NewOpenAPIValidator(opts NewOpenAPIValidatorOptions) echo.MiddlewareFunc {
+	// Merge patches are JSON documents, openapi3filter only decodes the content types it knows
+	openapi3filter.RegisterBodyDecoder(mimeApplicationMergePatchJSON, openapi3filter.RegisteredBodyDecoder(echo.MIMEApplicationJSON))
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			route := findRoute(opts.Swagger, ctx)
+			if route == nil {
+				return next(ctx)
+			}
+
+			pathParams := make(map[string]string, len(ctx.ParamNames()))
+			for i, name := range ctx.ParamNames() {
+				pathParams[name] = ctx.ParamValues()[i]
+			}
+
+			requestInput := &openapi3filter.RequestValidationInput{
+				Request:    ctx.Request(),
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					MultiError: true,
+					// authentication is handled by AuthenticationMiddleware
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				},
+			}
+
+			if err := openapi3filter.ValidateRequest(ctx.Request().Context(), requestInput); err != nil {
+				return WriteError(ctx, requestValidationError(err))
+			}
+
+			if !opts.ValidateResponses {
+				return next(ctx)
+			}
+
+			return validateResponse(ctx, next, requestInput)
+		}
+	}
+}
+
+// findRoute returns the operation of the spec that matches the route echo selected for the request
+func findRoute(swagger *openapi3.T, ctx echo.Context) *routers.Route {
+	path := echoPathParamPattern.ReplaceAllString(ctx.Path(), "{$1}")
+
+	pathItem := swagger.Paths.Find(path)
+	if pathItem == nil {
+		return nil
+	}
+
+	method := ctx.Request().Method
+	operation := pathItem.GetOperation(method)
+	if operation == nil {
+		return nil
+	}
+
+	return &routers.Route{
+		Spec:      swagger,
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    method,
+		Operation: operation,
+	}
+}
+
+// validateResponse buffers the response written by next and only sends it to the client if it matches the spec.
+// Otherwise the client receives a 500 so the drift does not go unnoticed.
+func validateResponse(ctx echo.Context, next echo.HandlerFunc, requestInput *openapi3filter.RequestValidationInput) error {
+	response := ctx.Response()
+	writer := response.Writer
+	header := writer.Header().Clone()
+
+	buffer := &bufferedResponseWriter{header: writer.Header(), status: http.StatusOK}
+	response.Writer = buffer
+	err := next(ctx)
+	response.Writer = writer
+
+	// Errors returned by the handler are written by echo's HTTPErrorHandler, which is outside of the spec
+	if err == nil && response.Committed {
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 buffer.status,
+			Header:                 buffer.header,
+			Options: &openapi3filter.Options{
+				MultiError:            true,
+				IncludeResponseStatus: true,
+			},
+		}
+		responseInput.SetBodyBytes(buffer.body.Bytes())
+
+		if validationErr := openapi3filter.ValidateResponse(ctx.Request().Context(), responseInput); validationErr != nil {
+			logging.FromContext(ctx.Request().Context()).Error("response does not match the spec", "error", validationErr)
+
+			// Discard the invalid response, including headers set by the handler and other middlewares
+			for key := range writer.Header() {
+				delete(writer.Header(), key)
+			}
+			for key, values := range header {
+				writer.Header()[key] = values
+			}
+			ctx.SetResponse(echo.NewResponse(writer, ctx.Echo()))
+
+			return WriteError(ctx, NewError(http.StatusInternalServerError, generated.ResponseValidationFailed))
+		}
+	}
+
+	if buffer.written {
+		writer.WriteHeader(buffer.status)
+		if _, writeErr := writer.Write(buffer.body.Bytes()); writeErr != nil {
+			return writeErr
+		}
+	}
+
+	return err
+}
+
+// unsupportedMediaTypeError returns the Error of a request body that is not of a content type of the operation, i.e.
+// application/merge-patch+json for PATCH /v1/user.
+func unsupportedMediaTypeError(requestBody *openapi3.RequestBody) *Error {
+	contentType := echo.MIMEApplicationJSON
+	if requestBody != nil && len(requestBody.Content) > 0 {
+		contentTypes := make([]string, 0, len(requestBody.Content))
+		for contentType := range requestBody.Content {
+			contentTypes = append(contentTypes, contentType)
+		}
+		sort.Strings(contentTypes)
+		contentType = contentTypes[0]
+	}
+
+	err := NewError(http.StatusUnsupportedMediaType, generated.UnsupportedMediaType)
+	err.Params = map[string]interface{}{"content_type": contentType}
+	return err
+}
+
+// requestValidationError maps the errors reported by openapi3filter.ValidateRequest to an Error.
+func requestValidationError(err error) *Error {
+	var fieldErrors []FieldError
+
+	for _, err := range flattenErrors(err) {
+		var requestErr *openapi3filter.RequestError
+		if !errors.As(err, &requestErr) {
+			return NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+		}
+
+		switch {
+		case requestErr.Parameter != nil:
+			code := generated.InvalidField
+			if errors.Is(requestErr.Err, openapi3filter.ErrInvalidRequired) {
+				code = generated.RequiredFieldMissing
+			}
+			fieldErrors = append(fieldErrors, newSpecFieldError(requestErr.Parameter.Name, code))
+		case strings.HasPrefix(requestErr.Reason, prefixInvalidContentType):
+			return unsupportedMediaTypeError(requestErr.RequestBody)
+		case requestErr.Err == nil, errors.Is(requestErr.Err, openapi3filter.ErrInvalidRequired):
+			return NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+		default:
+			var parseErr *openapi3filter.ParseError
+			if errors.As(requestErr.Err, &parseErr) {
+				return NewError(http.StatusBadRequest, generated.InvalidRequestBody)
+			}
+
+			for _, err := range flattenErrors(requestErr.Err) {
+				fieldErrors = append(fieldErrors, schemaFieldError(err))
+			}
+		}
+	}
+
+	// openapi3 reports object properties in map order
+	sort.SliceStable(fieldErrors, func(i, j int) bool {
+		return fieldErrors[i].Field < fieldErrors[j].Field
+	})
+
+	return newValidationError(fieldErrors)
+}
+
+// schemaFieldError maps an error reported while validating the request body against its schema to a FieldError.
+func schemaFieldError(err error) FieldError {
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		// readOnly violations are reported as plain errors, i.e. `readOnly property "id" in request`
+		if match := propertyPattern.FindStringSubmatch(err.Error()); match != nil && strings.HasPrefix(err.Error(), "readOnly") {
+			return newSpecFieldError(match[1], generated.ReadOnlyField)
+		}
+		return newSpecFieldError("", generated.InvalidField)
+	}
+
+	path := schemaErr.JSONPointer()
+
+	switch schemaErr.SchemaField {
+	case "properties":
+		// Reported on the object for `additionalProperties: false`, the property is only named in the reason
+		if match := propertyPattern.FindStringSubmatch(schemaErr.Reason); match != nil {
+			return newSpecFieldError(strings.Join(append(path, match[1]), "."), generated.UnknownField)
+		}
+	case "required":
+		return newSpecFieldError(strings.Join(path, "."), generated.RequiredFieldMissing)
+	case "type":
+		fieldError := newSpecFieldError(strings.Join(path, "."), generated.InvalidFieldType)
+		fieldError.Params["type"] = schemaErr.Schema.Type
+		return fieldError
+	}
+
+	return newSpecFieldError(strings.Join(path, "."), generated.InvalidField)
+}
+
+func newSpecFieldError(field string, code generated.ErrorCode) FieldError {
+	if field == "" {
+		field = "body"
+	}
+
+	return FieldError{
+		Field:  field,
+		Code:   code,
+		Params: map[string]interface{}{"field": field},
+	}
+}
+
+// flattenErrors returns the errors contained in (nested) openapi3.MultiError, or err itself.
+func flattenErrors(err error) []error {
+	// not errors.As, which would also unwrap the RequestError around a MultiError of schema errors
+	multiErr, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, err := range multiErr {
+		errs = append(errs, flattenErrors(err)...)
+	}
+
+	return errs
+}
+
+// bufferedResponseWriter holds the response in memory until it has been validated. The header is shared with
+// the original writer so middlewares setting headers, i.e. in Response.Before hooks, keep working.
+type bufferedResponseWriter struct {
+	header  http.Header
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.written = true
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
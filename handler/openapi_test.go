@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UserService/generated"
+	"github.com/labstack/echo/v4"
+)
+
+func TestNewOpenAPIValidator(t *testing.T) {
+	swagger, err := generated.GetSwagger()
+	if err != nil {
+		t.Fatalf("generated.GetSwagger() err = %v", err)
+	}
+
+	validResponse := func(ctx echo.Context) error {
+		return ctx.JSON(http.StatusCreated, map[string]interface{}{
+			"header": map[string]interface{}{"success": true, "messages": []string{"request successful"}},
+			"user":   map[string]interface{}{"id": 1},
+		})
+	}
+
+	tests := []struct {
+		name              string
+		validateResponses bool
+		method            string // POST when empty
+		path              string
+		contentType       string
+		requestBody       string
+		handler           echo.HandlerFunc
+
+		wantHttpStatusCode int
+		wantBody           string
+	}{
+		{
+			name:               "success",
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `{"phone_number":"+628123456789","full_name":"User","password":"P455w0rd!."}`,
+			handler:            validResponse,
+			wantHttpStatusCode: http.StatusCreated,
+			wantBody:           `{"header":{"messages":["request successful"],"success":true},"user":{"id":1}}` + "\n",
+		},
+		{
+			name:               "route-not-in-spec-is-not-validated",
+			path:               "/internal",
+			contentType:        echo.MIMETextPlain,
+			requestBody:        `not json`,
+			handler:            validResponse,
+			wantHttpStatusCode: http.StatusCreated,
+			wantBody:           `{"header":{"messages":["request successful"],"success":true},"user":{"id":1}}` + "\n",
+		},
+		{
+			name:               "fail-unknown-and-read-only-fields",
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `{"id":1,"phone_number":"+628123456789","email":"user@example.com","nickname":"user"}`,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["email is not a known field","id is read-only and cannot be set","nickname is not a known field"],"success":false}}` + "\n",
+		},
+		{
+			name:               "fail-replace-login-statistics",
+			method:             http.MethodPut,
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `{"phone_number":"+628123456789","full_name":"User","successful_login_count":0,"last_login_at":"2024-01-01T00:00:00Z"}`,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["last_login_at is not a known field","successful_login_count is not a known field"],"success":false}}` + "\n",
+		},
+		{
+			name:               "fail-register-login-statistics",
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `{"phone_number":"+628123456789","full_name":"User","password":"P455w0rd!.","successful_login_count":0}`,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["successful_login_count is read-only and cannot be set"],"success":false}}` + "\n",
+		},
+		{
+			name:               "fail-invalid-field-type",
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `{"phone_number":628123456789,"full_name":"User"}`,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["phone_number should be of type string"],"success":false}}` + "\n",
+		},
+		{
+			name:               "fail-body-is-not-an-object",
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `["+628123456789"]`,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["body should be of type object"],"success":false}}` + "\n",
+		},
+		{
+			name:               "fail-malformed-json",
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `{"phone_number":`,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["request body is not valid JSON"],"success":false}}` + "\n",
+		},
+		{
+			name:               "fail-missing-body",
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantBody:           `{"header":{"messages":["request body is not valid JSON"],"success":false}}` + "\n",
+		},
+		{
+			name:               "fail-unsupported-media-type",
+			path:               "/v1/user",
+			contentType:        echo.MIMETextPlain,
+			requestBody:        `{"phone_number":"+628123456789"}`,
+			wantHttpStatusCode: http.StatusUnsupportedMediaType,
+			wantBody:           `{"header":{"messages":["request body should be application/json"],"success":false}}` + "\n",
+		},
+		{
+			name:               "success-merge-patch",
+			method:             http.MethodPatch,
+			path:               "/v1/user",
+			contentType:        mimeApplicationMergePatchJSON,
+			requestBody:        `{"phone_number":null,"full_name":"User"}`,
+			handler:            validResponse,
+			wantHttpStatusCode: http.StatusCreated,
+			wantBody:           `{"header":{"messages":["request successful"],"success":true},"user":{"id":1}}` + "\n",
+		},
+		{
+			name:               "fail-merge-patch-unsupported-media-type",
+			method:             http.MethodPatch,
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `{"full_name":"User"}`,
+			wantHttpStatusCode: http.StatusUnsupportedMediaType,
+			wantBody:           `{"header":{"messages":["request body should be application/merge-patch+json"],"success":false}}` + "\n",
+		},
+		{
+			name:               "response-matches-spec",
+			validateResponses:  true,
+			path:               "/v1/user",
+			contentType:        echo.MIMEApplicationJSON,
+			requestBody:        `{"phone_number":"+628123456789","full_name":"User","password":"P455w0rd!."}`,
+			handler:            validResponse,
+			wantHttpStatusCode: http.StatusCreated,
+			wantBody:           `{"header":{"messages":["request successful"],"success":true},"user":{"id":1}}` + "\n",
+		},
+		{
+			name:              "fail-response-does-not-match-spec",
+			validateResponses: true,
+			path:              "/v1/user",
+			contentType:       echo.MIMEApplicationJSON,
+			requestBody:       `{"phone_number":"+628123456789","full_name":"User","password":"P455w0rd!."}`,
+			handler: func(ctx echo.Context) error {
+				return ctx.JSON(http.StatusCreated, map[string]interface{}{
+					"header": map[string]interface{}{"success": true, "messages": []string{"request successful"}},
+					"user":   map[string]interface{}{"id": 1, "password": "$2a$12$hash", "created_at": "2024-01-01"},
+				})
+			},
+			wantHttpStatusCode: http.StatusInternalServerError,
+			wantBody:           `{"header":{"messages":["response does not match the API specification"],"success":false}}` + "\n",
+		},
+		{
+			name:              "fail-response-status-not-in-spec",
+			validateResponses: true,
+			path:              "/v1/user",
+			contentType:       echo.MIMEApplicationJSON,
+			requestBody:       `{"phone_number":"+628123456789","full_name":"User","password":"P455w0rd!."}`,
+			handler: func(ctx echo.Context) error {
+				return ctx.JSON(http.StatusTeapot, map[string]interface{}{})
+			},
+			wantHttpStatusCode: http.StatusInternalServerError,
+			wantBody:           `{"header":{"messages":["response does not match the API specification"],"success":false}}` + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := echo.New()
+			e.Logger.SetOutput(io.Discard)
+			e.Use(NewOpenAPIValidator(NewOpenAPIValidatorOptions{
+				Swagger:           swagger,
+				ValidateResponses: test.validateResponses,
+			}))
+			method := test.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			e.Add(method, test.path, func(ctx echo.Context) error {
+				if test.handler == nil {
+					t.Fatalf("handler should not be called for an invalid request")
+				}
+				return test.handler(ctx)
+			})
+
+			request := httptest.NewRequest(method, test.path, strings.NewReader(test.requestBody))
+			request.Header.Set(echo.HeaderContentType, test.contentType)
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantHttpStatusCode {
+				t.Errorf("handler.NewOpenAPIValidator() httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, test.wantHttpStatusCode)
+			}
+
+			if gotBody := recorder.Body.String(); gotBody != test.wantBody {
+				t.Errorf("handler.NewOpenAPIValidator() body = %v, wantBody %v", gotBody, test.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"strings"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/i18n"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	mimeApplicationProblemJSON = "application/problem+json"
+
+	// problemTypePrefix prefixes the error code to build the `type` URI of a problem document
+	problemTypePrefix = "urn:user-service:problem:"
+
+	// errorCodeContextKey is the key of the code of the error written to the response in the echo context
+	errorCodeContextKey = "error_code"
+)
+
+// WriteError writes err to the response. Clients that accept application/problem+json receive an RFC 7807
+// problem document, all other clients receive the v1 ErrorResponse envelope. Messages are localized in the
+// locale negotiated by i18n.Middleware.
+func WriteError(ctx echo.Context, err *Error) error {
+	localizer := i18n.FromContext(ctx)
+	ctx.Set(errorCodeContextKey, err.Code)
+
+	if !acceptsProblemJSON(ctx.Request().Header.Get(echo.HeaderAccept)) {
+		return ctx.JSON(err.Status, generated.ErrorResponse{
+			Header: generated.ResponseHeader{
+				Success:  false,
+				Messages: err.messages(localizer),
+			},
+		})
+	}
+
+	problem, marshalErr := json.Marshal(newProblem(ctx, localizer, err))
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	return ctx.Blob(err.Status, mimeApplicationProblemJSON, problem)
+}
+
+// ErrorCodeFromContext returns the code of the error written to the response with WriteError, i.e. for middlewares
+// that run after the handler
+func ErrorCodeFromContext(ctx echo.Context) (code generated.ErrorCode, ok bool) {
+	code, ok = ctx.Get(errorCodeContextKey).(generated.ErrorCode)
+	return code, ok
+}
+
+func newProblem(ctx echo.Context, localizer *i18n.Localizer, err *Error) generated.Problem {
+	instance := ctx.Request().URL.Path
+	problem := generated.Problem{
+		Type:     problemTypePrefix + string(err.Code),
+		Title:    err.title(localizer),
+		Status:   err.Status,
+		Code:     err.Code,
+		Instance: &instance,
+	}
+
+	if len(err.InvalidParams) > 0 {
+		invalidParams := make([]generated.InvalidParam, 0, len(err.InvalidParams))
+		for _, param := range err.InvalidParams {
+			invalidParams = append(invalidParams, generated.InvalidParam{
+				Name:   param.Field,
+				Code:   param.Code,
+				Reason: localizer.Message(string(param.Code), param.Params),
+			})
+		}
+		problem.InvalidParams = &invalidParams
+	}
+
+	return problem
+}
+
+// acceptsProblemJSON reports whether the Accept header lists application/problem+json with a non-zero quality.
+func acceptsProblemJSON(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil || mediaType != mimeApplicationProblemJSON {
+			continue
+		}
+
+		if q, ok := params["q"]; ok && strings.Trim(q, "0.") == "" {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
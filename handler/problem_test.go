@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/i18n"
+	"github.com/labstack/echo/v4"
+)
+
+func TestWriteError(t *testing.T) {
+	validationError := newValidationError([]FieldError{
+		{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix, Params: map[string]interface{}{"prefix": "+62"}},
+		{Field: "password", Code: generated.PasswordMissingNumber},
+	})
+
+	catalog, err := i18n.NewCatalog(i18n.DefaultLocale)
+	if err != nil {
+		t.Fatalf("i18n.NewCatalog() err = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		accept         string
+		acceptLanguage string
+		err            *Error
+
+		wantHttpStatusCode int
+		wantContentType    string
+		wantBody           string
+	}{
+		{
+			name:               "v1-envelope-by-default",
+			accept:             "",
+			err:                validationError,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantContentType:    echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody:           `{"header":{"messages":["phone_number should start with +62 (rule 2)","password should contain a number (rule 4)"],"success":false}}` + "\n",
+		},
+		{
+			name:               "v1-envelope-for-json-clients",
+			accept:             "application/json",
+			err:                NewError(http.StatusNotFound, generated.UserNotFound),
+			wantHttpStatusCode: http.StatusNotFound,
+			wantContentType:    echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody:           `{"header":{"messages":["user not found"],"success":false}}` + "\n",
+		},
+		{
+			name:               "problem-json-with-invalid-params",
+			accept:             "application/problem+json, application/json;q=0.5",
+			err:                validationError,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantContentType:    mimeApplicationProblemJSON,
+			wantBody: `{"code":"validation_failed","instance":"/v1/user","invalid_params":[` +
+				`{"code":"phone_number_invalid_prefix","name":"phone_number","reason":"phone_number should start with +62 (rule 2)"},` +
+				`{"code":"password_missing_number","name":"password","reason":"password should contain a number (rule 4)"}],` +
+				`"status":400,"title":"request contains invalid fields","type":"urn:user-service:problem:validation_failed"}`,
+		},
+		{
+			name:               "problem-json",
+			accept:             "application/problem+json",
+			err:                NewError(http.StatusConflict, generated.PhoneNumberAlreadyRegistered),
+			wantHttpStatusCode: http.StatusConflict,
+			wantContentType:    mimeApplicationProblemJSON,
+			wantBody: `{"code":"phone_number_already_registered","instance":"/v1/user","status":409,` +
+				`"title":"phone number is already registered to an existing user","type":"urn:user-service:problem:phone_number_already_registered"}`,
+		},
+		{
+			name:               "v1-envelope-localized",
+			acceptLanguage:     "id-ID,id;q=0.9,en;q=0.8",
+			err:                validationError,
+			wantHttpStatusCode: http.StatusBadRequest,
+			wantContentType:    echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody:           `{"header":{"messages":["phone_number harus diawali dengan +62 (aturan 2)","password harus mengandung angka (aturan 4)"],"success":false}}` + "\n",
+		},
+		{
+			name:               "problem-json-localized",
+			accept:             "application/problem+json",
+			acceptLanguage:     "id",
+			err:                NewError(http.StatusNotFound, generated.UserNotFound),
+			wantHttpStatusCode: http.StatusNotFound,
+			wantContentType:    mimeApplicationProblemJSON,
+			wantBody: `{"code":"user_not_found","instance":"/v1/user","status":404,` +
+				`"title":"pengguna tidak ditemukan","type":"urn:user-service:problem:user_not_found"}`,
+		},
+		{
+			name:               "problem-json-not-acceptable",
+			accept:             "application/problem+json;q=0, application/json",
+			err:                NewError(http.StatusNotFound, generated.UserNotFound),
+			wantHttpStatusCode: http.StatusNotFound,
+			wantContentType:    echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody:           `{"header":{"messages":["user not found"],"success":false}}` + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodPost, "/v1/user", nil)
+			if test.accept != "" {
+				request.Header.Set(echo.HeaderAccept, test.accept)
+			}
+			request.Header.Set("Accept-Language", test.acceptLanguage)
+			recorder := httptest.NewRecorder()
+			ctx := e.NewContext(request, recorder)
+
+			writeError := i18n.Middleware(catalog)(func(ctx echo.Context) error {
+				return WriteError(ctx, test.err)
+			})
+			if err := writeError(ctx); err != nil {
+				t.Fatalf("handler.WriteError() err = %v", err)
+			}
+
+			if recorder.Code != test.wantHttpStatusCode {
+				t.Errorf("handler.WriteError() httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, test.wantHttpStatusCode)
+			}
+
+			if gotContentType := recorder.Header().Get(echo.HeaderContentType); gotContentType != test.wantContentType {
+				t.Errorf("handler.WriteError() contentType = %v, wantContentType %v", gotContentType, test.wantContentType)
+			}
+
+			if gotBody := recorder.Body.String(); gotBody != test.wantBody {
+				t.Errorf("handler.WriteError() body = %v, wantBody %v", gotBody, test.wantBody)
+			}
+
+			if gotCode, ok := ErrorCodeFromContext(ctx); !ok || gotCode != test.err.Code {
+				t.Errorf("handler.ErrorCodeFromContext() code = %v, wantCode %v", gotCode, test.err.Code)
+			}
+		})
+	}
+}
@@ -1,17 +1,143 @@
 package handler
 
-import "github.com/UserService/repository"
+import (
+	"sync"
+	"time"
+
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+)
+
+// DefaultDeletionGracePeriod is how long deleted users can be restored for by default
+const DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// DefaultExportLinkExpiry is how long the download links of exports are valid for by default
+const DefaultExportLinkExpiry = 15 * time.Minute
+
+// defaultDummyPasswordHash is the dummy password hash of servers not given one, built on first use
+var defaultDummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := NewDummyPasswordHash(repository.DefaultBcryptCost)
+	if err != nil {
+		panic(err)
+	}
+
+	return hash
+})
 
 type Server struct {
 	Repository repository.RepositoryInterface
+
+	// TokenExpiry is the lifetime of sessions and their tokens, defaults to utils.JWTExpiryDuration
+	TokenExpiry time.Duration
+
+	// Validation are the rules user input is validated against, defaults to DefaultValidationRules
+	Validation ValidationRules
+
+	// DeletionGracePeriod is how long deleted users can be restored for, defaults to DefaultDeletionGracePeriod
+	DeletionGracePeriod time.Duration
+
+	// ExportLinkExpiry is how long the download links of exports are valid for, defaults to DefaultExportLinkExpiry
+	ExportLinkExpiry time.Duration
+
+	// ExportSigningKey signs the download links of exports, see utils.DeriveKey. Exports cannot be downloaded without it.
+	ExportSigningKey []byte
+	// DummyPasswordHash is compared against the passwords of unknown phone numbers, see NewDummyPasswordHash. Defaults
+	// to a hash with repository.DefaultBcryptCost.
+	DummyPasswordHash string
 }
 
 type NewServerOptions struct {
 	Repository repository.RepositoryInterface
+
+	// TokenExpiry is the lifetime of sessions and their tokens, defaults to utils.JWTExpiryDuration
+	TokenExpiry time.Duration
+
+	// Validation are the rules user input is validated against, defaults to DefaultValidationRules
+	Validation ValidationRules
+
+	// DeletionGracePeriod is how long deleted users can be restored for, defaults to DefaultDeletionGracePeriod
+	DeletionGracePeriod time.Duration
+
+	// ExportLinkExpiry is how long the download links of exports are valid for, defaults to DefaultExportLinkExpiry
+	ExportLinkExpiry time.Duration
+
+	// ExportSigningKey signs the download links of exports, see utils.DeriveKey. Exports cannot be downloaded without it.
+	ExportSigningKey []byte
+	// DummyPasswordHash is compared against the passwords of unknown phone numbers, see NewDummyPasswordHash. Defaults
+	// to a hash with repository.DefaultBcryptCost.
+	DummyPasswordHash string
 }
 
 func NewServer(opts NewServerOptions) *Server {
+	if opts.TokenExpiry <= 0 {
+		opts.TokenExpiry = utils.JWTExpiryDuration
+	}
+	if opts.Validation == (ValidationRules{}) {
+		opts.Validation = DefaultValidationRules
+	}
+	if opts.DeletionGracePeriod <= 0 {
+		opts.DeletionGracePeriod = DefaultDeletionGracePeriod
+	}
+	if opts.ExportLinkExpiry <= 0 {
+		opts.ExportLinkExpiry = DefaultExportLinkExpiry
+	}
+
 	return &Server{
-		Repository: opts.Repository,
+		Repository:          opts.Repository,
+		TokenExpiry:         opts.TokenExpiry,
+		Validation:          opts.Validation,
+		DeletionGracePeriod: opts.DeletionGracePeriod,
+		ExportLinkExpiry:    opts.ExportLinkExpiry,
+		ExportSigningKey:    opts.ExportSigningKey,
+		DummyPasswordHash:   opts.DummyPasswordHash,
 	}
 }
+
+// tokenExpiry returns the lifetime of sessions, falling back to the default for servers not created by NewServer
+func (s *Server) tokenExpiry() time.Duration {
+	if s.TokenExpiry <= 0 {
+		return utils.JWTExpiryDuration
+	}
+
+	return s.TokenExpiry
+}
+
+// validationRules returns the rules user input is validated against, falling back to the default for servers not
+// created by NewServer
+func (s *Server) validationRules() ValidationRules {
+	if s.Validation == (ValidationRules{}) {
+		return DefaultValidationRules
+	}
+
+	return s.Validation
+}
+
+// deletionGracePeriod returns how long deleted users can be restored for, falling back to the default for servers
+// not created by NewServer
+func (s *Server) deletionGracePeriod() time.Duration {
+	if s.DeletionGracePeriod <= 0 {
+		return DefaultDeletionGracePeriod
+	}
+
+	return s.DeletionGracePeriod
+}
+
+// exportLinkExpiry returns how long the download links of exports are valid for, falling back to the default for
+// servers not created by NewServer
+func (s *Server) exportLinkExpiry() time.Duration {
+	if s.ExportLinkExpiry <= 0 {
+		return DefaultExportLinkExpiry
+	}
+
+	return s.ExportLinkExpiry
+}
+
+// dummyPasswordHash returns the hash compared against the passwords of unknown phone numbers, falling back to a hash
+// with the default cost of the repository for servers not given one
+func (s *Server) dummyPasswordHash() string {
+	if s.DummyPasswordHash == "" {
+		return defaultDummyPasswordHash()
+	}
+
+	return s.DummyPasswordHash
+}
@@ -0,0 +1,343 @@
+package handler
+
+// This file contains the logic shared by the v1 and v2 endpoints, so both versions behave the same way.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/logging"
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	//define function wrappers so we can inject dummy function in UT
+	fnNewSessionID func() (string, error) = newSessionID
+	fnTimeNow      func() time.Time       = time.Now
+)
+
+// sessionPermissions are the permissions granted to the token of a session
+var sessionPermissions = []utils.JWTPermission{utils.JWTPermissionGetUser, utils.JWTPermissionUpdateUser}
+
+// createUser validates the registration request and inserts the user.
+func (s *Server) createUser(ctx context.Context, request generated.User) (repository.User, *Error) {
+	user, errorList := fnConvertRegisterUserRequestToUser(s.validationRules(), request)
+	if len(errorList) > 0 {
+		return repository.User{}, newValidationError(errorList)
+	}
+
+	userID, err := s.Repository.InsertUser(ctx, user)
+	if err != nil {
+		return repository.User{}, repositoryError(err)
+	}
+
+	user.ID = userID
+
+	return user, nil
+}
+
+// login checks the credentials of the user and creates a new session for them.
+func (s *Server) login(ctx context.Context, request generated.UserLoginRequest) (repository.Session, *Error) {
+	user, credentialsErr := s.checkCredentials(ctx, request)
+	if credentialsErr != nil {
+		return repository.Session{}, credentialsErr
+	}
+
+	// Only tell users who know the password why they cannot log in
+	if statusErr := s.accountStatusError(user); statusErr != nil {
+		return repository.Session{}, statusErr
+	}
+
+	// Increment successful login count for the users
+	now := fnTimeNow()
+	s.Repository.IncrementSuccessfulLoginCount(ctx, user.ID, now)
+
+	sessionID, err := fnNewSessionID()
+	if err != nil {
+		return repository.Session{}, NewError(http.StatusInternalServerError, generated.InternalError)
+	}
+
+	session := repository.Session{
+		ID:          sessionID,
+		UserID:      user.ID,
+		CreatedTime: now,
+		ExpiresTime: now.Add(s.tokenExpiry()),
+	}
+
+	if err := s.Repository.InsertSession(ctx, session); err != nil {
+		return repository.Session{}, repositoryError(err)
+	}
+
+	return session, nil
+}
+
+// checkCredentials returns the user with the phone number of the request if the password matches, whether the user
+// is active or not.
+func (s *Server) checkCredentials(ctx context.Context, request generated.UserLoginRequest) (repository.User, *Error) {
+	// Get user's phone number from request body
+	validPhoneNumber, errorList := s.validationRules().validatePhoneNumber(request.PhoneNumber)
+	if len(errorList) > 0 {
+		return repository.User{}, newValidationError(errorList)
+	}
+
+	// Validate password format is valid
+	inputPassword, errorList := s.validationRules().validatePassword(request.Password)
+	if len(errorList) > 0 {
+		return repository.User{}, newValidationError(errorList)
+	}
+
+	// Get user data, inactive users included to tell them why they cannot log in
+	user, err := s.getSingleUser(ctx, repository.UserFilter{PhoneNumber: validPhoneNumber, IncludeInactive: true})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return repository.User{}, repositoryError(err)
+	}
+
+	// Unknown phone numbers are compared against a dummy hash and get the same error as wrong passwords, so login
+	// cannot tell which phone numbers are registered
+	hash := user.Password
+	if err != nil {
+		hash = s.dummyPasswordHash()
+	}
+
+	// Validate input password (plain) matches user's password (hashed and salted)
+	if utils.ComparePassword(ctx, hash, inputPassword) != nil || err != nil {
+		return repository.User{}, NewError(http.StatusBadRequest, generated.InvalidCredentials)
+	}
+	logging.SetUserID(ctx, user.ID)
+
+	return user, nil
+}
+
+// accountStatusError returns the Error of a user that cannot log in because it is deleted or suspended, or nil for
+// active users. Users deleted before the grace period are reported as not found, as they are about to be purged.
+func (s *Server) accountStatusError(user repository.User) *Error {
+	if user.DeletedTime != nil {
+		restorableUntil := user.DeletedTime.Add(s.deletionGracePeriod())
+		if !fnTimeNow().Before(restorableUntil) {
+			return NewError(http.StatusNotFound, generated.UserNotFound)
+		}
+
+		err := NewError(http.StatusForbidden, generated.AccountDeleted)
+		err.Params = map[string]interface{}{"restorable_until": restorableUntil.UTC().Format(time.RFC3339)}
+		return err
+	}
+
+	if user.SuspendedTime != nil {
+		return NewError(http.StatusForbidden, generated.AccountSuspended)
+	}
+
+	return nil
+}
+
+// deleteUserByID soft deletes the user with the given ID and logs it out of every session. It returns the end of
+// the grace period, until which the user can be restored.
+func (s *Server) deleteUserByID(ctx context.Context, userID int64) (time.Time, *Error) {
+	now := fnTimeNow()
+
+	// Users can only delete themselves
+	if err := s.Repository.DeleteUser(ctx, userID, now, repository.UserActor(userID)); err != nil {
+		return time.Time{}, repositoryError(err)
+	}
+
+	return now.Add(s.deletionGracePeriod()), nil
+}
+
+// restoreDeletedUser checks the credentials of a deleted user and restores it, if its grace period is not over.
+func (s *Server) restoreDeletedUser(ctx context.Context, request generated.UserLoginRequest) *Error {
+	user, credentialsErr := s.checkCredentials(ctx, request)
+	if credentialsErr != nil {
+		return credentialsErr
+	}
+
+	if user.DeletedTime == nil {
+		return NewError(http.StatusConflict, generated.AccountNotDeleted)
+	}
+
+	// Not found means the grace period is over
+	deletedAfter := fnTimeNow().Add(-s.deletionGracePeriod())
+	if err := s.Repository.RestoreUser(ctx, user.ID, deletedAfter, repository.UserActor(user.ID)); err != nil {
+		return repositoryError(err)
+	}
+
+	return nil
+}
+
+// getUserByID returns the user with the given ID.
+func (s *Server) getUserByID(ctx context.Context, userID int64) (repository.User, *Error) {
+	user, err := s.getSingleUser(ctx, repository.UserFilter{UserID: userID})
+	if err != nil {
+		return repository.User{}, repositoryError(err)
+	}
+
+	return user, nil
+}
+
+// updateUserByID validates the update request and updates the user with the given ID. The user is only updated if
+// it is at the given version, unless version is 0.
+func (s *Server) updateUserByID(ctx context.Context, userID int64, version int64, request userUpdate) *Error {
+	updateRequest, errorList := fnConvertUpdateUserRequestToUser(s.validationRules(), userID, request)
+	if len(errorList) > 0 {
+		return newValidationError(errorList)
+	}
+	updateRequest.Version = version
+
+	// Users can only update themselves
+	if err := s.Repository.UpdateUser(ctx, updateRequest, repository.UserActor(userID)); err != nil {
+		return repositoryError(err)
+	}
+
+	return nil
+}
+
+// AuthenticateToken verifies the JWT of an `Authorization: Bearer <token>` header and checks that its session has
+// not been revoked. It is used by AuthenticationMiddleware in cmd/main.go and by the gRPC authentication interceptor.
+func (s *Server) AuthenticateToken(ctx context.Context, publicKey *rsa.PublicKey, authHeader string) (utils.CustomClaims, *Error) {
+	if authHeader == "" {
+		return utils.CustomClaims{}, NewError(http.StatusUnauthorized, generated.MissingAuthorizationHeader)
+	}
+
+	if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
+		return utils.CustomClaims{}, NewError(http.StatusUnauthorized, generated.InvalidAuthorizationHeader)
+	}
+
+	claims, err := s.validateToken(ctx, publicKey, authHeader[7:])
+	if err != nil {
+		return utils.CustomClaims{}, err
+	}
+	logging.SetUserID(ctx, claims.UserID)
+
+	return claims, nil
+}
+
+// validateToken verifies a JWT and checks that its session has not been revoked.
+func (s *Server) validateToken(ctx context.Context, publicKey *rsa.PublicKey, token string) (utils.CustomClaims, *Error) {
+	claims, err := utils.ParseToken(publicKey, token)
+	if errors.Is(err, utils.ErrTokenExpired) {
+		return utils.CustomClaims{}, NewError(http.StatusUnauthorized, generated.TokenExpired)
+	}
+	if err != nil {
+		return utils.CustomClaims{}, NewError(http.StatusUnauthorized, generated.InvalidToken)
+	}
+
+	// Check the session of the token has not been revoked, i.e. by logging out
+	if err := s.AuthenticateSession(ctx, claims); err != nil {
+		return utils.CustomClaims{}, err
+	}
+
+	return claims, nil
+}
+
+// AuthenticateSession checks that the session of a JWT has not been revoked. It is used by AuthenticationMiddleware
+// in cmd/main.go once the token itself has been verified.
+func (s *Server) AuthenticateSession(ctx context.Context, claims utils.CustomClaims) *Error {
+	if claims.SessionID == "" {
+		return NewError(http.StatusUnauthorized, generated.InvalidToken)
+	}
+
+	session, err := s.Repository.GetSession(ctx, claims.SessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return NewError(http.StatusUnauthorized, generated.InvalidToken)
+	}
+	if err != nil {
+		return repositoryError(err)
+	}
+
+	if session.UserID != claims.UserID {
+		return NewError(http.StatusUnauthorized, generated.InvalidToken)
+	}
+
+	if session.RevokedTime != nil {
+		return NewError(http.StatusUnauthorized, generated.SessionRevoked)
+	}
+
+	return nil
+}
+
+// revokeSession logs out the session with the given ID, which must belong to the user.
+func (s *Server) revokeSession(ctx context.Context, userID int64, sessionID string) *Error {
+	session, err := s.Repository.GetSession(ctx, sessionID)
+	if err != nil {
+		return sessionRepositoryError(err)
+	}
+
+	// Users can only delete their own sessions, do not reveal whether other sessions exist
+	if session.UserID != userID {
+		return NewError(http.StatusNotFound, generated.SessionNotFound)
+	}
+
+	if err := s.Repository.RevokeSession(ctx, sessionID); err != nil {
+		return sessionRepositoryError(err)
+	}
+
+	return nil
+}
+
+// setSessionClaims sets the claims of the session to the Echo context so AuthenticatedMiddleware can generate and
+// return its JWT in the Authorization header.
+func setSessionClaims(ctx echo.Context, session repository.Session) {
+	ctx.Set(string(utils.JWTClaimUserID), session.UserID)
+	ctx.Set(string(utils.JWTClaimPermissions), sessionPermissions)
+	ctx.Set(string(utils.JWTClaimSessionID), session.ID)
+	ctx.Set(string(utils.JWTClaimExpiresAt), session.ExpiresTime)
+}
+
+// newSessionClaims returns the claims of the JWT of the session.
+func newSessionClaims(session repository.Session) utils.CustomClaims {
+	return utils.CustomClaims{
+		UserID:      session.UserID,
+		Permissions: sessionPermissions,
+		ExpiresAt:   session.ExpiresTime.Unix(),
+		SessionID:   session.ID,
+	}
+}
+
+// newUserResource returns the user as exposed by the API, without its password. The full name is left out of users that
+// removed it, and the creation time out of users that were not read back from the repository, i.e. right after they are
+// inserted.
+func newUserResource(user repository.User) generated.User {
+	resource := generated.User{
+		Id:                   &user.ID,
+		PhoneNumber:          &user.PhoneNumber,
+		SuccessfulLoginCount: &user.SuccessfulLoginCount,
+		LastLoginAt:          user.LastLoginTime,
+		UpdatedAt:            user.UpdatedTime,
+	}
+	if user.FullName != "" {
+		resource.FullName = &user.FullName
+	}
+	if !user.CreatedTime.IsZero() {
+		resource.CreatedAt = &user.CreatedTime
+	}
+
+	return resource
+}
+
+// NewDummyPasswordHash returns the hash of a random password with the given bcrypt cost. The passwords of unknown
+// phone numbers are compared against it, so it must have the cost of the password hashes of the repository for their
+// login to take as long as the login of registered users.
+func NewDummyPasswordHash(cost int) (string, error) {
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		return "", err
+	}
+
+	return utils.HashPassword(context.Background(), hex.EncodeToString(password), cost)
+}
+
+// newSessionID returns a random 128-bit session ID.
+func newSessionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+	"github.com/UserService/utils"
+	"github.com/golang/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestServer_AuthenticateSession(t *testing.T) {
+	revokedTime := time.Date(2024, 1, 1, 12, 10, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		claims         utils.CustomClaims
+		mockRepository func(mock *repository.MockRepositoryInterface)
+
+		wantErr *Error
+	}{
+		{
+			name:   "success",
+			claims: utils.CustomClaims{UserID: 123, SessionID: "session"},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{ID: "session", UserID: 123}, nil)
+			},
+		},
+		{
+			name:           "fail-missing-session-id",
+			claims:         utils.CustomClaims{UserID: 123},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+			wantErr:        &Error{Status: http.StatusUnauthorized, Code: generated.InvalidToken},
+		},
+		{
+			name:   "fail-unknown-session",
+			claims: utils.CustomClaims{UserID: 123, SessionID: "session"},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{}, repository.ErrNotFound)
+			},
+			wantErr: &Error{Status: http.StatusUnauthorized, Code: generated.InvalidToken},
+		},
+		{
+			name:   "fail-session-of-other-user",
+			claims: utils.CustomClaims{UserID: 123, SessionID: "session"},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{ID: "session", UserID: 456}, nil)
+			},
+			wantErr: &Error{Status: http.StatusUnauthorized, Code: generated.InvalidToken},
+		},
+		{
+			name:   "fail-revoked-session",
+			claims: utils.CustomClaims{UserID: 123, SessionID: "session"},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{ID: "session", UserID: 123, RevokedTime: &revokedTime}, nil)
+			},
+			wantErr: &Error{Status: http.StatusUnauthorized, Code: generated.SessionRevoked},
+		},
+		{
+			name:   "fail-repository-unavailable",
+			claims: utils.CustomClaims{UserID: 123, SessionID: "session"},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{}, repository.ErrUnavailable)
+			},
+			wantErr: &Error{Status: http.StatusServiceUnavailable, Code: generated.ServiceUnavailable},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			test.mockRepository(mock)
+
+			server := NewServer(NewServerOptions{Repository: mock})
+
+			gotErr := server.AuthenticateSession(context.Background(), test.claims)
+			if !reflect.DeepEqual(gotErr, test.wantErr) {
+				t.Errorf("handler.Server.AuthenticateSession() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewDummyPasswordHash(t *testing.T) {
+	// The dummy hash takes as long to compare as the stored hashes only with their cost
+	for _, cost := range []int{bcrypt.MinCost, bcrypt.MinCost + 1} {
+		hash, err := NewDummyPasswordHash(cost)
+		if err != nil {
+			t.Fatalf("handler.NewDummyPasswordHash() err = %v", err)
+		}
+
+		if gotCost, err := bcrypt.Cost([]byte(hash)); err != nil || gotCost != cost {
+			t.Errorf("handler.NewDummyPasswordHash() cost = %v, err = %v, wantCost %v", gotCost, err, cost)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
 	"strings"
 	"unicode"
 
@@ -10,64 +13,108 @@ import (
 	"github.com/UserService/repository"
 	"github.com/UserService/utils"
 	"github.com/labstack/echo/v4"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
 )
 
 var (
 	//define function wrappers so we can inject dummy function in UT
-	fnValidatePhoneNumber func(*string) (string, []string) = validatePhoneNumber
-	fnValidatePassword    func(*string) (string, []string) = validatePassword
-	fnValidateFullName    func(*string) (string, []string) = validateFullName
+	fnValidatePhoneNumber func(ValidationRules, *string) (string, []FieldError) = ValidationRules.validatePhoneNumber
+	fnValidatePassword    func(ValidationRules, *string) (string, []FieldError) = ValidationRules.validatePassword
+	fnValidateFullName    func(ValidationRules, *string) (string, []FieldError) = ValidationRules.validateFullName
 )
 
 const (
-	successMsg                   = "request successful"
-	duplicatePhoneNumberErrorMsg = "phone number is already registered to an existing user"
+	// messageRequestSuccessful is the i18n key of the message returned in the header of successful responses
+	messageRequestSuccessful = "request_successful"
 )
 
-func authorize(ctx echo.Context, requiredPermission utils.JWTPermission) (userID int64, err error) {
+// ValidationRules are the rules user input is validated against, lengths are in characters
+type ValidationRules struct {
+	PhoneNumberPrefix    string
+	PhoneNumberMinLength int
+	PhoneNumberMaxLength int
+	FullNameMinLength    int
+	FullNameMaxLength    int
+	PasswordMinLength    int
+	PasswordMaxLength    int
+
+	// FullNameScripts are the comma separated Unicode scripts the letters of full names are written in, i.e.
+	// `Latin,Han`. Full names can be written in any script when it is empty.
+	FullNameScripts string
+}
+
+// DefaultValidationRules are the rules used by servers that are not given any
+var DefaultValidationRules = ValidationRules{
+	PhoneNumberPrefix:    "+62",
+	PhoneNumberMinLength: 10,
+	PhoneNumberMaxLength: 13,
+	FullNameMinLength:    3,
+	FullNameMaxLength:    60,
+	PasswordMinLength:    6,
+	PasswordMaxLength:    64,
+}
+
+func authorize(ctx echo.Context, requiredPermission utils.JWTPermission) (userID int64, err *Error) {
 	permissions, _ := ctx.Get(string(utils.JWTClaimPermissions)).([]utils.JWTPermission)
 
-	hasRole := false
+	if !hasPermission(permissions, requiredPermission) {
+		return userID, NewError(http.StatusForbidden, generated.PermissionDenied)
+	}
+
+	return authenticatedUserID(ctx)
+}
+
+func hasPermission(permissions []utils.JWTPermission, requiredPermission utils.JWTPermission) bool {
 	for _, permission := range permissions {
 		if permission == requiredPermission {
-			hasRole = true
+			return true
 		}
 	}
 
-	if !hasRole {
-		return userID, errors.New("not authorized: missing required permission")
-	}
+	return false
+}
 
+// authenticatedUserID returns the userID of the requester, set by AuthenticationMiddleware in cmd/main.go
+func authenticatedUserID(ctx echo.Context) (userID int64, err *Error) {
 	userID, ok := ctx.Get(string(utils.JWTClaimUserID)).(int64)
 	if !ok {
-		return userID, errors.New("missing user_id")
+		return userID, NewError(http.StatusForbidden, generated.MissingUserId)
 	}
 
 	return userID, nil
 }
 
-func validatePhoneNumber(input *string) (validPhoneNumber string, errorList []string) {
+func (r ValidationRules) validatePhoneNumber(input *string) (validPhoneNumber string, errorList []FieldError) {
 	phoneNumber := ""
 
 	if input != nil {
 		phoneNumber = strings.TrimSpace(*input)
 	}
 
-	// Verify "+62" prefix
-	if !strings.HasPrefix(phoneNumber, "+62") {
-		errorList = append(errorList, "phone_number should start with +62 (rule 2)")
+	// Verify the country code prefix, i.e. "+62"
+	if !strings.HasPrefix(phoneNumber, r.PhoneNumberPrefix) {
+		errorList = append(errorList, FieldError{
+			Field:  "phone_number",
+			Code:   generated.PhoneNumberInvalidPrefix,
+			Params: map[string]interface{}{"prefix": r.PhoneNumberPrefix},
+		})
 	}
 
 	// Check the length of the phone number
-	if len(phoneNumber) < 10 || len(phoneNumber) > 13 {
-		errorList = append(errorList, "phone_number should be 10 to 13 digits (rule 1)")
+	if len(phoneNumber) < r.PhoneNumberMinLength || len(phoneNumber) > r.PhoneNumberMaxLength {
+		errorList = append(errorList, FieldError{
+			Field:  "phone_number",
+			Code:   generated.PhoneNumberInvalidLength,
+			Params: map[string]interface{}{"min": r.PhoneNumberMinLength, "max": r.PhoneNumberMaxLength},
+		})
 	}
 
 	// Check if all remaining characters are digits
-	for i := 3; i < len(phoneNumber); i++ {
+	for i := len(r.PhoneNumberPrefix); i < len(phoneNumber); i++ {
 		c := phoneNumber[i]
 		if !unicode.IsDigit(rune(c)) {
-			errorList = append(errorList, "phone_number should only contain numbers (rule 1)")
+			errorList = append(errorList, FieldError{Field: "phone_number", Code: generated.PhoneNumberNotNumeric})
 			break
 		}
 	}
@@ -79,15 +126,38 @@ func validatePhoneNumber(input *string) (validPhoneNumber string, errorList []st
 	return validPhoneNumber, errorList
 }
 
-func validateFullName(input *string) (validFullName string, errorList []string) {
+// validateFullName normalizes the full name with normalizeFullName and checks it. The length is counted in grapheme
+// clusters, the characters users see, so a letter written with a combining accent counts once.
+func (r ValidationRules) validateFullName(input *string) (validFullName string, errorList []FieldError) {
 	fullName := ""
 
 	if input != nil {
-		fullName = strings.TrimSpace(*input)
+		fullName = normalizeFullName(*input)
 	}
 
-	if len(fullName) < 3 || len(fullName) > 60 {
-		errorList = append(errorList, "full_name should be 3 to 60 characters (rule 3)")
+	length := uniseg.GraphemeClusterCount(fullName)
+	if length < r.FullNameMinLength || length > r.FullNameMaxLength {
+		errorList = append(errorList, FieldError{
+			Field:  "full_name",
+			Code:   generated.FullNameInvalidLength,
+			Params: map[string]interface{}{"min": r.FullNameMinLength, "max": r.FullNameMaxLength},
+		})
+	}
+
+	if strings.IndexFunc(fullName, isInvalidFullNameRune) >= 0 {
+		errorList = append(errorList, FieldError{Field: "full_name", Code: generated.FullNameInvalidCharacter})
+	}
+
+	// Marks, digits and punctuation are shared by scripts, only letters tell the script of a name
+	if scripts, tables := r.fullNameScripts(); len(tables) > 0 {
+		outsideScripts := func(c rune) bool { return unicode.IsLetter(c) && !unicode.In(c, tables...) }
+		if strings.IndexFunc(fullName, outsideScripts) >= 0 {
+			errorList = append(errorList, FieldError{
+				Field:  "full_name",
+				Code:   generated.FullNameInvalidScript,
+				Params: map[string]interface{}{"scripts": strings.Join(scripts, ", ")},
+			})
+		}
 	}
 
 	if len(errorList) == 0 {
@@ -97,15 +167,78 @@ func validateFullName(input *string) (validFullName string, errorList []string)
 	return validFullName, errorList
 }
 
-func validatePassword(input *string) (validPassword string, errorList []string) {
+// fullNameScripts returns the names and the tables of the scripts in FullNameScripts. Unknown scripts are rejected
+// by config.Validate and ignored here.
+func (r ValidationRules) fullNameScripts() (scripts []string, tables []*unicode.RangeTable) {
+	if r.FullNameScripts == "" {
+		return nil, nil
+	}
+
+	for _, script := range strings.Split(r.FullNameScripts, ",") {
+		script = strings.TrimSpace(script)
+		if table, ok := unicode.Scripts[script]; ok {
+			scripts = append(scripts, script)
+			tables = append(tables, table)
+		}
+	}
+
+	return scripts, tables
+}
+
+// normalizeFullName returns the full name in Unicode normalization form C, so names that look the same are stored
+// the same way, with runs of spaces, i.e. no-break or ideographic spaces, collapsed into a single space between
+// words.
+func normalizeFullName(input string) string {
+	fullName := norm.NFC.String(strings.TrimSpace(input))
+
+	var (
+		builder strings.Builder
+		space   bool
+	)
+	for _, c := range fullName {
+		if unicode.Is(unicode.Zs, c) {
+			space = true
+			continue
+		}
+
+		if space && builder.Len() > 0 {
+			builder.WriteByte(' ')
+		}
+		space = false
+		builder.WriteRune(c)
+	}
+
+	return builder.String()
+}
+
+// isInvalidFullNameRune reports whether c cannot be part of a full name. Names are made of letters, combining marks,
+// digits, punctuation and spaces; control and format characters, which include the zero-width ones, symbols, which
+// include emoji, and unassigned code points are rejected.
+func isInvalidFullNameRune(c rune) bool {
+	switch {
+	case unicode.Is(unicode.Variation_Selector, c), unicode.Is(unicode.Me, c):
+		// Marks that turn characters into emoji or keycaps
+		return true
+	case unicode.IsLetter(c), unicode.IsMark(c), unicode.IsNumber(c), unicode.IsPunct(c), c == ' ':
+		return false
+	default:
+		return true
+	}
+}
+
+func (r ValidationRules) validatePassword(input *string) (validPassword string, errorList []FieldError) {
 	password := ""
 
 	if input != nil {
 		password = *input
 	}
 
-	if len(password) < 6 || len(password) > 64 {
-		errorList = append(errorList, "password should be 6 to 64 characters (rule 4)")
+	if len(password) < r.PasswordMinLength || len(password) > r.PasswordMaxLength {
+		errorList = append(errorList, FieldError{
+			Field:  "password",
+			Code:   generated.PasswordInvalidLength,
+			Params: map[string]interface{}{"min": r.PasswordMinLength, "max": r.PasswordMaxLength},
+		})
 	}
 
 	containsCapital, containsNumber, containsSpecialAlphaNumeric := false, false, false
@@ -126,13 +259,13 @@ func validatePassword(input *string) (validPassword string, errorList []string)
 	}
 
 	if !containsCapital {
-		errorList = append(errorList, "password should contain a capital letter (rule 4)")
+		errorList = append(errorList, FieldError{Field: "password", Code: generated.PasswordMissingCapitalLetter})
 	}
 	if !containsNumber {
-		errorList = append(errorList, "password should contain a number (rule 4)")
+		errorList = append(errorList, FieldError{Field: "password", Code: generated.PasswordMissingNumber})
 	}
 	if !containsSpecialAlphaNumeric {
-		errorList = append(errorList, "password should contain a special alphanumeric character (rule 4)")
+		errorList = append(errorList, FieldError{Field: "password", Code: generated.PasswordMissingSpecialCharacter})
 
 	}
 
@@ -143,13 +276,13 @@ func validatePassword(input *string) (validPassword string, errorList []string)
 	return validPassword, errorList
 }
 
-func convertRegisterUserRequestToUser(request generated.User) (user repository.User, errorMsgs []string) {
-	validPhoneNumber, phoneNumberErrorMsgs := fnValidatePhoneNumber(request.PhoneNumber)
-	validFullName, fullNameErrorMsgs := fnValidateFullName(request.FullName)
-	validPassword, passwordErrorMsgs := fnValidatePassword(request.Password)
+func convertRegisterUserRequestToUser(rules ValidationRules, request generated.User) (user repository.User, errorList []FieldError) {
+	validPhoneNumber, phoneNumberErrors := fnValidatePhoneNumber(rules, request.PhoneNumber)
+	validFullName, fullNameErrors := fnValidateFullName(rules, request.FullName)
+	validPassword, passwordErrors := fnValidatePassword(rules, request.Password)
 
-	errorList := append(phoneNumberErrorMsgs, fullNameErrorMsgs...)
-	errorList = append(errorList, passwordErrorMsgs...)
+	errorList = append(phoneNumberErrors, fullNameErrors...)
+	errorList = append(errorList, passwordErrors...)
 
 	if len(errorList) > 0 {
 		return repository.User{}, errorList
@@ -162,30 +295,91 @@ func convertRegisterUserRequestToUser(request generated.User) (user repository.U
 	}, nil
 }
 
-func convertUpdateUserRequestToUser(userID int64, request generated.User) (user repository.User, errorMsgs []string) {
+// userUpdate are the fields of the profile to update. Nil fields are left unchanged and ClearFullName removes the full
+// name, the only optional field of the profile.
+type userUpdate struct {
+	PhoneNumber   *string
+	FullName      *string
+	ClearFullName bool
+}
+
+// replaceUserUpdate returns the update of a generated.ReplaceUserRequest, which sets every field and removes the full
+// name when it is null.
+func replaceUserUpdate(request generated.ReplaceUserRequest) userUpdate {
+	return userUpdate{
+		PhoneNumber:   &request.PhoneNumber,
+		FullName:      request.FullName,
+		ClearFullName: request.FullName == nil,
+	}
+}
+
+func convertUpdateUserRequestToUser(rules ValidationRules, userID int64, request userUpdate) (user repository.User, errorList []FieldError) {
 	if request.PhoneNumber != nil {
-		validPhoneNumber, phoneNumberErrorMsgs := fnValidatePhoneNumber(request.PhoneNumber)
+		validPhoneNumber, phoneNumberErrors := fnValidatePhoneNumber(rules, request.PhoneNumber)
 		user.PhoneNumber = validPhoneNumber
 
-		errorMsgs = append(errorMsgs, phoneNumberErrorMsgs...)
+		errorList = append(errorList, phoneNumberErrors...)
 	}
 
 	if request.FullName != nil {
-		validFullName, fullNameErrorMsgs := fnValidateFullName(request.FullName)
+		validFullName, fullNameErrors := fnValidateFullName(rules, request.FullName)
 		user.FullName = validFullName
 
-		errorMsgs = append(errorMsgs, fullNameErrorMsgs...)
+		errorList = append(errorList, fullNameErrors...)
 
 	}
+	user.ClearFullName = request.ClearFullName
 
-	if len(errorMsgs) > 0 {
-		return repository.User{}, errorMsgs
+	if len(errorList) > 0 {
+		return repository.User{}, errorList
 	}
 
 	user.ID = userID
 	return user, nil
 }
 
+// userMergePatchRequiredFields are the fields of a generated.UserMergePatch that cannot be cleared: the phone number
+// identifies the user at login. The full name is optional and cleared by null.
+var userMergePatchRequiredFields = []string{"phone_number"}
+
+// convertUserMergePatch decodes a JSON Merge Patch of the user into the fields to update: absent fields are left
+// unset, a null full name is removed and a null required field is rejected. The patch was validated against the spec
+// by NewOpenAPIValidator.
+func convertUserMergePatch(body io.Reader) (update userUpdate, errorList []FieldError, err error) {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return userUpdate{}, nil, err
+	}
+
+	// Unlike generated.UserMergePatch, the raw fields tell absent fields from null ones
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(content, &patch); err != nil {
+		return userUpdate{}, nil, err
+	}
+
+	for _, field := range userMergePatchRequiredFields {
+		if value, ok := patch[field]; ok && string(value) == "null" {
+			errorList = append(errorList, newSpecFieldError(field, generated.RequiredFieldMissing))
+		}
+	}
+	if len(errorList) > 0 {
+		return userUpdate{}, errorList, nil
+	}
+
+	// Only fields set to a value are left, absent and null fields are nil
+	var request generated.UserMergePatch
+	if err := json.Unmarshal(content, &request); err != nil {
+		return userUpdate{}, nil, err
+	}
+
+	value, ok := patch["full_name"]
+	return userUpdate{
+		PhoneNumber:   request.PhoneNumber,
+		FullName:      request.FullName,
+		ClearFullName: ok && string(value) == "null",
+	}, nil, nil
+}
+
 func (s *Server) getSingleUser(ctx context.Context, userFilter repository.UserFilter) (user repository.User, err error) {
 	if userFilter == (repository.UserFilter{}) {
 		return user, errors.New("userFilter cannot be empty to get a single user")
@@ -197,7 +391,7 @@ func (s *Server) getSingleUser(ctx context.Context, userFilter repository.UserFi
 	}
 
 	if len(users) == 0 {
-		return user, errors.New("user not found")
+		return user, repository.ErrNotFound
 	}
 
 	return users[0], nil
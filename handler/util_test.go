@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/UserService/generated"
@@ -13,6 +14,8 @@ import (
 	"github.com/UserService/utils"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
 )
 
 func Test_validatePhoneNumber(t *testing.T) {
@@ -22,10 +25,11 @@ func Test_validatePhoneNumber(t *testing.T) {
 
 	tests := []struct {
 		name  string
+		rules *ValidationRules
 		input *string
 
 		wantValidPhoneNumber string
-		wantErrorList        []string
+		wantErrorList        []FieldError
 		wantError            bool
 	}{
 		{
@@ -34,71 +38,101 @@ func Test_validatePhoneNumber(t *testing.T) {
 			wantValidPhoneNumber: "+628123456789",
 			wantErrorList:        nil,
 		},
+		{
+			name: "success-configured-rules",
+			rules: &ValidationRules{
+				PhoneNumberPrefix:    "+65",
+				PhoneNumberMinLength: 11,
+				PhoneNumberMaxLength: 11,
+			},
+			input:                stringPtr("+6581234567"),
+			wantValidPhoneNumber: "+6581234567",
+			wantErrorList:        nil,
+		},
+		{
+			name: "fail-configured-rules",
+			rules: &ValidationRules{
+				PhoneNumberPrefix:    "+65",
+				PhoneNumberMinLength: 11,
+				PhoneNumberMaxLength: 11,
+			},
+			input:                stringPtr("+628123456789"),
+			wantValidPhoneNumber: "",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix, Params: map[string]interface{}{"prefix": "+65"}},
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidLength, Params: map[string]interface{}{"min": 11, "max": 11}},
+			},
+		},
 		{
 			name:                 "nil",
 			input:                nil,
 			wantValidPhoneNumber: "",
-			wantErrorList: []string{
-				"phone_number should start with +62 (rule 2)",
-				"phone_number should be 10 to 13 digits (rule 1)",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix, Params: map[string]interface{}{"prefix": "+62"}},
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidLength, Params: map[string]interface{}{"min": 10, "max": 13}},
 			},
 		},
 		{
 			name:                 "empty",
 			input:                stringPtr(""),
 			wantValidPhoneNumber: "",
-			wantErrorList: []string{
-				"phone_number should start with +62 (rule 2)",
-				"phone_number should be 10 to 13 digits (rule 1)",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix, Params: map[string]interface{}{"prefix": "+62"}},
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidLength, Params: map[string]interface{}{"min": 10, "max": 13}},
 			},
 		},
 		{
 			name:                 "fail-rule-1-length-min",
 			input:                stringPtr("+62812345  "),
 			wantValidPhoneNumber: "",
-			wantErrorList: []string{
-				"phone_number should be 10 to 13 digits (rule 1)",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidLength, Params: map[string]interface{}{"min": 10, "max": 13}},
 			},
 		},
 		{
 			name:                 "fail-rule-1-length-max",
 			input:                stringPtr("  +6281234567890"),
 			wantValidPhoneNumber: "",
-			wantErrorList: []string{
-				"phone_number should be 10 to 13 digits (rule 1)",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidLength, Params: map[string]interface{}{"min": 10, "max": 13}},
 			},
 		},
 		{
 			name:                 "fail-rule-1-non-numbers",
 			input:                stringPtr("+628123456a"),
 			wantValidPhoneNumber: "",
-			wantErrorList: []string{
-				"phone_number should only contain numbers (rule 1)",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberNotNumeric},
 			},
 		},
 		{
 			name:                 "fail-rule-2",
 			input:                stringPtr("08123456789"),
 			wantValidPhoneNumber: "",
-			wantErrorList: []string{
-				"phone_number should start with +62 (rule 2)",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix, Params: map[string]interface{}{"prefix": "+62"}},
 			},
 		},
 		{
 			name:                 "fail-all-rules",
 			input:                stringPtr("0345abc"),
 			wantValidPhoneNumber: "",
-			wantErrorList: []string{
-				"phone_number should start with +62 (rule 2)",
-				"phone_number should be 10 to 13 digits (rule 1)",
-				"phone_number should only contain numbers (rule 1)",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix, Params: map[string]interface{}{"prefix": "+62"}},
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidLength, Params: map[string]interface{}{"min": 10, "max": 13}},
+				{Field: "phone_number", Code: generated.PhoneNumberNotNumeric},
 			},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			gotValidPhoneNumber, gotErrorList := validatePhoneNumber(test.input)
+			rules := DefaultValidationRules
+			if test.rules != nil {
+				rules = *test.rules
+			}
+
+			gotValidPhoneNumber, gotErrorList := rules.validatePhoneNumber(test.input)
 			if !reflect.DeepEqual(gotValidPhoneNumber, test.wantValidPhoneNumber) {
 				t.Errorf("util.validatePhoneNumber() gotValidPhoneNumber = %v, wantValidPhoneNumber %v", gotValidPhoneNumber, test.wantValidPhoneNumber)
 			}
@@ -115,12 +149,19 @@ func Test_validateFullName(t *testing.T) {
 		return &in
 	}
 
+	lengthError := FieldError{Field: "full_name", Code: generated.FullNameInvalidLength, Params: map[string]interface{}{"min": 3, "max": 60}}
+	characterError := FieldError{Field: "full_name", Code: generated.FullNameInvalidCharacter}
+
+	latinAndHan := DefaultValidationRules
+	latinAndHan.FullNameScripts = "Latin, Han"
+
 	tests := []struct {
 		name  string
+		rules *ValidationRules
 		input *string
 
 		wantValidFullName string
-		wantErrorList     []string
+		wantErrorList     []FieldError
 	}{
 		{
 			name:              "success",
@@ -128,37 +169,166 @@ func Test_validateFullName(t *testing.T) {
 			wantValidFullName: "abcd",
 			wantErrorList:     nil,
 		},
+		{
+			name:              "success-diacritics",
+			input:             stringPtr("Siti Nurhaliza Dé"),
+			wantValidFullName: "Siti Nurhaliza Dé",
+		},
+		{
+			name:              "success-normalized-to-nfc",
+			input:             stringPtr("Rene\u0301 O\u0308zil"),
+			wantValidFullName: "Ren\u00e9 \u00d6zil",
+		},
+		{
+			name:              "success-punctuation-and-digits",
+			input:             stringPtr("Mary-Jane O'Neil, Jr. III 2"),
+			wantValidFullName: "Mary-Jane O'Neil, Jr. III 2",
+		},
+		{
+			name:              "success-han",
+			input:             stringPtr("王小明"),
+			wantValidFullName: "王小明",
+		},
+		{
+			name:              "success-arabic",
+			input:             stringPtr("محمد علي"),
+			wantValidFullName: "محمد علي",
+		},
+		{
+			name:              "success-devanagari",
+			input:             stringPtr("अनुज"),
+			wantValidFullName: "अनुज",
+		},
+		{
+			name:              "success-collapses-spaces",
+			input:             stringPtr("\u3000 Budi \u00a0\u00a0 Santoso\u2003 "),
+			wantValidFullName: "Budi Santoso",
+		},
+		{
+			name:              "success-length-max-in-graphemes",
+			input:             stringPtr(strings.Repeat("e\u0301", 60)),
+			wantValidFullName: strings.Repeat("\u00e9", 60),
+		},
+		{
+			name:              "success-length-max-in-multibyte-characters",
+			input:             stringPtr(strings.Repeat("王", 60)),
+			wantValidFullName: strings.Repeat("王", 60),
+		},
+		{
+			name:              "success-allowed-scripts",
+			rules:             &latinAndHan,
+			input:             stringPtr("Wang 小明"),
+			wantValidFullName: "Wang 小明",
+		},
 		{
 			name:              "nil",
 			input:             nil,
 			wantValidFullName: "",
-			wantErrorList: []string{
-				"full_name should be 3 to 60 characters (rule 3)",
-			},
+			wantErrorList:     []FieldError{lengthError},
 		},
 		{
 			name:              "fail-length-min",
 			input:             stringPtr(" ab"),
 			wantValidFullName: "",
-			wantErrorList: []string{
-				"full_name should be 3 to 60 characters (rule 3)",
-			},
+			wantErrorList:     []FieldError{lengthError},
+		},
+		{
+			// Three code points, but two characters: the vowel sign is combined with the consonant
+			name:          "fail-length-min-in-graphemes",
+			input:         stringPtr("अनु"),
+			wantErrorList: []FieldError{lengthError},
+		},
+		{
+			name:          "fail-length-min-after-collapsing-spaces",
+			input:         stringPtr("a\u00a0\u00a0"),
+			wantErrorList: []FieldError{lengthError},
 		},
 		{
 			name:              "fail-length-max",
 			input:             stringPtr("    S2EeAKi6fze0JVsVbo6OR9uxmzdy89Kiy59z4Wzi2jTdomVUSUIh8G1GmHpJF "),
 			wantValidFullName: "",
-			wantErrorList: []string{
-				"full_name should be 3 to 60 characters (rule 3)",
+			wantErrorList:     []FieldError{lengthError},
+		},
+		{
+			name:          "fail-length-max-in-graphemes",
+			input:         stringPtr(strings.Repeat("王", 61)),
+			wantErrorList: []FieldError{lengthError},
+		},
+		{
+			name:          "fail-zero-width-space",
+			input:         stringPtr("Budi\u200bSantoso"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-bidi-override",
+			input:         stringPtr("Budi \u202eosotnaS"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-control-character",
+			input:         stringPtr("Budi\x00Santoso"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-internal-newline",
+			input:         stringPtr("Budi\nSantoso"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-emoji",
+			input:         stringPtr("Budi 😀"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-emoji-skin-tone",
+			input:         stringPtr("Budi 👍🏽"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-variation-selector",
+			input:         stringPtr("Budi\ufe0f"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-private-use",
+			input:         stringPtr("Budi \ue000"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-replacement-character",
+			input:         stringPtr("Budi \ufffd"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:          "fail-invalid-utf8",
+			input:         stringPtr("Budi \xff"),
+			wantErrorList: []FieldError{characterError},
+		},
+		{
+			name:  "fail-script-not-allowed",
+			rules: &latinAndHan,
+			input: stringPtr("Budi محمد"),
+			wantErrorList: []FieldError{
+				{Field: "full_name", Code: generated.FullNameInvalidScript, Params: map[string]interface{}{"scripts": "Latin, Han"}},
 			},
 		},
+		{
+			name:          "fail-length-and-character",
+			input:         stringPtr("😀😀"),
+			wantErrorList: []FieldError{lengthError, characterError},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			gotValidFullName, gotErrorList := validateFullName(test.input)
+			rules := DefaultValidationRules
+			if test.rules != nil {
+				rules = *test.rules
+			}
+
+			gotValidFullName, gotErrorList := rules.validateFullName(test.input)
 			if !reflect.DeepEqual(gotValidFullName, test.wantValidFullName) {
-				t.Errorf("util.validateFullName() gotValidFullName = %v, wantValidFullName %v", gotValidFullName, test.wantValidFullName)
+				t.Errorf("util.validateFullName() gotValidFullName = %q, wantValidFullName %q", gotValidFullName, test.wantValidFullName)
 			}
 
 			if !reflect.DeepEqual(gotErrorList, test.wantErrorList) {
@@ -168,6 +338,41 @@ func Test_validateFullName(t *testing.T) {
 	}
 }
 
+func FuzzValidateFullName(f *testing.F) {
+	for _, seed := range []string{"abcd", " Siti  Nurhaliza ", "Rene\u0301", "王小明", "محمد علي", "Budi\u200b", "Budi 👍🏽", "\u3000\u00a0", "\xff\xfe"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		validFullName, errorList := DefaultValidationRules.validateFullName(&input)
+		if len(errorList) > 0 {
+			if validFullName != "" {
+				t.Errorf("util.validateFullName(%q) = %q with errors %v, want empty", input, validFullName, errorList)
+			}
+			return
+		}
+
+		if !norm.NFC.IsNormalString(validFullName) {
+			t.Errorf("util.validateFullName(%q) = %q, want NFC", input, validFullName)
+		}
+		if strings.TrimSpace(validFullName) != validFullName || strings.Contains(validFullName, "  ") {
+			t.Errorf("util.validateFullName(%q) = %q, want single spaces between words", input, validFullName)
+		}
+		if strings.IndexFunc(validFullName, isInvalidFullNameRune) >= 0 {
+			t.Errorf("util.validateFullName(%q) = %q, want no invalid characters", input, validFullName)
+		}
+		if length := uniseg.GraphemeClusterCount(validFullName); length < DefaultValidationRules.FullNameMinLength || length > DefaultValidationRules.FullNameMaxLength {
+			t.Errorf("util.validateFullName(%q) = %q, want %d to %d characters", input, validFullName, DefaultValidationRules.FullNameMinLength, DefaultValidationRules.FullNameMaxLength)
+		}
+
+		// Valid full names are stored as they are returned, they should stay valid and unchanged
+		again, errorList := DefaultValidationRules.validateFullName(&validFullName)
+		if again != validFullName || len(errorList) > 0 {
+			t.Errorf("util.validateFullName(%q) = %q with errors %v, want unchanged", validFullName, again, errorList)
+		}
+	})
+}
+
 func Test_validatePassword(t *testing.T) {
 	stringPtr := func(in string) *string {
 		return &in
@@ -178,7 +383,7 @@ func Test_validatePassword(t *testing.T) {
 		input *string
 
 		wantValidPassword string
-		wantErrorList     []string
+		wantErrorList     []FieldError
 	}{
 		{
 			name:              "success",
@@ -190,18 +395,18 @@ func Test_validatePassword(t *testing.T) {
 			name:              "fail-all-rules",
 			input:             stringPtr(""),
 			wantValidPassword: "",
-			wantErrorList: []string{
-				"password should be 6 to 64 characters (rule 4)",
-				"password should contain a capital letter (rule 4)",
-				"password should contain a number (rule 4)",
-				"password should contain a special alphanumeric character (rule 4)",
+			wantErrorList: []FieldError{
+				{Field: "password", Code: generated.PasswordInvalidLength, Params: map[string]interface{}{"min": 6, "max": 64}},
+				{Field: "password", Code: generated.PasswordMissingCapitalLetter},
+				{Field: "password", Code: generated.PasswordMissingNumber},
+				{Field: "password", Code: generated.PasswordMissingSpecialCharacter},
 			},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			gotValidPassword, gotErrorList := validatePassword(test.input)
+			gotValidPassword, gotErrorList := DefaultValidationRules.validatePassword(test.input)
 			if !reflect.DeepEqual(gotValidPassword, test.wantValidPassword) {
 				t.Errorf("util.validatePassword() gotValidPassword = %v, wantValidPassword %v", gotValidPassword, test.wantValidPassword)
 			}
@@ -221,12 +426,12 @@ func Test_convertRegisterUserRequestToUser(t *testing.T) {
 	tests := []struct {
 		name                  string
 		input                 generated.User
-		fnValidatePhoneNumber func(*string) (string, []string)
-		fnValidatePassword    func(*string) (string, []string)
-		fnValidateFullName    func(*string) (string, []string)
+		fnValidatePhoneNumber func(ValidationRules, *string) (string, []FieldError)
+		fnValidatePassword    func(ValidationRules, *string) (string, []FieldError)
+		fnValidateFullName    func(ValidationRules, *string) (string, []FieldError)
 
 		wantUser      repository.User
-		wantErrorMsgs []string
+		wantErrorList []FieldError
 	}{
 		{
 			name: "success",
@@ -235,21 +440,21 @@ func Test_convertRegisterUserRequestToUser(t *testing.T) {
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("P455w0rd!."),
 			},
-			fnValidatePhoneNumber: func(*string) (string, []string) {
-				return "+628123456789", []string{}
+			fnValidatePhoneNumber: func(ValidationRules, *string) (string, []FieldError) {
+				return "+628123456789", []FieldError{}
 			},
-			fnValidateFullName: func(*string) (string, []string) {
-				return "User", []string{}
+			fnValidateFullName: func(ValidationRules, *string) (string, []FieldError) {
+				return "User", []FieldError{}
 			},
-			fnValidatePassword: func(*string) (string, []string) {
-				return "P455w0rd!.", []string{}
+			fnValidatePassword: func(ValidationRules, *string) (string, []FieldError) {
+				return "P455w0rd!.", []FieldError{}
 			},
 			wantUser: repository.User{
 				FullName:    "User",
 				PhoneNumber: "+628123456789",
 				Password:    "P455w0rd!.",
 			},
-			wantErrorMsgs: nil,
+			wantErrorList: nil,
 		},
 		{
 			name: "fail-all-validations",
@@ -258,20 +463,22 @@ func Test_convertRegisterUserRequestToUser(t *testing.T) {
 				PhoneNumber: stringPtr("+628123456789"),
 				Password:    stringPtr("P455w0rd!."),
 			},
-			fnValidatePhoneNumber: func(*string) (string, []string) {
-				return "", []string{"invalid-phone-number-1", "invalid-phone-number-2", "invalid-phone-number-3"}
+			fnValidatePhoneNumber: func(ValidationRules, *string) (string, []FieldError) {
+				return "", []FieldError{{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix}, {Field: "phone_number", Code: generated.PhoneNumberInvalidLength}, {Field: "phone_number", Code: generated.PhoneNumberNotNumeric}}
 			},
-			fnValidateFullName: func(*string) (string, []string) {
-				return "", []string{"invalid-full-name-1", "invalid-full-name-2"}
+			fnValidateFullName: func(ValidationRules, *string) (string, []FieldError) {
+				return "", []FieldError{{Field: "full_name", Code: generated.FullNameInvalidLength}}
 			},
-			fnValidatePassword: func(*string) (string, []string) {
-				return "", []string{"invalid-password"}
+			fnValidatePassword: func(ValidationRules, *string) (string, []FieldError) {
+				return "", []FieldError{{Field: "password", Code: generated.PasswordInvalidLength}}
 			},
 			wantUser: repository.User{},
-			wantErrorMsgs: []string{
-				"invalid-phone-number-1", "invalid-phone-number-2", "invalid-phone-number-3",
-				"invalid-full-name-1", "invalid-full-name-2",
-				"invalid-password",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix},
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidLength},
+				{Field: "phone_number", Code: generated.PhoneNumberNotNumeric},
+				{Field: "full_name", Code: generated.FullNameInvalidLength},
+				{Field: "password", Code: generated.PasswordInvalidLength},
 			},
 		},
 	}
@@ -281,14 +488,19 @@ func Test_convertRegisterUserRequestToUser(t *testing.T) {
 			fnValidateFullName = test.fnValidateFullName
 			fnValidatePassword = test.fnValidatePassword
 			fnValidatePhoneNumber = test.fnValidatePhoneNumber
+			defer func() {
+				fnValidateFullName = ValidationRules.validateFullName
+				fnValidatePassword = ValidationRules.validatePassword
+				fnValidatePhoneNumber = ValidationRules.validatePhoneNumber
+			}()
 
-			gotUser, gotErrorMsgs := convertRegisterUserRequestToUser(test.input)
+			gotUser, gotErrorList := convertRegisterUserRequestToUser(DefaultValidationRules, test.input)
 			if !reflect.DeepEqual(gotUser, test.wantUser) {
 				t.Errorf("util.convertRegisterUserRequestToUser() gotUser = %v, wantUser %v", gotUser, test.wantUser)
 			}
 
-			if !reflect.DeepEqual(gotErrorMsgs, test.wantErrorMsgs) {
-				t.Errorf("util.convertRegisterUserRequestToUser() gotErrorMsgs = %v, wantErrorMsgs %v", gotErrorMsgs, test.wantErrorMsgs)
+			if !reflect.DeepEqual(gotErrorList, test.wantErrorList) {
+				t.Errorf("util.convertRegisterUserRequestToUser() gotErrorList = %v, wantErrorList %v", gotErrorList, test.wantErrorList)
 			}
 		})
 	}
@@ -302,51 +514,68 @@ func Test_convertUpdateUserRequestToUser(t *testing.T) {
 	tests := []struct {
 		name                  string
 		inputUserID           int64
-		input                 generated.User
-		fnValidatePhoneNumber func(*string) (string, []string)
-		fnValidateFullName    func(*string) (string, []string)
+		input                 userUpdate
+		fnValidatePhoneNumber func(ValidationRules, *string) (string, []FieldError)
+		fnValidateFullName    func(ValidationRules, *string) (string, []FieldError)
 
 		wantUser      repository.User
-		wantErrorMsgs []string
+		wantErrorList []FieldError
 	}{
 		{
 			name:        "success",
 			inputUserID: 123,
-			input: generated.User{
+			input: userUpdate{
 				FullName:    stringPtr("User"),
 				PhoneNumber: stringPtr("+628123456789"),
-				Password:    stringPtr("P455w0rd!."),
 			},
-			fnValidatePhoneNumber: func(*string) (string, []string) {
-				return "+628123456789", []string{}
+			fnValidatePhoneNumber: func(ValidationRules, *string) (string, []FieldError) {
+				return "+628123456789", []FieldError{}
 			},
-			fnValidateFullName: func(*string) (string, []string) {
-				return "User", []string{}
+			fnValidateFullName: func(ValidationRules, *string) (string, []FieldError) {
+				return "User", []FieldError{}
 			},
 			wantUser: repository.User{
 				ID:          123,
 				FullName:    "User",
 				PhoneNumber: "+628123456789",
 			},
-			wantErrorMsgs: nil,
+			wantErrorList: nil,
+		},
+		{
+			name:        "success-clear-full-name",
+			inputUserID: 123,
+			input: userUpdate{
+				PhoneNumber:   stringPtr("+628123456789"),
+				ClearFullName: true,
+			},
+			fnValidatePhoneNumber: func(ValidationRules, *string) (string, []FieldError) {
+				return "+628123456789", []FieldError{}
+			},
+			wantUser: repository.User{
+				ID:            123,
+				PhoneNumber:   "+628123456789",
+				ClearFullName: true,
+			},
+			wantErrorList: nil,
 		},
 		{
 			name: "fail-all-validations",
-			input: generated.User{
+			input: userUpdate{
 				FullName:    stringPtr("User"),
 				PhoneNumber: stringPtr("+628123456789"),
-				Password:    stringPtr("P455w0rd!."),
 			},
-			fnValidatePhoneNumber: func(*string) (string, []string) {
-				return "", []string{"invalid-phone-number-1", "invalid-phone-number-2", "invalid-phone-number-3"}
+			fnValidatePhoneNumber: func(ValidationRules, *string) (string, []FieldError) {
+				return "", []FieldError{{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix}, {Field: "phone_number", Code: generated.PhoneNumberInvalidLength}, {Field: "phone_number", Code: generated.PhoneNumberNotNumeric}}
 			},
-			fnValidateFullName: func(*string) (string, []string) {
-				return "", []string{"invalid-full-name-1", "invalid-full-name-2"}
+			fnValidateFullName: func(ValidationRules, *string) (string, []FieldError) {
+				return "", []FieldError{{Field: "full_name", Code: generated.FullNameInvalidLength}}
 			},
 			wantUser: repository.User{},
-			wantErrorMsgs: []string{
-				"invalid-phone-number-1", "invalid-phone-number-2", "invalid-phone-number-3",
-				"invalid-full-name-1", "invalid-full-name-2",
+			wantErrorList: []FieldError{
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidPrefix},
+				{Field: "phone_number", Code: generated.PhoneNumberInvalidLength},
+				{Field: "phone_number", Code: generated.PhoneNumberNotNumeric},
+				{Field: "full_name", Code: generated.FullNameInvalidLength},
 			},
 		},
 	}
@@ -355,14 +584,83 @@ func Test_convertUpdateUserRequestToUser(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			fnValidateFullName = test.fnValidateFullName
 			fnValidatePhoneNumber = test.fnValidatePhoneNumber
+			defer func() {
+				fnValidateFullName = ValidationRules.validateFullName
+				fnValidatePhoneNumber = ValidationRules.validatePhoneNumber
+			}()
 
-			gotUser, gotErrorMsgs := convertUpdateUserRequestToUser(test.inputUserID, test.input)
+			gotUser, gotErrorList := convertUpdateUserRequestToUser(DefaultValidationRules, test.inputUserID, test.input)
 			if !reflect.DeepEqual(gotUser, test.wantUser) {
 				t.Errorf("util.convertUpdateUserRequestToUser() gotUser = %v, wantUser %v", gotUser, test.wantUser)
 			}
 
-			if !reflect.DeepEqual(gotErrorMsgs, test.wantErrorMsgs) {
-				t.Errorf("util.convertUpdateUserRequestToUser() gotErrorMsgs = %v, wantErrorMsgs %v", gotErrorMsgs, test.wantErrorMsgs)
+			if !reflect.DeepEqual(gotErrorList, test.wantErrorList) {
+				t.Errorf("util.convertUpdateUserRequestToUser() gotErrorList = %v, wantErrorList %v", gotErrorList, test.wantErrorList)
+			}
+		})
+	}
+}
+
+func Test_convertUserMergePatch(t *testing.T) {
+	stringPtr := func(in string) *string {
+		return &in
+	}
+
+	tests := []struct {
+		name  string
+		patch string
+
+		wantRequest   userUpdate
+		wantErrorList []FieldError
+		wantErr       bool
+	}{
+		{
+			name:        "success-set-fields",
+			patch:       `{"phone_number":"+628123456789","full_name":"User"}`,
+			wantRequest: userUpdate{PhoneNumber: stringPtr("+628123456789"), FullName: stringPtr("User")},
+		},
+		{
+			name:        "success-absent-field-unchanged",
+			patch:       `{"full_name":"User"}`,
+			wantRequest: userUpdate{FullName: stringPtr("User")},
+		},
+		{
+			name:        "success-empty-patch",
+			patch:       `{}`,
+			wantRequest: userUpdate{},
+		},
+		{
+			name:        "success-null-clears-full-name",
+			patch:       `{"phone_number":"+628123456789","full_name":null}`,
+			wantRequest: userUpdate{PhoneNumber: stringPtr("+628123456789"), ClearFullName: true},
+		},
+		{
+			name:  "fail-clear-required-field",
+			patch: `{"phone_number":null,"full_name":null}`,
+			wantErrorList: []FieldError{
+				newSpecFieldError("phone_number", generated.RequiredFieldMissing),
+			},
+		},
+		{
+			name:    "fail-invalid-json",
+			patch:   `{"full_name":`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotRequest, gotErrorList, gotErr := convertUserMergePatch(strings.NewReader(test.patch))
+			if (gotErr != nil) != test.wantErr {
+				t.Fatalf("util.convertUserMergePatch() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(gotRequest, test.wantRequest) {
+				t.Errorf("util.convertUserMergePatch() gotRequest = %v, wantRequest %v", gotRequest, test.wantRequest)
+			}
+
+			if !reflect.DeepEqual(gotErrorList, test.wantErrorList) {
+				t.Errorf("util.convertUserMergePatch() gotErrorList = %v, wantErrorList %v", gotErrorList, test.wantErrorList)
 			}
 		})
 	}
@@ -376,7 +674,7 @@ func Test_authorize(t *testing.T) {
 		requiredPermission utils.JWTPermission
 
 		wantUserID int64
-		wantErr    error
+		wantErr    *Error
 	}{
 		{
 			name:      "success",
@@ -397,7 +695,7 @@ func Test_authorize(t *testing.T) {
 			},
 			requiredPermission: utils.JWTPermissionGetUser,
 			wantUserID:         0,
-			wantErr:            errors.New("not authorized: missing required permission"),
+			wantErr:            &Error{Status: http.StatusForbidden, Code: generated.PermissionDenied},
 		},
 		{
 			name:      "fail-not-authorized-user-id",
@@ -407,7 +705,7 @@ func Test_authorize(t *testing.T) {
 			},
 			requiredPermission: utils.JWTPermissionGetUser,
 			wantUserID:         0,
-			wantErr:            errors.New("missing user_id"),
+			wantErr:            &Error{Status: http.StatusForbidden, Code: generated.MissingUserId},
 		},
 	}
 
@@ -512,7 +810,7 @@ func Test_getSingleUser(t *testing.T) {
 				return mock
 			},
 			wantUser: repository.User{},
-			wantErr:  errors.New("user not found"),
+			wantErr:  repository.ErrNotFound,
 		},
 		{
 			name:       "fail-empty-filter",
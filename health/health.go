@@ -0,0 +1,196 @@
+// Package health serves the liveness and readiness probes of the service.
+package health
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	PathHealthz = "/healthz"
+	PathReadyz  = "/readyz"
+
+	defaultTimeout = 2 * time.Second
+)
+
+var (
+	//define function wrappers so we can inject dummy function in UT
+	fnTimeNow = time.Now
+)
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Report is the response body of the probes
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the result of one of the checks of the readiness probe
+type CheckResult struct {
+	Status     Status `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type Handler struct {
+	repository   repository.RepositoryInterface
+	privateKey   *rsa.PrivateKey
+	publicKey    *rsa.PublicKey
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+type NewHandlerOptions struct {
+	Repository repository.RepositoryInterface
+
+	// PrivateKey and PublicKey are the keys tokens are signed and verified with, the service is not ready without them
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+
+	// Timeout bounds each check of the database, defaults to 2 seconds
+	Timeout time.Duration
+}
+
+func NewHandler(opts NewHandlerOptions) *Handler {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	return &Handler{
+		repository: opts.Repository,
+		privateKey: opts.PrivateKey,
+		publicKey:  opts.PublicKey,
+		timeout:    opts.Timeout,
+	}
+}
+
+// RegisterRoutes registers the liveness and readiness probe routes.
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.GET(PathHealthz, h.Healthz)
+	e.GET(PathReadyz, h.Readyz)
+}
+
+// Shutdown makes the readiness probe fail, so load balancers stop sending requests before the servers stop accepting
+// connections.
+func (h *Handler) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Healthz reports that the process is alive. It does not depend on the database, so an outage of the database does
+// not get the process restarted.
+func (h *Handler) Healthz(ctx echo.Context) error {
+	return writeReport(ctx, Report{Status: StatusOK})
+}
+
+// Readyz reports whether the service can serve requests: the database is reachable and migrated, and the signing
+// keys are loaded. It fails once the service is shutting down.
+func (h *Handler) Readyz(ctx echo.Context) error {
+	if h.shuttingDown.Load() {
+		return writeReport(ctx, Report{
+			Status: StatusFail,
+			Checks: map[string]CheckResult{
+				"shutdown": {Status: StatusFail, Error: "service is shutting down"},
+			},
+		})
+	}
+
+	checks := map[string]func(context.Context) error{
+		"database":     h.checkDatabase,
+		"migrations":   h.checkMigrations,
+		"signing_keys": h.checkSigningKeys,
+	}
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			result := h.run(ctx.Request().Context(), check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return writeReport(ctx, report)
+}
+
+// run runs the check within the timeout
+func (h *Handler) run(ctx context.Context, check func(context.Context) error) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := fnTimeNow()
+	err := check(ctx)
+	result := CheckResult{Status: StatusOK, DurationMs: fnTimeNow().Sub(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func (h *Handler) checkDatabase(ctx context.Context) error {
+	return h.repository.Ping(ctx)
+}
+
+func (h *Handler) checkMigrations(ctx context.Context) error {
+	version, err := h.repository.GetSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Newer schemas are compatible, so instances of the previous release stay ready during a deployment
+	if version < repository.SchemaVersion {
+		return fmt.Errorf("schema version %d is older than %d, apply database.sql", version, repository.SchemaVersion)
+	}
+
+	return nil
+}
+
+func (h *Handler) checkSigningKeys(context.Context) error {
+	var errs []error
+	if h.privateKey == nil {
+		errs = append(errs, errors.New("private key is not loaded, tokens cannot be issued"))
+	}
+	if h.publicKey == nil {
+		errs = append(errs, errors.New("public key is not loaded, tokens cannot be verified"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func writeReport(ctx echo.Context, report Report) error {
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	// Probes should always reach the service
+	ctx.Response().Header().Set("Cache-Control", "no-store")
+	return ctx.JSON(status, report)
+}
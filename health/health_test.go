@@ -0,0 +1,220 @@
+package health
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandler(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		noKeys       bool
+		shuttingDown bool
+		mockRepo     func(mock *repository.MockRepositoryInterface)
+
+		wantHttpStatusCode int
+		wantReport         Report
+	}{
+		{
+			name:               "healthz",
+			path:               PathHealthz,
+			mockRepo:           func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusOK,
+			wantReport:         Report{Status: StatusOK},
+		},
+		{
+			name: "readyz",
+			path: PathReadyz,
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().Ping(gomock.Any()).Return(nil)
+				mock.EXPECT().GetSchemaVersion(gomock.Any()).Return(repository.SchemaVersion, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantReport: Report{
+				Status: StatusOK,
+				Checks: map[string]CheckResult{
+					"database":     {Status: StatusOK},
+					"migrations":   {Status: StatusOK},
+					"signing_keys": {Status: StatusOK},
+				},
+			},
+		},
+		{
+			name: "readyz-newer-schema",
+			path: PathReadyz,
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().Ping(gomock.Any()).Return(nil)
+				mock.EXPECT().GetSchemaVersion(gomock.Any()).Return(repository.SchemaVersion+1, nil)
+			},
+			wantHttpStatusCode: http.StatusOK,
+			wantReport: Report{
+				Status: StatusOK,
+				Checks: map[string]CheckResult{
+					"database":     {Status: StatusOK},
+					"migrations":   {Status: StatusOK},
+					"signing_keys": {Status: StatusOK},
+				},
+			},
+		},
+		{
+			name: "readyz-database-down",
+			path: PathReadyz,
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().Ping(gomock.Any()).Return(repository.ErrUnavailable)
+				mock.EXPECT().GetSchemaVersion(gomock.Any()).Return(0, repository.ErrUnavailable)
+			},
+			wantHttpStatusCode: http.StatusServiceUnavailable,
+			wantReport: Report{
+				Status: StatusFail,
+				Checks: map[string]CheckResult{
+					"database":     {Status: StatusFail, Error: "database unavailable"},
+					"migrations":   {Status: StatusFail, Error: "database unavailable"},
+					"signing_keys": {Status: StatusOK},
+				},
+			},
+		},
+		{
+			name: "readyz-database-timeout",
+			path: PathReadyz,
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().Ping(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				})
+				mock.EXPECT().GetSchemaVersion(gomock.Any()).Return(repository.SchemaVersion, nil)
+			},
+			wantHttpStatusCode: http.StatusServiceUnavailable,
+			wantReport: Report{
+				Status: StatusFail,
+				Checks: map[string]CheckResult{
+					"database":     {Status: StatusFail, Error: "context deadline exceeded"},
+					"migrations":   {Status: StatusOK},
+					"signing_keys": {Status: StatusOK},
+				},
+			},
+		},
+		{
+			name: "readyz-schema-not-migrated",
+			path: PathReadyz,
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().Ping(gomock.Any()).Return(nil)
+				mock.EXPECT().GetSchemaVersion(gomock.Any()).Return(repository.SchemaVersion-1, nil)
+			},
+			wantHttpStatusCode: http.StatusServiceUnavailable,
+			wantReport: Report{
+				Status: StatusFail,
+				Checks: map[string]CheckResult{
+					"database":     {Status: StatusOK},
+					"migrations":   {Status: StatusFail, Error: fmt.Sprintf("schema version %d is older than %d, apply database.sql", repository.SchemaVersion-1, repository.SchemaVersion)},
+					"signing_keys": {Status: StatusOK},
+				},
+			},
+		},
+		{
+			name:   "readyz-keys-not-loaded",
+			path:   PathReadyz,
+			noKeys: true,
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().Ping(gomock.Any()).Return(nil)
+				mock.EXPECT().GetSchemaVersion(gomock.Any()).Return(repository.SchemaVersion, nil)
+			},
+			wantHttpStatusCode: http.StatusServiceUnavailable,
+			wantReport: Report{
+				Status: StatusFail,
+				Checks: map[string]CheckResult{
+					"database":   {Status: StatusOK},
+					"migrations": {Status: StatusOK},
+					"signing_keys": {
+						Status: StatusFail,
+						Error:  "private key is not loaded, tokens cannot be issued\npublic key is not loaded, tokens cannot be verified",
+					},
+				},
+			},
+		},
+		{
+			name:               "readyz-shutting-down",
+			path:               PathReadyz,
+			shuttingDown:       true,
+			mockRepo:           func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusServiceUnavailable,
+			wantReport: Report{
+				Status: StatusFail,
+				Checks: map[string]CheckResult{
+					"shutdown": {Status: StatusFail, Error: "service is shutting down"},
+				},
+			},
+		},
+		{
+			name:               "healthz-shutting-down",
+			path:               PathHealthz,
+			shuttingDown:       true,
+			mockRepo:           func(mock *repository.MockRepositoryInterface) {},
+			wantHttpStatusCode: http.StatusOK,
+			wantReport:         Report{Status: StatusOK},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := repository.NewMockRepositoryInterface(ctrl)
+			test.mockRepo(mock)
+
+			// Durations are not deterministic
+			fnTimeNow = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+			defer func() { fnTimeNow = time.Now }()
+
+			opts := NewHandlerOptions{Repository: mock, Timeout: 50 * time.Millisecond}
+			if !test.noKeys {
+				opts.PrivateKey = privateKey
+				opts.PublicKey = &privateKey.PublicKey
+			}
+			handler := NewHandler(opts)
+			if test.shuttingDown {
+				handler.Shutdown()
+			}
+
+			e := echo.New()
+			handler.RegisterRoutes(e)
+
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if recorder.Code != test.wantHttpStatusCode {
+				t.Errorf("health.Handler httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, test.wantHttpStatusCode)
+			}
+
+			if gotCacheControl := recorder.Header().Get("Cache-Control"); gotCacheControl != "no-store" {
+				t.Errorf("health.Handler Cache-Control = %v, want no-store", gotCacheControl)
+			}
+
+			var gotReport Report
+			if err := json.Unmarshal(recorder.Body.Bytes(), &gotReport); err != nil {
+				t.Fatalf("json.Unmarshal() err = %v", err)
+			}
+			if !reflect.DeepEqual(gotReport, test.wantReport) {
+				t.Errorf("health.Handler report = %+v, wantReport %+v", gotReport, test.wantReport)
+			}
+		})
+	}
+}
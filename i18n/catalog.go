@@ -0,0 +1,143 @@
+// Package i18n contains the message catalog used to localize messages returned to clients.
+// Messages are keyed by error code (see ErrorCode in api.yml) and stored per locale in the locales directory.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// DefaultLocale is the locale used when none of the locales requested by a client is supported.
+const DefaultLocale = "en"
+
+// Catalog holds the message bundle of every supported locale.
+type Catalog struct {
+	bundles  map[language.Tag]map[string]string
+	tags     []language.Tag // tags[0] is the fallback locale
+	matcher  language.Matcher
+	fallback language.Tag
+}
+
+// NewCatalog loads the embedded message bundles. fallbackLocale is used when a client does not request any
+// supported locale, and for messages missing from the negotiated locale.
+func NewCatalog(fallbackLocale string) (*Catalog, error) {
+	fallback, err := language.Parse(fallbackLocale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fallback locale %q: %w", fallbackLocale, err)
+	}
+
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{
+		bundles: map[language.Tag]map[string]string{},
+	}
+	for _, file := range files {
+		tag, err := language.Parse(strings.TrimSuffix(file.Name(), path.Ext(file.Name())))
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale file %s: %w", file.Name(), err)
+		}
+
+		data, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		bundle := map[string]string{}
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return nil, fmt.Errorf("invalid locale file %s: %w", file.Name(), err)
+		}
+
+		catalog.bundles[tag] = bundle
+	}
+
+	if _, ok := catalog.bundles[fallback]; !ok {
+		return nil, fmt.Errorf("fallback locale %q is not supported", fallbackLocale)
+	}
+
+	// The matcher returns the first tag when nothing matches, so the fallback goes first
+	catalog.fallback = fallback
+	catalog.tags = append(catalog.tags, fallback)
+	for tag := range catalog.bundles {
+		if tag != fallback {
+			catalog.tags = append(catalog.tags, tag)
+		}
+	}
+	others := catalog.tags[1:]
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].String() < others[j].String()
+	})
+	catalog.matcher = language.NewMatcher(catalog.tags)
+
+	return catalog, nil
+}
+
+// Locales returns the BCP 47 tags of the supported locales, starting with the fallback locale.
+func (c *Catalog) Locales() []string {
+	locales := make([]string, 0, len(c.tags))
+	for _, tag := range c.tags {
+		locales = append(locales, tag.String())
+	}
+
+	return locales
+}
+
+// Negotiate returns a Localizer for the best supported match of an Accept-Language header value.
+func (c *Catalog) Negotiate(acceptLanguage string) *Localizer {
+	requested, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := c.matcher.Match(requested...)
+
+	return &Localizer{
+		catalog: c,
+		tag:     c.tags[index],
+	}
+}
+
+// Localizer renders messages in a single locale.
+type Localizer struct {
+	catalog *Catalog
+	tag     language.Tag
+}
+
+// Locale returns the BCP 47 tag of the locale, e.g. "id".
+func (l *Localizer) Locale() string {
+	return l.tag.String()
+}
+
+// Message returns the message stored under key, replacing every `{name}` placeholder with params[name].
+// The fallback locale is used when the locale has no such message, and the key itself when no locale has it.
+func (l *Localizer) Message(key string, params map[string]interface{}) string {
+	message, ok := l.catalog.bundles[l.tag][key]
+	if !ok {
+		message, ok = l.catalog.bundles[l.catalog.fallback][key]
+	}
+	if !ok {
+		return key
+	}
+
+	return interpolate(message, params)
+}
+
+func interpolate(message string, params map[string]interface{}) string {
+	if len(params) == 0 {
+		return message
+	}
+
+	oldnew := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		oldnew = append(oldnew, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return strings.NewReplacer(oldnew...).Replace(message)
+}
@@ -0,0 +1,190 @@
+package i18n
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNewCatalog(t *testing.T) {
+	tests := []struct {
+		name           string
+		fallbackLocale string
+
+		wantLocales []string
+		wantErr     bool
+	}{
+		{
+			name:           "fallback-en",
+			fallbackLocale: "en",
+			wantLocales:    []string{"en", "id"},
+		},
+		{
+			name:           "fallback-id",
+			fallbackLocale: "id",
+			wantLocales:    []string{"id", "en"},
+		},
+		{
+			name:           "fail-unsupported-fallback",
+			fallbackLocale: "fr",
+			wantErr:        true,
+		},
+		{
+			name:           "fail-invalid-fallback",
+			fallbackLocale: "not a locale",
+			wantErr:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			catalog, err := NewCatalog(test.fallbackLocale)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("i18n.NewCatalog() err = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(catalog.Locales(), test.wantLocales) {
+				t.Errorf("i18n.NewCatalog() locales = %v, wantLocales %v", catalog.Locales(), test.wantLocales)
+			}
+		})
+	}
+}
+
+func TestCatalog_Negotiate(t *testing.T) {
+	catalog, err := NewCatalog("en")
+	if err != nil {
+		t.Fatalf("i18n.NewCatalog() err = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+
+		wantLocale string
+	}{
+		{
+			name:           "empty-uses-fallback",
+			acceptLanguage: "",
+			wantLocale:     "en",
+		},
+		{
+			name:           "indonesian",
+			acceptLanguage: "id",
+			wantLocale:     "id",
+		},
+		{
+			name:           "indonesian-region",
+			acceptLanguage: "id-ID,id;q=0.9,en-US;q=0.8,en;q=0.7",
+			wantLocale:     "id",
+		},
+		{
+			name:           "quality-order",
+			acceptLanguage: "id;q=0.5, en-GB;q=0.8",
+			wantLocale:     "en",
+		},
+		{
+			name:           "unsupported-uses-fallback",
+			acceptLanguage: "fr-FR, de;q=0.5",
+			wantLocale:     "en",
+		},
+		{
+			name:           "unsupported-then-supported",
+			acceptLanguage: "ja, id;q=0.1",
+			wantLocale:     "id",
+		},
+		{
+			name:           "malformed-uses-fallback",
+			acceptLanguage: ";;;",
+			wantLocale:     "en",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotLocale := catalog.Negotiate(test.acceptLanguage).Locale()
+			if gotLocale != test.wantLocale {
+				t.Errorf("i18n.Catalog.Negotiate() locale = %v, wantLocale %v", gotLocale, test.wantLocale)
+			}
+		})
+	}
+}
+
+func TestLocalizer_Message(t *testing.T) {
+	catalog, err := NewCatalog("en")
+	if err != nil {
+		t.Fatalf("i18n.NewCatalog() err = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		locale string
+		key    string
+		params map[string]interface{}
+
+		wantMessage string
+	}{
+		{
+			name:        "english",
+			locale:      "en",
+			key:         "user_not_found",
+			wantMessage: "user not found",
+		},
+		{
+			name:        "indonesian",
+			locale:      "id",
+			key:         "user_not_found",
+			wantMessage: "pengguna tidak ditemukan",
+		},
+		{
+			name:        "interpolation",
+			locale:      "id",
+			key:         "phone_number_invalid_length",
+			params:      map[string]interface{}{"min": 10, "max": 13},
+			wantMessage: "phone_number harus terdiri dari 10 sampai 13 digit (aturan 1)",
+		},
+		{
+			name:        "missing-param-is-kept",
+			locale:      "en",
+			key:         "phone_number_invalid_prefix",
+			wantMessage: "phone_number should start with {prefix} (rule 2)",
+		},
+		{
+			name:        "unknown-key",
+			locale:      "id",
+			key:         "unknown_key",
+			wantMessage: "unknown_key",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotMessage := catalog.Negotiate(test.locale).Message(test.key, test.params)
+			if gotMessage != test.wantMessage {
+				t.Errorf("i18n.Localizer.Message() message = %v, wantMessage %v", gotMessage, test.wantMessage)
+			}
+		})
+	}
+}
+
+func TestBundlesHaveSameKeys(t *testing.T) {
+	catalog, err := NewCatalog(DefaultLocale)
+	if err != nil {
+		t.Fatalf("i18n.NewCatalog() err = %v", err)
+	}
+
+	keys := func(bundle map[string]string) []string {
+		keys := make([]string, 0, len(bundle))
+		for key := range bundle {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	wantKeys := keys(catalog.bundles[catalog.fallback])
+	for tag, bundle := range catalog.bundles {
+		if gotKeys := keys(bundle); !reflect.DeepEqual(gotKeys, wantKeys) {
+			t.Errorf("locale %v keys = %v, wantKeys %v", tag, gotKeys, wantKeys)
+		}
+	}
+}
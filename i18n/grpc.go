@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	metadataAcceptLanguage  = "accept-language"
+	metadataContentLanguage = "content-language"
+)
+
+type localizerContextKey struct{}
+
+// UnaryServerInterceptor is the gRPC counterpart of Middleware: it negotiates the locale of every call from its
+// `accept-language` metadata and stores the resulting Localizer in the context of the call.
+func UnaryServerInterceptor(catalog *Catalog) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		acceptLanguage := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(metadataAcceptLanguage); len(values) > 0 {
+				acceptLanguage = values[0]
+			}
+		}
+
+		localizer := catalog.Negotiate(acceptLanguage)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metadataContentLanguage, localizer.Locale()))
+
+		return handler(NewContext(ctx, localizer), req)
+	}
+}
+
+// NewContext returns a copy of ctx holding localizer.
+func NewContext(ctx context.Context, localizer *Localizer) context.Context {
+	return context.WithValue(ctx, localizerContextKey{}, localizer)
+}
+
+// FromGoContext returns the Localizer stored by NewContext. Calls that did not go through UnaryServerInterceptor
+// get a Localizer for DefaultLocale.
+func FromGoContext(ctx context.Context) *Localizer {
+	if localizer, ok := ctx.Value(localizerContextKey{}).(*Localizer); ok {
+		return localizer
+	}
+
+	return Default()
+}
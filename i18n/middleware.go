@@ -0,0 +1,59 @@
+package i18n
+
+import (
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// ContextKey is the Echo context key holding the *Localizer negotiated for the request
+	ContextKey = "localizer"
+
+	headerAcceptLanguage  = "Accept-Language"
+	headerContentLanguage = "Content-Language"
+)
+
+var (
+	defaultLocalizer     *Localizer
+	defaultLocalizerOnce sync.Once
+)
+
+// Middleware negotiates the locale of every request from its Accept-Language header and stores the resulting
+// Localizer in the Echo context. Register it before any middleware that may write an error response.
+func Middleware(catalog *Catalog) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			localizer := catalog.Negotiate(ctx.Request().Header.Get(headerAcceptLanguage))
+
+			ctx.Set(ContextKey, localizer)
+			ctx.Response().Header().Set(headerContentLanguage, localizer.Locale())
+
+			return next(ctx)
+		}
+	}
+}
+
+// FromContext returns the Localizer negotiated by Middleware. Requests that did not go through Middleware,
+// e.g. in unit tests, get a Localizer for DefaultLocale.
+func FromContext(ctx echo.Context) *Localizer {
+	if localizer, ok := ctx.Get(ContextKey).(*Localizer); ok {
+		return localizer
+	}
+
+	return Default()
+}
+
+// Default returns a Localizer for DefaultLocale.
+func Default() *Localizer {
+	defaultLocalizerOnce.Do(func() {
+		catalog, err := NewCatalog(DefaultLocale)
+		if err != nil {
+			// The bundles are embedded in the binary, so this can only be a programming error
+			panic(err)
+		}
+		defaultLocalizer = catalog.Negotiate("")
+	})
+
+	return defaultLocalizer
+}
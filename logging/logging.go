@@ -0,0 +1,125 @@
+// Package logging writes structured JSON logs with log/slog. Every request gets a logger scoped to its request ID,
+// which handlers and the repository get with FromContext. Phone numbers, passwords and tokens are redacted by the
+// handler, before anything is written.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redacted = "REDACTED"
+
+var (
+	// sensitiveKeys are the parts of the keys of attributes whose value is always redacted
+	sensitiveKeys = []string{"password", "token", "authorization", "phone", "secret"}
+
+	// sensitivePatterns are redacted from messages and string values, i.e. from errors of the database driver
+	sensitivePatterns = []struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}{
+		// JWT, the header and payload are base64url encoded JSON objects
+		{regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), redacted},
+		{regexp.MustCompile(`(?i)(bearer)\s+\S+`), "$1 " + redacted},
+		// Phone numbers are validated to start with a + prefix
+		{regexp.MustCompile(`\+[0-9]{6,15}\b`), redacted},
+	}
+)
+
+type contextKey struct{}
+
+// requestLog is the logger of a request, and the user the request is authenticated as once known
+type requestLog struct {
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	userID int64
+}
+
+type NewLoggerOptions struct {
+	// Writer defaults to os.Stderr
+	Writer io.Writer
+	Level  slog.Leveler
+}
+
+// NewLogger returns a logger writing JSON lines with sensitive values redacted
+func NewLogger(opts NewLoggerOptions) *slog.Logger {
+	if opts.Writer == nil {
+		opts.Writer = os.Stderr
+	}
+
+	return slog.New(slog.NewJSONHandler(opts.Writer, &slog.HandlerOptions{
+		Level:       opts.Level,
+		ReplaceAttr: redactAttr,
+	}))
+}
+
+// redactAttr redacts the attributes with a sensitive key, and the sensitive patterns of the other string values.
+// It is also called with the message of the record.
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, sensitiveKey := range sensitiveKeys {
+		if strings.Contains(key, sensitiveKey) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+
+	switch value := attr.Value.Any().(type) {
+	case string:
+		return slog.String(attr.Key, Redact(value))
+	case error:
+		return slog.String(attr.Key, Redact(value.Error()))
+	}
+
+	return attr
+}
+
+// Redact returns s with its phone numbers and tokens redacted
+func Redact(s string) string {
+	for _, sensitive := range sensitivePatterns {
+		s = sensitive.pattern.ReplaceAllString(s, sensitive.replacement)
+	}
+
+	return s
+}
+
+// NewContext returns a copy of ctx carrying logger, see FromContext
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestLog{logger: logger})
+}
+
+// FromContext returns the logger of the request of ctx, with the ID of the authenticated user, or the default logger
+// outside of requests
+func FromContext(ctx context.Context) *slog.Logger {
+	entry, ok := ctx.Value(contextKey{}).(*requestLog)
+	if !ok {
+		return slog.Default()
+	}
+
+	if userID := entry.getUserID(); userID != 0 {
+		return entry.logger.With("user_id", userID)
+	}
+
+	return entry.logger
+}
+
+// SetUserID records the user the request of ctx is authenticated as, it is logged with the request
+func SetUserID(ctx context.Context, userID int64) {
+	if entry, ok := ctx.Value(contextKey{}).(*requestLog); ok {
+		entry.mu.Lock()
+		defer entry.mu.Unlock()
+		entry.userID = userID
+	}
+}
+
+func (r *requestLog) getUserID() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.userID
+}
@@ -0,0 +1,164 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadataRequestID is the gRPC metadata of the request ID, like the X-Request-ID header
+const metadataRequestID = "x-request-id"
+
+var (
+	//define function wrappers so we can inject dummy function in UT
+	fnTimeNow      = time.Now
+	fnNewRequestID = newRequestID
+
+	// requestIDPattern is the format of the request IDs propagated from clients, other IDs are replaced so they
+	// cannot inject content in the logs
+	requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+	// grpcServerErrors are the codes of the calls that failed because of the service rather than the client, like
+	// 5xx status codes
+	grpcServerErrors = map[codes.Code]bool{
+		codes.Unknown:          true,
+		codes.DeadlineExceeded: true,
+		codes.Unimplemented:    true,
+		codes.Internal:         true,
+		codes.Unavailable:      true,
+		codes.DataLoss:         true,
+	}
+)
+
+type NewMiddlewareOptions struct {
+	Logger *slog.Logger
+}
+
+// NewMiddleware returns a middleware that scopes a logger to the request ID of every request and logs the request
+// once it is handled. The X-Request-ID header of the request is propagated, or generated when missing, and echoed in
+// the response. Register it with echo.Use after the tracing middleware so the logs have the trace ID.
+func NewMiddleware(opts NewMiddlewareOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := fnTimeNow()
+			request := ctx.Request()
+
+			requestID := requestIDOrNew(request.Header.Get(echo.HeaderXRequestID))
+			ctx.Response().Header().Set(echo.HeaderXRequestID, requestID)
+
+			logCtx := NewContext(request.Context(), scopedLogger(request.Context(), opts.Logger, requestID))
+			ctx.SetRequest(request.WithContext(logCtx))
+
+			err := next(ctx)
+
+			statusCode := ctx.Response().Status
+			if err != nil {
+				// The error is written by the error handler of echo once the middlewares returned
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					statusCode = httpErr.Code
+				} else {
+					statusCode = http.StatusInternalServerError
+				}
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", request.Method),
+				slog.String("route", ctx.Path()),
+				slog.String("path", request.URL.Path),
+				slog.Int("status", statusCode),
+				slog.Int64("latency_ms", fnTimeNow().Sub(start).Milliseconds()),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.Any("error", err))
+			}
+
+			level := slog.LevelInfo
+			if statusCode >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			FromContext(logCtx).LogAttrs(logCtx, level, "request", attrs...)
+
+			return err
+		}
+	}
+}
+
+// NewUnaryServerInterceptor returns an interceptor that scopes a logger to the request ID of every call and logs the
+// call once it is handled, like NewMiddleware. The request ID is read from and written to the `x-request-id`
+// metadata. Register it after the tracing interceptor.
+func NewUnaryServerInterceptor(opts NewMiddlewareOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		start := fnTimeNow()
+
+		var requestID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(metadataRequestID); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		requestID = requestIDOrNew(requestID)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, requestID))
+
+		ctx = NewContext(ctx, scopedLogger(ctx, opts.Logger, requestID))
+
+		resp, err := next(ctx, req)
+
+		code := status.Code(err)
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Int64("latency_ms", fnTimeNow().Sub(start).Milliseconds()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+
+		level := slog.LevelInfo
+		if grpcServerErrors[code] {
+			level = slog.LevelError
+		}
+		FromContext(ctx).LogAttrs(ctx, level, "request", attrs...)
+
+		return resp, err
+	}
+}
+
+// scopedLogger returns logger with the request ID and, when the request is traced, its trace ID
+func scopedLogger(ctx context.Context, logger *slog.Logger, requestID string) *slog.Logger {
+	logger = logger.With("request_id", requestID)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.With("trace_id", spanContext.TraceID().String())
+	}
+
+	return logger
+}
+
+func requestIDOrNew(requestID string) string {
+	if requestIDPattern.MatchString(requestID) {
+		return requestID
+	}
+
+	return fnNewRequestID()
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// stubClock makes every call of fnTimeNow 25 milliseconds later than the previous one
+func stubClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	fnTimeNow = func() time.Time {
+		now = now.Add(25 * time.Millisecond)
+		return now
+	}
+	fnNewRequestID = func() string { return "generated" }
+	t.Cleanup(func() {
+		fnTimeNow = time.Now
+		fnNewRequestID = newRequestID
+	})
+}
+
+func TestNewMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		requestID string
+		handler   echo.HandlerFunc
+
+		wantStatus    int
+		wantRequestID string
+		wantLines     []map[string]interface{}
+	}{
+		{
+			name:      "success-propagates-request-id",
+			target:    "/v2/users/1",
+			requestID: "client-id-1",
+			handler: func(ctx echo.Context) error {
+				SetUserID(ctx.Request().Context(), 1)
+				FromContext(ctx.Request().Context()).Info("found user", "phone_number", "+628123456789")
+				return ctx.NoContent(http.StatusOK)
+			},
+			wantStatus:    http.StatusOK,
+			wantRequestID: "client-id-1",
+			wantLines: []map[string]interface{}{
+				{"level": "INFO", "msg": "found user", "request_id": "client-id-1", "user_id": float64(1), "phone_number": "REDACTED"},
+				{
+					"level": "INFO", "msg": "request", "request_id": "client-id-1", "user_id": float64(1),
+					"method": "GET", "route": "/v2/users/:id", "path": "/v2/users/1", "status": float64(200), "latency_ms": float64(25),
+				},
+			},
+		},
+		{
+			name:      "success-generates-request-id",
+			target:    "/v2/users/1",
+			requestID: "",
+			handler: func(ctx echo.Context) error {
+				return ctx.NoContent(http.StatusNotFound)
+			},
+			wantStatus:    http.StatusNotFound,
+			wantRequestID: "generated",
+			wantLines: []map[string]interface{}{
+				{
+					"level": "INFO", "msg": "request", "request_id": "generated",
+					"method": "GET", "route": "/v2/users/:id", "path": "/v2/users/1", "status": float64(404), "latency_ms": float64(25),
+				},
+			},
+		},
+		{
+			name:      "success-replaces-invalid-request-id",
+			target:    "/v2/users/1",
+			requestID: "id\nforged log line",
+			handler: func(ctx echo.Context) error {
+				return ctx.NoContent(http.StatusOK)
+			},
+			wantStatus:    http.StatusOK,
+			wantRequestID: "generated",
+			wantLines: []map[string]interface{}{
+				{
+					"level": "INFO", "msg": "request", "request_id": "generated",
+					"method": "GET", "route": "/v2/users/:id", "path": "/v2/users/1", "status": float64(200), "latency_ms": float64(25),
+				},
+			},
+		},
+		{
+			name:      "fail-handler-error",
+			target:    "/v2/users/1",
+			requestID: "client-id-1",
+			handler: func(ctx echo.Context) error {
+				return echo.NewHTTPError(http.StatusServiceUnavailable, "database unavailable")
+			},
+			wantStatus:    http.StatusServiceUnavailable,
+			wantRequestID: "client-id-1",
+			wantLines: []map[string]interface{}{
+				{
+					"level": "ERROR", "msg": "request", "request_id": "client-id-1",
+					"method": "GET", "route": "/v2/users/:id", "path": "/v2/users/1", "status": float64(503), "latency_ms": float64(25),
+					"error": "code=503, message=database unavailable",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stubClock(t)
+
+			var output bytes.Buffer
+			e := echo.New()
+			e.Use(NewMiddleware(NewMiddlewareOptions{Logger: NewLogger(NewLoggerOptions{Writer: &output})}))
+			e.GET("/v2/users/:id", test.handler)
+
+			request := httptest.NewRequest(http.MethodGet, test.target, nil)
+			if test.requestID != "" {
+				request.Header.Set(echo.HeaderXRequestID, test.requestID)
+			}
+			response := httptest.NewRecorder()
+			e.ServeHTTP(response, request)
+
+			if response.Code != test.wantStatus {
+				t.Errorf("NewMiddleware() httpStatusCode = %v, wantHttpStatusCode %v", response.Code, test.wantStatus)
+			}
+			if got := response.Header().Get(echo.HeaderXRequestID); got != test.wantRequestID {
+				t.Errorf("NewMiddleware() X-Request-ID = %v, wantRequestID %v", got, test.wantRequestID)
+			}
+			if got := decodeLines(t, &output); !reflect.DeepEqual(got, test.wantLines) {
+				t.Errorf("NewMiddleware() lines = %v, wantLines %v", got, test.wantLines)
+			}
+		})
+	}
+}
+
+// headerStream records the headers set with grpc.SetHeader
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestNewUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		err  error
+
+		wantRequestID string
+		wantLines     []map[string]interface{}
+	}{
+		{
+			name:          "success-propagates-request-id",
+			md:            metadata.Pairs(metadataRequestID, "client-id-1"),
+			wantRequestID: "client-id-1",
+			wantLines: []map[string]interface{}{
+				{
+					"level": "INFO", "msg": "request", "request_id": "client-id-1", "user_id": float64(1),
+					"method": "/userservice.v1.UserService/GetUser", "code": "OK", "latency_ms": float64(25),
+				},
+			},
+		},
+		{
+			name:          "fail-server-error",
+			err:           status.Error(codes.Unavailable, "database unavailable"),
+			wantRequestID: "generated",
+			wantLines: []map[string]interface{}{
+				{
+					"level": "ERROR", "msg": "request", "request_id": "generated", "user_id": float64(1),
+					"method": "/userservice.v1.UserService/GetUser", "code": "Unavailable", "latency_ms": float64(25),
+					"error": "rpc error: code = Unavailable desc = database unavailable",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stubClock(t)
+
+			stream := &headerStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			if test.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.md)
+			}
+
+			var output bytes.Buffer
+			interceptor := NewUnaryServerInterceptor(NewMiddlewareOptions{Logger: NewLogger(NewLoggerOptions{Writer: &output})})
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/userservice.v1.UserService/GetUser"},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					SetUserID(ctx, 1)
+					return nil, test.err
+				})
+			if err != test.err {
+				t.Errorf("NewUnaryServerInterceptor() err = %v, wantErr %v", err, test.err)
+			}
+
+			if got := stream.header.Get(metadataRequestID); !reflect.DeepEqual(got, []string{test.wantRequestID}) {
+				t.Errorf("NewUnaryServerInterceptor() x-request-id = %v, wantRequestID %v", got, test.wantRequestID)
+			}
+			if got := decodeLines(t, &output); !reflect.DeepEqual(got, test.wantLines) {
+				t.Errorf("NewUnaryServerInterceptor() lines = %v, wantLines %v", got, test.wantLines)
+			}
+		})
+	}
+}
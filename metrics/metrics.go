@@ -0,0 +1,120 @@
+// Package metrics exports the metrics of the service in the Prometheus text format. Requests are instrumented by
+// the middleware and the gRPC interceptor, database queries by the repository decorator, so the handlers and the
+// repository do not depend on this package.
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	PathMetrics = "/metrics"
+
+	namespace = "user_service"
+
+	// outcomeSuccess is the outcome of successful registrations and logins, failures are labelled with their error code
+	outcomeSuccess = "success"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestDuration     *prometheus.HistogramVec
+	grpcRequestDuration     *prometheus.HistogramVec
+	registrations           *prometheus.CounterVec
+	logins                  *prometheus.CounterVec
+	tokenValidationFailures *prometheus.CounterVec
+	conflicts               *prometheus.CounterVec
+	repositoryQueryDuration *prometheus.HistogramVec
+	passwordDuration        *prometheus.HistogramVec
+}
+
+type NewMetricsOptions struct {
+	// DB exports the statistics of the connection pool when set
+	DB *sql.DB
+}
+
+func NewMetrics(opts NewMetricsOptions) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		grpcRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "Duration of gRPC calls by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		registrations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "Registrations of users by outcome, success or the error code.",
+		}, []string{"outcome"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Logins by outcome, success or the error code.",
+		}, []string{"outcome"}),
+		tokenValidationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_validation_failures_total",
+			Help:      "Requests rejected because of their JWT, by error code.",
+		}, []string{"reason"}),
+		conflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "conflicts_total",
+			Help:      "Requests rejected because they conflict with the stored data, by error code.",
+		}, []string{"code"}),
+		repositoryQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_query_duration_seconds",
+			Help:      "Duration of the calls of the repository by method and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "outcome"}),
+		passwordDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "password_hash_duration_seconds",
+			Help:      "Duration of bcrypt password hashes and comparisons.",
+			Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequestDuration,
+		m.grpcRequestDuration,
+		m.registrations,
+		m.logins,
+		m.tokenValidationFailures,
+		m.conflicts,
+		m.repositoryQueryDuration,
+		m.passwordDuration,
+	)
+	if opts.DB != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(opts.DB, namespace))
+	}
+
+	return m
+}
+
+// RegisterRoutes registers the route serving the metrics.
+func (m *Metrics) RegisterRoutes(e *echo.Echo) {
+	e.GET(PathMetrics, echo.WrapHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})))
+}
+
+// ObservePassword records the duration of a password hash or comparison, see utils.PasswordObserver.
+func (m *Metrics) ObservePassword(operation utils.PasswordOperation, duration time.Duration) {
+	m.passwordDuration.WithLabelValues(string(operation)).Observe(duration.Seconds())
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UserService/utils"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gatherSamples returns the value of the counters and the sample count of the histograms of the service, keyed by
+// name and labels, i.e. `user_service_logins_total{outcome="success"}`
+func gatherSamples(t *testing.T, m *Metrics) map[string]float64 {
+	t.Helper()
+
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("registry.Gather() err = %v", err)
+	}
+
+	samples := map[string]float64{}
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), namespace+"_") {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make([]string, 0, len(metric.GetLabel()))
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetName()+`="`+label.GetValue()+`"`)
+			}
+			sort.Strings(labels)
+			key := family.GetName() + "{" + strings.Join(labels, ",") + "}"
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				samples[key] = metric.GetCounter().GetValue()
+			case dto.MetricType_HISTOGRAM:
+				samples[key] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	return samples
+}
+
+func TestMetrics_RegisterRoutes(t *testing.T) {
+	m := NewMetrics(NewMetricsOptions{})
+	m.ObservePassword(utils.PasswordOperationHash, 100*time.Millisecond)
+
+	e := newEcho(m)
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, PathMetrics, nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("metrics httpStatusCode = %v, wantHttpStatusCode %v", recorder.Code, http.StatusOK)
+	}
+	for _, want := range []string{
+		`user_service_password_hash_duration_seconds_count{operation="hash"} 1`,
+		"go_goroutines",
+		"process_cpu_seconds_total",
+	} {
+		if !strings.Contains(recorder.Body.String(), want) {
+			t.Errorf("metrics body does not contain %q", want)
+		}
+	}
+
+	got := gatherSamples(t, m)
+	want := map[string]float64{
+		`user_service_http_request_duration_seconds{method="GET",route="/metrics",status="200"}`: 1,
+		`user_service_password_hash_duration_seconds{operation="hash"}`:                          1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("metrics samples = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/generated/userpb"
+	"github.com/UserService/handler"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// operation is what a request does, the domain counters are derived from the operation and the outcome of requests
+type operation int
+
+const (
+	operationOther operation = iota
+	operationRegister
+	operationLogin
+)
+
+var (
+	// httpOperations maps `METHOD route` to the operation of the request
+	httpOperations = map[string]operation{
+		"POST /v1/user":       operationRegister,
+		"POST /v2/users":      operationRegister,
+		"POST /v1/user/login": operationLogin,
+		"POST /v2/sessions":   operationLogin,
+	}
+
+	grpcOperations = map[string]operation{
+		userpb.UserService_CreateUser_FullMethodName:    operationRegister,
+		userpb.UserService_CreateSession_FullMethodName: operationLogin,
+	}
+
+	// tokenErrorCodes are the error codes of requests rejected because of their JWT
+	tokenErrorCodes = map[generated.ErrorCode]bool{
+		generated.MissingAuthorizationHeader: true,
+		generated.InvalidAuthorizationHeader: true,
+		generated.InvalidToken:               true,
+		generated.TokenExpired:               true,
+		generated.SessionRevoked:             true,
+	}
+)
+
+// requestOutcome is how a request ended, in terms of the error codes shared by the HTTP and gRPC APIs
+type requestOutcome struct {
+	operation operation
+	success   bool
+	code      generated.ErrorCode
+	conflict  bool
+
+	// admin requests are authenticated with the admin token rather than a JWT
+	admin bool
+}
+
+// Middleware returns a middleware that records the duration of HTTP requests and the domain counters derived from
+// their response. Register it with echo.Use before the other middlewares so it sees the errors they write.
+func (m *Metrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := time.Now()
+			err := next(ctx)
+
+			statusCode := ctx.Response().Status
+			if err != nil {
+				// The error is written by the error handler of echo once the middlewares returned
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					statusCode = httpErr.Code
+				} else {
+					statusCode = http.StatusInternalServerError
+				}
+			}
+
+			// Unmatched requests are grouped so scanners cannot create a series per URL
+			route := ctx.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			method := ctx.Request().Method
+			m.httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(statusCode)).Observe(time.Since(start).Seconds())
+
+			code, _ := handler.ErrorCodeFromContext(ctx)
+			m.observeOutcome(requestOutcome{
+				operation: httpOperations[method+" "+route],
+				success:   statusCode < http.StatusBadRequest,
+				code:      code,
+				conflict:  statusCode == http.StatusConflict || statusCode == http.StatusPreconditionFailed,
+				admin:     strings.HasPrefix(route, "/admin/"),
+			})
+
+			return err
+		}
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor that records the duration of gRPC calls and the domain counters
+// derived from their result, like Middleware. Register it before the other interceptors.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+
+		st := status.Convert(err)
+		m.grpcRequestDuration.WithLabelValues(info.FullMethod, st.Code().String()).Observe(time.Since(start).Seconds())
+
+		outcome := requestOutcome{
+			operation: grpcOperations[info.FullMethod],
+			success:   err == nil,
+			conflict:  st.Code() == codes.AlreadyExists || st.Code() == codes.Aborted,
+		}
+		for _, detail := range st.Details() {
+			if errorInfo, ok := detail.(*errdetails.ErrorInfo); ok {
+				outcome.code = generated.ErrorCode(errorInfo.Reason)
+			}
+		}
+		m.observeOutcome(outcome)
+
+		return resp, err
+	}
+}
+
+func (m *Metrics) observeOutcome(outcome requestOutcome) {
+	label := outcomeSuccess
+	if !outcome.success {
+		label = string(outcome.code)
+		if label == "" {
+			label = "unknown"
+		}
+	}
+
+	switch outcome.operation {
+	case operationRegister:
+		m.registrations.WithLabelValues(label).Inc()
+	case operationLogin:
+		m.logins.WithLabelValues(label).Inc()
+	}
+
+	if outcome.success {
+		return
+	}
+
+	if tokenErrorCodes[outcome.code] && !outcome.admin {
+		m.tokenValidationFailures.WithLabelValues(string(outcome.code)).Inc()
+	}
+	if outcome.conflict {
+		m.conflicts.WithLabelValues(label).Inc()
+	}
+}
@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/generated/userpb"
+	"github.com/UserService/handler"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newEcho returns an echo instrumented by m, whose routes respond with the status code and error code of the
+// `status` and `code` query parameters
+func newEcho(m *Metrics) *echo.Echo {
+	respond := func(ctx echo.Context) error {
+		switch ctx.QueryParam("status") {
+		case "":
+			return ctx.NoContent(http.StatusOK)
+		case "panic":
+			return echo.NewHTTPError(http.StatusTeapot)
+		}
+
+		var statusCode int
+		if err := echo.QueryParamsBinder(ctx).Int("status", &statusCode).BindError(); err != nil {
+			return err
+		}
+		return handler.WriteError(ctx, handler.NewError(statusCode, generated.ErrorCode(ctx.QueryParam("code"))))
+	}
+
+	e := echo.New()
+	e.Use(m.Middleware())
+	e.POST("/v1/user", respond)
+	e.POST("/v1/user/login", respond)
+	e.POST("/v2/sessions", respond)
+	e.GET("/v2/users/:id", respond)
+	e.PATCH("/admin/users/:id", respond)
+	m.RegisterRoutes(e)
+
+	return e
+}
+
+func TestMetrics_Middleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+
+		wantSamples map[string]float64
+	}{
+		{
+			name:   "success-register",
+			method: http.MethodPost,
+			target: "/v1/user",
+			wantSamples: map[string]float64{
+				`user_service_http_request_duration_seconds{method="POST",route="/v1/user",status="200"}`: 1,
+				`user_service_registrations_total{outcome="success"}`:                                     1,
+			},
+		},
+		{
+			name:   "fail-register-conflict",
+			method: http.MethodPost,
+			target: "/v1/user?status=409&code=phone_number_already_registered",
+			wantSamples: map[string]float64{
+				`user_service_http_request_duration_seconds{method="POST",route="/v1/user",status="409"}`: 1,
+				`user_service_registrations_total{outcome="phone_number_already_registered"}`:             1,
+				`user_service_conflicts_total{code="phone_number_already_registered"}`:                    1,
+			},
+		},
+		{
+			name:   "fail-login-invalid-password",
+			method: http.MethodPost,
+			target: "/v1/user/login?status=400&code=invalid_credentials",
+			wantSamples: map[string]float64{
+				`user_service_http_request_duration_seconds{method="POST",route="/v1/user/login",status="400"}`: 1,
+				`user_service_logins_total{outcome="invalid_credentials"}`:                                      1,
+			},
+		},
+		{
+			name:   "success-login-v2",
+			method: http.MethodPost,
+			target: "/v2/sessions",
+			wantSamples: map[string]float64{
+				`user_service_http_request_duration_seconds{method="POST",route="/v2/sessions",status="200"}`: 1,
+				`user_service_logins_total{outcome="success"}`:                                                1,
+			},
+		},
+		{
+			name:   "fail-login-error-without-code",
+			method: http.MethodPost,
+			target: "/v2/sessions?status=panic",
+			wantSamples: map[string]float64{
+				`user_service_http_request_duration_seconds{method="POST",route="/v2/sessions",status="418"}`: 1,
+				`user_service_logins_total{outcome="unknown"}`:                                                1,
+			},
+		},
+		{
+			name:   "fail-token-expired",
+			method: http.MethodGet,
+			target: "/v2/users/1?status=401&code=token_expired",
+			wantSamples: map[string]float64{
+				`user_service_http_request_duration_seconds{method="GET",route="/v2/users/:id",status="401"}`: 1,
+				`user_service_token_validation_failures_total{reason="token_expired"}`:                        1,
+			},
+		},
+		{
+			name:   "fail-admin-token-not-counted",
+			method: http.MethodPatch,
+			target: "/admin/users/1?status=401&code=invalid_authorization_header",
+			wantSamples: map[string]float64{
+				`user_service_http_request_duration_seconds{method="PATCH",route="/admin/users/:id",status="401"}`: 1,
+			},
+		},
+		{
+			name:   "fail-unmatched-route",
+			method: http.MethodGet,
+			target: "/wp-login.php",
+			wantSamples: map[string]float64{
+				`user_service_http_request_duration_seconds{method="GET",route="unmatched",status="404"}`: 1,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMetrics(NewMetricsOptions{})
+			e := newEcho(m)
+
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(test.method, test.target, nil))
+
+			got := gatherSamples(t, m)
+			if !reflect.DeepEqual(got, test.wantSamples) {
+				t.Errorf("Middleware() samples = %v, wantSamples %v", got, test.wantSamples)
+			}
+		})
+	}
+}
+
+func TestMetrics_UnaryServerInterceptor(t *testing.T) {
+	errorWithCode := func(c codes.Code, code generated.ErrorCode) error {
+		st, err := status.New(c, "failed").WithDetails(&errdetails.ErrorInfo{Reason: string(code)})
+		if err != nil {
+			t.Fatalf("status.WithDetails() err = %v", err)
+		}
+		return st.Err()
+	}
+
+	tests := []struct {
+		name       string
+		fullMethod string
+		err        error
+
+		wantSamples map[string]float64
+	}{
+		{
+			name:       "success-create-user",
+			fullMethod: userpb.UserService_CreateUser_FullMethodName,
+			wantSamples: map[string]float64{
+				`user_service_grpc_request_duration_seconds{code="OK",method="/userservice.v1.UserService/CreateUser"}`: 1,
+				`user_service_registrations_total{outcome="success"}`:                                                   1,
+			},
+		},
+		{
+			name:       "fail-create-user-conflict",
+			fullMethod: userpb.UserService_CreateUser_FullMethodName,
+			err:        errorWithCode(codes.AlreadyExists, generated.PhoneNumberAlreadyRegistered),
+			wantSamples: map[string]float64{
+				`user_service_grpc_request_duration_seconds{code="AlreadyExists",method="/userservice.v1.UserService/CreateUser"}`: 1,
+				`user_service_registrations_total{outcome="phone_number_already_registered"}`:                                      1,
+				`user_service_conflicts_total{code="phone_number_already_registered"}`:                                             1,
+			},
+		},
+		{
+			name:       "fail-create-session",
+			fullMethod: userpb.UserService_CreateSession_FullMethodName,
+			err:        errorWithCode(codes.InvalidArgument, generated.InvalidCredentials),
+			wantSamples: map[string]float64{
+				`user_service_grpc_request_duration_seconds{code="InvalidArgument",method="/userservice.v1.UserService/CreateSession"}`: 1,
+				`user_service_logins_total{outcome="invalid_credentials"}`:                                                              1,
+			},
+		},
+		{
+			name:       "fail-invalid-token",
+			fullMethod: userpb.UserService_GetUser_FullMethodName,
+			err:        errorWithCode(codes.Unauthenticated, generated.InvalidToken),
+			wantSamples: map[string]float64{
+				`user_service_grpc_request_duration_seconds{code="Unauthenticated",method="/userservice.v1.UserService/GetUser"}`: 1,
+				`user_service_token_validation_failures_total{reason="invalid_token"}`:                                            1,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMetrics(NewMetricsOptions{})
+
+			interceptor := m.UnaryServerInterceptor()
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: test.fullMethod},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return nil, test.err
+				})
+			if err != test.err {
+				t.Errorf("UnaryServerInterceptor() err = %v, wantErr %v", err, test.err)
+			}
+
+			got := gatherSamples(t, m)
+			if !reflect.DeepEqual(got, test.wantSamples) {
+				t.Errorf("UnaryServerInterceptor() samples = %v, wantSamples %v", got, test.wantSamples)
+			}
+		})
+	}
+}
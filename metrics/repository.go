@@ -0,0 +1,248 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/UserService/repository"
+)
+
+// instrumentedRepository records the duration of every call of the repository it decorates.
+// Add new methods of repository.RepositoryInterface here.
+type instrumentedRepository struct {
+	next    repository.RepositoryInterface
+	metrics *Metrics
+}
+
+// Repository returns repo decorated to record the duration of its calls by method and outcome
+func (m *Metrics) Repository(repo repository.RepositoryInterface) repository.RepositoryInterface {
+	return &instrumentedRepository{next: repo, metrics: m}
+}
+
+func (r *instrumentedRepository) observe(method string, start time.Time, err *error) {
+	outcome := "success"
+	var conflict repository.ErrConflict
+	switch {
+	case *err == nil:
+	case errors.Is(*err, repository.ErrNotFound):
+		outcome = "not_found"
+	case errors.Is(*err, repository.ErrVersionMismatch):
+		outcome = "version_mismatch"
+	case errors.As(*err, &conflict):
+		outcome = "conflict"
+	default:
+		outcome = "error"
+	}
+
+	r.metrics.repositoryQueryDuration.WithLabelValues(method, outcome).Observe(time.Since(start).Seconds())
+}
+
+func (r *instrumentedRepository) InsertUser(ctx context.Context, user repository.User) (userID int64, err error) {
+	defer r.observe("InsertUser", time.Now(), &err)
+	return r.next.InsertUser(ctx, user)
+}
+
+func (r *instrumentedRepository) GetUsers(ctx context.Context, request repository.UserFilter) (users []repository.User, err error) {
+	defer r.observe("GetUsers", time.Now(), &err)
+	return r.next.GetUsers(ctx, request)
+}
+
+func (r *instrumentedRepository) GetUsersByIDs(ctx context.Context, userIDs []int64) (users []repository.User, err error) {
+	defer r.observe("GetUsersByIDs", time.Now(), &err)
+	return r.next.GetUsersByIDs(ctx, userIDs)
+}
+
+func (r *instrumentedRepository) IncrementSuccessfulLoginCount(ctx context.Context, userID int64, loginTime time.Time) (err error) {
+	defer r.observe("IncrementSuccessfulLoginCount", time.Now(), &err)
+	return r.next.IncrementSuccessfulLoginCount(ctx, userID, loginTime)
+}
+
+func (r *instrumentedRepository) UpdateUser(ctx context.Context, user repository.User, actor string) (err error) {
+	defer r.observe("UpdateUser", time.Now(), &err)
+	return r.next.UpdateUser(ctx, user, actor)
+}
+
+func (r *instrumentedRepository) DeleteUser(ctx context.Context, userID int64, deletedTime time.Time, actor string) (err error) {
+	defer r.observe("DeleteUser", time.Now(), &err)
+	return r.next.DeleteUser(ctx, userID, deletedTime, actor)
+}
+
+func (r *instrumentedRepository) RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time, actor string) (err error) {
+	defer r.observe("RestoreUser", time.Now(), &err)
+	return r.next.RestoreUser(ctx, userID, deletedAfter, actor)
+}
+
+func (r *instrumentedRepository) SuspendUser(ctx context.Context, userID int64, reason string, actor string) (err error) {
+	defer r.observe("SuspendUser", time.Now(), &err)
+	return r.next.SuspendUser(ctx, userID, reason, actor)
+}
+
+func (r *instrumentedRepository) UnsuspendUser(ctx context.Context, userID int64, actor string) (err error) {
+	defer r.observe("UnsuspendUser", time.Now(), &err)
+	return r.next.UnsuspendUser(ctx, userID, actor)
+}
+
+func (r *instrumentedRepository) GetUsersToErase(ctx context.Context, deletedBefore time.Time, afterID int64, limit int) (users []repository.User, err error) {
+	defer r.observe("GetUsersToErase", time.Now(), &err)
+	return r.next.GetUsersToErase(ctx, deletedBefore, afterID, limit)
+}
+
+func (r *instrumentedRepository) EraseUser(ctx context.Context, userID int64, deletedBefore, erasedTime time.Time, actor string) (err error) {
+	defer r.observe("EraseUser", time.Now(), &err)
+	return r.next.EraseUser(ctx, userID, deletedBefore, erasedTime, actor)
+}
+
+func (r *instrumentedRepository) InsertSession(ctx context.Context, session repository.Session) (err error) {
+	defer r.observe("InsertSession", time.Now(), &err)
+	return r.next.InsertSession(ctx, session)
+}
+
+func (r *instrumentedRepository) GetSession(ctx context.Context, sessionID string) (session repository.Session, err error) {
+	defer r.observe("GetSession", time.Now(), &err)
+	return r.next.GetSession(ctx, sessionID)
+}
+
+func (r *instrumentedRepository) RevokeSession(ctx context.Context, sessionID string) (err error) {
+	defer r.observe("RevokeSession", time.Now(), &err)
+	return r.next.RevokeSession(ctx, sessionID)
+}
+
+func (r *instrumentedRepository) GetUserSessions(ctx context.Context, userID int64) (sessions []repository.Session, err error) {
+	defer r.observe("GetUserSessions", time.Now(), &err)
+	return r.next.GetUserSessions(ctx, userID)
+}
+
+func (r *instrumentedRepository) ReserveIdempotencyKey(ctx context.Context, key repository.IdempotencyKey) (reserved bool, err error) {
+	defer r.observe("ReserveIdempotencyKey", time.Now(), &err)
+	return r.next.ReserveIdempotencyKey(ctx, key)
+}
+
+func (r *instrumentedRepository) GetIdempotencyKey(ctx context.Context, id string) (key repository.IdempotencyKey, err error) {
+	defer r.observe("GetIdempotencyKey", time.Now(), &err)
+	return r.next.GetIdempotencyKey(ctx, id)
+}
+
+func (r *instrumentedRepository) CompleteIdempotencyKey(ctx context.Context, key repository.IdempotencyKey) (err error) {
+	defer r.observe("CompleteIdempotencyKey", time.Now(), &err)
+	return r.next.CompleteIdempotencyKey(ctx, key)
+}
+
+func (r *instrumentedRepository) DeleteIdempotencyKey(ctx context.Context, id string) (err error) {
+	defer r.observe("DeleteIdempotencyKey", time.Now(), &err)
+	return r.next.DeleteIdempotencyKey(ctx, id)
+}
+
+func (r *instrumentedRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (deleted int64, err error) {
+	defer r.observe("DeleteExpiredIdempotencyKeys", time.Now(), &err)
+	return r.next.DeleteExpiredIdempotencyKeys(ctx, before)
+}
+
+func (r *instrumentedRepository) InsertWebhookSubscription(ctx context.Context, subscription repository.WebhookSubscription) (subscriptionID int64, err error) {
+	defer r.observe("InsertWebhookSubscription", time.Now(), &err)
+	return r.next.InsertWebhookSubscription(ctx, subscription)
+}
+
+func (r *instrumentedRepository) GetWebhookSubscriptions(ctx context.Context, request repository.WebhookSubscriptionFilter) (subscriptions []repository.WebhookSubscription, err error) {
+	defer r.observe("GetWebhookSubscriptions", time.Now(), &err)
+	return r.next.GetWebhookSubscriptions(ctx, request)
+}
+
+func (r *instrumentedRepository) DeleteWebhookSubscription(ctx context.Context, subscriptionID int64) (err error) {
+	defer r.observe("DeleteWebhookSubscription", time.Now(), &err)
+	return r.next.DeleteWebhookSubscription(ctx, subscriptionID)
+}
+
+func (r *instrumentedRepository) InsertWebhookEvent(ctx context.Context, event repository.WebhookEvent) (err error) {
+	defer r.observe("InsertWebhookEvent", time.Now(), &err)
+	return r.next.InsertWebhookEvent(ctx, event)
+}
+
+func (r *instrumentedRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (attempts []repository.WebhookDeliveryAttempt, err error) {
+	defer r.observe("ClaimWebhookDeliveries", time.Now(), &err)
+	return r.next.ClaimWebhookDeliveries(ctx, now, limit, leaseUntil)
+}
+
+func (r *instrumentedRepository) UpdateWebhookDelivery(ctx context.Context, delivery repository.WebhookDelivery) (err error) {
+	defer r.observe("UpdateWebhookDelivery", time.Now(), &err)
+	return r.next.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func (r *instrumentedRepository) GetWebhookDeliveries(ctx context.Context, request repository.WebhookDeliveryFilter) (deliveries []repository.WebhookDelivery, err error) {
+	defer r.observe("GetWebhookDeliveries", time.Now(), &err)
+	return r.next.GetWebhookDeliveries(ctx, request)
+}
+
+func (r *instrumentedRepository) RetryWebhookDelivery(ctx context.Context, deliveryID int64) (err error) {
+	defer r.observe("RetryWebhookDelivery", time.Now(), &err)
+	return r.next.RetryWebhookDelivery(ctx, deliveryID)
+}
+
+func (r *instrumentedRepository) ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (events []repository.OutboxEvent, err error) {
+	defer r.observe("ClaimOutboxEvents", time.Now(), &err)
+	return r.next.ClaimOutboxEvents(ctx, now, limit, leaseUntil)
+}
+
+func (r *instrumentedRepository) UpdateOutboxEvent(ctx context.Context, event repository.OutboxEvent) (err error) {
+	defer r.observe("UpdateOutboxEvent", time.Now(), &err)
+	return r.next.UpdateOutboxEvent(ctx, event)
+}
+
+func (r *instrumentedRepository) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (deleted int64, err error) {
+	defer r.observe("DeletePublishedOutboxEvents", time.Now(), &err)
+	return r.next.DeletePublishedOutboxEvents(ctx, before)
+}
+
+func (r *instrumentedRepository) GetAuditLog(ctx context.Context, request repository.AuditLogFilter) (entries []repository.AuditLogEntry, err error) {
+	defer r.observe("GetAuditLog", time.Now(), &err)
+	return r.next.GetAuditLog(ctx, request)
+}
+
+func (r *instrumentedRepository) InsertUserExport(ctx context.Context, export repository.UserExport) (inserted repository.UserExport, err error) {
+	defer r.observe("InsertUserExport", time.Now(), &err)
+	return r.next.InsertUserExport(ctx, export)
+}
+
+func (r *instrumentedRepository) GetUserExport(ctx context.Context, exportID string) (export repository.UserExport, err error) {
+	defer r.observe("GetUserExport", time.Now(), &err)
+	return r.next.GetUserExport(ctx, exportID)
+}
+
+func (r *instrumentedRepository) GetUserExportArchive(ctx context.Context, exportID string) (archive []byte, err error) {
+	defer r.observe("GetUserExportArchive", time.Now(), &err)
+	return r.next.GetUserExportArchive(ctx, exportID)
+}
+
+func (r *instrumentedRepository) ClaimUserExports(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (exports []repository.UserExport, err error) {
+	defer r.observe("ClaimUserExports", time.Now(), &err)
+	return r.next.ClaimUserExports(ctx, now, limit, leaseUntil)
+}
+
+func (r *instrumentedRepository) UpdateUserExport(ctx context.Context, export repository.UserExport) (err error) {
+	defer r.observe("UpdateUserExport", time.Now(), &err)
+	return r.next.UpdateUserExport(ctx, export)
+}
+
+func (r *instrumentedRepository) ExpireUserExports(ctx context.Context, now time.Time) (expired int64, err error) {
+	defer r.observe("ExpireUserExports", time.Now(), &err)
+	return r.next.ExpireUserExports(ctx, now)
+}
+
+func (r *instrumentedRepository) VerifyAuditLog(ctx context.Context) (verification repository.AuditLogVerification, err error) {
+	defer r.observe("VerifyAuditLog", time.Now(), &err)
+	return r.next.VerifyAuditLog(ctx)
+}
+
+func (r *instrumentedRepository) Ping(ctx context.Context) (err error) {
+	defer r.observe("Ping", time.Now(), &err)
+	return r.next.Ping(ctx)
+}
+
+func (r *instrumentedRepository) GetSchemaVersion(ctx context.Context) (version int, err error) {
+	defer r.observe("GetSchemaVersion", time.Now(), &err)
+	return r.next.GetSchemaVersion(ctx)
+}
+
+func (r *instrumentedRepository) Close() error {
+	return r.next.Close()
+}
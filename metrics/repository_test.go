@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMetrics_Repository(t *testing.T) {
+	tests := []struct {
+		name     string
+		mockRepo func(mock *repository.MockRepositoryInterface)
+
+		wantErr     error
+		wantSamples map[string]float64
+	}{
+		{
+			name: "success",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertUser(gomock.Any(), repository.User{FullName: "John"}).Return(int64(1), nil)
+			},
+			wantSamples: map[string]float64{
+				`user_service_repository_query_duration_seconds{method="InsertUser",outcome="success"}`: 1,
+			},
+		},
+		{
+			name: "fail-conflict",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertUser(gomock.Any(), repository.User{FullName: "John"}).Return(int64(0), repository.ErrConflict{Field: "phone_number"})
+			},
+			wantErr: repository.ErrConflict{Field: "phone_number"},
+			wantSamples: map[string]float64{
+				`user_service_repository_query_duration_seconds{method="InsertUser",outcome="conflict"}`: 1,
+			},
+		},
+		{
+			name: "fail-unavailable",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertUser(gomock.Any(), repository.User{FullName: "John"}).Return(int64(0), repository.ErrUnavailable)
+			},
+			wantErr: repository.ErrUnavailable,
+			wantSamples: map[string]float64{
+				`user_service_repository_query_duration_seconds{method="InsertUser",outcome="error"}`: 1,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := repository.NewMockRepositoryInterface(ctrl)
+			test.mockRepo(mock)
+
+			m := NewMetrics(NewMetricsOptions{})
+			_, err := m.Repository(mock).InsertUser(context.Background(), repository.User{FullName: "John"})
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("InsertUser() err = %v, wantErr %v", err, test.wantErr)
+			}
+
+			got := gatherSamples(t, m)
+			if !reflect.DeepEqual(got, test.wantSamples) {
+				t.Errorf("Repository() samples = %v, wantSamples %v", got, test.wantSamples)
+			}
+		})
+	}
+}
+
+func TestMetrics_Repository_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := repository.NewMockRepositoryInterface(ctrl)
+	mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{}, repository.ErrNotFound)
+
+	m := NewMetrics(NewMetricsOptions{})
+	if _, err := m.Repository(mock).GetSession(context.Background(), "session"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetSession() err = %v, wantErr %v", err, repository.ErrNotFound)
+	}
+
+	want := map[string]float64{
+		`user_service_repository_query_duration_seconds{method="GetSession",outcome="not_found"}`: 1,
+	}
+	if got := gatherSamples(t, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository() samples = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event is a domain event as published to consumers. Events are published at least once: consumers should discard
+// events whose ID they already processed.
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`         // i.e. user.registered
+	AggregateID int64           `json:"aggregate_id"` // ID of the user
+	CreatedAt   time.Time       `json:"created_at"`
+	Data        json.RawMessage `json:"data"` // see repository.UserEventPayload
+}
+
+// EventPublisher publishes the events relayed from the outbox. Publish must only return nil once the event is
+// durably handed over, the event is published again otherwise.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// MultiPublisher publishes events to every publisher, i.e. to the configured publisher and to the webhook
+// subscriptions. An event is only published once every publisher accepted it, so the publishers that already did
+// get it again when it is retried.
+type MultiPublisher []EventPublisher
+
+func (p MultiPublisher) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WriterPublisher writes events to an io.Writer as JSON lines.
+type WriterPublisher struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewWriterPublisher(writer io.Writer) *WriterPublisher {
+	return &WriterPublisher{writer: writer}
+}
+
+// NewStdoutPublisher returns a publisher writing events to stdout, i.e. to be collected with the logs.
+func NewStdoutPublisher() *WriterPublisher {
+	return NewWriterPublisher(os.Stdout)
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.writer.Write(append(line, '\n'))
+	return err
+}
+
+// FilePublisher appends events to a file as JSON lines, syncing the file after every event.
+type FilePublisher struct {
+	*WriterPublisher
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilePublisher{WriterPublisher: NewWriterPublisher(file), file: file}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, event Event) error {
+	if err := p.WriterPublisher.Publish(ctx, event); err != nil {
+		return err
+	}
+
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// MemoryPublisher keeps the published events in memory, i.e. for tests.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the published events in publication order, including duplicates.
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Event(nil), p.events...)
+}
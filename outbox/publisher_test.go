@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilePublisher(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{ID: "registered", Type: "user.registered", AggregateID: 123, CreatedAt: createdAt, Data: json.RawMessage(`{"user_id":123}`)},
+		{ID: "updated", Type: "user.updated", AggregateID: 123, CreatedAt: createdAt, Data: json.RawMessage(`{"user_id":123,"full_name":"User"}`)},
+	}
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	// Events of every publisher opening the file are appended
+	for _, event := range events {
+		publisher, err := NewFilePublisher(path)
+		if err != nil {
+			t.Fatalf("outbox.NewFilePublisher() err = %v", err)
+		}
+		if err := publisher.Publish(context.Background(), event); err != nil {
+			t.Fatalf("outbox.FilePublisher.Publish() err = %v", err)
+		}
+		if err := publisher.Close(); err != nil {
+			t.Fatalf("outbox.FilePublisher.Close() err = %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile() err = %v", err)
+	}
+
+	var gotEvents []Event
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("json.Unmarshal() err = %v", err)
+		}
+		gotEvents = append(gotEvents, event)
+	}
+
+	if !reflect.DeepEqual(gotEvents, events) {
+		t.Errorf("outbox.FilePublisher events = %v, want %v", gotEvents, events)
+	}
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buffer bytes.Buffer
+	publisher := NewWriterPublisher(&buffer)
+
+	event := Event{
+		ID:          "registered",
+		Type:        "user.registered",
+		AggregateID: 123,
+		CreatedAt:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Data:        json.RawMessage(`{"user_id":123}`),
+	}
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatalf("outbox.WriterPublisher.Publish() err = %v", err)
+	}
+
+	want := `{"id":"registered","type":"user.registered","aggregate_id":123,"created_at":"2024-01-01T12:00:00Z","data":{"user_id":123}}` + "\n"
+	if got := buffer.String(); got != want {
+		t.Errorf("outbox.WriterPublisher output = %v, want %v", got, want)
+	}
+}
+
+// failingPublisher fails to publish every event
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, Event) error {
+	return errors.New("connection reset")
+}
+
+func TestMultiPublisher(t *testing.T) {
+	event := Event{ID: "registered", Type: "user.registered", AggregateID: 123, Data: json.RawMessage(`{"user_id":123}`)}
+
+	tests := []struct {
+		name    string
+		failing bool
+
+		wantErr bool
+	}{
+		{
+			name: "success-publishes-to-every-publisher",
+		},
+		{
+			name:    "fail-still-publishes-to-the-other-publishers",
+			failing: true,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, last := NewMemoryPublisher(), NewMemoryPublisher()
+			publisher := MultiPublisher{first, last}
+			if test.failing {
+				publisher = MultiPublisher{first, failingPublisher{}, last}
+			}
+
+			if err := publisher.Publish(context.Background(), event); (err != nil) != test.wantErr {
+				t.Errorf("outbox.MultiPublisher.Publish() err = %v, wantErr %v", err, test.wantErr)
+			}
+
+			for _, memory := range []*MemoryPublisher{first, last} {
+				if got := memory.Events(); !reflect.DeepEqual(got, []Event{event}) {
+					t.Errorf("outbox.MultiPublisher events = %v, want %v", got, []Event{event})
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package outbox relays the domain events written to the outbox table by the repository to an EventPublisher.
+//
+// The repository writes an event in the same transaction as the user change it is about, so events are neither lost
+// nor published for changes that were rolled back. The Relay publishes them at least once: an event is published
+// again when the relay fails to record that it was published, so consumers should deduplicate on Event.ID. Events
+// are published in the order they were written, except that an event the publisher failed on is retried after the
+// events that follow it.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/UserService/logging"
+	"github.com/UserService/repository"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+	defaultBackoff      = time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+	defaultLease        = time.Minute
+)
+
+var (
+	//define function wrappers so we can inject dummy function in UT
+	fnTimeNow func() time.Time = time.Now
+)
+
+// Relay publishes the events of the outbox. Several relays, i.e. one per replica of the service, can run against the
+// same database: events are claimed so each attempt is made by a single relay.
+type Relay struct {
+	repository   repository.RepositoryInterface
+	publisher    EventPublisher
+	batchSize    int
+	pollInterval time.Duration
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	lease        time.Duration
+}
+
+type NewRelayOptions struct {
+	Repository repository.RepositoryInterface
+	Publisher  EventPublisher
+
+	// BatchSize is how many events are claimed at once, defaults to 100.
+	BatchSize int
+
+	// PollInterval is how long Run waits for new events once the outbox is empty, defaults to 1s.
+	PollInterval time.Duration
+
+	// Backoff is how long to wait before publishing an event again once the publisher failed, doubled after every
+	// failed attempt up to MaxBackoff. Defaults to 1s and 5m. Events are retried until they are published.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Lease is how long claimed events are reserved for the relay, defaults to 1m. It must exceed the time the
+	// publisher takes to publish a batch, or events are published twice.
+	Lease time.Duration
+}
+
+func NewRelay(opts NewRelayOptions) *Relay {
+	relay := &Relay{
+		repository:   opts.Repository,
+		publisher:    opts.Publisher,
+		batchSize:    opts.BatchSize,
+		pollInterval: opts.PollInterval,
+		backoff:      opts.Backoff,
+		maxBackoff:   opts.MaxBackoff,
+		lease:        opts.Lease,
+	}
+
+	if relay.batchSize <= 0 {
+		relay.batchSize = defaultBatchSize
+	}
+	if relay.pollInterval <= 0 {
+		relay.pollInterval = defaultPollInterval
+	}
+	if relay.backoff <= 0 {
+		relay.backoff = defaultBackoff
+	}
+	if relay.maxBackoff <= 0 {
+		relay.maxBackoff = defaultMaxBackoff
+	}
+	if relay.lease <= 0 {
+		relay.lease = defaultLease
+	}
+
+	return relay
+}
+
+// Run publishes the events of the outbox until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		relayed, err := r.RelayPending(ctx)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to relay outbox events", "error", err)
+		}
+
+		// A full batch means more events are probably pending
+		if err == nil && relayed == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// RelayPending claims the events that are due and attempts to publish them once, in the order they were written.
+// It returns how many events were attempted.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	now := fnTimeNow()
+
+	events, err := r.repository.ClaimOutboxEvents(ctx, now, r.batchSize, now.Add(r.lease))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		event = r.publish(ctx, event)
+
+		if err := r.repository.UpdateOutboxEvent(ctx, event); err != nil {
+			// The event is published again once its claim expires
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to update outbox event", "event_id", event.EventID, "error", err)
+		}
+	}
+
+	return len(events), nil
+}
+
+// publish publishes the event and returns it updated with the outcome.
+func (r *Relay) publish(ctx context.Context, event repository.OutboxEvent) repository.OutboxEvent {
+	err := r.publisher.Publish(ctx, Event{
+		ID:          event.EventID,
+		Type:        event.EventType,
+		AggregateID: event.AggregateID,
+		CreatedAt:   event.CreatedTime.UTC(),
+		Data:        json.RawMessage(event.Payload),
+	})
+
+	now := fnTimeNow()
+	if err != nil {
+		event.LastError = err.Error()
+		event.NextAttemptTime = now.Add(r.retryBackoff(event.Attempts))
+		return event
+	}
+
+	event.LastError = ""
+	event.NextAttemptTime = now
+	event.PublishedTime = &now
+	return event
+}
+
+// retryBackoff returns how long to wait after the given number of failed attempts.
+func (r *Relay) retryBackoff(attempts int) time.Duration {
+	backoff := r.backoff
+	for i := 1; i < attempts && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > r.maxBackoff {
+		return r.maxBackoff
+	}
+
+	return backoff
+}
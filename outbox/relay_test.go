@@ -0,0 +1,162 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+// fakeOutbox is an in-memory stand-in for the outbox table, claiming events the way Repository.ClaimOutboxEvents does.
+type fakeOutbox struct {
+	events     []repository.OutboxEvent
+	failUpdate map[string]int // number of updates of an event to fail, by event ID
+}
+
+func (o *fakeOutbox) claim(_ context.Context, now time.Time, limit int, leaseUntil time.Time) ([]repository.OutboxEvent, error) {
+	var claimed []repository.OutboxEvent
+
+	for i := range o.events {
+		event := &o.events[i]
+		if event.PublishedTime != nil || event.NextAttemptTime.After(now) || len(claimed) == limit {
+			continue
+		}
+
+		event.Attempts++
+		event.NextAttemptTime = leaseUntil
+		claimed = append(claimed, *event)
+	}
+
+	return claimed, nil
+}
+
+func (o *fakeOutbox) update(_ context.Context, event repository.OutboxEvent) error {
+	if o.failUpdate[event.EventID] > 0 {
+		o.failUpdate[event.EventID]--
+		return repository.ErrUnavailable
+	}
+
+	for i := range o.events {
+		if o.events[i].ID == event.ID {
+			o.events[i] = event
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+// flakyPublisher fails to publish some events before handing them over to a MemoryPublisher.
+type flakyPublisher struct {
+	*MemoryPublisher
+	fail map[string]int // number of attempts of an event to fail, by event ID
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, event Event) error {
+	if p.fail[event.ID] > 0 {
+		p.fail[event.ID]--
+		return errors.New("broker unavailable")
+	}
+
+	return p.MemoryPublisher.Publish(ctx, event)
+}
+
+func TestRelay_RelayPending(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newEvents := func() []repository.OutboxEvent {
+		return []repository.OutboxEvent{
+			{ID: 1, EventID: "registered", EventType: repository.OutboxEventUserRegistered, AggregateID: 123, Payload: []byte(`{"user_id":123}`), NextAttemptTime: start, CreatedTime: start},
+			{ID: 2, EventID: "updated", EventType: repository.OutboxEventUserUpdated, AggregateID: 123, Payload: []byte(`{"user_id":123}`), NextAttemptTime: start, CreatedTime: start},
+			{ID: 3, EventID: "other", EventType: repository.OutboxEventUserRegistered, AggregateID: 456, Payload: []byte(`{"user_id":456}`), NextAttemptTime: start, CreatedTime: start},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		failPublish  map[string]int
+		failUpdate   map[string]int
+		wantEventIDs []string
+		wantAttempts []int
+	}{
+		{
+			name:         "success-in-order",
+			wantEventIDs: []string{"registered", "updated", "other"},
+			wantAttempts: []int{1, 1, 1},
+		},
+		{
+			name:         "success-retry-failed-publish",
+			failPublish:  map[string]int{"updated": 2},
+			wantEventIDs: []string{"registered", "other", "updated"},
+			wantAttempts: []int{1, 3, 1},
+		},
+		{
+			name:         "success-publish-again-when-update-fails",
+			failUpdate:   map[string]int{"registered": 1},
+			wantEventIDs: []string{"registered", "updated", "other", "registered"},
+			wantAttempts: []int{2, 1, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := start
+			fnTimeNow = func() time.Time { return now }
+			defer func() {
+				fnTimeNow = time.Now
+			}()
+
+			outbox := &fakeOutbox{events: newEvents(), failUpdate: map[string]int{}}
+			for eventID, failures := range test.failUpdate {
+				outbox.failUpdate[eventID] = failures
+			}
+			publisher := &flakyPublisher{MemoryPublisher: NewMemoryPublisher(), fail: map[string]int{}}
+			for eventID, failures := range test.failPublish {
+				publisher.fail[eventID] = failures
+			}
+
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			mock.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(outbox.claim).AnyTimes()
+			mock.EXPECT().UpdateOutboxEvent(gomock.Any(), gomock.Any()).DoAndReturn(outbox.update).AnyTimes()
+
+			relay := NewRelay(NewRelayOptions{
+				Repository: mock,
+				Publisher:  publisher,
+				Backoff:    time.Second,
+				Lease:      time.Minute,
+			})
+
+			// Let every backoff and lease expire between rounds
+			for round := 0; round < 10; round++ {
+				if _, err := relay.RelayPending(context.Background()); err != nil {
+					t.Fatalf("outbox.Relay.RelayPending() err = %v", err)
+				}
+				now = now.Add(time.Hour)
+			}
+
+			var gotEventIDs []string
+			for _, event := range publisher.Events() {
+				gotEventIDs = append(gotEventIDs, event.ID)
+			}
+			if !reflect.DeepEqual(gotEventIDs, test.wantEventIDs) {
+				t.Errorf("outbox.Relay published = %v, want %v", gotEventIDs, test.wantEventIDs)
+			}
+
+			var gotAttempts []int
+			for _, event := range outbox.events {
+				if event.PublishedTime == nil {
+					t.Errorf("outbox.Relay event %s was not marked as published", event.EventID)
+				}
+				gotAttempts = append(gotAttempts, event.Attempts)
+			}
+			if !reflect.DeepEqual(gotAttempts, test.wantAttempts) {
+				t.Errorf("outbox.Relay attempts = %v, wantAttempts %v", gotAttempts, test.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// ClaimOutboxEvents claims up to limit unpublished events that are due at now, oldest first, and counts their
+// attempt. Claimed events are not claimed again before leaseUntil, so concurrent relays do not publish the same event
+// at the same time, while events of a relay that stopped before updating them are published again.
+func (r *Repository) ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (events []OutboxEvent, err error) {
+	rows, err := r.Db.QueryContext(ctx, queryClaimOutboxEvents, now, limit, leaseUntil)
+	if err != nil {
+		return []OutboxEvent{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			event     OutboxEvent
+			lastError sql.NullString
+		)
+
+		if err := rows.Scan(
+			&event.ID,
+			&event.EventID,
+			&event.EventType,
+			&event.AggregateID,
+			&event.Payload,
+			&event.Attempts,
+			&event.NextAttemptTime,
+			&lastError,
+			&event.CreatedTime,
+			&event.PublishedTime,
+		); err != nil {
+			return []OutboxEvent{}, translateError(ctx, err)
+		}
+
+		event.LastError = lastError.String
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []OutboxEvent{}, translateError(ctx, err)
+	}
+
+	// RETURNING does not preserve the order of the claim
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	return events, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ClaimUserExports claims up to limit pending exports of active users that are due at now, and counts their attempt.
+// Claimed exports are not claimed again before leaseUntil, so concurrent workers never build the same export, while
+// exports of a worker that stopped before updating them are built again.
+func (r *Repository) ClaimUserExports(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (exports []UserExport, err error) {
+	rows, err := r.Db.QueryContext(ctx, queryClaimUserExports, now, limit, leaseUntil)
+	if err != nil {
+		return []UserExport{}, translateError(ctx, err)
+	}
+
+	exports, err = scanUserExports(rows)
+	if err != nil {
+		return []UserExport{}, translateError(ctx, err)
+	}
+
+	// RETURNING does not preserve the order of the claim
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].CreatedTime.Before(exports[j].CreatedTime)
+	})
+
+	return exports, nil
+}
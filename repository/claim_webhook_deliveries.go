@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// ClaimWebhookDeliveries claims up to limit deliveries that are due at now, at most one per subscription, and counts
+// their attempt. Claimed deliveries are not claimed again before leaseUntil, so concurrent dispatchers never send the
+// same delivery, while deliveries of a dispatcher that stopped before updating them are retried.
+func (r *Repository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (attempts []WebhookDeliveryAttempt, err error) {
+	rows, err := r.Db.QueryContext(ctx, queryClaimWebhookDeliveries, now, limit, leaseUntil)
+	if err != nil {
+		return []WebhookDeliveryAttempt{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			attempt        WebhookDeliveryAttempt
+			lastStatusCode sql.NullInt64
+			lastError      sql.NullString
+		)
+
+		if err := rows.Scan(
+			&attempt.ID,
+			&attempt.SubscriptionID,
+			&attempt.EventID,
+			&attempt.EventType,
+			&attempt.Status,
+			&attempt.Attempts,
+			&attempt.NextAttemptTime,
+			&attempt.LastAttemptTime,
+			&lastStatusCode,
+			&lastError,
+			&attempt.DeliveredTime,
+			&attempt.CreatedTime,
+			&attempt.URL,
+			&attempt.Secret,
+			&attempt.Payload,
+		); err != nil {
+			return []WebhookDeliveryAttempt{}, translateError(ctx, err)
+		}
+
+		attempt.LastStatusCode = int(lastStatusCode.Int64)
+		attempt.LastError = lastError.String
+
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []WebhookDeliveryAttempt{}, translateError(ctx, err)
+	}
+
+	// RETURNING does not preserve the order of the claim
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].ID < attempts[j].ID
+	})
+
+	return attempts, nil
+}
@@ -0,0 +1,6 @@
+package repository
+
+// Close closes the connection pool once the queries in progress are done. The repository cannot be used afterwards.
+func (r *Repository) Close() error {
+	return r.Db.Close()
+}
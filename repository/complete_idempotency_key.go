@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// CompleteIdempotencyKey stores the response of the request the key was reserved for.
+func (r *Repository) CompleteIdempotencyKey(ctx context.Context, key IdempotencyKey) error {
+	responseHeader, err := json.Marshal(key.ResponseHeader)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.Db.ExecContext(ctx, queryCompleteIdempotencyKey, key.StatusCode, responseHeader, key.ResponseBody, key.ID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means the key does not exist
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// DeleteIdempotencyKey releases the key so the request can be retried.
+func (r *Repository) DeleteIdempotencyKey(ctx context.Context, id string) error {
+	_, err := r.Db.ExecContext(ctx, queryDeleteIdempotencyKey, id)
+
+	return translateError(ctx, err)
+}
+
+// DeleteExpiredIdempotencyKeys deletes the keys that expired before the given time.
+func (r *Repository) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (deleted int64, err error) {
+	result, err := r.Db.ExecContext(ctx, queryDeleteExpiredIdempotencyKeys, before)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	deleted, err = result.RowsAffected()
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// DeletePublishedOutboxEvents deletes the events that were published before the given time.
+func (r *Repository) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (deleted int64, err error) {
+	result, err := r.Db.ExecContext(ctx, queryDeletePublishedOutboxEvents, before)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	deleted, err = result.RowsAffected()
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DeleteUser soft deletes the active user and revokes its sessions, and records the deletion in the audit log as made
+// by actor. The user is no longer returned, and can be restored with RestoreUser until it is purged.
+func (r *Repository) DeleteUser(ctx context.Context, userID int64, deletedTime time.Time, actor string) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, queryDeleteUser, deletedTime, userID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means user does not exist or is not active
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	if err := revokeUserSessions(ctx, tx, userID, deletedTime); err != nil {
+		return err
+	}
+
+	if err := insertAuditLogEntry(ctx, tx, AuditLogEntry{
+		Actor:        actor,
+		Action:       AuditActionUserDeleted,
+		TargetUserID: userID,
+		CreatedTime:  deletedTime,
+	}); err != nil {
+		return err
+	}
+
+	return translateError(ctx, tx.Commit())
+}
+
+// revokeUserSessions revokes every session of the user within tx, logging the user out of every device
+func revokeUserSessions(ctx context.Context, tx *sql.Tx, userID int64, revokedTime time.Time) error {
+	_, err := tx.ExecContext(ctx, queryRevokeUserSessions, revokedTime, userID)
+
+	return translateError(ctx, err)
+}
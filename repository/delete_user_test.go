@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRepository_DeleteUser(t *testing.T) {
+	deletedTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		mockDb func(mock sqlmock.Sqlmock)
+
+		wantErr error
+	}{
+		{
+			name: "success-revokes-sessions-and-writes-audit-log-entry",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET deleted_time = $1, version = version + 1 WHERE id = $2 AND deleted_time IS NULL AND suspended_time IS NULL`)).
+					WithArgs(deletedTime, int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "session" SET revoked_time = $1 WHERE user_id = $2 AND revoked_time IS NULL`)).
+					WithArgs(deletedTime, int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				expectInsertAuditLogEntry(mock, "a1b2").
+					WithArgs("user:123", AuditActionUserDeleted, int64(123), []byte(`{}`), deletedTime, "a1b2", sqlmock.AnyArg())
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "fail-not-active-writes-nothing",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET deleted_time`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "fail-revoke-sessions-rolls-back-deletion",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET deleted_time`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "session"`)).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db}
+
+			gotErr := repository.DeleteUser(context.Background(), 123, deletedTime, UserActor(123))
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.DeleteUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.DeleteUser() %v", err)
+			}
+		})
+	}
+}
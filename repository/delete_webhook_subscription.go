@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// DeleteWebhookSubscription marks the subscription as deleted, its deliveries are kept as the delivery log.
+func (r *Repository) DeleteWebhookSubscription(ctx context.Context, subscriptionID int64) error {
+	result, err := r.Db.ExecContext(ctx, queryDeleteWebhookSubscription, time.Now(), subscriptionID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means subscription does not exist or is already deleted
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// EraseUser erases the personal data of the user deleted before deletedBefore, and records a receipt of the erasure
+// in the audit log as made by actor. Rows referencing the user are kept, without personal data:
+//   - the user keeps its id, with no name, password or login count and a placeholder phone number that frees the number
+//   - its sessions, the history of its logins, and its exports are deleted
+//   - its webhook and outbox events are redacted to its id
+//   - the key of its audit log values is deleted, so the values of its audit log changes can no longer be decrypted
+func (r *Repository) EraseUser(ctx context.Context, userID int64, deletedBefore, erasedTime time.Time, actor string) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, queryEraseUser, erasedTime, userID, deletedBefore)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means user does not exist, was restored or is already erased
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	for _, query := range []string{
+		queryDeleteUserSessions,
+		queryDeleteUserExports,
+		queryRedactUserWebhookEvents,
+		queryRedactUserOutboxEvents,
+		queryDeleteAuditLogKey,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return translateError(ctx, err)
+		}
+	}
+
+	// The receipt has no changes, which would be encrypted with a new key of the user
+	if err := insertAuditLogEntry(ctx, tx, AuditLogEntry{
+		Actor:        actor,
+		Action:       AuditActionUserErased,
+		TargetUserID: userID,
+		CreatedTime:  erasedTime,
+	}); err != nil {
+		return err
+	}
+
+	return translateError(ctx, tx.Commit())
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRepository_EraseUser(t *testing.T) {
+	var (
+		deletedBefore = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		erasedTime    = deletedBefore.Add(time.Hour)
+	)
+
+	tests := []struct {
+		name   string
+		mockDb func(mock sqlmock.Sqlmock)
+
+		wantErr error
+	}{
+		{
+			name: "success-erases-personal-data-and-writes-receipt",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET full_name = NULL, phone_number = 'erased:' || id, "password" = '', successful_login_count = 0,
+		last_login_time = NULL, erased_time = $1, version = version + 1 WHERE id = $2 AND deleted_time <= $3 AND erased_time IS NULL`)).
+					WithArgs(erasedTime, int64(123), deletedBefore).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "session" WHERE user_id = $1`)).
+					WithArgs(int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM user_export WHERE user_id = $1`)).
+					WithArgs(int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE webhook_event SET payload = jsonb_set(payload, '{data}', jsonb_build_object('user_id', $1::int))`)).
+					WithArgs(int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 6))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE outbox SET payload = jsonb_build_object('user_id', aggregate_id) WHERE aggregate_id = $1`)).
+					WithArgs(int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM audit_log_key WHERE user_id = $1`)).
+					WithArgs(int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectInsertAuditLogEntry(mock, "a1b2").
+					WithArgs(AuditActorSystem, AuditActionUserErased, int64(123), []byte(`{}`), erasedTime, "a1b2", sqlmock.AnyArg())
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "fail-restored-or-erased-writes-nothing",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET full_name = NULL`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "fail-redact-events-rolls-back-erasure",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET full_name = NULL`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "session"`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM user_export`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE webhook_event`)).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db}
+
+			gotErr := repository.EraseUser(context.Background(), 123, deletedBefore, erasedTime, AuditActorSystem)
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.EraseUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.EraseUser() %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// This file contains the errors returned by the repository layer.
+// Callers should only depend on these errors and never on driver specific errors.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/UserService/logging"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrNotFound is returned when the requested row, i.e. a user or a session, does not exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrValidation is returned when the database rejects a value, e.g. a NOT NULL or CHECK constraint violation.
+	ErrValidation = errors.New("invalid value")
+
+	// ErrVersionMismatch is returned when a conditional update expects a version of the row that is no longer current,
+	// i.e. because the row was updated concurrently.
+	ErrVersionMismatch = errors.New("version mismatch")
+
+	// ErrUnavailable is returned when the database cannot be reached or is shutting down.
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// ErrConflict is returned when a write violates a unique constraint.
+// Field is the name of the column holding the duplicate value.
+type ErrConflict struct {
+	Field string
+}
+
+func (e ErrConflict) Error() string {
+	return fmt.Sprintf("%s already exists", e.Field)
+}
+
+// uniqueConstraintFields maps unique constraint names in database.sql to the column they guard.
+var uniqueConstraintFields = map[string]string{
+	"user_phone_number_uniquekey": "phone_number",
+}
+
+// translateError converts errors returned by database/sql and the Postgres driver into repository errors.
+// Errors that do not match any repository error are returned as is. Unavailable and unexpected errors are logged
+// with the logger of ctx, since the errors of the API do not tell what failed.
+func translateError(ctx context.Context, err error) error {
+	translated := translateDriverError(err)
+
+	var conflict ErrConflict
+	switch {
+	case translated == nil, errors.Is(translated, ErrNotFound), errors.Is(translated, ErrVersionMismatch), errors.As(translated, &conflict),
+		errors.Is(translated, ErrValidation), errors.Is(translated, context.Canceled):
+	default:
+		logging.FromContext(ctx).ErrorContext(ctx, "database query failed", "error", err)
+	}
+
+	return translated
+}
+
+func translateDriverError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	// http://godoc.org/github.com/lib/pq#Error
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "23505": // unique_violation
+			field, ok := uniqueConstraintFields[pqErr.Constraint]
+			if !ok {
+				field = pqErr.Column
+			}
+			return ErrConflict{Field: field}
+		case pqErr.Code.Class() == "22" || pqErr.Code.Class() == "23": // data_exception, integrity_constraint_violation
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		case pqErr.Code.Class() == "08" || pqErr.Code.Class() == "53" || pqErr.Code.Class() == "57": // connection_exception, insufficient_resources, operator_intervention
+			return fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func Test_translateError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input error
+
+		wantErr    error
+		wantTarget error
+	}{
+		{
+			name:    "nil",
+			input:   nil,
+			wantErr: nil,
+		},
+		{
+			name:    "no-rows",
+			input:   sql.ErrNoRows,
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "unique-violation-phone-number",
+			input:   &pq.Error{Code: "23505", Constraint: "user_phone_number_uniquekey"},
+			wantErr: ErrConflict{Field: "phone_number"},
+		},
+		{
+			name:    "unique-violation-unknown-constraint",
+			input:   &pq.Error{Code: "23505", Constraint: "some_other_key", Column: "full_name"},
+			wantErr: ErrConflict{Field: "full_name"},
+		},
+		{
+			name:       "not-null-violation",
+			input:      &pq.Error{Code: "23502"},
+			wantTarget: ErrValidation,
+		},
+		{
+			name:       "string-data-right-truncation",
+			input:      &pq.Error{Code: "22001"},
+			wantTarget: ErrValidation,
+		},
+		{
+			name:       "admin-shutdown",
+			input:      &pq.Error{Code: "57P01"},
+			wantTarget: ErrUnavailable,
+		},
+		{
+			name:       "bad-connection",
+			input:      driver.ErrBadConn,
+			wantTarget: ErrUnavailable,
+		},
+		{
+			name:    "unknown-pq-error",
+			input:   &pq.Error{Code: "42P01"},
+			wantErr: &pq.Error{Code: "42P01"},
+		},
+		{
+			name:    "unknown-error",
+			input:   errors.New("error-unknown"),
+			wantErr: errors.New("error-unknown"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotErr := translateError(context.Background(), test.input)
+
+			if test.wantTarget != nil {
+				if !errors.Is(gotErr, test.wantTarget) {
+					t.Errorf("repository.translateError() gotErr = %v, want error wrapping %v", gotErr, test.wantTarget)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(gotErr, test.wantErr) {
+				t.Errorf("repository.translateError() gotErr = %v, wantErr %v", gotErr, test.wantErr)
+			}
+		})
+	}
+}
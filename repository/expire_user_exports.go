@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// ExpireUserExports deletes the archives of the completed exports that expire at or before now, and returns how many
+// were expired.
+func (r *Repository) ExpireUserExports(ctx context.Context, now time.Time) (expired int64, err error) {
+	result, err := r.Db.ExecContext(ctx, queryExpireUserExports, now)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	expired, err = result.RowsAffected()
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"crypto/cipher"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const defaultAuditLogLimit = 50
+
+// GetAuditLog returns the audit log entries matching the filter, newest first. The values of the changes of erased
+// users are AuditValueErased.
+func (r *Repository) GetAuditLog(ctx context.Context, request AuditLogFilter) (entries []AuditLogEntry, err error) {
+	query, params := buildQueryGetAuditLog(request)
+
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return []AuditLogEntry{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		entry, err := scanAuditLogEntry(rows)
+		if err != nil {
+			return []AuditLogEntry{}, translateError(ctx, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []AuditLogEntry{}, translateError(ctx, err)
+	}
+
+	if err := r.decryptAuditLogEntries(ctx, entries); err != nil {
+		return []AuditLogEntry{}, err
+	}
+
+	return entries, nil
+}
+
+// decryptAuditLogEntries decrypts the values of the changes of the entries with the keys of their users
+func (r *Repository) decryptAuditLogEntries(ctx context.Context, entries []AuditLogEntry) error {
+	var userIDs []int64
+	for _, entry := range entries {
+		if len(entry.Changes) > 0 {
+			userIDs = append(userIDs, entry.TargetUserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	rows, err := r.Db.QueryContext(ctx, querySelectAuditLogKeys, pq.Array(userIDs))
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	ciphers := map[int64]cipher.AEAD{}
+	for rows.Next() {
+		var (
+			userID int64
+			key    []byte
+		)
+		if err := rows.Scan(&userID, &key); err != nil {
+			return translateError(ctx, err)
+		}
+
+		aead, err := newAuditLogCipher(key)
+		if err != nil {
+			return err
+		}
+		ciphers[userID] = aead
+	}
+
+	if err := rows.Err(); err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Erased users have no key left
+	for i, entry := range entries {
+		changes, err := decryptAuditChanges(ciphers[entry.TargetUserID], entry.Changes)
+		if err != nil {
+			return fmt.Errorf("invalid changes of audit log entry %d: %w", entry.ID, err)
+		}
+		entries[i].Changes = changes
+	}
+
+	return nil
+}
+
+func scanAuditLogEntry(rows *sql.Rows) (AuditLogEntry, error) {
+	var (
+		entry   AuditLogEntry
+		changes []byte
+	)
+
+	if err := rows.Scan(
+		&entry.ID,
+		&entry.Actor,
+		&entry.Action,
+		&entry.TargetUserID,
+		&changes,
+		&entry.CreatedTime,
+		&entry.PrevHash,
+		&entry.Hash,
+	); err != nil {
+		return AuditLogEntry{}, err
+	}
+
+	if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+		return AuditLogEntry{}, fmt.Errorf("invalid changes of audit log entry %d: %w", entry.ID, err)
+	}
+
+	return entry, nil
+}
+
+func buildQueryGetAuditLog(in AuditLogFilter) (string, []interface{}) {
+	var (
+		query  string = querySelectAuditLog
+		params []interface{}
+		offset int = 0
+	)
+
+	if in.TargetUserID != 0 {
+		query += fmt.Sprintf(whereAuditLogTargetUserID, offset+1)
+		params = append(
+			params,
+			in.TargetUserID,
+		)
+		offset++
+	}
+
+	if in.BeforeID != 0 {
+		query += fmt.Sprintf(whereAuditLogBeforeID, offset+1)
+		params = append(
+			params,
+			in.BeforeID,
+		)
+		offset++
+	}
+
+	limit := in.Limit
+	if limit <= 0 {
+		limit = defaultAuditLogLimit
+	}
+
+	query += fmt.Sprintf(orderAuditLogF, offset+1)
+	params = append(
+		params,
+		limit,
+	)
+	offset++
+
+	return query, params
+}
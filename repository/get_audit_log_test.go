@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestRepository_GetAuditLog(t *testing.T) {
+	createdTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	encrypted, err := encryptAuditChanges(testAuditLogKey, map[string]AuditChange{"full_name": {Before: "User", After: "New User"}})
+	if err != nil {
+		t.Fatalf("encryptAuditChanges() err = %v", err)
+	}
+	encryptedValue := encrypted["full_name"].After
+
+	columns := []string{"id", "actor", "action", "target_user_id", "changes", "created_time", "prev_hash", "hash"}
+	tests := []struct {
+		name   string
+		mockDb func(mock sqlmock.Sqlmock)
+
+		want []AuditLogEntry
+	}{
+		{
+			name: "success-decrypts-values-with-key-of-user",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, actor, action, target_user_id, changes, created_time, prev_hash, hash FROM audit_log`)).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(2, "user:123", AuditActionUserUpdated, 123, `{"full_name":{"after":"`+encryptedValue+`"}}`, createdTime, "a1b2", "c3d4"))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, key FROM audit_log_key WHERE user_id = ANY($1)`)).
+					WithArgs(pq.Array([]int64{123})).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "key"}).AddRow(123, testAuditLogKey))
+			},
+			want: []AuditLogEntry{
+				{ID: 2, Actor: "user:123", Action: AuditActionUserUpdated, TargetUserID: 123, Changes: map[string]AuditChange{"full_name": {After: "New User"}},
+					CreatedTime: createdTime, PrevHash: "a1b2", Hash: "c3d4"},
+			},
+		},
+		{
+			name: "success-values-of-erased-user-are-erased",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, actor, action, target_user_id, changes`)).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(3, AuditActorSystem, AuditActionUserErased, 123, `{}`, createdTime, "c3d4", "e5f6").
+						AddRow(2, "user:123", AuditActionUserUpdated, 123, `{"full_name":{"after":"`+encryptedValue+`"}}`, createdTime, "a1b2", "c3d4"))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, key FROM audit_log_key`)).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "key"}))
+			},
+			want: []AuditLogEntry{
+				{ID: 3, Actor: AuditActorSystem, Action: AuditActionUserErased, TargetUserID: 123, Changes: map[string]AuditChange{},
+					CreatedTime: createdTime, PrevHash: "c3d4", Hash: "e5f6"},
+				{ID: 2, Actor: "user:123", Action: AuditActionUserUpdated, TargetUserID: 123, Changes: map[string]AuditChange{"full_name": {After: AuditValueErased}},
+					CreatedTime: createdTime, PrevHash: "a1b2", Hash: "c3d4"},
+			},
+		},
+		{
+			name: "success-entries-without-changes-need-no-key",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, actor, action, target_user_id, changes`)).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, "user:123", AuditActionUserDeleted, 123, `{}`, createdTime, "", "a1b2"))
+			},
+			want: []AuditLogEntry{
+				{ID: 1, Actor: "user:123", Action: AuditActionUserDeleted, TargetUserID: 123, Changes: map[string]AuditChange{},
+					CreatedTime: createdTime, Hash: "a1b2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db}
+
+			got, err := repository.GetAuditLog(context.Background(), AuditLogFilter{TargetUserID: 123})
+			if err != nil {
+				t.Fatalf("repository.GetAuditLog() err = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("repository.GetAuditLog() = %+v, want %+v", got, test.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.GetAuditLog() %v", err)
+			}
+		})
+	}
+}
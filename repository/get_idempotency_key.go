@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+func (r *Repository) GetIdempotencyKey(ctx context.Context, id string) (key IdempotencyKey, err error) {
+	var (
+		statusCode     sql.NullInt64
+		responseHeader []byte
+	)
+
+	err = r.Db.QueryRowContext(ctx, querySelectIdempotencyKey, id).Scan(
+		&key.ID,
+		&key.Fingerprint,
+		&statusCode,
+		&responseHeader,
+		&key.ResponseBody,
+		&key.CreatedTime,
+		&key.ExpiresTime,
+	)
+	if err != nil {
+		return IdempotencyKey{}, translateError(ctx, err)
+	}
+
+	key.StatusCode = int(statusCode.Int64)
+
+	if responseHeader != nil {
+		if err := json.Unmarshal(responseHeader, &key.ResponseHeader); err != nil {
+			return IdempotencyKey{}, err
+		}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SchemaVersion is the version of database.sql this code expects, see the schema_version table
+const SchemaVersion = 8
+
+// GetSchemaVersion returns the version of the schema the database was migrated to, 0 when it was never set
+func (r *Repository) GetSchemaVersion(ctx context.Context) (version int, err error) {
+	var nullVersion sql.NullInt64
+	if err := r.Db.QueryRowContext(ctx, querySelectSchemaVersion).Scan(&nullVersion); err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return int(nullVersion.Int64), nil
+}
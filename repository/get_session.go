@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+)
+
+func (r *Repository) GetSession(ctx context.Context, sessionID string) (session Session, err error) {
+	err = r.Db.QueryRowContext(ctx, querySelectSession, sessionID).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.CreatedTime,
+		&session.ExpiresTime,
+		&session.RevokedTime,
+	)
+	if err != nil {
+		return Session{}, translateError(ctx, err)
+	}
+
+	return session, nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+)
+
+// GetUserExport returns the export of an active user, without its archive, see GetUserExportArchive.
+func (r *Repository) GetUserExport(ctx context.Context, exportID string) (export UserExport, err error) {
+	rows, err := r.Db.QueryContext(ctx, querySelectUserExport, exportID)
+	if err != nil {
+		return UserExport{}, translateError(ctx, err)
+	}
+
+	exports, err := scanUserExports(rows)
+	if err != nil {
+		return UserExport{}, translateError(ctx, err)
+	}
+
+	if len(exports) == 0 {
+		return UserExport{}, ErrNotFound
+	}
+
+	return exports[0], nil
+}
+
+// GetUserExportArchive returns the archive of the completed export of an active user.
+func (r *Repository) GetUserExportArchive(ctx context.Context, exportID string) (archive []byte, err error) {
+	err = r.Db.QueryRowContext(ctx, querySelectUserExportArchive, exportID).Scan(&archive)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+
+	return archive, nil
+}
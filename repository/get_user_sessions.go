@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+)
+
+// GetUserSessions returns every session of the user, newest first, whether it is revoked or expired or not.
+func (r *Repository) GetUserSessions(ctx context.Context, userID int64) (sessions []Session, err error) {
+	rows, err := r.Db.QueryContext(ctx, querySelectUserSessions, userID)
+	if err != nil {
+		return []Session{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.CreatedTime,
+			&session.ExpiresTime,
+			&session.RevokedTime,
+		); err != nil {
+			return []Session{}, translateError(ctx, err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Session{}, translateError(ctx, err)
+	}
+
+	return sessions, nil
+}
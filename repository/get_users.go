@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strconv"
 )
@@ -14,30 +15,48 @@ func (r *Repository) GetUsers(ctx context.Context, request UserFilter) (users []
 
 	rows, err := r.Db.QueryContext(ctx, query, params...)
 	if err != nil {
-		return []User{}, err
+		return []User{}, translateError(ctx, err)
 	}
 
 	defer rows.Close()
 	for rows.Next() {
-		user := User{}
-
-		if err := rows.Scan(
-			&user.ID,
-			&user.FullName,
-			&user.PhoneNumber,
-			&user.Password,
-			&user.CreatedTime,
-			&user.UpdatedTime,
-		); err != nil {
-			return []User{}, err
+		user, err := scanUser(rows)
+		if err != nil {
+			return []User{}, translateError(ctx, err)
 		}
 
 		users = append(users, user)
 	}
 
+	if err := rows.Err(); err != nil {
+		return []User{}, translateError(ctx, err)
+	}
+
 	return users, nil
 }
 
+// scanUser scans a row of querySelectUsers
+func scanUser(rows *sql.Rows) (User, error) {
+	var user User
+
+	err := rows.Scan(
+		&user.ID,
+		&user.FullName,
+		&user.PhoneNumber,
+		&user.Password,
+		&user.CreatedTime,
+		&user.UpdatedTime,
+		&user.SuccessfulLoginCount,
+		&user.LastLoginTime,
+		&user.Version,
+		&user.DeletedTime,
+		&user.SuspendedTime,
+		&user.SuspensionReason,
+	)
+
+	return user, err
+}
+
 func buildQueryGetUsers(in UserFilter) (string, []interface{}, error) {
 	var (
 		query  string = querySelectUsers
@@ -45,6 +64,10 @@ func buildQueryGetUsers(in UserFilter) (string, []interface{}, error) {
 		offset int = 0
 	)
 
+	if !in.IncludeInactive {
+		query += whereUserActive
+	}
+
 	if in.PhoneNumber != "" {
 		query += fmt.Sprintf(whereUserPhoneNumber, offset+1)
 		params = append(
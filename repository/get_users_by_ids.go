@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) GetUsersByIDs(ctx context.Context, userIDs []int64) (users []User, err error) {
+	query := querySelectUsers + whereUserActive + fmt.Sprintf(whereUserIDIn, 1)
+
+	rows, err := r.Db.QueryContext(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		return []User{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return []User{}, translateError(ctx, err)
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []User{}, translateError(ctx, err)
+	}
+
+	return users, nil
+}
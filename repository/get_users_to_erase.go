@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// GetUsersToErase returns the users deleted before deletedBefore that are not erased yet, see EraseUser, in id order
+// after afterID to page through them. Only their ID and DeletedTime are set.
+func (r *Repository) GetUsersToErase(ctx context.Context, deletedBefore time.Time, afterID int64, limit int) (users []User, err error) {
+	rows, err := r.Db.QueryContext(ctx, querySelectUsersToErase, deletedBefore, afterID, limit)
+	if err != nil {
+		return []User{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.DeletedTime); err != nil {
+			return []User{}, translateError(ctx, err)
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []User{}, translateError(ctx, err)
+	}
+
+	return users, nil
+}
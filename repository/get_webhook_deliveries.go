@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const defaultWebhookDeliveriesLimit = 50
+
+// GetWebhookDeliveries returns the deliveries matching the filter, newest first.
+func (r *Repository) GetWebhookDeliveries(ctx context.Context, request WebhookDeliveryFilter) (deliveries []WebhookDelivery, err error) {
+	query, params := buildQueryGetWebhookDeliveries(request)
+
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return []WebhookDelivery{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			delivery       WebhookDelivery
+			lastStatusCode sql.NullInt64
+			lastError      sql.NullString
+		)
+
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptTime,
+			&delivery.LastAttemptTime,
+			&lastStatusCode,
+			&lastError,
+			&delivery.DeliveredTime,
+			&delivery.CreatedTime,
+		); err != nil {
+			return []WebhookDelivery{}, translateError(ctx, err)
+		}
+
+		delivery.LastStatusCode = int(lastStatusCode.Int64)
+		delivery.LastError = lastError.String
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []WebhookDelivery{}, translateError(ctx, err)
+	}
+
+	return deliveries, nil
+}
+
+func buildQueryGetWebhookDeliveries(in WebhookDeliveryFilter) (string, []interface{}) {
+	var (
+		query  string = querySelectWebhookDeliveries
+		params []interface{}
+		offset int = 0
+	)
+
+	if in.SubscriptionID != 0 {
+		query += fmt.Sprintf(whereWebhookDeliverySubscriptionID, offset+1)
+		params = append(
+			params,
+			in.SubscriptionID,
+		)
+		offset++
+	}
+
+	if in.DeliveryID != 0 {
+		query += fmt.Sprintf(whereWebhookDeliveryID, offset+1)
+		params = append(
+			params,
+			in.DeliveryID,
+		)
+		offset++
+	}
+
+	if in.Status != "" {
+		query += fmt.Sprintf(whereWebhookDeliveryStatus, offset+1)
+		params = append(
+			params,
+			in.Status,
+		)
+		offset++
+	}
+
+	limit := in.Limit
+	if limit <= 0 {
+		limit = defaultWebhookDeliveriesLimit
+	}
+
+	query += fmt.Sprintf(orderWebhookDeliveriesF, offset+1)
+	params = append(
+		params,
+		limit,
+	)
+	offset++
+
+	return query, params
+}
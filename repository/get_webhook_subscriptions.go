@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) GetWebhookSubscriptions(ctx context.Context, request WebhookSubscriptionFilter) (subscriptions []WebhookSubscription, err error) {
+	query, params := buildQueryGetWebhookSubscriptions(request)
+
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return []WebhookSubscription{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		subscription := WebhookSubscription{}
+
+		if err := rows.Scan(
+			&subscription.ID,
+			&subscription.URL,
+			&subscription.Secret,
+			pq.Array(&subscription.EventTypes),
+			&subscription.CreatedTime,
+			&subscription.DeletedTime,
+		); err != nil {
+			return []WebhookSubscription{}, translateError(ctx, err)
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []WebhookSubscription{}, translateError(ctx, err)
+	}
+
+	return subscriptions, nil
+}
+
+func buildQueryGetWebhookSubscriptions(in WebhookSubscriptionFilter) (string, []interface{}) {
+	var (
+		query  string = querySelectWebhookSubscriptions
+		params []interface{}
+		offset int = 0
+	)
+
+	if in.SubscriptionID != 0 {
+		query += fmt.Sprintf(whereWebhookSubscriptionID, offset+1)
+		params = append(
+			params,
+			in.SubscriptionID,
+		)
+		offset++
+	}
+
+	query += orderWebhookSubscriptions
+
+	return query, params
+}
@@ -2,24 +2,25 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"time"
 )
 
-func (r *Repository) IncrementSuccessfulLoginCount(ctx context.Context, userID int64) error {
-	result, err := r.Db.ExecContext(ctx, queryIncrementSuccessfulLoginCount, userID)
+// IncrementSuccessfulLoginCount counts a login of the active user and records loginTime as its last login.
+func (r *Repository) IncrementSuccessfulLoginCount(ctx context.Context, userID int64, loginTime time.Time) error {
+	result, err := r.Db.ExecContext(ctx, queryIncrementSuccessfulLoginCount, userID, loginTime)
 	if err != nil {
-		return err
+		return translateError(ctx, err)
 	}
 
 	// Check the affected rows count
 	affectedRows, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return translateError(ctx, err)
 	}
 
 	// No rows updated means user does not exist
 	if affectedRows == 0 {
-		return errors.New("user not found")
+		return ErrNotFound
 	}
 
 	return nil
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// auditValueEncryptedPrefix marks the values of changes that are encrypted with the key of the user, followed by the
+// base64 nonce and ciphertext
+const auditValueEncryptedPrefix = "enc:"
+
+// insertAuditLogEntry appends the entry to the audit log within tx, so the entry is only kept if the change it is
+// about is committed. Appends are serialized until tx ends, to chain the entry to the last committed entry.
+func insertAuditLogEntry(ctx context.Context, tx *sql.Tx, entry AuditLogEntry) error {
+	// Values are encrypted with the key of the user, so they can be erased from the append-only log by deleting the
+	// key, see EraseUser. The hash covers the encrypted values, so the chain still verifies after the erasure.
+	if len(entry.Changes) > 0 {
+		key, err := upsertAuditLogKey(ctx, tx, entry.TargetUserID)
+		if err != nil {
+			return err
+		}
+
+		entry.Changes, err = encryptAuditChanges(key, entry.Changes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, queryLockAuditLog, auditLogLockID); err != nil {
+		return translateError(ctx, err)
+	}
+
+	err := tx.QueryRowContext(ctx, querySelectAuditLogHead).Scan(&entry.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return translateError(ctx, err)
+	}
+
+	// The hash covers the timestamp as stored, Postgres keeps microseconds
+	entry.CreatedTime = entry.CreatedTime.UTC().Truncate(time.Microsecond)
+	entry.Hash, err = hashAuditLogEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	// Entries without changes, i.e. deletions, store an empty object as they are hashed
+	if entry.Changes == nil {
+		entry.Changes = map[string]AuditChange{}
+	}
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, queryInsertAuditLogEntry, entry.Actor, entry.Action, entry.TargetUserID, changes,
+		entry.CreatedTime, entry.PrevHash, entry.Hash)
+
+	return translateError(ctx, err)
+}
+
+// hashAuditLogEntry returns the hex SHA-256 of the entry and the hash of the previous entry. The entry is encoded
+// as JSON with sorted keys, so the hash can be computed again from the stored columns.
+func hashAuditLogEntry(entry AuditLogEntry) (string, error) {
+	changes := entry.Changes
+	if changes == nil {
+		changes = map[string]AuditChange{}
+	}
+
+	content, err := json.Marshal(struct {
+		PrevHash     string                 `json:"prev_hash"`
+		Actor        string                 `json:"actor"`
+		Action       string                 `json:"action"`
+		TargetUserID int64                  `json:"target_user_id"`
+		Changes      map[string]AuditChange `json:"changes"`
+		CreatedTime  string                 `json:"created_time"`
+	}{
+		PrevHash:     entry.PrevHash,
+		Actor:        entry.Actor,
+		Action:       entry.Action,
+		TargetUserID: entry.TargetUserID,
+		Changes:      changes,
+		CreatedTime:  entry.CreatedTime.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// upsertAuditLogKey returns the key of the audit log values of the user within tx, creating it for the first entry of
+// the user
+func upsertAuditLogKey(ctx context.Context, tx *sql.Tx, userID int64) ([]byte, error) {
+	newKey := make([]byte, 32)
+	if _, err := rand.Read(newKey); err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	if err := tx.QueryRowContext(ctx, queryUpsertAuditLogKey, userID, newKey).Scan(&key); err != nil {
+		return nil, translateError(ctx, err)
+	}
+
+	return key, nil
+}
+
+// encryptAuditChanges returns the changes with their values encrypted with AES-GCM under key. Empty values are kept
+// empty, as they are omitted.
+func encryptAuditChanges(key []byte, changes map[string]AuditChange) (map[string]AuditChange, error) {
+	aead, err := newAuditLogCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypt := func(value string) (string, error) {
+		if value == "" {
+			return "", nil
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+
+		sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+		return auditValueEncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+	}
+
+	encrypted := make(map[string]AuditChange, len(changes))
+	for field, change := range changes {
+		before, err := encrypt(change.Before)
+		if err != nil {
+			return nil, err
+		}
+		after, err := encrypt(change.After)
+		if err != nil {
+			return nil, err
+		}
+
+		encrypted[field] = AuditChange{Before: before, After: after}
+	}
+
+	return encrypted, nil
+}
+
+// decryptAuditChanges returns the changes with their encrypted values decrypted with aead. The encrypted values are
+// replaced by AuditValueErased when aead is nil, as the key of the user was deleted.
+func decryptAuditChanges(aead cipher.AEAD, changes map[string]AuditChange) (map[string]AuditChange, error) {
+	decrypt := func(value string) (string, error) {
+		if !strings.HasPrefix(value, auditValueEncryptedPrefix) {
+			return value, nil
+		}
+		if aead == nil {
+			return AuditValueErased, nil
+		}
+
+		sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, auditValueEncryptedPrefix))
+		if err != nil || len(sealed) < aead.NonceSize() {
+			return "", errors.New("invalid encrypted audit log value")
+		}
+
+		plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt audit log value: %w", err)
+		}
+
+		return string(plaintext), nil
+	}
+
+	decrypted := make(map[string]AuditChange, len(changes))
+	for field, change := range changes {
+		before, err := decrypt(change.Before)
+		if err != nil {
+			return nil, err
+		}
+		after, err := decrypt(change.After)
+		if err != nil {
+			return nil, err
+		}
+
+		decrypted[field] = AuditChange{Before: before, After: after}
+	}
+
+	return decrypted, nil
+}
+
+func newAuditLogCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
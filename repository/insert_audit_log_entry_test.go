@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// testAuditLogKey is the key of the audit log values of the users of the tests
+var testAuditLogKey = bytes.Repeat([]byte{7}, 32)
+
+// expectUpsertAuditLogKey expects the key of the audit log values of the user to be looked up, it is testAuditLogKey
+func expectUpsertAuditLogKey(mock sqlmock.Sqlmock, userID int64) {
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO audit_log_key(user_id, key) VALUES ($1, $2)`)).
+		WithArgs(userID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow(testAuditLogKey))
+}
+
+// encryptedAuditChanges matches the changes of an audit log entry whose values are encrypted with testAuditLogKey,
+// and are the changes in the JSON once decrypted
+type encryptedAuditChanges string
+
+func (want encryptedAuditChanges) Match(value driver.Value) bool {
+	content, ok := value.([]byte)
+	if !ok {
+		return false
+	}
+
+	var changes map[string]AuditChange
+	if err := json.Unmarshal(content, &changes); err != nil {
+		return false
+	}
+	for _, change := range changes {
+		for _, value := range []string{change.Before, change.After} {
+			if value != "" && !strings.HasPrefix(value, auditValueEncryptedPrefix) {
+				return false
+			}
+		}
+	}
+
+	aead, err := newAuditLogCipher(testAuditLogKey)
+	if err != nil {
+		return false
+	}
+	decrypted, err := decryptAuditChanges(aead, changes)
+	if err != nil {
+		return false
+	}
+
+	got, err := json.Marshal(decrypted)
+	return err == nil && string(got) == string(want)
+}
+
+// expectInsertAuditLogEntry expects an entry to be appended to an audit log whose last entry has the hash prevHash
+func expectInsertAuditLogEntry(mock sqlmock.Sqlmock, prevHash string) *sqlmock.ExpectedExec {
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WithArgs(auditLogLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	head := sqlmock.NewRows([]string{"hash"})
+	if prevHash != "" {
+		head.AddRow(prevHash)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`)).WillReturnRows(head)
+
+	return mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestHashAuditLogEntry(t *testing.T) {
+	entry := AuditLogEntry{
+		Actor:        "user:123",
+		Action:       AuditActionUserUpdated,
+		TargetUserID: 123,
+		Changes:      map[string]AuditChange{"full_name": {Before: "User", After: "New User"}},
+		CreatedTime:  time.Date(2023, 10, 1, 12, 0, 0, 123456000, time.UTC),
+		PrevHash:     "a1b2",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(entry *AuditLogEntry)
+
+		wantSameHash bool
+	}{
+		{
+			name:         "same-entry-same-hash",
+			modify:       func(entry *AuditLogEntry) {},
+			wantSameHash: true,
+		},
+		{
+			name: "same-time-in-other-location-same-hash",
+			modify: func(entry *AuditLogEntry) {
+				entry.CreatedTime = entry.CreatedTime.In(time.FixedZone("WIB", 7*60*60))
+			},
+			wantSameHash: true,
+		},
+		{
+			name: "id-and-hash-are-not-hashed",
+			modify: func(entry *AuditLogEntry) {
+				entry.ID = 42
+				entry.Hash = "c3d4"
+			},
+			wantSameHash: true,
+		},
+		{
+			name:   "other-previous-hash-other-hash",
+			modify: func(entry *AuditLogEntry) { entry.PrevHash = "c3d4" },
+		},
+		{
+			name:   "other-actor-other-hash",
+			modify: func(entry *AuditLogEntry) { entry.Actor = AuditActorAdmin },
+		},
+		{
+			name: "other-change-other-hash",
+			modify: func(entry *AuditLogEntry) {
+				entry.Changes = map[string]AuditChange{"full_name": {Before: "User", After: "Other User"}}
+			},
+		},
+		{
+			name:   "other-time-other-hash",
+			modify: func(entry *AuditLogEntry) { entry.CreatedTime = entry.CreatedTime.Add(time.Microsecond) },
+		},
+	}
+
+	want, err := hashAuditLogEntry(entry)
+	if err != nil {
+		t.Fatalf("hashAuditLogEntry() err = %v", err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modified := entry
+			test.modify(&modified)
+
+			got, err := hashAuditLogEntry(modified)
+			if err != nil {
+				t.Fatalf("hashAuditLogEntry() err = %v", err)
+			}
+
+			if (got == want) != test.wantSameHash {
+				t.Errorf("hashAuditLogEntry() = %v, hash of the original entry %v, wantSameHash %v", got, want, test.wantSameHash)
+			}
+		})
+	}
+}
+
+func TestEncryptAuditChanges(t *testing.T) {
+	changes := map[string]AuditChange{
+		"full_name":    {Before: "User", After: "New User"},
+		"phone_number": {After: "+628123456789"},
+	}
+
+	encrypted, err := encryptAuditChanges(testAuditLogKey, changes)
+	if err != nil {
+		t.Fatalf("encryptAuditChanges() err = %v", err)
+	}
+	if encrypted["phone_number"].Before != "" {
+		t.Errorf("encryptAuditChanges() encrypted empty value = %q, want empty", encrypted["phone_number"].Before)
+	}
+	if encrypted["full_name"].After == changes["full_name"].After {
+		t.Errorf("encryptAuditChanges() value is not encrypted = %q", encrypted["full_name"].After)
+	}
+
+	tests := []struct {
+		name string
+		key  []byte
+
+		want    map[string]AuditChange
+		wantErr bool
+	}{
+		{
+			name: "key-of-user-decrypts",
+			key:  testAuditLogKey,
+			want: changes,
+		},
+		{
+			name: "deleted-key-erases",
+			want: map[string]AuditChange{
+				"full_name":    {Before: AuditValueErased, After: AuditValueErased},
+				"phone_number": {After: AuditValueErased},
+			},
+		},
+		{
+			name:    "fail-other-key",
+			key:     bytes.Repeat([]byte{8}, 32),
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// A nil cipher is the key of an erased user
+			var aead cipher.AEAD
+			if test.key != nil {
+				if aead, err = newAuditLogCipher(test.key); err != nil {
+					t.Fatalf("newAuditLogCipher() err = %v", err)
+				}
+			}
+
+			got, err := decryptAuditChanges(aead, encrypted)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("decryptAuditChanges() err = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("decryptAuditChanges() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// insertOutboxEvent writes an event of the user within tx, so the event is only published if the change it is about
+// is committed.
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, eventType string, payload UserEventPayload, now time.Time) error {
+	eventID, err := newOutboxEventID()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, queryInsertOutboxEvent, eventID, eventType, payload.UserID, data, now)
+
+	return translateError(ctx, err)
+}
+
+// newOutboxEventID returns a random 128-bit event ID.
+func newOutboxEventID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
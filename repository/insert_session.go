@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+)
+
+// InsertSession inserts the session of an active user, ErrNotFound is returned when the user is not active. The
+// login is written to the outbox in the same transaction.
+func (r *Repository) InsertSession(ctx context.Context, session Session) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, queryInsertSession, session.ID, session.UserID, session.CreatedTime, session.ExpiresTime)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows inserted means user does not exist or is not active, i.e. it was suspended during the login
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	// The event is only published if the session is committed
+	if err := insertOutboxEvent(ctx, tx, OutboxEventUserLoggedIn, UserEventPayload{
+		UserID:    session.UserID,
+		SessionID: session.ID,
+	}, session.CreatedTime); err != nil {
+		return err
+	}
+
+	return translateError(ctx, tx.Commit())
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRepository_InsertSession(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	session := Session{ID: "a1b2", UserID: 123, CreatedTime: now, ExpiresTime: now.Add(time.Hour)}
+
+	tests := []struct {
+		name   string
+		mockDb func(mock sqlmock.Sqlmock)
+
+		wantErr error
+	}{
+		{
+			name: "success-writes-outbox-event",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "session"(id, user_id, created_time, expires_time)`)).
+					WithArgs("a1b2", int64(123), now, now.Add(time.Hour)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WithArgs(sqlmock.AnyArg(), OutboxEventUserLoggedIn, int64(123), []byte(`{"user_id":123,"session_id":"a1b2"}`), now).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "fail-user-not-active-writes-no-outbox-event",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "session"`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "fail-outbox-event-rolls-back-session",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "session"`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db}
+
+			gotErr := repository.InsertSession(context.Background(), session)
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.InsertSession() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.InsertSession() %v", err)
+			}
+		})
+	}
+}
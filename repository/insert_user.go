@@ -5,26 +5,65 @@ import (
 	"fmt"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/UserService/utils"
 )
 
-const (
-	saltCost = 12
-)
+// DefaultBcryptCost is the cost of password hashes when Repository.BcryptCost is not set
+const DefaultBcryptCost = 12
 
 func (r *Repository) InsertUser(ctx context.Context, user User) (userID int64, err error) {
-	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(user.Password), saltCost)
+	cost := r.BcryptCost
+	if cost == 0 {
+		cost = DefaultBcryptCost
+	}
+
+	hashedPassword, err := utils.HashPassword(ctx, user.Password, cost)
 	if err != nil {
 		return userID, err
 	}
 
-	user.Password = string(hashedPasswordBytes)
+	user.Password = hashedPassword
 
 	query, params := buildQueryInsertUsers([]User{user})
 
-	err = r.Db.QueryRowContext(ctx, query, params...).Scan(&userID)
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return userID, translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, query, params...).Scan(&userID); err != nil {
+		return userID, translateError(ctx, err)
+	}
+
+	// Users register themselves
+	if err := insertAuditLogEntry(ctx, tx, AuditLogEntry{
+		Actor:        UserActor(userID),
+		Action:       AuditActionUserRegistered,
+		TargetUserID: userID,
+		Changes: map[string]AuditChange{
+			"phone_number": {After: user.PhoneNumber},
+			"full_name":    {After: user.FullName},
+		},
+		CreatedTime: time.Now(),
+	}); err != nil {
+		return 0, err
+	}
+
+	// The event is only published if the user is committed
+	if err := insertOutboxEvent(ctx, tx, OutboxEventUserRegistered, UserEventPayload{
+		UserID:      userID,
+		PhoneNumber: user.PhoneNumber,
+		FullName:    user.FullName,
+	}, time.Now()); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, translateError(ctx, err)
+	}
 
-	return
+	return userID, nil
 }
 
 func buildQueryInsertUsers(in []User) (string, []interface{}) {
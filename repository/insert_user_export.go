@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// InsertUserExport queues the export of the active user, to be claimed with ClaimUserExports. When the user already
+// has a pending export, that export is returned instead of queuing another one.
+func (r *Repository) InsertUserExport(ctx context.Context, export UserExport) (inserted UserExport, err error) {
+	rows, err := r.Db.QueryContext(ctx, queryInsertUserExport, export.ID, export.UserID, export.CreatedTime)
+	if err != nil {
+		return UserExport{}, translateError(ctx, err)
+	}
+
+	exports, err := scanUserExports(rows)
+	if err != nil {
+		return UserExport{}, translateError(ctx, err)
+	}
+
+	// No rows means the user does not exist or is not active
+	if len(exports) == 0 {
+		return UserExport{}, ErrNotFound
+	}
+
+	return exports[0], nil
+}
+
+// scanUserExports scans and closes rows of userExportColumns
+func scanUserExports(rows *sql.Rows) ([]UserExport, error) {
+	defer rows.Close()
+
+	var exports []UserExport
+	for rows.Next() {
+		var export UserExport
+		if err := rows.Scan(
+			&export.ID,
+			&export.UserID,
+			&export.Status,
+			&export.Attempts,
+			&export.NextAttemptTime,
+			&export.LastError,
+			&export.CreatedTime,
+			&export.CompletedTime,
+			&export.ExpiresTime,
+		); err != nil {
+			return nil, err
+		}
+
+		exports = append(exports, export)
+	}
+
+	return exports, rows.Err()
+}
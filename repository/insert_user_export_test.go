@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRepository_InsertUserExport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "status", "attempts", "next_attempt_time", "last_error", "created_time", "completed_time", "expires_time"}
+
+	tests := []struct {
+		name   string
+		mockDb func(mock sqlmock.Sqlmock)
+
+		wantExport UserExport
+		wantErr    error
+	}{
+		{
+			name: "success-inserted",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO user_export(id, user_id, created_time, next_attempt_time)`)).
+					WithArgs("a1b2", int64(123), now).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow("a1b2", 123, "pending", 0, now, "", now, nil, nil))
+			},
+			wantExport: UserExport{ID: "a1b2", UserID: 123, Status: UserExportPending, NextAttemptTime: now, CreatedTime: now},
+		},
+		{
+			name: "success-returns-pending-export",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM inserted UNION ALL`)).
+					WithArgs("a1b2", int64(123), now).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow("c3d4", 123, "pending", 1, now, "connection reset", now.Add(-time.Minute), nil, nil))
+			},
+			wantExport: UserExport{ID: "c3d4", UserID: 123, Status: UserExportPending, Attempts: 1, NextAttemptTime: now, LastError: "connection reset", CreatedTime: now.Add(-time.Minute)},
+		},
+		{
+			name: "fail-user-not-active",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO user_export`)).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "fail-query",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO user_export`)).
+					WillReturnError(errors.New("connection reset"))
+			},
+			wantErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db}
+
+			gotExport, gotErr := repository.InsertUserExport(context.Background(), UserExport{ID: "a1b2", UserID: 123, CreatedTime: now})
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.InsertUserExport() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(gotExport, test.wantExport) {
+				t.Errorf("repository.InsertUserExport() export = %v, want %v", gotExport, test.wantExport)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.InsertUserExport() %v", err)
+			}
+		})
+	}
+}
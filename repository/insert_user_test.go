@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashCost matches a bcrypt password hash of the given cost
+type hashCost int
+
+func (c hashCost) Match(value driver.Value) bool {
+	hash, ok := value.(string)
+	if !ok {
+		return false
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost == int(c)
+}
+
+func TestRepository_InsertUser(t *testing.T) {
+	user := User{FullName: "User", PhoneNumber: "+628123456789", Password: "P455w0rd!."}
+
+	tests := []struct {
+		name       string
+		bcryptCost int
+		mockDb     func(mock sqlmock.Sqlmock)
+
+		wantUserID int64
+		wantErr    error
+	}{
+		{
+			name: "success-writes-outbox-event-in-transaction",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user"`)).
+					WithArgs("User", "+628123456789", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(123))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "").
+					WithArgs("user:123", AuditActionUserRegistered, int64(123),
+						encryptedAuditChanges(`{"full_name":{"after":"User"},"phone_number":{"after":"+628123456789"}}`), sqlmock.AnyArg(), "", sqlmock.AnyArg())
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WithArgs(sqlmock.AnyArg(), OutboxEventUserRegistered, int64(123),
+						[]byte(`{"user_id":123,"phone_number":"+628123456789","full_name":"User"}`), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantUserID: 123,
+		},
+		{
+			name:       "success-hashes-password-with-configured-cost",
+			bcryptCost: bcrypt.MinCost,
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user"`)).
+					WithArgs("User", "+628123456789", hashCost(bcrypt.MinCost), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(123))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "a1b2")
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantUserID: 123,
+		},
+		{
+			name: "fail-outbox-event-rolls-back-user",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(123))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "a1b2")
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("connection reset"),
+		},
+		{
+			name: "fail-user-conflict-writes-no-outbox-event",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user"`)).
+					WillReturnError(ErrNotFound)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db, BcryptCost: test.bcryptCost}
+
+			gotUserID, gotErr := repository.InsertUser(context.Background(), user)
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.InsertUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if gotUserID != test.wantUserID {
+				t.Errorf("repository.InsertUser() userID = %v, wantUserID %v", gotUserID, test.wantUserID)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.InsertUser() %v", err)
+			}
+		})
+	}
+}
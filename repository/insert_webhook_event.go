@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+)
+
+// InsertWebhookEvent inserts the event and queues a delivery of it for every subscription of its type. Events that
+// were already inserted are ignored, so the relay of the outbox can insert an event again when it is retried.
+func (r *Repository) InsertWebhookEvent(ctx context.Context, event WebhookEvent) error {
+	_, err := r.Db.ExecContext(ctx, queryInsertWebhookEvent, event.ID, event.EventType, event.Payload, event.CreatedTime)
+
+	return translateError(ctx, err)
+}
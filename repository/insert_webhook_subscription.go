@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) InsertWebhookSubscription(ctx context.Context, subscription WebhookSubscription) (subscriptionID int64, err error) {
+	err = r.Db.QueryRowContext(ctx, queryInsertWebhookSubscription,
+		subscription.URL,
+		subscription.Secret,
+		pq.Array(subscription.EventTypes),
+		subscription.CreatedTime,
+	).Scan(&subscriptionID)
+
+	return subscriptionID, translateError(ctx, err)
+}
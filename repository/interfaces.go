@@ -4,11 +4,52 @@
 // interfaces using mockgen. See the Makefile for more information.
 package repository
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type RepositoryInterface interface {
 	InsertUser(ctx context.Context, user User) (userID int64, err error)
 	GetUsers(ctx context.Context, request UserFilter) (users []User, err error)
-	IncrementSuccessfulLoginCount(ctx context.Context, userID int64) error
-	UpdateUser(ctx context.Context, user User) error
+	GetUsersByIDs(ctx context.Context, userIDs []int64) (users []User, err error)
+	IncrementSuccessfulLoginCount(ctx context.Context, userID int64, loginTime time.Time) error
+	UpdateUser(ctx context.Context, user User, actor string) error
+	DeleteUser(ctx context.Context, userID int64, deletedTime time.Time, actor string) error
+	RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time, actor string) error
+	SuspendUser(ctx context.Context, userID int64, reason string, actor string) error
+	UnsuspendUser(ctx context.Context, userID int64, actor string) error
+	GetUsersToErase(ctx context.Context, deletedBefore time.Time, afterID int64, limit int) (users []User, err error)
+	EraseUser(ctx context.Context, userID int64, deletedBefore, erasedTime time.Time, actor string) error
+	InsertSession(ctx context.Context, session Session) error
+	GetSession(ctx context.Context, sessionID string) (session Session, err error)
+	RevokeSession(ctx context.Context, sessionID string) error
+	GetUserSessions(ctx context.Context, userID int64) (sessions []Session, err error)
+	ReserveIdempotencyKey(ctx context.Context, key IdempotencyKey) (reserved bool, err error)
+	GetIdempotencyKey(ctx context.Context, id string) (key IdempotencyKey, err error)
+	CompleteIdempotencyKey(ctx context.Context, key IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, id string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (deleted int64, err error)
+	InsertWebhookSubscription(ctx context.Context, subscription WebhookSubscription) (subscriptionID int64, err error)
+	GetWebhookSubscriptions(ctx context.Context, request WebhookSubscriptionFilter) (subscriptions []WebhookSubscription, err error)
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID int64) error
+	InsertWebhookEvent(ctx context.Context, event WebhookEvent) error
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (attempts []WebhookDeliveryAttempt, err error)
+	UpdateWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error
+	GetWebhookDeliveries(ctx context.Context, request WebhookDeliveryFilter) (deliveries []WebhookDelivery, err error)
+	RetryWebhookDelivery(ctx context.Context, deliveryID int64) error
+	ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (events []OutboxEvent, err error)
+	UpdateOutboxEvent(ctx context.Context, event OutboxEvent) error
+	DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (deleted int64, err error)
+	GetAuditLog(ctx context.Context, request AuditLogFilter) (entries []AuditLogEntry, err error)
+	InsertUserExport(ctx context.Context, export UserExport) (inserted UserExport, err error)
+	GetUserExport(ctx context.Context, exportID string) (export UserExport, err error)
+	GetUserExportArchive(ctx context.Context, exportID string) (archive []byte, err error)
+	ClaimUserExports(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (exports []UserExport, err error)
+	UpdateUserExport(ctx context.Context, export UserExport) error
+	ExpireUserExports(ctx context.Context, now time.Time) (expired int64, err error)
+	VerifyAuditLog(ctx context.Context) (verification AuditLogVerification, err error)
+	Ping(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (version int, err error)
+	Close() error
 }
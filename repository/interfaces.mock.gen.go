@@ -7,6 +7,7 @@ package repository
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,6 +35,285 @@ func (m *MockRepositoryInterface) EXPECT() *MockRepositoryInterfaceMockRecorder
 	return m.recorder
 }
 
+// ClaimOutboxEvents mocks base method.
+func (m *MockRepositoryInterface) ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutboxEvents", ctx, now, limit, leaseUntil)
+	ret0, _ := ret[0].([]OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutboxEvents indicates an expected call of ClaimOutboxEvents.
+func (mr *MockRepositoryInterfaceMockRecorder) ClaimOutboxEvents(ctx, now, limit, leaseUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutboxEvents", reflect.TypeOf((*MockRepositoryInterface)(nil).ClaimOutboxEvents), ctx, now, limit, leaseUntil)
+}
+
+// ClaimUserExports mocks base method.
+func (m *MockRepositoryInterface) ClaimUserExports(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimUserExports", ctx, now, limit, leaseUntil)
+	ret0, _ := ret[0].([]UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimUserExports indicates an expected call of ClaimUserExports.
+func (mr *MockRepositoryInterfaceMockRecorder) ClaimUserExports(ctx, now, limit, leaseUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimUserExports", reflect.TypeOf((*MockRepositoryInterface)(nil).ClaimUserExports), ctx, now, limit, leaseUntil)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockRepositoryInterface) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]WebhookDeliveryAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, now, limit, leaseUntil)
+	ret0, _ := ret[0].([]WebhookDeliveryAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockRepositoryInterfaceMockRecorder) ClaimWebhookDeliveries(ctx, now, limit, leaseUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockRepositoryInterface)(nil).ClaimWebhookDeliveries), ctx, now, limit, leaseUntil)
+}
+
+// Close mocks base method.
+func (m *MockRepositoryInterface) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockRepositoryInterfaceMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRepositoryInterface)(nil).Close))
+}
+
+// CompleteIdempotencyKey mocks base method.
+func (m *MockRepositoryInterface) CompleteIdempotencyKey(ctx context.Context, key IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotencyKey indicates an expected call of CompleteIdempotencyKey.
+func (mr *MockRepositoryInterfaceMockRecorder) CompleteIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyKey", reflect.TypeOf((*MockRepositoryInterface)(nil).CompleteIdempotencyKey), ctx, key)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockRepositoryInterface) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteExpiredIdempotencyKeys(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteExpiredIdempotencyKeys), ctx, before)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockRepositoryInterface) DeleteIdempotencyKey(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteIdempotencyKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteIdempotencyKey), ctx, id)
+}
+
+// DeletePublishedOutboxEvents mocks base method.
+func (m *MockRepositoryInterface) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublishedOutboxEvents", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePublishedOutboxEvents indicates an expected call of DeletePublishedOutboxEvents.
+func (mr *MockRepositoryInterfaceMockRecorder) DeletePublishedOutboxEvents(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublishedOutboxEvents", reflect.TypeOf((*MockRepositoryInterface)(nil).DeletePublishedOutboxEvents), ctx, before)
+}
+
+// DeleteUser mocks base method.
+func (m *MockRepositoryInterface) DeleteUser(ctx context.Context, userID int64, deletedTime time.Time, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID, deletedTime, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteUser(ctx, userID, deletedTime, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteUser), ctx, userID, deletedTime, actor)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockRepositoryInterface) DeleteWebhookSubscription(ctx context.Context, subscriptionID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, subscriptionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteWebhookSubscription(ctx, subscriptionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteWebhookSubscription), ctx, subscriptionID)
+}
+
+// EraseUser mocks base method.
+func (m *MockRepositoryInterface) EraseUser(ctx context.Context, userID int64, deletedBefore, erasedTime time.Time, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, userID, deletedBefore, erasedTime, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockRepositoryInterfaceMockRecorder) EraseUser(ctx, userID, deletedBefore, erasedTime, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockRepositoryInterface)(nil).EraseUser), ctx, userID, deletedBefore, erasedTime, actor)
+}
+
+// ExpireUserExports mocks base method.
+func (m *MockRepositoryInterface) ExpireUserExports(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireUserExports", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireUserExports indicates an expected call of ExpireUserExports.
+func (mr *MockRepositoryInterfaceMockRecorder) ExpireUserExports(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireUserExports", reflect.TypeOf((*MockRepositoryInterface)(nil).ExpireUserExports), ctx, now)
+}
+
+// GetAuditLog mocks base method.
+func (m *MockRepositoryInterface) GetAuditLog(ctx context.Context, request AuditLogFilter) ([]AuditLogEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLog", ctx, request)
+	ret0, _ := ret[0].([]AuditLogEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLog indicates an expected call of GetAuditLog.
+func (mr *MockRepositoryInterfaceMockRecorder) GetAuditLog(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLog", reflect.TypeOf((*MockRepositoryInterface)(nil).GetAuditLog), ctx, request)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockRepositoryInterface) GetIdempotencyKey(ctx context.Context, id string) (IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, id)
+	ret0, _ := ret[0].(IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockRepositoryInterfaceMockRecorder) GetIdempotencyKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockRepositoryInterface)(nil).GetIdempotencyKey), ctx, id)
+}
+
+// GetSchemaVersion mocks base method.
+func (m *MockRepositoryInterface) GetSchemaVersion(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchemaVersion", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchemaVersion indicates an expected call of GetSchemaVersion.
+func (mr *MockRepositoryInterfaceMockRecorder) GetSchemaVersion(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemaVersion", reflect.TypeOf((*MockRepositoryInterface)(nil).GetSchemaVersion), ctx)
+}
+
+// GetSession mocks base method.
+func (m *MockRepositoryInterface) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, sessionID)
+	ret0, _ := ret[0].(Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockRepositoryInterfaceMockRecorder) GetSession(ctx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockRepositoryInterface)(nil).GetSession), ctx, sessionID)
+}
+
+// GetUserExport mocks base method.
+func (m *MockRepositoryInterface) GetUserExport(ctx context.Context, exportID string) (UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserExport", ctx, exportID)
+	ret0, _ := ret[0].(UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserExport indicates an expected call of GetUserExport.
+func (mr *MockRepositoryInterfaceMockRecorder) GetUserExport(ctx, exportID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserExport", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUserExport), ctx, exportID)
+}
+
+// GetUserExportArchive mocks base method.
+func (m *MockRepositoryInterface) GetUserExportArchive(ctx context.Context, exportID string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserExportArchive", ctx, exportID)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserExportArchive indicates an expected call of GetUserExportArchive.
+func (mr *MockRepositoryInterfaceMockRecorder) GetUserExportArchive(ctx, exportID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserExportArchive", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUserExportArchive), ctx, exportID)
+}
+
+// GetUserSessions mocks base method.
+func (m *MockRepositoryInterface) GetUserSessions(ctx context.Context, userID int64) ([]Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSessions", ctx, userID)
+	ret0, _ := ret[0].([]Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSessions indicates an expected call of GetUserSessions.
+func (mr *MockRepositoryInterfaceMockRecorder) GetUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSessions", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUserSessions), ctx, userID)
+}
+
 // GetUsers mocks base method.
 func (m *MockRepositoryInterface) GetUsers(ctx context.Context, request UserFilter) ([]User, error) {
 	m.ctrl.T.Helper()
@@ -49,18 +329,92 @@ func (mr *MockRepositoryInterfaceMockRecorder) GetUsers(ctx, request interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUsers), ctx, request)
 }
 
+// GetUsersByIDs mocks base method.
+func (m *MockRepositoryInterface) GetUsersByIDs(ctx context.Context, userIDs []int64) ([]User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersByIDs", ctx, userIDs)
+	ret0, _ := ret[0].([]User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersByIDs indicates an expected call of GetUsersByIDs.
+func (mr *MockRepositoryInterfaceMockRecorder) GetUsersByIDs(ctx, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByIDs", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUsersByIDs), ctx, userIDs)
+}
+
+// GetUsersToErase mocks base method.
+func (m *MockRepositoryInterface) GetUsersToErase(ctx context.Context, deletedBefore time.Time, afterID int64, limit int) ([]User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersToErase", ctx, deletedBefore, afterID, limit)
+	ret0, _ := ret[0].([]User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersToErase indicates an expected call of GetUsersToErase.
+func (mr *MockRepositoryInterfaceMockRecorder) GetUsersToErase(ctx, deletedBefore, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersToErase", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUsersToErase), ctx, deletedBefore, afterID, limit)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockRepositoryInterface) GetWebhookDeliveries(ctx context.Context, request WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, request)
+	ret0, _ := ret[0].([]WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockRepositoryInterfaceMockRecorder) GetWebhookDeliveries(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockRepositoryInterface)(nil).GetWebhookDeliveries), ctx, request)
+}
+
+// GetWebhookSubscriptions mocks base method.
+func (m *MockRepositoryInterface) GetWebhookSubscriptions(ctx context.Context, request WebhookSubscriptionFilter) ([]WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscriptions", ctx, request)
+	ret0, _ := ret[0].([]WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscriptions indicates an expected call of GetWebhookSubscriptions.
+func (mr *MockRepositoryInterfaceMockRecorder) GetWebhookSubscriptions(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscriptions", reflect.TypeOf((*MockRepositoryInterface)(nil).GetWebhookSubscriptions), ctx, request)
+}
+
 // IncrementSuccessfulLoginCount mocks base method.
-func (m *MockRepositoryInterface) IncrementSuccessfulLoginCount(ctx context.Context, userID int64) error {
+func (m *MockRepositoryInterface) IncrementSuccessfulLoginCount(ctx context.Context, userID int64, loginTime time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IncrementSuccessfulLoginCount", ctx, userID)
+	ret := m.ctrl.Call(m, "IncrementSuccessfulLoginCount", ctx, userID, loginTime)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // IncrementSuccessfulLoginCount indicates an expected call of IncrementSuccessfulLoginCount.
-func (mr *MockRepositoryInterfaceMockRecorder) IncrementSuccessfulLoginCount(ctx, userID interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) IncrementSuccessfulLoginCount(ctx, userID, loginTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementSuccessfulLoginCount", reflect.TypeOf((*MockRepositoryInterface)(nil).IncrementSuccessfulLoginCount), ctx, userID, loginTime)
+}
+
+// InsertSession mocks base method.
+func (m *MockRepositoryInterface) InsertSession(ctx context.Context, session Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertSession indicates an expected call of InsertSession.
+func (mr *MockRepositoryInterfaceMockRecorder) InsertSession(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementSuccessfulLoginCount", reflect.TypeOf((*MockRepositoryInterface)(nil).IncrementSuccessfulLoginCount), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertSession", reflect.TypeOf((*MockRepositoryInterface)(nil).InsertSession), ctx, session)
 }
 
 // InsertUser mocks base method.
@@ -78,16 +432,216 @@ func (mr *MockRepositoryInterfaceMockRecorder) InsertUser(ctx, user interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUser", reflect.TypeOf((*MockRepositoryInterface)(nil).InsertUser), ctx, user)
 }
 
+// InsertUserExport mocks base method.
+func (m *MockRepositoryInterface) InsertUserExport(ctx context.Context, export UserExport) (UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertUserExport", ctx, export)
+	ret0, _ := ret[0].(UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertUserExport indicates an expected call of InsertUserExport.
+func (mr *MockRepositoryInterfaceMockRecorder) InsertUserExport(ctx, export interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUserExport", reflect.TypeOf((*MockRepositoryInterface)(nil).InsertUserExport), ctx, export)
+}
+
+// InsertWebhookEvent mocks base method.
+func (m *MockRepositoryInterface) InsertWebhookEvent(ctx context.Context, event WebhookEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertWebhookEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertWebhookEvent indicates an expected call of InsertWebhookEvent.
+func (mr *MockRepositoryInterfaceMockRecorder) InsertWebhookEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertWebhookEvent", reflect.TypeOf((*MockRepositoryInterface)(nil).InsertWebhookEvent), ctx, event)
+}
+
+// InsertWebhookSubscription mocks base method.
+func (m *MockRepositoryInterface) InsertWebhookSubscription(ctx context.Context, subscription WebhookSubscription) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertWebhookSubscription", ctx, subscription)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertWebhookSubscription indicates an expected call of InsertWebhookSubscription.
+func (mr *MockRepositoryInterfaceMockRecorder) InsertWebhookSubscription(ctx, subscription interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertWebhookSubscription", reflect.TypeOf((*MockRepositoryInterface)(nil).InsertWebhookSubscription), ctx, subscription)
+}
+
+// Ping mocks base method.
+func (m *MockRepositoryInterface) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockRepositoryInterfaceMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRepositoryInterface)(nil).Ping), ctx)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockRepositoryInterface) ReserveIdempotencyKey(ctx context.Context, key IdempotencyKey) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockRepositoryInterfaceMockRecorder) ReserveIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockRepositoryInterface)(nil).ReserveIdempotencyKey), ctx, key)
+}
+
+// RestoreUser mocks base method.
+func (m *MockRepositoryInterface) RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", ctx, userID, deletedAfter, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockRepositoryInterfaceMockRecorder) RestoreUser(ctx, userID, deletedAfter, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockRepositoryInterface)(nil).RestoreUser), ctx, userID, deletedAfter, actor)
+}
+
+// RetryWebhookDelivery mocks base method.
+func (m *MockRepositoryInterface) RetryWebhookDelivery(ctx context.Context, deliveryID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryWebhookDelivery", ctx, deliveryID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryWebhookDelivery indicates an expected call of RetryWebhookDelivery.
+func (mr *MockRepositoryInterfaceMockRecorder) RetryWebhookDelivery(ctx, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryWebhookDelivery", reflect.TypeOf((*MockRepositoryInterface)(nil).RetryWebhookDelivery), ctx, deliveryID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockRepositoryInterface) RevokeSession(ctx context.Context, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeSession(ctx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeSession), ctx, sessionID)
+}
+
+// SuspendUser mocks base method.
+func (m *MockRepositoryInterface) SuspendUser(ctx context.Context, userID int64, reason, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", ctx, userID, reason, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockRepositoryInterfaceMockRecorder) SuspendUser(ctx, userID, reason, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockRepositoryInterface)(nil).SuspendUser), ctx, userID, reason, actor)
+}
+
+// UnsuspendUser mocks base method.
+func (m *MockRepositoryInterface) UnsuspendUser(ctx context.Context, userID int64, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsuspendUser", ctx, userID, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnsuspendUser indicates an expected call of UnsuspendUser.
+func (mr *MockRepositoryInterfaceMockRecorder) UnsuspendUser(ctx, userID, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsuspendUser", reflect.TypeOf((*MockRepositoryInterface)(nil).UnsuspendUser), ctx, userID, actor)
+}
+
+// UpdateOutboxEvent mocks base method.
+func (m *MockRepositoryInterface) UpdateOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOutboxEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOutboxEvent indicates an expected call of UpdateOutboxEvent.
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateOutboxEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOutboxEvent", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateOutboxEvent), ctx, event)
+}
+
 // UpdateUser mocks base method.
-func (m *MockRepositoryInterface) UpdateUser(ctx context.Context, user User) error {
+func (m *MockRepositoryInterface) UpdateUser(ctx context.Context, user User, actor string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", ctx, user)
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, user, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockRepositoryInterfaceMockRecorder) UpdateUser(ctx, user interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateUser(ctx, user, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateUser), ctx, user, actor)
+}
+
+// UpdateUserExport mocks base method.
+func (m *MockRepositoryInterface) UpdateUserExport(ctx context.Context, export UserExport) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserExport", ctx, export)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserExport indicates an expected call of UpdateUserExport.
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateUserExport(ctx, export interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserExport", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateUserExport), ctx, export)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockRepositoryInterface) UpdateWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateWebhookDelivery), ctx, delivery)
+}
+
+// VerifyAuditLog mocks base method.
+func (m *MockRepositoryInterface) VerifyAuditLog(ctx context.Context) (AuditLogVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAuditLog", ctx)
+	ret0, _ := ret[0].(AuditLogVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAuditLog indicates an expected call of VerifyAuditLog.
+func (mr *MockRepositoryInterfaceMockRecorder) VerifyAuditLog(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateUser), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAuditLog", reflect.TypeOf((*MockRepositoryInterface)(nil).VerifyAuditLog), ctx)
 }
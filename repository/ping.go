@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+)
+
+func (r *Repository) Ping(ctx context.Context) error {
+	if err := r.Db.PingContext(ctx); err != nil {
+		return translateError(ctx, err)
+	}
+
+	return nil
+}
@@ -1,24 +1,217 @@
 package repository
 
 var (
-	queryInsertUsers         = "INSERT INTO user(full_name, phone_number, password, created_time) VALUES"
+	queryInsertUsers         = `INSERT INTO "user"(full_name, phone_number, password, created_time) VALUES`
 	valuesInsertUsersF       = "($%d, $%d, $%d, $%d),"
 	returnLastInsertedUserID = "RETURNING id"
 )
 
+// userActive is the condition of users that are neither deleted nor suspended. Every query of users and their
+// sessions excludes inactive users, except the queries that change the status of a user and GetUsers with
+// UserFilter.IncludeInactive.
+const userActive = "deleted_time IS NULL AND suspended_time IS NULL"
+
 var (
-	querySelectUsers     = "SELECT id, full_name, phone_number, password, created_time, updated_time FROM user WHERE true"
+	querySelectUsers = `SELECT id, COALESCE(full_name, ''), phone_number, password, created_time, updated_time, successful_login_count,
+		last_login_time, version, deleted_time, suspended_time, COALESCE(suspension_reason, '') FROM "user" WHERE true`
+	whereUserActive      = " AND " + userActive
 	whereUserPhoneNumber = " AND phone_number = $%d"
 	whereUserID          = " AND id = $%d"
+	whereUserIDIn        = " AND id = ANY($%d)"
 )
 
 var (
-	queryIncrementSuccessfulLoginCount = "UPDATE user SET successful_login_count = successful_login_count + 1 WHERE id = $1"
+	queryIncrementSuccessfulLoginCount = `UPDATE "user" SET successful_login_count = successful_login_count + 1, last_login_time = $2
+		WHERE id = $1 AND ` + userActive
 )
 
 var (
-	queryUpdateUserF    = "UPDATE user SET %s WHERE TRUE"
+	queryUpdateUserF    = `UPDATE "user" SET %s WHERE ` + userActive
 	setUserPhoneNumberF = "phone_number = $%d"
 	setUserFullNameF    = "full_name = $%d"
+	setUserFullNameNull = "full_name = NULL"
 	setUserUpdatedTimeF = "updated_time = $%d"
+	setUserVersion      = "version = version + 1"
+)
+
+var (
+	// Inactive users cannot log in, even when they were deactivated after their credentials were checked
+	queryInsertSession = `INSERT INTO "session"(id, user_id, created_time, expires_time)
+		SELECT $1, id, $3, $4 FROM "user" WHERE id = $2 AND ` + userActive
+	querySelectUserSessions = `SELECT id, user_id, created_time, expires_time, revoked_time FROM "session"
+		WHERE user_id = $1 ORDER BY created_time DESC`
+	querySelectSession = `SELECT session.id, session.user_id, session.created_time, session.expires_time, session.revoked_time
+		FROM "session" session JOIN "user" ON "user".id = session.user_id
+		WHERE session.id = $1 AND "user".deleted_time IS NULL AND "user".suspended_time IS NULL`
+	queryRevokeSession      = `UPDATE "session" SET revoked_time = $1 WHERE id = $2 AND revoked_time IS NULL`
+	queryRevokeUserSessions = `UPDATE "session" SET revoked_time = $1 WHERE user_id = $2 AND revoked_time IS NULL`
+)
+
+var (
+	// Expired keys are taken over by the new request
+	queryReserveIdempotencyKey = `INSERT INTO idempotency_key(id, fingerprint, created_time, expires_time) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, response_header = NULL,
+			response_body = NULL, created_time = EXCLUDED.created_time, expires_time = EXCLUDED.expires_time
+		WHERE idempotency_key.expires_time <= EXCLUDED.created_time`
+	querySelectIdempotencyKey         = `SELECT id, fingerprint, status_code, response_header, response_body, created_time, expires_time FROM idempotency_key WHERE id = $1`
+	queryCompleteIdempotencyKey       = `UPDATE idempotency_key SET status_code = $1, response_header = $2, response_body = $3 WHERE id = $4`
+	queryDeleteIdempotencyKey         = `DELETE FROM idempotency_key WHERE id = $1`
+	queryDeleteExpiredIdempotencyKeys = `DELETE FROM idempotency_key WHERE expires_time <= $1`
+)
+
+var (
+	queryInsertWebhookSubscription  = `INSERT INTO webhook_subscription(url, secret, event_types, created_time) VALUES ($1, $2, $3, $4) RETURNING id`
+	querySelectWebhookSubscriptions = `SELECT id, url, secret, event_types, created_time, deleted_time FROM webhook_subscription
+		WHERE deleted_time IS NULL`
+	whereWebhookSubscriptionID     = " AND id = $%d"
+	orderWebhookSubscriptions      = " ORDER BY id"
+	queryDeleteWebhookSubscription = `UPDATE webhook_subscription SET deleted_time = $1 WHERE id = $2 AND deleted_time IS NULL`
+)
+
+var (
+	// The event is queued for every subscription of its type in the same statement
+	queryInsertWebhookEvent = `WITH event AS (
+			INSERT INTO webhook_event(id, event_type, payload, created_time) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id) DO NOTHING
+			RETURNING id, event_type, created_time
+		)
+		INSERT INTO webhook_delivery(subscription_id, event_id, next_attempt_time, created_time)
+		SELECT subscription.id, event.id, event.created_time, event.created_time FROM webhook_subscription subscription, event
+		WHERE subscription.deleted_time IS NULL AND event.event_type = ANY(subscription.event_types)
+		ORDER BY subscription.id`
+
+	// Only the oldest pending delivery of a subscription can be claimed, so events are delivered in order. Claimed
+	// deliveries stay pending until their lease expires, hence deliveries of a crashed dispatcher are retried.
+	queryClaimWebhookDeliveries = `UPDATE webhook_delivery delivery
+		SET attempts = delivery.attempts + 1, last_attempt_time = $1, next_attempt_time = $3
+		FROM webhook_subscription subscription, webhook_event event
+		WHERE delivery.id IN (
+			SELECT pending.id FROM webhook_delivery pending
+			JOIN webhook_subscription active ON active.id = pending.subscription_id AND active.deleted_time IS NULL
+			WHERE pending.status = 'pending' AND pending.next_attempt_time <= $1
+			AND NOT EXISTS (
+				SELECT 1 FROM webhook_delivery earlier
+				WHERE earlier.subscription_id = pending.subscription_id AND earlier.status = 'pending' AND earlier.id < pending.id
+			)
+			ORDER BY pending.id LIMIT $2
+			FOR UPDATE OF pending SKIP LOCKED
+		)
+		AND subscription.id = delivery.subscription_id AND event.id = delivery.event_id
+		RETURNING delivery.id, delivery.subscription_id, delivery.event_id, event.event_type, delivery.status,
+			delivery.attempts, delivery.next_attempt_time, delivery.last_attempt_time, delivery.last_status_code,
+			delivery.last_error, delivery.delivered_time, delivery.created_time, subscription.url, subscription.secret,
+			event.payload`
+	queryUpdateWebhookDelivery = `UPDATE webhook_delivery SET status = $1, next_attempt_time = $2, last_status_code = $3,
+		last_error = $4, delivered_time = $5 WHERE id = $6`
+	queryRetryWebhookDelivery = `UPDATE webhook_delivery SET status = 'pending', attempts = 0, next_attempt_time = $1
+		WHERE id = $2 AND status = 'dead'`
+)
+
+var (
+	querySelectWebhookDeliveries = `SELECT delivery.id, delivery.subscription_id, delivery.event_id, event.event_type,
+		delivery.status, delivery.attempts, delivery.next_attempt_time, delivery.last_attempt_time, delivery.last_status_code,
+		delivery.last_error, delivery.delivered_time, delivery.created_time
+		FROM webhook_delivery delivery JOIN webhook_event event ON event.id = delivery.event_id WHERE true`
+	whereWebhookDeliverySubscriptionID = " AND delivery.subscription_id = $%d"
+	whereWebhookDeliveryID             = " AND delivery.id = $%d"
+	whereWebhookDeliveryStatus         = " AND delivery.status = $%d"
+	orderWebhookDeliveriesF            = " ORDER BY delivery.id DESC LIMIT $%d"
+)
+
+var (
+	queryInsertOutboxEvent = `INSERT INTO outbox(event_id, event_type, aggregate_id, payload, next_attempt_time, created_time)
+		VALUES ($1, $2, $3, $4, $5, $5)`
+
+	// Claimed events are not claimed again before their lease expires, hence events of a crashed relay are retried
+	queryClaimOutboxEvents = `UPDATE outbox SET attempts = attempts + 1, next_attempt_time = $3
+		WHERE id IN (
+			SELECT id FROM outbox WHERE published_time IS NULL AND next_attempt_time <= $1
+			ORDER BY id LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_id, event_type, aggregate_id, payload, attempts, next_attempt_time, last_error, created_time, published_time`
+	queryUpdateOutboxEvent           = `UPDATE outbox SET next_attempt_time = $1, last_error = $2, published_time = $3 WHERE id = $4`
+	queryDeletePublishedOutboxEvents = `DELETE FROM outbox WHERE published_time <= $1`
+)
+
+var (
+	querySelectSchemaVersion = `SELECT max(version) FROM schema_version`
+)
+
+var (
+	// auditLogLockID is the key of the advisory lock that serializes appends to the audit log, so every entry is
+	// chained to the entry committed before it
+	auditLogLockID = 7271001
+
+	queryLockAuditLog        = `SELECT pg_advisory_xact_lock($1)`
+	querySelectAuditLogHead  = `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`
+	queryInsertAuditLogEntry = `INSERT INTO audit_log(actor, action, target_user_id, changes, created_time, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	querySelectAuditLog       = `SELECT id, actor, action, target_user_id, changes, created_time, prev_hash, hash FROM audit_log WHERE true`
+	whereAuditLogTargetUserID = " AND target_user_id = $%d"
+	whereAuditLogBeforeID     = " AND id < $%d"
+	orderAuditLogF            = " ORDER BY id DESC LIMIT $%d"
+	orderAuditLogChain        = " ORDER BY id"
+
+	queryUpsertAuditLogKey = `INSERT INTO audit_log_key(user_id, key) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET key = audit_log_key.key RETURNING key`
+	querySelectAuditLogKeys = `SELECT user_id, key FROM audit_log_key WHERE user_id = ANY($1)`
+
+	querySelectUserForUpdate = `SELECT phone_number, COALESCE(full_name, ''), version FROM "user" WHERE id = $1 AND ` + userActive + ` FOR UPDATE`
+)
+
+var (
+	// Deleting, restoring and erasing users are the only queries of deleted users, restoring within the grace period
+	// and erasing after it
+	queryDeleteUser  = `UPDATE "user" SET deleted_time = $1, version = version + 1 WHERE id = $2 AND ` + userActive
+	queryRestoreUser = `UPDATE "user" SET deleted_time = NULL, version = version + 1
+		WHERE id = $1 AND deleted_time > $2 AND erased_time IS NULL`
+	querySelectUsersToErase = `SELECT id, deleted_time FROM "user" WHERE deleted_time <= $1 AND erased_time IS NULL AND id > $2
+		ORDER BY id LIMIT $3`
+	queryEraseUser = `UPDATE "user" SET full_name = NULL, phone_number = 'erased:' || id, "password" = '', successful_login_count = 0,
+		last_login_time = NULL, erased_time = $1, version = version + 1 WHERE id = $2 AND deleted_time <= $3 AND erased_time IS NULL`
+	queryDeleteUserSessions      = `DELETE FROM "session" WHERE user_id = $1`
+	queryRedactUserWebhookEvents = `UPDATE webhook_event SET payload = jsonb_set(payload, '{data}', jsonb_build_object('user_id', $1::int))
+		WHERE payload->'data'->'user_id' = to_jsonb($1::int)`
+	queryRedactUserOutboxEvents = `UPDATE outbox SET payload = jsonb_build_object('user_id', aggregate_id) WHERE aggregate_id = $1`
+	queryDeleteAuditLogKey      = `DELETE FROM audit_log_key WHERE user_id = $1`
+	queryDeleteUserExports      = `DELETE FROM user_export WHERE user_id = $1`
+
+	// Suspensions apply to users that are not deleted, whether they are suspended already or not
+	querySelectUserSuspensionForUpdate = `SELECT suspended_time IS NOT NULL, COALESCE(suspension_reason, '') FROM "user"
+		WHERE id = $1 AND deleted_time IS NULL FOR UPDATE`
+	querySuspendUser = `UPDATE "user" SET suspended_time = COALESCE(suspended_time, $1), suspension_reason = $2,
+		version = version + 1 WHERE id = $3`
+	queryUnsuspendUser = `UPDATE "user" SET suspended_time = NULL, suspension_reason = NULL, version = version + 1 WHERE id = $1`
+)
+
+// Exports of inactive users are neither built nor served, like the users themselves
+var (
+	userExportColumns = `id, user_id, status, attempts, next_attempt_time, COALESCE(last_error, ''), created_time,
+		completed_time, expires_time`
+	whereUserExportOfActiveUser = ` AND user_id IN (SELECT id FROM "user" WHERE ` + userActive + `)`
+
+	// The pending export of the user is returned instead when there is one, the select does not see the inserted row
+	queryInsertUserExport = `WITH inserted AS (
+			INSERT INTO user_export(id, user_id, created_time, next_attempt_time)
+			SELECT $1, id, $3, $3 FROM "user" WHERE id = $2 AND ` + userActive + `
+			ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+			RETURNING ` + userExportColumns + `
+		)
+		SELECT * FROM inserted
+		UNION ALL
+		SELECT ` + userExportColumns + ` FROM user_export WHERE user_id = $2 AND status = 'pending'
+		LIMIT 1`
+	querySelectUserExport        = `SELECT ` + userExportColumns + ` FROM user_export WHERE id = $1` + whereUserExportOfActiveUser
+	querySelectUserExportArchive = `SELECT archive FROM user_export WHERE id = $1 AND status = 'completed'` + whereUserExportOfActiveUser
+	queryClaimUserExports        = `UPDATE user_export SET attempts = attempts + 1, next_attempt_time = $3
+		WHERE id IN (
+			SELECT id FROM user_export WHERE status = 'pending' AND next_attempt_time <= $1` + whereUserExportOfActiveUser + `
+			ORDER BY next_attempt_time LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + userExportColumns
+	queryUpdateUserExport = `UPDATE user_export SET status = $1, next_attempt_time = $2, last_error = $3, archive = $4,
+		completed_time = $5, expires_time = $6 WHERE id = $7`
+	queryExpireUserExports = `UPDATE user_export SET status = 'expired', archive = NULL WHERE status = 'completed' AND expires_time <= $1`
 )
@@ -9,10 +9,16 @@ import (
 
 type Repository struct {
 	Db *sql.DB
+
+	// BcryptCost is the cost of password hashes, defaults to DefaultBcryptCost
+	BcryptCost int
 }
 
 type NewRepositoryOptions struct {
 	Dsn string
+
+	// BcryptCost is the cost of password hashes, defaults to DefaultBcryptCost
+	BcryptCost int
 }
 
 func NewRepository(opts NewRepositoryOptions) *Repository {
@@ -21,6 +27,7 @@ func NewRepository(opts NewRepositoryOptions) *Repository {
 		panic(err)
 	}
 	return &Repository{
-		Db: db,
+		Db:         db,
+		BcryptCost: opts.BcryptCost,
 	}
 }
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+)
+
+// ReserveIdempotencyKey inserts the key, marking its request as in progress. It returns false when the key is
+// already used by a request that has not expired.
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, key IdempotencyKey) (reserved bool, err error) {
+	result, err := r.Db.ExecContext(ctx, queryReserveIdempotencyKey, key.ID, key.Fingerprint, key.CreatedTime, key.ExpiresTime)
+	if err != nil {
+		return false, translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, translateError(ctx, err)
+	}
+
+	return affectedRows > 0, nil
+}
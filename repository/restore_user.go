@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RestoreUser restores the user if it was deleted after deletedAfter, the start of the grace period, and records the
+// restoration in the audit log as made by actor. ErrNotFound is returned for users that are not deleted or were
+// deleted before the grace period.
+func (r *Repository) RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time, actor string) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, queryRestoreUser, userID, deletedAfter)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means user does not exist, is not deleted or its grace period is over
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	if err := insertAuditLogEntry(ctx, tx, AuditLogEntry{
+		Actor:        actor,
+		Action:       AuditActionUserRestored,
+		TargetUserID: userID,
+		CreatedTime:  time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	return translateError(ctx, tx.Commit())
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RetryWebhookDelivery queues a dead delivery again, with a fresh set of attempts.
+func (r *Repository) RetryWebhookDelivery(ctx context.Context, deliveryID int64) error {
+	result, err := r.Db.ExecContext(ctx, queryRetryWebhookDelivery, time.Now(), deliveryID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means delivery does not exist or is not dead
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
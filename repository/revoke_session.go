@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+func (r *Repository) RevokeSession(ctx context.Context, sessionID string) error {
+	result, err := r.Db.ExecContext(ctx, queryRevokeSession, time.Now(), sessionID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means session does not exist or is already revoked
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
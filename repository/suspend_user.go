@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// SuspendUser suspends the user that is not deleted for reason and revokes its sessions, and records the suspension
+// in the audit log as made by actor. Suspending a suspended user replaces the reason of its suspension.
+func (r *Repository) SuspendUser(ctx context.Context, userID int64, reason string, actor string) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Lock the user until the suspension is committed, so the audit log records the reason it replaced
+	var (
+		suspended    bool
+		beforeReason string
+	)
+	if err := tx.QueryRowContext(ctx, querySelectUserSuspensionForUpdate, userID).Scan(&suspended, &beforeReason); err != nil {
+		return translateError(ctx, err)
+	}
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, querySuspendUser, now, reason, userID); err != nil {
+		return translateError(ctx, err)
+	}
+
+	if err := revokeUserSessions(ctx, tx, userID, now); err != nil {
+		return err
+	}
+
+	if !suspended || reason != beforeReason {
+		if err := insertAuditLogEntry(ctx, tx, AuditLogEntry{
+			Actor:        actor,
+			Action:       AuditActionUserSuspended,
+			TargetUserID: userID,
+			Changes:      map[string]AuditChange{"suspension_reason": {Before: beforeReason, After: reason}},
+			CreatedTime:  now,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return translateError(ctx, tx.Commit())
+}
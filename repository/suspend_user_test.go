@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRepository_SuspendUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		mockDb func(mock sqlmock.Sqlmock)
+
+		wantErr error
+	}{
+		{
+			name:   "success-revokes-sessions-and-writes-audit-log-entry",
+			reason: "Fraud",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT suspended_time IS NOT NULL, COALESCE(suspension_reason, '') FROM "user"
+		WHERE id = $1 AND deleted_time IS NULL FOR UPDATE`)).
+					WithArgs(int64(123)).
+					WillReturnRows(sqlmock.NewRows([]string{"suspended", "suspension_reason"}).AddRow(false, ""))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET suspended_time = COALESCE(suspended_time, $1), suspension_reason = $2`)).
+					WithArgs(sqlmock.AnyArg(), "Fraud", int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "session" SET revoked_time = $1 WHERE user_id = $2`)).
+					WithArgs(sqlmock.AnyArg(), int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "a1b2").
+					WithArgs(AuditActorAdmin, AuditActionUserSuspended, int64(123), encryptedAuditChanges(`{"suspension_reason":{"after":"Fraud"}}`),
+						sqlmock.AnyArg(), "a1b2", sqlmock.AnyArg())
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "success-replaces-reason",
+			reason: "Chargebacks",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT suspended_time IS NOT NULL`)).
+					WillReturnRows(sqlmock.NewRows([]string{"suspended", "suspension_reason"}).AddRow(true, "Fraud"))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET suspended_time`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "session"`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "a1b2").
+					WithArgs(AuditActorAdmin, AuditActionUserSuspended, int64(123), encryptedAuditChanges(`{"suspension_reason":{"before":"Fraud","after":"Chargebacks"}}`),
+						sqlmock.AnyArg(), "a1b2", sqlmock.AnyArg())
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "success-same-reason-writes-no-audit-log-entry",
+			reason: "Fraud",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT suspended_time IS NOT NULL`)).
+					WillReturnRows(sqlmock.NewRows([]string{"suspended", "suspension_reason"}).AddRow(true, "Fraud"))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET suspended_time`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "session"`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "fail-not-found",
+			reason: "Fraud",
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT suspended_time IS NOT NULL`)).
+					WillReturnRows(sqlmock.NewRows([]string{"suspended", "suspension_reason"}))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db}
+
+			gotErr := repository.SuspendUser(context.Background(), 123, test.reason, AuditActorAdmin)
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.SuspendUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.SuspendUser() %v", err)
+			}
+		})
+	}
+}
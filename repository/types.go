@@ -1,7 +1,10 @@
 // This file contains types that are used in the repository layer.
 package repository
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type GetTestByIdInput struct {
 	Id string
@@ -13,14 +16,226 @@ type GetTestByIdOutput struct {
 
 type User struct {
 	ID          int64      `db:"id"`
-	FullName    string     `db:"full_name"`
+	FullName    string     `db:"full_name"` // empty when the user removed it
 	PhoneNumber string     `db:"phone_number"`
 	Password    string     `db:"password"`
 	CreatedTime time.Time  `db:"created_time"`
 	UpdatedTime *time.Time `db:"updated_time"`
+	Version     int64      `db:"version"` // incremented by every update, see UpdateUser
+
+	// Updated by IncrementSuccessfulLoginCount
+	SuccessfulLoginCount int64      `db:"successful_login_count"`
+	LastLoginTime        *time.Time `db:"last_login_time"`
+
+	// Inactive users are only returned by GetUsers with UserFilter.IncludeInactive
+	DeletedTime      *time.Time `db:"deleted_time"` // the user can be restored for a grace period, see RestoreUser
+	SuspendedTime    *time.Time `db:"suspended_time"`
+	SuspensionReason string     `db:"suspension_reason"`
+
+	// ClearFullName makes UpdateUser remove the full name of the user, the only optional field of the profile
+	ClearFullName bool `db:"-"`
 }
 
 type UserFilter struct {
 	UserID      int64  `db:"user_id"`
 	PhoneNumber string `db:"phone_number"`
+
+	// IncludeInactive also returns deleted and suspended users, i.e. to tell them why they cannot log in
+	IncludeInactive bool
+}
+
+type Session struct {
+	ID          string     `db:"id"`
+	UserID      int64      `db:"user_id"`
+	CreatedTime time.Time  `db:"created_time"`
+	ExpiresTime time.Time  `db:"expires_time"`
+	RevokedTime *time.Time `db:"revoked_time"`
+}
+
+// IdempotencyKey holds the response of a request sent with an Idempotency-Key header.
+type IdempotencyKey struct {
+	ID             string              `db:"id"`
+	Fingerprint    string              `db:"fingerprint"`     // hash of the request the key was first used with
+	StatusCode     int                 `db:"status_code"`     // 0 while the request is in progress
+	ResponseHeader map[string][]string `db:"response_header"` // stored as JSON
+	ResponseBody   []byte              `db:"response_body"`
+	CreatedTime    time.Time           `db:"created_time"`
+	ExpiresTime    time.Time           `db:"expires_time"`
+}
+
+// WebhookSubscription is a subscription of another system to user lifecycle events.
+type WebhookSubscription struct {
+	ID          int64      `db:"id"`
+	URL         string     `db:"url"`
+	Secret      string     `db:"secret"`      // key of the HMAC signature of the deliveries
+	EventTypes  []string   `db:"event_types"` // stored as text[]
+	CreatedTime time.Time  `db:"created_time"`
+	DeletedTime *time.Time `db:"deleted_time"`
+}
+
+// WebhookSubscriptionFilter selects subscriptions that have not been deleted.
+type WebhookSubscriptionFilter struct {
+	SubscriptionID int64 `db:"id"`
+}
+
+// WebhookEvent is a user lifecycle event, delivered to every subscription of its type.
+type WebhookEvent struct {
+	ID          string    `db:"id"`
+	EventType   string    `db:"event_type"`
+	Payload     []byte    `db:"payload"` // JSON body of the deliveries
+	CreatedTime time.Time `db:"created_time"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is the delivery of an event to a subscription.
+type WebhookDelivery struct {
+	ID              int64                 `db:"id"`
+	SubscriptionID  int64                 `db:"subscription_id"`
+	EventID         string                `db:"event_id"`
+	EventType       string                `db:"event_type"` // from webhook_event
+	Status          WebhookDeliveryStatus `db:"status"`
+	Attempts        int                   `db:"attempts"`
+	NextAttemptTime time.Time             `db:"next_attempt_time"`
+	LastAttemptTime *time.Time            `db:"last_attempt_time"`
+	LastStatusCode  int                   `db:"last_status_code"` // 0 when no response was received
+	LastError       string                `db:"last_error"`
+	DeliveredTime   *time.Time            `db:"delivered_time"`
+	CreatedTime     time.Time             `db:"created_time"`
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionID int64                 `db:"subscription_id"`
+	DeliveryID     int64                 `db:"id"`
+	Status         WebhookDeliveryStatus `db:"status"`
+	Limit          int
+}
+
+// WebhookDeliveryAttempt is a delivery claimed by a dispatcher, along with what it needs to send it.
+type WebhookDeliveryAttempt struct {
+	WebhookDelivery
+	URL     string `db:"url"`
+	Secret  string `db:"secret"`
+	Payload []byte `db:"payload"`
+}
+
+type UserExportStatus string
+
+const (
+	UserExportPending   UserExportStatus = "pending"
+	UserExportCompleted UserExportStatus = "completed"
+	UserExportFailed    UserExportStatus = "failed"
+	UserExportExpired   UserExportStatus = "expired" // the archive was deleted
+)
+
+// UserExport is an export of the personal data of a user, built by the worker of the export package.
+type UserExport struct {
+	ID              string           `db:"id"`
+	UserID          int64            `db:"user_id"`
+	Status          UserExportStatus `db:"status"`
+	Attempts        int              `db:"attempts"`
+	NextAttemptTime time.Time        `db:"next_attempt_time"`
+	LastError       string           `db:"last_error"`
+	Archive         []byte           `db:"archive"` // only read by GetUserExportArchive
+	CreatedTime     time.Time        `db:"created_time"`
+	CompletedTime   *time.Time       `db:"completed_time"`
+	ExpiresTime     *time.Time       `db:"expires_time"` // when the archive is deleted
+}
+
+const (
+	OutboxEventUserRegistered      = "user.registered"
+	OutboxEventUserUpdated         = "user.updated"
+	OutboxEventUserLoggedIn        = "user.logged_in"
+	OutboxEventUserExportCompleted = "user.export_completed"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the change it is about. It is published by the
+// relay of the outbox package.
+type OutboxEvent struct {
+	ID              int64      `db:"id"`           // orders the events
+	EventID         string     `db:"event_id"`     // sent to consumers to discard duplicates
+	EventType       string     `db:"event_type"`   // i.e. OutboxEventUserRegistered
+	AggregateID     int64      `db:"aggregate_id"` // ID of the user
+	Payload         []byte     `db:"payload"`      // JSON
+	Attempts        int        `db:"attempts"`
+	NextAttemptTime time.Time  `db:"next_attempt_time"`
+	LastError       string     `db:"last_error"`
+	CreatedTime     time.Time  `db:"created_time"`
+	PublishedTime   *time.Time `db:"published_time"`
+}
+
+// UserEventPayload is the payload of the outbox events of a user. Events of updates only carry the updated fields,
+// and FullNameCleared when the full name was removed, events of logins the ID of the new session and events of
+// completed exports the ID of the export.
+type UserEventPayload struct {
+	UserID          int64  `json:"user_id"`
+	PhoneNumber     string `json:"phone_number,omitempty"`
+	FullName        string `json:"full_name,omitempty"`
+	FullNameCleared bool   `json:"full_name_cleared,omitempty"`
+	SessionID       string `json:"session_id,omitempty"`
+	ExportID        string `json:"export_id,omitempty"`
+}
+
+const (
+	AuditActionUserRegistered  = "user.registered"
+	AuditActionUserUpdated     = "user.updated"
+	AuditActionUserDeleted     = "user.deleted"
+	AuditActionUserRestored    = "user.restored"
+	AuditActionUserSuspended   = "user.suspended"
+	AuditActionUserUnsuspended = "user.unsuspended"
+	AuditActionUserErased      = "user.erased" // receipt of the erasure, without changes
+
+	// AuditActorAdmin is the actor of changes made through the admin API, see UserActor for changes made by users
+	AuditActorAdmin = "admin"
+	// AuditActorSystem is the actor of changes made by the service itself, i.e. the erasure of deleted users
+	AuditActorSystem = "system"
+
+	// AuditValueErased replaces the values of the changes of erased users, whose key was deleted by EraseUser
+	AuditValueErased = "[erased]"
+)
+
+// UserActor returns the actor of the changes made by the user
+func UserActor(userID int64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// AuditLogEntry is an account change in the append-only audit log. Entries are hash-chained: Hash covers the entry
+// and the hash of the previous entry, so editing or deleting an entry breaks the chain, see VerifyAuditLog.
+type AuditLogEntry struct {
+	ID           int64                  `db:"id"`
+	Actor        string                 `db:"actor"`  // i.e. UserActor(123) or AuditActorAdmin
+	Action       string                 `db:"action"` // i.e. AuditActionUserUpdated
+	TargetUserID int64                  `db:"target_user_id"`
+	Changes      map[string]AuditChange `db:"changes"` // by field, only the fields that changed
+	CreatedTime  time.Time              `db:"created_time"`
+	PrevHash     string                 `db:"prev_hash"` // empty for the first entry
+	Hash         string                 `db:"hash"`
+}
+
+// AuditChange is the value of a field before and after a change. Before is empty for created fields.
+type AuditChange struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+type AuditLogFilter struct {
+	TargetUserID int64
+	BeforeID     int64 // only entries older than this entry, to page through the log
+	Limit        int
+}
+
+// AuditLogVerification is the result of the verification of the hash chain of the audit log
+type AuditLogVerification struct {
+	Entries  int64
+	HeadHash string // hash of the last entry, compare it with a previously recorded head to detect truncation
+
+	// BrokenEntryID is the first entry whose hash does not match, 0 when the chain is intact
+	BrokenEntryID int64
+	Problem       string
 }
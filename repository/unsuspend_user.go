@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// UnsuspendUser lifts the suspension of the user that is not deleted, and records it in the audit log as made by
+// actor. Users that are not suspended are left as they are.
+func (r *Repository) UnsuspendUser(ctx context.Context, userID int64, actor string) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var (
+		suspended    bool
+		beforeReason string
+	)
+	if err := tx.QueryRowContext(ctx, querySelectUserSuspensionForUpdate, userID).Scan(&suspended, &beforeReason); err != nil {
+		return translateError(ctx, err)
+	}
+
+	if !suspended {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUnsuspendUser, userID); err != nil {
+		return translateError(ctx, err)
+	}
+
+	if err := insertAuditLogEntry(ctx, tx, AuditLogEntry{
+		Actor:        actor,
+		Action:       AuditActionUserUnsuspended,
+		TargetUserID: userID,
+		Changes:      map[string]AuditChange{"suspension_reason": {Before: beforeReason}},
+		CreatedTime:  time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	return translateError(ctx, tx.Commit())
+}
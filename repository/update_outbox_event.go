@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// UpdateOutboxEvent records the outcome of the last attempt to publish the event.
+func (r *Repository) UpdateOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	result, err := r.Db.ExecContext(ctx, queryUpdateOutboxEvent,
+		event.NextAttemptTime,
+		sql.NullString{String: event.LastError, Valid: event.LastError != ""},
+		event.PublishedTime,
+		event.ID,
+	)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means event does not exist
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
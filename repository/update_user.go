@@ -2,36 +2,74 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 )
 
-func (r *Repository) UpdateUser(ctx context.Context, in User) error {
+// UpdateUser updates the non-empty fields of the user that differ from the stored ones, and removes the full name when
+// User.ClearFullName is set. It records the fields that changed in the audit log as changed by actor and in the outbox.
+// When no field changed, nothing is written and the version is kept, so the ETag of the user stays valid. When the version of in is set, the user is only updated if it
+// is still at this version, otherwise ErrVersionMismatch is returned.
+func (r *Repository) UpdateUser(ctx context.Context, in User, actor string) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Lock the user until the update is committed, so the audit log records the values it replaced
+	var before User
+	if err := tx.QueryRowContext(ctx, querySelectUserForUpdate, in.ID).Scan(&before.PhoneNumber, &before.FullName, &before.Version); err != nil {
+		return translateError(ctx, err)
+	}
+
+	if in.Version != 0 && in.Version != before.Version {
+		return ErrVersionMismatch
+	}
+
 	var (
 		query     string
 		setFields []string
 		params    []interface{}
 		offset    int = 0
+
+		changes = map[string]AuditChange{}
+		changed = UserEventPayload{UserID: in.ID}
 	)
 
-	if in.PhoneNumber != "" {
+	if in.PhoneNumber != "" && in.PhoneNumber != before.PhoneNumber {
 		setFields = append(setFields, fmt.Sprintf(setUserPhoneNumberF, offset+1))
 		params = append(
 			params,
 			in.PhoneNumber,
 		)
 		offset++
+
+		changes["phone_number"] = AuditChange{Before: before.PhoneNumber, After: in.PhoneNumber}
+		changed.PhoneNumber = in.PhoneNumber
 	}
 
-	if in.FullName != "" {
+	if in.ClearFullName && before.FullName != "" {
+		setFields = append(setFields, setUserFullNameNull)
+
+		changes["full_name"] = AuditChange{Before: before.FullName}
+		changed.FullNameCleared = true
+	} else if in.FullName != "" && in.FullName != before.FullName {
 		setFields = append(setFields, fmt.Sprintf(setUserFullNameF, offset+1))
 		params = append(
 			params,
 			in.FullName,
 		)
 		offset++
+
+		changes["full_name"] = AuditChange{Before: before.FullName, After: in.FullName}
+		changed.FullName = in.FullName
+	}
+
+	// Nothing changed, the user is left as it is
+	if len(changes) == 0 {
+		return nil
 	}
 
 	setFields = append(setFields, fmt.Sprintf(setUserUpdatedTimeF, offset+1))
@@ -41,6 +79,8 @@ func (r *Repository) UpdateUser(ctx context.Context, in User) error {
 	)
 	offset++
 
+	setFields = append(setFields, setUserVersion)
+
 	query = fmt.Sprintf(queryUpdateUserF, strings.Join(setFields, ","))
 
 	query += fmt.Sprintf(whereUserID, offset+1)
@@ -48,23 +88,37 @@ func (r *Repository) UpdateUser(ctx context.Context, in User) error {
 		params,
 		in.ID,
 	)
-	offset++
 
-	result, err := r.Db.ExecContext(ctx, query, params...)
+	result, err := tx.ExecContext(ctx, query, params...)
 	if err != nil {
-		return err
+		return translateError(ctx, err)
 	}
 
 	// Check the affected rows count
 	affectedRows, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return translateError(ctx, err)
 	}
 
-	// No rows updated means user does not exist
+	// The user is locked and exists, so it is updated
 	if affectedRows == 0 {
-		return errors.New("user not found")
+		return ErrNotFound
+	}
+
+	if err := insertAuditLogEntry(ctx, tx, AuditLogEntry{
+		Actor:        actor,
+		Action:       AuditActionUserUpdated,
+		TargetUserID: in.ID,
+		Changes:      changes,
+		CreatedTime:  time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	// The event is only published if the update is committed
+	if err := insertOutboxEvent(ctx, tx, OutboxEventUserUpdated, changed, time.Now()); err != nil {
+		return err
 	}
 
-	return nil
+	return translateError(ctx, tx.Commit())
 }
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// UpdateUserExport records the outcome of the last attempt to build the export, along with its archive once it is
+// completed. The completion is written to the outbox in the same transaction.
+func (r *Repository) UpdateUserExport(ctx context.Context, export UserExport) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, queryUpdateUserExport,
+		export.Status,
+		export.NextAttemptTime,
+		sql.NullString{String: export.LastError, Valid: export.LastError != ""},
+		export.Archive,
+		export.CompletedTime,
+		export.ExpiresTime,
+		export.ID,
+	)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means export does not exist
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	// The event is only published if the archive is committed
+	if export.Status == UserExportCompleted && export.CompletedTime != nil {
+		if err := insertOutboxEvent(ctx, tx, OutboxEventUserExportCompleted, UserEventPayload{
+			UserID:   export.UserID,
+			ExportID: export.ID,
+		}, *export.CompletedTime); err != nil {
+			return err
+		}
+	}
+
+	return translateError(ctx, tx.Commit())
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRepository_UpdateUserExport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	expiresTime := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name   string
+		export UserExport
+		mockDb func(mock sqlmock.Sqlmock)
+
+		wantErr error
+	}{
+		{
+			name:   "success-completed-writes-outbox-event",
+			export: UserExport{ID: "a1b2", UserID: 123, Status: UserExportCompleted, NextAttemptTime: now, Archive: []byte(`{}`), CompletedTime: &now, ExpiresTime: &expiresTime},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE user_export SET status = $1`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WithArgs(sqlmock.AnyArg(), OutboxEventUserExportCompleted, int64(123), []byte(`{"user_id":123,"export_id":"a1b2"}`), now).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "success-retried-writes-no-outbox-event",
+			export: UserExport{ID: "a1b2", UserID: 123, Status: UserExportPending, NextAttemptTime: now, LastError: "connection reset"},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE user_export SET status = $1`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "fail-not-found",
+			export: UserExport{ID: "a1b2", UserID: 123, Status: UserExportCompleted, CompletedTime: &now},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE user_export`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:   "fail-outbox-event-rolls-back-archive",
+			export: UserExport{ID: "a1b2", UserID: 123, Status: UserExportCompleted, CompletedTime: &now},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE user_export`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db}
+
+			gotErr := repository.UpdateUserExport(context.Background(), test.export)
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.UpdateUserExport() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.UpdateUserExport() %v", err)
+			}
+		})
+	}
+}
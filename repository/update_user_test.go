@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRepository_UpdateUser(t *testing.T) {
+	userRow := func(phoneNumber, fullName string, version int64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"phone_number", "full_name", "version"}).AddRow(phoneNumber, fullName, version)
+	}
+
+	tests := []struct {
+		name   string
+		user   User
+		mockDb func(mock sqlmock.Sqlmock)
+
+		wantErr error
+	}{
+		{
+			name: "success-writes-audit-log-entry-and-outbox-event-of-updated-fields",
+			user: User{ID: 123, FullName: "New User"},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user" WHERE id = $1 AND deleted_time IS NULL AND suspended_time IS NULL FOR UPDATE`)).
+					WithArgs(int64(123)).
+					WillReturnRows(userRow("+628123456789", "User", 4))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET full_name = $1,updated_time = $2,version = version + 1 WHERE deleted_time IS NULL AND suspended_time IS NULL AND id = $3`)).
+					WithArgs("New User", sqlmock.AnyArg(), int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "").
+					WithArgs("user:123", AuditActionUserUpdated, int64(123), encryptedAuditChanges(`{"full_name":{"before":"User","after":"New User"}}`),
+						sqlmock.AnyArg(), "", sqlmock.AnyArg())
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WithArgs(sqlmock.AnyArg(), OutboxEventUserUpdated, int64(123), []byte(`{"user_id":123,"full_name":"New User"}`), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "success-updates-expected-version",
+			user: User{ID: 123, FullName: "New User", Version: 4},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628123456789", "User", 4))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET full_name = $1,updated_time = $2,version = version + 1 WHERE deleted_time IS NULL AND suspended_time IS NULL AND id = $3`)).
+					WithArgs("New User", sqlmock.AnyArg(), int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "a1b2")
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "success-only-writes-changed-fields",
+			user: User{ID: 123, PhoneNumber: "+628123456789", FullName: "New User"},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628123456789", "User", 4))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET full_name = $1,updated_time = $2,version = version + 1`)).
+					WithArgs("New User", sqlmock.AnyArg(), int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "a1b2")
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WithArgs(sqlmock.AnyArg(), OutboxEventUserUpdated, int64(123), []byte(`{"user_id":123,"full_name":"New User"}`), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "success-clears-full-name",
+			user: User{ID: 123, ClearFullName: true},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628123456789", "User", 4))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET full_name = NULL,updated_time = $1,version = version + 1 WHERE deleted_time IS NULL AND suspended_time IS NULL AND id = $2`)).
+					WithArgs(sqlmock.AnyArg(), int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "a1b2").
+					WithArgs("user:123", AuditActionUserUpdated, int64(123), encryptedAuditChanges(`{"full_name":{"before":"User"}}`),
+						sqlmock.AnyArg(), "a1b2", sqlmock.AnyArg())
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WithArgs(sqlmock.AnyArg(), OutboxEventUserUpdated, int64(123), []byte(`{"user_id":123,"full_name_cleared":true}`), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "success-clearing-missing-full-name-writes-nothing",
+			user: User{ID: 123, ClearFullName: true},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628123456789", "", 4))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "success-unchanged-fields-write-nothing-and-keep-version",
+			user: User{ID: 123, PhoneNumber: "+628123456789", FullName: "User", Version: 4},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628123456789", "User", 4))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "fail-version-mismatch-writes-nothing",
+			user: User{ID: 123, FullName: "New User", Version: 4},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628123456789", "User", 5))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrVersionMismatch,
+		},
+		{
+			name: "fail-version-mismatch-of-unchanged-fields",
+			user: User{ID: 123, FullName: "User", Version: 4},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628123456789", "User", 5))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrVersionMismatch,
+		},
+		{
+			name: "fail-not-found-writes-no-outbox-event",
+			user: User{ID: 123, FullName: "New User"},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"phone_number", "full_name", "version"}))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "fail-audit-log-entry-rolls-back-update",
+			user: User{ID: 123, FullName: "New User"},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628123456789", "User", 4))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user"`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUpsertAuditLogKey(mock, 123)
+				mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("connection reset"),
+		},
+		{
+			name: "fail-outbox-event-rolls-back-update",
+			user: User{ID: 123, PhoneNumber: "+628123456789"},
+			mockDb: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT phone_number, COALESCE(full_name, ''), version FROM "user"`)).
+					WillReturnRows(userRow("+628111111111", "User", 4))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user"`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUpsertAuditLogKey(mock, 123)
+				expectInsertAuditLogEntry(mock, "a1b2")
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox`)).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+			test.mockDb(mock)
+
+			repository := &Repository{Db: db}
+
+			gotErr := repository.UpdateUser(context.Background(), test.user, UserActor(test.user.ID))
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.UpdateUser() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("repository.UpdateUser() %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// UpdateWebhookDelivery records the outcome of the last attempt of the delivery.
+func (r *Repository) UpdateWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	result, err := r.Db.ExecContext(ctx, queryUpdateWebhookDelivery,
+		delivery.Status,
+		delivery.NextAttemptTime,
+		sql.NullInt64{Int64: int64(delivery.LastStatusCode), Valid: delivery.LastStatusCode != 0},
+		sql.NullString{String: delivery.LastError, Valid: delivery.LastError != ""},
+		delivery.DeliveredTime,
+		delivery.ID,
+	)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// Check the affected rows count
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	// No rows updated means delivery does not exist
+	if affectedRows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
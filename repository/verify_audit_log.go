@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// VerifyAuditLog walks the audit log in order and checks that every entry is chained to the previous one and that
+// its hash matches its content. It stops at the first broken entry.
+func (r *Repository) VerifyAuditLog(ctx context.Context) (verification AuditLogVerification, err error) {
+	rows, err := r.Db.QueryContext(ctx, querySelectAuditLog+orderAuditLogChain)
+	if err != nil {
+		return AuditLogVerification{}, translateError(ctx, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		entry, err := scanAuditLogEntry(rows)
+		if err != nil {
+			return AuditLogVerification{}, translateError(ctx, err)
+		}
+
+		if entry.PrevHash != verification.HeadHash {
+			verification.BrokenEntryID = entry.ID
+			verification.Problem = fmt.Sprintf("previous hash %q does not match the hash of the previous entry %q, an entry was deleted or edited", entry.PrevHash, verification.HeadHash)
+			return verification, nil
+		}
+
+		hash, err := hashAuditLogEntry(entry)
+		if err != nil {
+			return AuditLogVerification{}, err
+		}
+		if entry.Hash != hash {
+			verification.BrokenEntryID = entry.ID
+			verification.Problem = fmt.Sprintf("hash %q does not match the content of the entry, the entry was edited", entry.Hash)
+			return verification, nil
+		}
+
+		verification.Entries++
+		verification.HeadHash = entry.Hash
+	}
+
+	if err := rows.Err(); err != nil {
+		return AuditLogVerification{}, translateError(ctx, err)
+	}
+
+	return verification, nil
+}
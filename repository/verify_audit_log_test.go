@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newAuditLogChain returns a valid chain of n entries
+func newAuditLogChain(t *testing.T, n int) []AuditLogEntry {
+	var entries []AuditLogEntry
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		entry := AuditLogEntry{
+			ID:           int64(i),
+			Actor:        UserActor(123),
+			Action:       AuditActionUserUpdated,
+			TargetUserID: 123,
+			Changes:      map[string]AuditChange{"full_name": {Before: "User", After: "New User"}},
+			CreatedTime:  time.Date(2023, 10, 1, 12, i, 0, 0, time.UTC),
+			PrevHash:     prevHash,
+		}
+
+		hash, err := hashAuditLogEntry(entry)
+		if err != nil {
+			t.Fatalf("hashAuditLogEntry() err = %v", err)
+		}
+		entry.Hash = hash
+
+		entries = append(entries, entry)
+		prevHash = hash
+	}
+
+	return entries
+}
+
+func auditLogRows(t *testing.T, entries []AuditLogEntry) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "actor", "action", "target_user_id", "changes", "created_time", "prev_hash", "hash"})
+	for _, entry := range entries {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			t.Fatalf("json.Marshal() err = %v", err)
+		}
+		rows.AddRow(entry.ID, entry.Actor, entry.Action, entry.TargetUserID, changes, entry.CreatedTime, entry.PrevHash, entry.Hash)
+	}
+
+	return rows
+}
+
+func TestRepository_VerifyAuditLog(t *testing.T) {
+	chain := newAuditLogChain(t, 3)
+
+	tests := []struct {
+		name    string
+		entries func() []AuditLogEntry
+		mockErr error
+
+		want    AuditLogVerification
+		wantErr error
+	}{
+		{
+			name:    "success-empty-log",
+			entries: func() []AuditLogEntry { return nil },
+			want:    AuditLogVerification{},
+		},
+		{
+			name:    "success-intact-chain",
+			entries: func() []AuditLogEntry { return chain },
+			want:    AuditLogVerification{Entries: 3, HeadHash: chain[2].Hash},
+		},
+		{
+			name: "broken-edited-entry",
+			entries: func() []AuditLogEntry {
+				edited := append([]AuditLogEntry{}, chain...)
+				edited[1].Changes = map[string]AuditChange{"full_name": {Before: "User", After: "Other User"}}
+				return edited
+			},
+			want: AuditLogVerification{
+				Entries:       1,
+				HeadHash:      chain[0].Hash,
+				BrokenEntryID: 2,
+				Problem:       `hash "` + chain[1].Hash + `" does not match the content of the entry, the entry was edited`,
+			},
+		},
+		{
+			name: "broken-deleted-entry",
+			entries: func() []AuditLogEntry {
+				return []AuditLogEntry{chain[0], chain[2]}
+			},
+			want: AuditLogVerification{
+				Entries:       1,
+				HeadHash:      chain[0].Hash,
+				BrokenEntryID: 3,
+				Problem: `previous hash "` + chain[1].Hash + `" does not match the hash of the previous entry "` + chain[0].Hash +
+					`", an entry was deleted or edited`,
+			},
+		},
+		{
+			name:    "fail-query",
+			entries: func() []AuditLogEntry { return nil },
+			mockErr: errors.New("connection reset"),
+			wantErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() err = %v", err)
+			}
+			defer db.Close()
+
+			query := mock.ExpectQuery(regexp.QuoteMeta(`FROM audit_log WHERE true ORDER BY id`))
+			if test.mockErr != nil {
+				query.WillReturnError(test.mockErr)
+			} else {
+				query.WillReturnRows(auditLogRows(t, test.entries()))
+			}
+
+			repository := &Repository{Db: db}
+
+			got, gotErr := repository.VerifyAuditLog(context.Background())
+			if (gotErr == nil) != (test.wantErr == nil) || (gotErr != nil && gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("repository.VerifyAuditLog() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("repository.VerifyAuditLog() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServerErrors are the codes of the calls that failed because of the service rather than the client, like 5xx
+// status codes
+var grpcServerErrors = map[grpccodes.Code]bool{
+	grpccodes.Unknown:          true,
+	grpccodes.DeadlineExceeded: true,
+	grpccodes.Unimplemented:    true,
+	grpccodes.Internal:         true,
+	grpccodes.Unavailable:      true,
+	grpccodes.DataLoss:         true,
+}
+
+// Middleware returns a middleware that starts the server span of HTTP requests, as a child of the trace context of
+// the request headers. Register it with echo.Use before the other middlewares so their spans are its children.
+func (t *Tracing) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			request := ctx.Request()
+			parent := t.propagator.Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+
+			attributes := []attribute.KeyValue{
+				semconv.HTTPRequestMethodKey.String(request.Method),
+				semconv.URLPath(request.URL.Path),
+			}
+
+			// Unmatched requests are named after their method only so scanners cannot create a span name per URL
+			name := request.Method
+			if route := ctx.Path(); route != "" {
+				name += " " + route
+				attributes = append(attributes, semconv.HTTPRoute(route))
+			}
+
+			spanCtx, span := t.tracer.Start(parent, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attributes...),
+			)
+			defer span.End()
+			ctx.SetRequest(request.WithContext(spanCtx))
+
+			err := next(ctx)
+
+			statusCode := ctx.Response().Status
+			if err != nil {
+				// The error is written by the error handler of echo once the middlewares returned
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					statusCode = httpErr.Code
+				} else {
+					statusCode = http.StatusInternalServerError
+				}
+				span.RecordError(err)
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+			if statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(statusCode))
+			}
+
+			return err
+		}
+	}
+}
+
+// WrapMiddleware returns mw traced in a span named after the middleware, i.e. to tell the time spent authenticating
+// requests from the time spent in the handler. The span includes the middlewares and the handler called by mw.
+func (t *Tracing) WrapMiddleware(name string, mw echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		wrapped := mw(next)
+		return func(ctx echo.Context) error {
+			request := ctx.Request()
+			spanCtx, span := t.tracer.Start(request.Context(), "middleware "+name)
+			defer span.End()
+			ctx.SetRequest(request.WithContext(spanCtx))
+
+			err := wrapped(ctx)
+			if err != nil {
+				span.RecordError(err)
+			}
+
+			return err
+		}
+	}
+}
+
+// HandlerMiddleware returns a middleware that traces the handler of the route. Register it with echo.Use after the
+// other middlewares.
+func (t *Tracing) HandlerMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			// Unmatched requests have no handler, echo responds with 404 or 405
+			if ctx.Path() == "" {
+				return next(ctx)
+			}
+
+			request := ctx.Request()
+			spanCtx, span := t.tracer.Start(request.Context(), "handler "+request.Method+" "+ctx.Path())
+			defer span.End()
+			ctx.SetRequest(request.WithContext(spanCtx))
+
+			err := next(ctx)
+			if err != nil {
+				span.RecordError(err)
+			}
+
+			return err
+		}
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor that starts the server span of gRPC calls, as a child of the trace
+// context of the call metadata, like Middleware. Register it before the other interceptors.
+func (t *Tracing) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = t.propagator.Extract(ctx, metadataCarrier(md))
+
+		// FullMethod is `/package.Service/Method`
+		service, method, _ := strings.Cut(strings.TrimPrefix(info.FullMethod, "/"), "/")
+		ctx, span := t.tracer.Start(ctx, strings.TrimPrefix(info.FullMethod, "/"),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.RPCSystemGRPC,
+				semconv.RPCService(service),
+				semconv.RPCMethod(method),
+			),
+		)
+		defer span.End()
+
+		resp, err := next(ctx, req)
+
+		st := status.Convert(err)
+		span.SetAttributes(attribute.Int(string(semconv.RPCGRPCStatusCodeKey), int(st.Code())))
+		if err != nil {
+			span.RecordError(err)
+		}
+		if grpcServerErrors[st.Code()] {
+			span.SetStatus(codes.Error, st.Code().String())
+		}
+
+		return resp, err
+	}
+}
+
+// metadataCarrier adapts gRPC metadata to propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
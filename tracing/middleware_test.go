@@ -0,0 +1,243 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+)
+
+func TestTracing_Middleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		traceparent string
+		handler     echo.HandlerFunc
+
+		wantStatus  int
+		wantTraceID string
+		wantSpans   []recordedSpan
+	}{
+		{
+			name:        "success-continues-trace",
+			target:      "/v2/users/1",
+			traceparent: traceparent,
+			handler: func(ctx echo.Context) error {
+				return ctx.NoContent(http.StatusOK)
+			},
+			wantStatus:  http.StatusOK,
+			wantTraceID: traceID,
+			wantSpans: []recordedSpan{
+				{name: "handler GET /v2/users/:id", parent: "middleware authentication"},
+				{name: "middleware authentication", parent: "GET /v2/users/:id"},
+				{
+					name: "GET /v2/users/:id",
+					attributes: map[string]string{
+						"http.request.method":       "GET",
+						"http.route":                "/v2/users/:id",
+						"http.response.status_code": "200",
+					},
+				},
+			},
+		},
+		{
+			name:   "fail-middleware-rejects-request",
+			target: "/v2/users/1?reject=true",
+			handler: func(ctx echo.Context) error {
+				return ctx.NoContent(http.StatusOK)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantSpans: []recordedSpan{
+				{name: "middleware authentication", parent: "GET /v2/users/:id"},
+				{
+					name: "GET /v2/users/:id",
+					attributes: map[string]string{
+						"http.request.method":       "GET",
+						"http.route":                "/v2/users/:id",
+						"http.response.status_code": "401",
+					},
+				},
+			},
+		},
+		{
+			name:   "fail-handler-error",
+			target: "/v2/users/1",
+			handler: func(ctx echo.Context) error {
+				return errors.New("database unavailable")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantSpans: []recordedSpan{
+				{name: "handler GET /v2/users/:id", parent: "middleware authentication"},
+				{name: "middleware authentication", parent: "GET /v2/users/:id"},
+				{
+					name: "GET /v2/users/:id",
+					attributes: map[string]string{
+						"http.request.method":       "GET",
+						"http.route":                "/v2/users/:id",
+						"http.response.status_code": "500",
+					},
+					failed: true,
+				},
+			},
+		},
+		{
+			name:       "fail-unmatched-route",
+			target:     "/wp-login.php",
+			wantStatus: http.StatusNotFound,
+			wantSpans: []recordedSpan{
+				{name: "middleware authentication", parent: "GET"},
+				{
+					name: "GET",
+					attributes: map[string]string{
+						"http.request.method":       "GET",
+						"http.response.status_code": "404",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracing, recorder := newRecordingTracing()
+
+			var gotTraceID string
+			authentication := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(ctx echo.Context) error {
+					gotTraceID = trace.SpanContextFromContext(ctx.Request().Context()).TraceID().String()
+					if ctx.QueryParam("reject") != "" {
+						return echo.NewHTTPError(http.StatusUnauthorized)
+					}
+					return next(ctx)
+				}
+			}
+
+			e := echo.New()
+			e.Use(tracing.Middleware())
+			e.Use(tracing.WrapMiddleware("authentication", authentication))
+			e.Use(tracing.HandlerMiddleware())
+			if test.handler != nil {
+				e.GET("/v2/users/:id", test.handler)
+			}
+
+			request := httptest.NewRequest(http.MethodGet, test.target, nil)
+			if test.traceparent != "" {
+				request.Header.Set("traceparent", test.traceparent)
+			}
+			response := httptest.NewRecorder()
+			e.ServeHTTP(response, request)
+
+			if response.Code != test.wantStatus {
+				t.Errorf("Middleware() httpStatusCode = %v, wantHttpStatusCode %v", response.Code, test.wantStatus)
+			}
+			if test.wantTraceID != "" && gotTraceID != test.wantTraceID {
+				t.Errorf("Middleware() traceID = %v, wantTraceID %v", gotTraceID, test.wantTraceID)
+			}
+
+			got := endedSpans(recorder, "http.request.method", "http.route", "http.response.status_code")
+			if !reflect.DeepEqual(got, test.wantSpans) {
+				t.Errorf("Middleware() spans = %+v, wantSpans %+v", got, test.wantSpans)
+			}
+		})
+	}
+}
+
+func TestTracing_UnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		err  error
+
+		wantTraceID string
+		wantSpans   []recordedSpan
+	}{
+		{
+			name:        "success-continues-trace",
+			md:          metadata.Pairs("traceparent", traceparent),
+			wantTraceID: traceID,
+			wantSpans: []recordedSpan{
+				{
+					name: "userservice.v1.UserService/GetUser",
+					attributes: map[string]string{
+						"rpc.service":          "userservice.v1.UserService",
+						"rpc.method":           "GetUser",
+						"rpc.grpc.status_code": "0",
+					},
+				},
+			},
+		},
+		{
+			name: "success-client-error-not-failed",
+			err:  status.Error(codes.NotFound, "user not found"),
+			wantSpans: []recordedSpan{
+				{
+					name: "userservice.v1.UserService/GetUser",
+					attributes: map[string]string{
+						"rpc.service":          "userservice.v1.UserService",
+						"rpc.method":           "GetUser",
+						"rpc.grpc.status_code": "5",
+					},
+				},
+			},
+		},
+		{
+			name: "fail-server-error",
+			err:  status.Error(codes.Unavailable, "database unavailable"),
+			wantSpans: []recordedSpan{
+				{
+					name: "userservice.v1.UserService/GetUser",
+					attributes: map[string]string{
+						"rpc.service":          "userservice.v1.UserService",
+						"rpc.method":           "GetUser",
+						"rpc.grpc.status_code": "14",
+					},
+					failed: true,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracing, recorder := newRecordingTracing()
+
+			ctx := context.Background()
+			if test.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.md)
+			}
+
+			var gotTraceID string
+			interceptor := tracing.UnaryServerInterceptor()
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/userservice.v1.UserService/GetUser"},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					gotTraceID = trace.SpanContextFromContext(ctx).TraceID().String()
+					return nil, test.err
+				})
+			if err != test.err {
+				t.Errorf("UnaryServerInterceptor() err = %v, wantErr %v", err, test.err)
+			}
+			if test.wantTraceID != "" && gotTraceID != test.wantTraceID {
+				t.Errorf("UnaryServerInterceptor() traceID = %v, wantTraceID %v", gotTraceID, test.wantTraceID)
+			}
+
+			got := endedSpans(recorder, "rpc.service", "rpc.method", "rpc.grpc.status_code")
+			if !reflect.DeepEqual(got, test.wantSpans) {
+				t.Errorf("UnaryServerInterceptor() spans = %+v, wantSpans %+v", got, test.wantSpans)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/UserService/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedRepository traces every call of the repository it decorates.
+// Add new methods of repository.RepositoryInterface here.
+type tracedRepository struct {
+	next   repository.RepositoryInterface
+	tracer trace.Tracer
+}
+
+// Repository returns repo decorated to trace its calls in client spans named after the method
+func (t *Tracing) Repository(repo repository.RepositoryInterface) repository.RepositoryInterface {
+	return &tracedRepository{next: repo, tracer: t.tracer}
+}
+
+func (r *tracedRepository) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "repository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+}
+
+// end ends the span, with an error status unless the error is an expected outcome of the query
+func end(span trace.Span, err *error) {
+	defer span.End()
+
+	var conflict repository.ErrConflict
+	switch {
+	case *err == nil:
+	case errors.Is(*err, repository.ErrNotFound):
+		span.SetAttributes(attribute.String("repository.outcome", "not_found"))
+	case errors.Is(*err, repository.ErrVersionMismatch):
+		span.SetAttributes(attribute.String("repository.outcome", "version_mismatch"))
+	case errors.As(*err, &conflict):
+		span.SetAttributes(attribute.String("repository.outcome", "conflict"))
+	default:
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+}
+
+func (r *tracedRepository) InsertUser(ctx context.Context, user repository.User) (userID int64, err error) {
+	ctx, span := r.start(ctx, "InsertUser")
+	defer end(span, &err)
+	return r.next.InsertUser(ctx, user)
+}
+
+func (r *tracedRepository) GetUsers(ctx context.Context, request repository.UserFilter) (users []repository.User, err error) {
+	ctx, span := r.start(ctx, "GetUsers")
+	defer end(span, &err)
+	return r.next.GetUsers(ctx, request)
+}
+
+func (r *tracedRepository) GetUsersByIDs(ctx context.Context, userIDs []int64) (users []repository.User, err error) {
+	ctx, span := r.start(ctx, "GetUsersByIDs")
+	defer end(span, &err)
+	return r.next.GetUsersByIDs(ctx, userIDs)
+}
+
+func (r *tracedRepository) IncrementSuccessfulLoginCount(ctx context.Context, userID int64, loginTime time.Time) (err error) {
+	ctx, span := r.start(ctx, "IncrementSuccessfulLoginCount")
+	defer end(span, &err)
+	return r.next.IncrementSuccessfulLoginCount(ctx, userID, loginTime)
+}
+
+func (r *tracedRepository) UpdateUser(ctx context.Context, user repository.User, actor string) (err error) {
+	ctx, span := r.start(ctx, "UpdateUser")
+	defer end(span, &err)
+	return r.next.UpdateUser(ctx, user, actor)
+}
+
+func (r *tracedRepository) DeleteUser(ctx context.Context, userID int64, deletedTime time.Time, actor string) (err error) {
+	ctx, span := r.start(ctx, "DeleteUser")
+	defer end(span, &err)
+	return r.next.DeleteUser(ctx, userID, deletedTime, actor)
+}
+
+func (r *tracedRepository) RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time, actor string) (err error) {
+	ctx, span := r.start(ctx, "RestoreUser")
+	defer end(span, &err)
+	return r.next.RestoreUser(ctx, userID, deletedAfter, actor)
+}
+
+func (r *tracedRepository) SuspendUser(ctx context.Context, userID int64, reason string, actor string) (err error) {
+	ctx, span := r.start(ctx, "SuspendUser")
+	defer end(span, &err)
+	return r.next.SuspendUser(ctx, userID, reason, actor)
+}
+
+func (r *tracedRepository) UnsuspendUser(ctx context.Context, userID int64, actor string) (err error) {
+	ctx, span := r.start(ctx, "UnsuspendUser")
+	defer end(span, &err)
+	return r.next.UnsuspendUser(ctx, userID, actor)
+}
+
+func (r *tracedRepository) GetUsersToErase(ctx context.Context, deletedBefore time.Time, afterID int64, limit int) (users []repository.User, err error) {
+	ctx, span := r.start(ctx, "GetUsersToErase")
+	defer end(span, &err)
+	return r.next.GetUsersToErase(ctx, deletedBefore, afterID, limit)
+}
+
+func (r *tracedRepository) EraseUser(ctx context.Context, userID int64, deletedBefore, erasedTime time.Time, actor string) (err error) {
+	ctx, span := r.start(ctx, "EraseUser")
+	defer end(span, &err)
+	return r.next.EraseUser(ctx, userID, deletedBefore, erasedTime, actor)
+}
+
+func (r *tracedRepository) InsertSession(ctx context.Context, session repository.Session) (err error) {
+	ctx, span := r.start(ctx, "InsertSession")
+	defer end(span, &err)
+	return r.next.InsertSession(ctx, session)
+}
+
+func (r *tracedRepository) GetSession(ctx context.Context, sessionID string) (session repository.Session, err error) {
+	ctx, span := r.start(ctx, "GetSession")
+	defer end(span, &err)
+	return r.next.GetSession(ctx, sessionID)
+}
+
+func (r *tracedRepository) RevokeSession(ctx context.Context, sessionID string) (err error) {
+	ctx, span := r.start(ctx, "RevokeSession")
+	defer end(span, &err)
+	return r.next.RevokeSession(ctx, sessionID)
+}
+
+func (r *tracedRepository) GetUserSessions(ctx context.Context, userID int64) (sessions []repository.Session, err error) {
+	ctx, span := r.start(ctx, "GetUserSessions")
+	defer end(span, &err)
+	return r.next.GetUserSessions(ctx, userID)
+}
+
+func (r *tracedRepository) ReserveIdempotencyKey(ctx context.Context, key repository.IdempotencyKey) (reserved bool, err error) {
+	ctx, span := r.start(ctx, "ReserveIdempotencyKey")
+	defer end(span, &err)
+	return r.next.ReserveIdempotencyKey(ctx, key)
+}
+
+func (r *tracedRepository) GetIdempotencyKey(ctx context.Context, id string) (key repository.IdempotencyKey, err error) {
+	ctx, span := r.start(ctx, "GetIdempotencyKey")
+	defer end(span, &err)
+	return r.next.GetIdempotencyKey(ctx, id)
+}
+
+func (r *tracedRepository) CompleteIdempotencyKey(ctx context.Context, key repository.IdempotencyKey) (err error) {
+	ctx, span := r.start(ctx, "CompleteIdempotencyKey")
+	defer end(span, &err)
+	return r.next.CompleteIdempotencyKey(ctx, key)
+}
+
+func (r *tracedRepository) DeleteIdempotencyKey(ctx context.Context, id string) (err error) {
+	ctx, span := r.start(ctx, "DeleteIdempotencyKey")
+	defer end(span, &err)
+	return r.next.DeleteIdempotencyKey(ctx, id)
+}
+
+func (r *tracedRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (deleted int64, err error) {
+	ctx, span := r.start(ctx, "DeleteExpiredIdempotencyKeys")
+	defer end(span, &err)
+	return r.next.DeleteExpiredIdempotencyKeys(ctx, before)
+}
+
+func (r *tracedRepository) InsertWebhookSubscription(ctx context.Context, subscription repository.WebhookSubscription) (subscriptionID int64, err error) {
+	ctx, span := r.start(ctx, "InsertWebhookSubscription")
+	defer end(span, &err)
+	return r.next.InsertWebhookSubscription(ctx, subscription)
+}
+
+func (r *tracedRepository) GetWebhookSubscriptions(ctx context.Context, request repository.WebhookSubscriptionFilter) (subscriptions []repository.WebhookSubscription, err error) {
+	ctx, span := r.start(ctx, "GetWebhookSubscriptions")
+	defer end(span, &err)
+	return r.next.GetWebhookSubscriptions(ctx, request)
+}
+
+func (r *tracedRepository) DeleteWebhookSubscription(ctx context.Context, subscriptionID int64) (err error) {
+	ctx, span := r.start(ctx, "DeleteWebhookSubscription")
+	defer end(span, &err)
+	return r.next.DeleteWebhookSubscription(ctx, subscriptionID)
+}
+
+func (r *tracedRepository) InsertWebhookEvent(ctx context.Context, event repository.WebhookEvent) (err error) {
+	ctx, span := r.start(ctx, "InsertWebhookEvent")
+	defer end(span, &err)
+	return r.next.InsertWebhookEvent(ctx, event)
+}
+
+func (r *tracedRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (attempts []repository.WebhookDeliveryAttempt, err error) {
+	ctx, span := r.start(ctx, "ClaimWebhookDeliveries")
+	defer end(span, &err)
+	return r.next.ClaimWebhookDeliveries(ctx, now, limit, leaseUntil)
+}
+
+func (r *tracedRepository) UpdateWebhookDelivery(ctx context.Context, delivery repository.WebhookDelivery) (err error) {
+	ctx, span := r.start(ctx, "UpdateWebhookDelivery")
+	defer end(span, &err)
+	return r.next.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func (r *tracedRepository) GetWebhookDeliveries(ctx context.Context, request repository.WebhookDeliveryFilter) (deliveries []repository.WebhookDelivery, err error) {
+	ctx, span := r.start(ctx, "GetWebhookDeliveries")
+	defer end(span, &err)
+	return r.next.GetWebhookDeliveries(ctx, request)
+}
+
+func (r *tracedRepository) RetryWebhookDelivery(ctx context.Context, deliveryID int64) (err error) {
+	ctx, span := r.start(ctx, "RetryWebhookDelivery")
+	defer end(span, &err)
+	return r.next.RetryWebhookDelivery(ctx, deliveryID)
+}
+
+func (r *tracedRepository) ClaimOutboxEvents(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (events []repository.OutboxEvent, err error) {
+	ctx, span := r.start(ctx, "ClaimOutboxEvents")
+	defer end(span, &err)
+	return r.next.ClaimOutboxEvents(ctx, now, limit, leaseUntil)
+}
+
+func (r *tracedRepository) UpdateOutboxEvent(ctx context.Context, event repository.OutboxEvent) (err error) {
+	ctx, span := r.start(ctx, "UpdateOutboxEvent")
+	defer end(span, &err)
+	return r.next.UpdateOutboxEvent(ctx, event)
+}
+
+func (r *tracedRepository) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (deleted int64, err error) {
+	ctx, span := r.start(ctx, "DeletePublishedOutboxEvents")
+	defer end(span, &err)
+	return r.next.DeletePublishedOutboxEvents(ctx, before)
+}
+
+func (r *tracedRepository) GetAuditLog(ctx context.Context, request repository.AuditLogFilter) (entries []repository.AuditLogEntry, err error) {
+	ctx, span := r.start(ctx, "GetAuditLog")
+	defer end(span, &err)
+	return r.next.GetAuditLog(ctx, request)
+}
+
+func (r *tracedRepository) InsertUserExport(ctx context.Context, export repository.UserExport) (inserted repository.UserExport, err error) {
+	ctx, span := r.start(ctx, "InsertUserExport")
+	defer end(span, &err)
+	return r.next.InsertUserExport(ctx, export)
+}
+
+func (r *tracedRepository) GetUserExport(ctx context.Context, exportID string) (export repository.UserExport, err error) {
+	ctx, span := r.start(ctx, "GetUserExport")
+	defer end(span, &err)
+	return r.next.GetUserExport(ctx, exportID)
+}
+
+func (r *tracedRepository) GetUserExportArchive(ctx context.Context, exportID string) (archive []byte, err error) {
+	ctx, span := r.start(ctx, "GetUserExportArchive")
+	defer end(span, &err)
+	return r.next.GetUserExportArchive(ctx, exportID)
+}
+
+func (r *tracedRepository) ClaimUserExports(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) (exports []repository.UserExport, err error) {
+	ctx, span := r.start(ctx, "ClaimUserExports")
+	defer end(span, &err)
+	return r.next.ClaimUserExports(ctx, now, limit, leaseUntil)
+}
+
+func (r *tracedRepository) UpdateUserExport(ctx context.Context, export repository.UserExport) (err error) {
+	ctx, span := r.start(ctx, "UpdateUserExport")
+	defer end(span, &err)
+	return r.next.UpdateUserExport(ctx, export)
+}
+
+func (r *tracedRepository) ExpireUserExports(ctx context.Context, now time.Time) (expired int64, err error) {
+	ctx, span := r.start(ctx, "ExpireUserExports")
+	defer end(span, &err)
+	return r.next.ExpireUserExports(ctx, now)
+}
+
+func (r *tracedRepository) VerifyAuditLog(ctx context.Context) (verification repository.AuditLogVerification, err error) {
+	ctx, span := r.start(ctx, "VerifyAuditLog")
+	defer end(span, &err)
+	return r.next.VerifyAuditLog(ctx)
+}
+
+func (r *tracedRepository) Ping(ctx context.Context) (err error) {
+	ctx, span := r.start(ctx, "Ping")
+	defer end(span, &err)
+	return r.next.Ping(ctx)
+}
+
+func (r *tracedRepository) GetSchemaVersion(ctx context.Context) (version int, err error) {
+	ctx, span := r.start(ctx, "GetSchemaVersion")
+	defer end(span, &err)
+	return r.next.GetSchemaVersion(ctx)
+}
+
+func (r *tracedRepository) Close() error {
+	return r.next.Close()
+}
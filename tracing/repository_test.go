@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+func TestTracing_Repository(t *testing.T) {
+	tests := []struct {
+		name     string
+		mockRepo func(mock *repository.MockRepositoryInterface)
+
+		wantErr   error
+		wantSpans []recordedSpan
+	}{
+		{
+			name: "success",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{}, nil)
+			},
+			wantSpans: []recordedSpan{
+				{name: "repository.GetSession", parent: "request", attributes: map[string]string{"db.system": "postgresql"}},
+			},
+		},
+		{
+			name: "success-not-found-not-failed",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{}, repository.ErrNotFound)
+			},
+			wantErr: repository.ErrNotFound,
+			wantSpans: []recordedSpan{
+				{
+					name:       "repository.GetSession",
+					parent:     "request",
+					attributes: map[string]string{"db.system": "postgresql", "repository.outcome": "not_found"},
+				},
+			},
+		},
+		{
+			name: "fail-unavailable",
+			mockRepo: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().GetSession(gomock.Any(), "session").Return(repository.Session{}, repository.ErrUnavailable)
+			},
+			wantErr: repository.ErrUnavailable,
+			wantSpans: []recordedSpan{
+				{name: "repository.GetSession", parent: "request", attributes: map[string]string{"db.system": "postgresql"}, failed: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := repository.NewMockRepositoryInterface(ctrl)
+			test.mockRepo(mock)
+
+			tracing, recorder := newRecordingTracing()
+			ctx, span := tracing.tracer.Start(context.Background(), "request")
+			_, err := tracing.Repository(mock).GetSession(ctx, "session")
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("GetSession() err = %v, wantErr %v", err, test.wantErr)
+			}
+			span.End()
+
+			got := endedSpans(recorder, "db.system", "repository.outcome")
+			if !reflect.DeepEqual(got[:len(got)-1], test.wantSpans) {
+				t.Errorf("Repository() spans = %+v, wantSpans %+v", got, test.wantSpans)
+			}
+		})
+	}
+}
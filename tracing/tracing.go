@@ -0,0 +1,122 @@
+// Package tracing traces requests with OpenTelemetry. HTTP requests are traced by the middleware, gRPC calls by the
+// interceptor and database queries by the repository decorator, so the handlers and the repository only depend on
+// the OpenTelemetry API. The W3C trace context of incoming requests is propagated.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer of the service, like the tracer of utils.HashPassword
+const instrumentationName = "github.com/UserService"
+
+type Exporter string
+
+const (
+	ExporterNone   Exporter = "none"
+	ExporterStdout Exporter = "stdout"
+	ExporterOTLP   Exporter = "otlp"
+)
+
+type Tracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+type NewTracingOptions struct {
+	// TracerProvider and Propagator default to the global ones, see otel.SetTracerProvider
+	TracerProvider trace.TracerProvider
+	Propagator     propagation.TextMapPropagator
+}
+
+func NewTracing(opts NewTracingOptions) *Tracing {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+	if opts.Propagator == nil {
+		opts.Propagator = otel.GetTextMapPropagator()
+	}
+
+	return &Tracing{
+		tracer:     opts.TracerProvider.Tracer(instrumentationName),
+		propagator: opts.Propagator,
+	}
+}
+
+type NewTracerProviderOptions struct {
+	Exporter    Exporter
+	ServiceName string
+
+	// OTLPEndpoint is the host and port of the OTLP/HTTP collector, defaults to OTEL_EXPORTER_OTLP_ENDPOINT or
+	// localhost:4318
+	OTLPEndpoint string
+	OTLPInsecure bool
+
+	// Writer is where the stdout exporter writes spans, defaults to os.Stdout
+	Writer io.Writer
+}
+
+// NewTracerProvider returns a tracer provider exporting spans with the exporter. Spans are not sampled with
+// ExporterNone, but the trace context of incoming requests is still propagated. Shut the provider down to export
+// the last spans.
+func NewTracerProvider(opts NewTracerProviderOptions) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case ExporterNone, "":
+		return sdktrace.NewTracerProvider(
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.NeverSample()),
+		), nil
+	case ExporterStdout:
+		writer := opts.Writer
+		if writer == nil {
+			writer = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(writer))
+	case ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+		if opts.OTLPEndpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.OTLPEndpoint))
+		}
+		if opts.OTLPInsecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		// The exporter connects lazily, so the service starts while the collector is down
+		exporter, err = otlptracehttp.New(context.Background(), clientOpts...)
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	), nil
+}
+
+// Propagator returns the propagator of the W3C trace context and baggage
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
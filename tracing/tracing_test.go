@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordedSpan is the part of a recorded span the tests compare
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes map[string]string
+	failed     bool
+}
+
+// newRecordingTracing returns a Tracing whose spans are recorded in memory
+func newRecordingTracing() (*Tracing, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	return NewTracing(NewTracingOptions{TracerProvider: provider, Propagator: Propagator()}), recorder
+}
+
+// endedSpans returns the ended spans in the order they ended, with the attributes named in keys
+func endedSpans(recorder *tracetest.SpanRecorder, keys ...string) []recordedSpan {
+	ended := recorder.Ended()
+	names := map[string]string{}
+	for _, span := range ended {
+		names[span.SpanContext().SpanID().String()] = span.Name()
+	}
+
+	spans := make([]recordedSpan, 0, len(ended))
+	for _, span := range ended {
+		got := recordedSpan{
+			name:   span.Name(),
+			parent: names[span.Parent().SpanID().String()],
+			failed: span.Status().Code.String() == "Error",
+		}
+		for _, attribute := range span.Attributes() {
+			for _, key := range keys {
+				if string(attribute.Key) == key {
+					if got.attributes == nil {
+						got.attributes = map[string]string{}
+					}
+					got.attributes[key] = attribute.Value.Emit()
+				}
+			}
+		}
+		spans = append(spans, got)
+	}
+
+	return spans
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter Exporter
+
+		wantOutput string
+		wantErr    string
+	}{
+		{
+			name:     "success-none",
+			exporter: ExporterNone,
+		},
+		{
+			name:       "success-stdout",
+			exporter:   ExporterStdout,
+			wantOutput: `"Name":"span"`,
+		},
+		{
+			name:     "success-otlp",
+			exporter: ExporterOTLP,
+		},
+		{
+			name:     "fail-unknown-exporter",
+			exporter: "jaeger",
+			wantErr:  `unknown exporter "jaeger"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			provider, err := NewTracerProvider(NewTracerProviderOptions{
+				Exporter:     test.exporter,
+				ServiceName:  "user-service",
+				OTLPEndpoint: "127.0.0.1:0",
+				Writer:       &output,
+			})
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Errorf("NewTracerProvider() err = %v, wantErr %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTracerProvider() err = %v", err)
+			}
+
+			_, span := provider.Tracer(instrumentationName).Start(context.Background(), "span")
+			span.End()
+
+			// The OTLP exporter fails to export to the closed port, only the stdout exporter output is checked
+			ctx, cancel := context.WithCancel(context.Background())
+			if test.exporter == ExporterOTLP {
+				cancel()
+			}
+			defer cancel()
+			provider.Shutdown(ctx)
+
+			if !strings.Contains(output.String(), test.wantOutput) {
+				t.Errorf("NewTracerProvider() output = %v, wantOutput %v", output.String(), test.wantOutput)
+			}
+			if test.wantOutput == "" && output.Len() > 0 {
+				t.Errorf("NewTracerProvider() output = %v, want none", output.String())
+			}
+		})
+	}
+}
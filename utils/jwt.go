@@ -1,10 +1,21 @@
 package utils
 
 import (
-	"github.com/UserService/generated"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"os"
+	"time"
+
 	"github.com/dgrijalva/jwt-go"
 )
 
+const (
+	JWTExpiryDuration = time.Minute * 30 // Token expires in 30 minutes by default
+)
+
 type JWTPermission string
 
 const (
@@ -17,6 +28,13 @@ type JWTClaimKey string
 const (
 	JWTClaimUserID      JWTClaimKey = "user_id"
 	JWTClaimPermissions JWTClaimKey = "permissions"
+	JWTClaimSessionID   JWTClaimKey = "session_id"
+	JWTClaimExpiresAt   JWTClaimKey = "expires_at"
+)
+
+var (
+	ErrTokenExpired = errors.New("token has expired")
+	ErrInvalidToken = errors.New("invalid token")
 )
 
 // CustomClaims represents the claims you want to include in your JWT.
@@ -24,9 +42,77 @@ type CustomClaims struct {
 	UserID      int64           `json:"user_id"`
 	Permissions []JWTPermission `json:"permissions"`
 	ExpiresAt   int64           `json:"exp"`
+	SessionID   string          `json:"jti"` // ID of the session the token belongs to, see repository.Session
 	jwt.StandardClaims
 }
 
-type JWTResponse struct {
-	Header generated.ResponseHeader `json:"header"`
+// LoadRSAPrivateKey reads the PEM encoded private key used to sign JWTs.
+// See command in Dockerfile: openssl genrsa -out /tmp/rsa 4096
+func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	privateKeyData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.ParseRSAPrivateKeyFromPEM(privateKeyData)
+}
+
+// LoadRSAPublicKey reads the PEM encoded public key used to verify JWTs.
+// See command in Dockerfile: openssl rsa -in /tmp/rsa -pubout -out /tmp/rsa.pub
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	publicKeyData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.ParseRSAPublicKeyFromPEM(publicKeyData)
+}
+
+// DeriveKey returns a 256-bit key for purpose derived from privateKey, so replicas loading the same private key share
+// the key without another secret to distribute. It returns nil if privateKey is nil.
+func DeriveKey(privateKey *rsa.PrivateKey, purpose string) []byte {
+	if privateKey == nil {
+		return nil
+	}
+
+	mac := hmac.New(sha256.New, x509.MarshalPKCS1PrivateKey(privateKey))
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil)
+}
+
+// NewToken returns a JWT (RS256 algorithm) with the given claims, signed with privateKey.
+func NewToken(privateKey *rsa.PrivateKey, claims CustomClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+}
+
+// ParseToken verifies a JWT (RS256 algorithm) with publicKey and returns its claims.
+// It returns ErrTokenExpired if the token has expired and ErrInvalidToken for any other verification failure.
+func ParseToken(publicKey *rsa.PublicKey, token string) (CustomClaims, error) {
+	claims := CustomClaims{}
+	tok, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		// Only accept tokens signed by our private key
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, ErrInvalidToken
+		}
+		return publicKey, nil
+	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return CustomClaims{}, ErrTokenExpired
+		}
+		return CustomClaims{}, ErrInvalidToken
+	}
+
+	if !tok.Valid {
+		return CustomClaims{}, ErrInvalidToken
+	}
+
+	// CustomClaims.ExpiresAt shadows the standard `exp` claim, so jwt-go does not check it
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return CustomClaims{}, ErrTokenExpired
+	}
+
+	return claims, nil
 }
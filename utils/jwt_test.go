@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestParseToken(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v", err)
+	}
+
+	claims := CustomClaims{
+		UserID:      123,
+		Permissions: []JWTPermission{JWTPermissionGetUser},
+		ExpiresAt:   time.Now().Add(JWTExpiryDuration).Unix(),
+		SessionID:   "session",
+	}
+
+	newToken := func(key *rsa.PrivateKey, claims CustomClaims) string {
+		token, err := NewToken(key, claims)
+		if err != nil {
+			t.Fatalf("utils.NewToken() err = %v", err)
+		}
+		return token
+	}
+
+	expiredClaims := claims
+	expiredClaims.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("jwt.Token.SignedString() err = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+
+		wantClaims CustomClaims
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			token:      newToken(privateKey, claims),
+			wantClaims: claims,
+		},
+		{
+			name:    "fail-expired",
+			token:   newToken(privateKey, expiredClaims),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:    "fail-signed-by-other-key",
+			token:   newToken(otherKey, claims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "fail-other-signing-method",
+			token:   hmacToken,
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "fail-malformed",
+			token:   "token",
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotClaims, gotErr := ParseToken(&privateKey.PublicKey, test.token)
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("utils.ParseToken() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(gotClaims, test.wantClaims) {
+				t.Errorf("utils.ParseToken() claims = %v, wantClaims %v", gotClaims, test.wantClaims)
+			}
+		})
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v", err)
+	}
+
+	key := DeriveKey(privateKey, "purpose")
+	if len(key) != 32 {
+		t.Fatalf("utils.DeriveKey() len = %d, want 32", len(key))
+	}
+
+	if !reflect.DeepEqual(key, DeriveKey(privateKey, "purpose")) {
+		t.Errorf("utils.DeriveKey() is not deterministic")
+	}
+	if reflect.DeepEqual(key, DeriveKey(privateKey, "other purpose")) {
+		t.Errorf("utils.DeriveKey() returned the same key for another purpose")
+	}
+	if reflect.DeepEqual(key, DeriveKey(otherKey, "purpose")) {
+		t.Errorf("utils.DeriveKey() returned the same key for another private key")
+	}
+	if got := DeriveKey(nil, "purpose"); got != nil {
+		t.Errorf("utils.DeriveKey() without private key = %v, want nil", got)
+	}
+}
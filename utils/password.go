@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PasswordOperation string
+
+const (
+	PasswordOperationHash    PasswordOperation = "hash"
+	PasswordOperationCompare PasswordOperation = "compare"
+)
+
+// PasswordObserver is called with the duration of every password hash and comparison, i.e. to export bcrypt latency
+// as a metric. It is set once at startup, before any password is hashed.
+var PasswordObserver func(operation PasswordOperation, duration time.Duration)
+
+// tracer traces bcrypt with the tracer provider set at startup, see package tracing
+var tracer = otel.Tracer("github.com/UserService")
+
+// HashPassword returns the bcrypt hash of password with the given cost
+func HashPassword(ctx context.Context, password string, cost int) (string, error) {
+	_, span := tracer.Start(ctx, "password.hash")
+	span.SetAttributes(attribute.Int("bcrypt.cost", cost))
+	defer span.End()
+	defer observePassword(PasswordOperationHash, time.Now())
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// ComparePassword returns nil if password matches the bcrypt hash
+func ComparePassword(ctx context.Context, hash string, password string) error {
+	_, span := tracer.Start(ctx, "password.compare")
+	defer span.End()
+	defer observePassword(PasswordOperationCompare, time.Now())
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+func observePassword(operation PasswordOperation, start time.Time) {
+	if PasswordObserver != nil {
+		PasswordObserver(operation, time.Since(start))
+	}
+}
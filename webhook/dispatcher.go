@@ -0,0 +1,232 @@
+// Package webhook delivers user lifecycle events to the webhook subscriptions managed through the admin API.
+//
+// Events are queued in the database by the OutboxPublisher from the domain events relayed from the outbox, with
+// RepositoryInterface.InsertWebhookEvent, one delivery per subscription of the event type. The Dispatcher sends the
+// deliveries in order, signed with the secret of their subscription, and retries failed deliveries with exponential
+// backoff until they are marked as dead.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/UserService/logging"
+	"github.com/UserService/repository"
+)
+
+const (
+	defaultBatchSize    = 10
+	defaultMaxAttempts  = 8
+	defaultBackoff      = time.Minute
+	defaultMaxBackoff   = time.Hour
+	defaultPollInterval = 5 * time.Second
+	defaultTimeout      = 10 * time.Second
+
+	// maxErrorLength bounds the response body recorded as the error of a failed attempt
+	maxErrorLength = 512
+)
+
+const (
+	headerEvent    = "X-Webhook-Event"
+	headerEventID  = "X-Webhook-Event-ID"
+	headerDelivery = "X-Webhook-Delivery"
+	headerAttempt  = "X-Webhook-Attempt"
+	userAgent      = "user-service-webhooks/1.0"
+)
+
+var (
+	//define function wrappers so we can inject dummy function in UT
+	fnTimeNow func() time.Time = time.Now
+)
+
+// Dispatcher sends the queued webhook deliveries. Several dispatchers, i.e. one per replica of the service, can run
+// against the same database: deliveries are claimed so each attempt is made by a single dispatcher.
+type Dispatcher struct {
+	repository   repository.RepositoryInterface
+	client       *http.Client
+	batchSize    int
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+}
+
+type NewDispatcherOptions struct {
+	Repository repository.RepositoryInterface
+
+	// HTTPClient sends the deliveries, defaults to a client with a 10s timeout. The timeout bounds how long a
+	// delivery stays claimed, so a client without timeout is not supported.
+	HTTPClient *http.Client
+
+	// BatchSize is how many deliveries are claimed at once, defaults to 10.
+	BatchSize int
+
+	// MaxAttempts is how many times a delivery is attempted before it is marked as dead, defaults to 8.
+	MaxAttempts int
+
+	// Backoff is how long to wait before retrying a failed delivery, doubled after every failed attempt up to
+	// MaxBackoff. Defaults to 1m and 1h, so a delivery is dead after about 2 hours of failures.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// PollInterval is how long Run waits for new deliveries once the queue is empty, defaults to 5s.
+	PollInterval time.Duration
+}
+
+func NewDispatcher(opts NewDispatcherOptions) *Dispatcher {
+	dispatcher := &Dispatcher{
+		repository:   opts.Repository,
+		client:       opts.HTTPClient,
+		batchSize:    opts.BatchSize,
+		maxAttempts:  opts.MaxAttempts,
+		backoff:      opts.Backoff,
+		maxBackoff:   opts.MaxBackoff,
+		pollInterval: opts.PollInterval,
+	}
+
+	if dispatcher.client == nil || dispatcher.client.Timeout == 0 {
+		dispatcher.client = &http.Client{Timeout: defaultTimeout}
+	}
+	if dispatcher.batchSize <= 0 {
+		dispatcher.batchSize = defaultBatchSize
+	}
+	if dispatcher.maxAttempts <= 0 {
+		dispatcher.maxAttempts = defaultMaxAttempts
+	}
+	if dispatcher.backoff <= 0 {
+		dispatcher.backoff = defaultBackoff
+	}
+	if dispatcher.maxBackoff <= 0 {
+		dispatcher.maxBackoff = defaultMaxBackoff
+	}
+	if dispatcher.pollInterval <= 0 {
+		dispatcher.pollInterval = defaultPollInterval
+	}
+
+	return dispatcher
+}
+
+// Run sends the queued deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		dispatched, err := d.DispatchDue(ctx)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to dispatch webhook deliveries", "error", err)
+		}
+
+		// A full batch means more deliveries are probably due
+		if err == nil && dispatched == d.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+// DispatchDue claims the deliveries that are due and attempts them once. It returns how many deliveries were
+// attempted.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	now := fnTimeNow()
+
+	// Claims outlive the attempts made with them, so a delivery is never sent twice at the same time
+	attempts, err := d.repository.ClaimWebhookDeliveries(ctx, now, d.batchSize, now.Add(d.leaseDuration()))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, attempt := range attempts {
+		delivery := d.attempt(ctx, attempt)
+
+		if err := d.repository.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			// The delivery is attempted again once its claim expires
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to update webhook delivery",
+				"delivery_id", delivery.ID, "error", err)
+		}
+	}
+
+	return len(attempts), nil
+}
+
+// attempt sends the delivery and returns it updated with the outcome.
+func (d *Dispatcher) attempt(
+	ctx context.Context, attempt repository.WebhookDeliveryAttempt,
+) repository.WebhookDelivery {
+	delivery := attempt.WebhookDelivery
+
+	statusCode, err := d.send(ctx, attempt)
+	delivery.LastStatusCode, delivery.LastError = statusCode, ""
+
+	now := fnTimeNow()
+	switch {
+	case err == nil:
+		delivery.Status = repository.WebhookDeliveryDelivered
+		delivery.DeliveredTime = &now
+		return delivery
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = repository.WebhookDeliveryDead
+	default:
+		delivery.Status = repository.WebhookDeliveryPending
+	}
+
+	delivery.LastError = err.Error()
+	delivery.NextAttemptTime = now.Add(d.retryBackoff(delivery.Attempts))
+
+	return delivery
+}
+
+// send posts the payload of the delivery to its subscription and returns the status code of the response, if any.
+func (d *Dispatcher) send(ctx context.Context, attempt repository.WebhookDeliveryAttempt) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, attempt.URL, bytes.NewReader(attempt.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set(SignatureHeader, Sign(attempt.Secret, fnTimeNow(), attempt.Payload))
+	request.Header.Set(headerEvent, attempt.EventType)
+	request.Header.Set(headerEventID, attempt.EventID)
+	request.Header.Set(headerDelivery, strconv.FormatInt(attempt.ID, 10))
+	request.Header.Set(headerAttempt, strconv.Itoa(attempt.Attempts))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorLength))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected status %d: %s", response.StatusCode, body)
+	}
+
+	return response.StatusCode, nil
+}
+
+// retryBackoff returns how long to wait after the given number of failed attempts.
+func (d *Dispatcher) retryBackoff(attempts int) time.Duration {
+	backoff := d.backoff
+	for i := 1; i < attempts && backoff < d.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > d.maxBackoff {
+		return d.maxBackoff
+	}
+
+	return backoff
+}
+
+// leaseDuration is how long claimed deliveries are reserved: long enough to attempt a whole batch.
+func (d *Dispatcher) leaseDuration() time.Duration {
+	return time.Duration(d.batchSize)*d.client.Timeout + time.Minute
+}
@@ -0,0 +1,295 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+// fakeQueue is an in-memory stand-in for the webhook_delivery table, claiming deliveries the way
+// Repository.ClaimWebhookDeliveries does: only the oldest pending delivery of a subscription, once it is due.
+type fakeQueue struct {
+	deliveries []repository.WebhookDeliveryAttempt
+}
+
+func (q *fakeQueue) claim(_ context.Context, now time.Time, limit int, leaseUntil time.Time) ([]repository.WebhookDeliveryAttempt, error) {
+	var (
+		claimed []repository.WebhookDeliveryAttempt
+		head    = map[int64]bool{}
+	)
+
+	for i := range q.deliveries {
+		delivery := &q.deliveries[i]
+		if delivery.Status != repository.WebhookDeliveryPending || head[delivery.SubscriptionID] {
+			continue
+		}
+		head[delivery.SubscriptionID] = true
+
+		if delivery.NextAttemptTime.After(now) || len(claimed) == limit {
+			continue
+		}
+
+		lastAttemptTime := now
+		delivery.Attempts++
+		delivery.LastAttemptTime = &lastAttemptTime
+		delivery.NextAttemptTime = leaseUntil
+		claimed = append(claimed, *delivery)
+	}
+
+	return claimed, nil
+}
+
+func (q *fakeQueue) update(_ context.Context, delivery repository.WebhookDelivery) error {
+	for i := range q.deliveries {
+		if q.deliveries[i].ID == delivery.ID {
+			q.deliveries[i].WebhookDelivery = delivery
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+// receiver is a webhook receiver verifying the signature of the deliveries, which fails the first deliveries of
+// some events to the first subscription. Subscriptions are told apart by the path of their URL.
+type receiver struct {
+	t       *testing.T
+	now     func() time.Time
+	secrets map[string]string // by path
+	fail    map[string]int    // number of deliveries of an event to /first to fail, by event ID
+
+	mu       sync.Mutex
+	received map[string][]string // IDs of the received events, by path
+	accepted map[string][]string // IDs of the events that were answered with 200, by path
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rc.t.Fatalf("io.ReadAll() err = %v", err)
+	}
+
+	if err := Verify(rc.secrets[r.URL.Path], r.Header.Get(SignatureHeader), body, DefaultTolerance, rc.now()); err != nil {
+		rc.t.Errorf("webhook.Verify() of a delivery to %s err = %v", r.URL.Path, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		rc.t.Fatalf("json.Unmarshal() err = %v", err)
+	}
+	if got := r.Header.Get(headerEventID); got != event.ID {
+		rc.t.Errorf("delivery %s header = %v, want %v", headerEventID, got, event.ID)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.received[r.URL.Path] = append(rc.received[r.URL.Path], event.ID)
+	if r.URL.Path == "/first" && rc.fail[event.ID] > 0 {
+		rc.fail[event.ID]--
+		http.Error(w, "try again", http.StatusInternalServerError)
+		return
+	}
+
+	rc.accepted[r.URL.Path] = append(rc.accepted[r.URL.Path], event.ID)
+}
+
+func TestDispatcher_DispatchDue(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newEvent := func(eventType generated.WebhookEventType, userID int64) repository.WebhookEvent {
+		event, err := NewEvent(string(eventType), eventType, EventData{UserID: userID}, start)
+		if err != nil {
+			t.Fatalf("webhook.NewEvent() err = %v", err)
+		}
+		return event
+	}
+	registered := newEvent(generated.UserRegistered, 123)
+	loggedIn := newEvent(generated.UserLoggedIn, 123)
+	renamed := newEvent(generated.UserFullNameChanged, 123)
+	events := []repository.WebhookEvent{registered, loggedIn, renamed}
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		fail        map[string]int
+
+		wantReceived map[string][]string
+		wantAccepted map[string][]string
+		wantStatus   map[string][]repository.WebhookDeliveryStatus // by path, in event order
+		wantAttempts map[string][]int
+	}{
+		{
+			name: "success-in-order",
+			wantReceived: map[string][]string{
+				"/first":  {registered.ID, loggedIn.ID, renamed.ID},
+				"/second": {registered.ID, loggedIn.ID, renamed.ID},
+			},
+			wantAccepted: map[string][]string{
+				"/first":  {registered.ID, loggedIn.ID, renamed.ID},
+				"/second": {registered.ID, loggedIn.ID, renamed.ID},
+			},
+			wantStatus: map[string][]repository.WebhookDeliveryStatus{
+				"/first":  {repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered},
+				"/second": {repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered},
+			},
+			wantAttempts: map[string][]int{"/first": {1, 1, 1}, "/second": {1, 1, 1}},
+		},
+		{
+			name: "success-retry-holds-back-later-events",
+			fail: map[string]int{loggedIn.ID: 2},
+			wantReceived: map[string][]string{
+				"/first":  {registered.ID, loggedIn.ID, loggedIn.ID, loggedIn.ID, renamed.ID},
+				"/second": {registered.ID, loggedIn.ID, renamed.ID},
+			},
+			wantAccepted: map[string][]string{
+				"/first":  {registered.ID, loggedIn.ID, renamed.ID},
+				"/second": {registered.ID, loggedIn.ID, renamed.ID},
+			},
+			wantStatus: map[string][]repository.WebhookDeliveryStatus{
+				"/first":  {repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered},
+				"/second": {repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered},
+			},
+			wantAttempts: map[string][]int{"/first": {1, 3, 1}, "/second": {1, 1, 1}},
+		},
+		{
+			name:        "fail-dead-letter-after-max-attempts",
+			maxAttempts: 3,
+			fail:        map[string]int{registered.ID: 10},
+			wantReceived: map[string][]string{
+				"/first":  {registered.ID, registered.ID, registered.ID, loggedIn.ID, renamed.ID},
+				"/second": {registered.ID, loggedIn.ID, renamed.ID},
+			},
+			wantAccepted: map[string][]string{
+				"/first":  {loggedIn.ID, renamed.ID},
+				"/second": {registered.ID, loggedIn.ID, renamed.ID},
+			},
+			wantStatus: map[string][]repository.WebhookDeliveryStatus{
+				"/first":  {repository.WebhookDeliveryDead, repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered},
+				"/second": {repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered, repository.WebhookDeliveryDelivered},
+			},
+			wantAttempts: map[string][]int{"/first": {3, 1, 1}, "/second": {1, 1, 1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := start
+			fnTimeNow = func() time.Time { return now }
+			defer func() {
+				fnTimeNow = time.Now
+			}()
+
+			rc := &receiver{
+				t:        t,
+				now:      fnTimeNow,
+				secrets:  map[string]string{"/first": "first secret", "/second": "second secret"},
+				fail:     map[string]int{},
+				received: map[string][]string{},
+				accepted: map[string][]string{},
+			}
+			for eventID, failures := range test.fail {
+				rc.fail[eventID] = failures
+			}
+			server := httptest.NewServer(rc)
+			defer server.Close()
+
+			// Deliveries are queued in event order, interleaving the subscriptions like InsertWebhookEvent does
+			queue := &fakeQueue{}
+			paths := []string{"/first", "/second"}
+			for _, event := range events {
+				for i, path := range paths {
+					queue.deliveries = append(queue.deliveries, repository.WebhookDeliveryAttempt{
+						WebhookDelivery: repository.WebhookDelivery{
+							ID:              int64(len(queue.deliveries) + 1),
+							SubscriptionID:  int64(i + 1),
+							EventID:         event.ID,
+							EventType:       event.EventType,
+							Status:          repository.WebhookDeliveryPending,
+							NextAttemptTime: event.CreatedTime,
+							CreatedTime:     event.CreatedTime,
+						},
+						URL:     server.URL + path,
+						Secret:  rc.secrets[path],
+						Payload: event.Payload,
+					})
+				}
+			}
+
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			mock.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(queue.claim).AnyTimes()
+			mock.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).DoAndReturn(queue.update).AnyTimes()
+
+			dispatcher := NewDispatcher(NewDispatcherOptions{
+				Repository:  mock,
+				MaxAttempts: test.maxAttempts,
+				Backoff:     time.Minute,
+				MaxBackoff:  time.Hour,
+			})
+
+			// Let every backoff expire between rounds
+			for round := 0; round < 20; round++ {
+				if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+					t.Fatalf("webhook.Dispatcher.DispatchDue() err = %v", err)
+				}
+				now = now.Add(time.Hour)
+			}
+
+			if !reflect.DeepEqual(rc.received, test.wantReceived) {
+				t.Errorf("webhook.Dispatcher received = %v, wantReceived %v", rc.received, test.wantReceived)
+			}
+			if !reflect.DeepEqual(rc.accepted, test.wantAccepted) {
+				t.Errorf("webhook.Dispatcher accepted = %v, wantAccepted %v", rc.accepted, test.wantAccepted)
+			}
+
+			gotStatus := map[string][]repository.WebhookDeliveryStatus{}
+			gotAttempts := map[string][]int{}
+			for _, delivery := range queue.deliveries {
+				path := strings.TrimPrefix(delivery.URL, server.URL)
+				gotStatus[path] = append(gotStatus[path], delivery.Status)
+				gotAttempts[path] = append(gotAttempts[path], delivery.Attempts)
+			}
+			if !reflect.DeepEqual(gotStatus, test.wantStatus) {
+				t.Errorf("webhook.Dispatcher status = %v, wantStatus %v", gotStatus, test.wantStatus)
+			}
+			if !reflect.DeepEqual(gotAttempts, test.wantAttempts) {
+				t.Errorf("webhook.Dispatcher attempts = %v, wantAttempts %v", gotAttempts, test.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDispatcher_retryBackoff(t *testing.T) {
+	dispatcher := NewDispatcher(NewDispatcherOptions{Backoff: time.Minute, MaxBackoff: 10 * time.Minute})
+
+	tests := []struct {
+		attempts    int
+		wantBackoff time.Duration
+	}{
+		{attempts: 1, wantBackoff: time.Minute},
+		{attempts: 2, wantBackoff: 2 * time.Minute},
+		{attempts: 4, wantBackoff: 8 * time.Minute},
+		{attempts: 5, wantBackoff: 10 * time.Minute},
+		{attempts: 50, wantBackoff: 10 * time.Minute},
+	}
+
+	for _, test := range tests {
+		if gotBackoff := dispatcher.retryBackoff(test.attempts); gotBackoff != test.wantBackoff {
+			t.Errorf("webhook.Dispatcher.retryBackoff(%d) = %v, wantBackoff %v", test.attempts, gotBackoff, test.wantBackoff)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/repository"
+)
+
+// Event is the JSON body of a delivery, see WebhookEvent in api.yml.
+type Event struct {
+	ID        string                     `json:"id"`
+	Type      generated.WebhookEventType `json:"type"`
+	CreatedAt time.Time                  `json:"created_at"`
+	Data      EventData                  `json:"data"`
+}
+
+// EventData is the user an event is about. Only the fields that are part of the event are set.
+type EventData struct {
+	UserID      int64  `json:"user_id"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	FullName    string `json:"full_name,omitempty"`
+	SessionID   string `json:"session_id,omitempty"`
+	ExportID    string `json:"export_id,omitempty"`
+}
+
+// NewEvent returns the event to insert with RepositoryInterface.InsertWebhookEvent, which queues its deliveries.
+func NewEvent(id string, eventType generated.WebhookEventType, data EventData, now time.Time) (repository.WebhookEvent, error) {
+	// Postgres stores microseconds, keep the payload consistent with created_time
+	createdAt := now.UTC().Truncate(time.Microsecond)
+
+	payload, err := json.Marshal(Event{
+		ID:        id,
+		Type:      eventType,
+		CreatedAt: createdAt,
+		Data:      data,
+	})
+	if err != nil {
+		return repository.WebhookEvent{}, err
+	}
+
+	return repository.WebhookEvent{
+		ID:          id,
+		EventType:   string(eventType),
+		Payload:     payload,
+		CreatedTime: createdAt,
+	}, nil
+}
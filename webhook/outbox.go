@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/outbox"
+	"github.com/UserService/repository"
+)
+
+// OutboxPublisher queues the webhook events of the domain events relayed from the outbox, so webhook events are
+// only queued for committed changes and are not lost when the service stops after the change is committed.
+type OutboxPublisher struct {
+	repository repository.RepositoryInterface
+}
+
+type NewOutboxPublisherOptions struct {
+	Repository repository.RepositoryInterface
+}
+
+func NewOutboxPublisher(opts NewOutboxPublisherOptions) *OutboxPublisher {
+	return &OutboxPublisher{repository: opts.Repository}
+}
+
+// Publish queues the webhook events of the domain event. The IDs of the webhook events are derived from the ID of the
+// domain event, so an event relayed again does not queue its webhook events twice.
+func (p *OutboxPublisher) Publish(ctx context.Context, event outbox.Event) error {
+	var payload repository.UserEventPayload
+	if err := json.Unmarshal(event.Data, &payload); err != nil {
+		return err
+	}
+
+	for _, typed := range webhookEvents(event.Type, payload) {
+		webhookEvent, err := NewEvent(webhookEventID(event.ID, typed.eventType), typed.eventType, typed.data, event.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if err := p.repository.InsertWebhookEvent(ctx, webhookEvent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// typedEventData is the data of a webhook event along with its type
+type typedEventData struct {
+	eventType generated.WebhookEventType
+	data      EventData
+}
+
+// webhookEvents returns the webhook events of a domain event, in the order they are queued. An update is one event
+// per changed or removed field, and domain events without webhook events return none.
+func webhookEvents(eventType string, payload repository.UserEventPayload) []typedEventData {
+	var events []typedEventData
+
+	switch eventType {
+	case repository.OutboxEventUserRegistered:
+		events = append(events, typedEventData{generated.UserRegistered, EventData{
+			UserID:      payload.UserID,
+			PhoneNumber: payload.PhoneNumber,
+			FullName:    payload.FullName,
+		}})
+	case repository.OutboxEventUserUpdated:
+		if payload.PhoneNumber != "" {
+			events = append(events, typedEventData{generated.UserPhoneNumberChanged, EventData{
+				UserID:      payload.UserID,
+				PhoneNumber: payload.PhoneNumber,
+			}})
+		}
+		// A removed full name is an event without full name
+		if payload.FullName != "" || payload.FullNameCleared {
+			events = append(events, typedEventData{generated.UserFullNameChanged, EventData{
+				UserID:   payload.UserID,
+				FullName: payload.FullName,
+			}})
+		}
+	case repository.OutboxEventUserLoggedIn:
+		events = append(events, typedEventData{generated.UserLoggedIn, EventData{
+			UserID:    payload.UserID,
+			SessionID: payload.SessionID,
+		}})
+	case repository.OutboxEventUserExportCompleted:
+		events = append(events, typedEventData{generated.UserExportCompleted, EventData{
+			UserID:   payload.UserID,
+			ExportID: payload.ExportID,
+		}})
+	}
+
+	return events
+}
+
+// webhookEventID returns the 128-bit ID of the webhook event of the given type derived from a domain event.
+func webhookEventID(outboxEventID string, eventType generated.WebhookEventType) string {
+	sum := sha256.Sum256([]byte(outboxEventID + "/" + string(eventType)))
+	return hex.EncodeToString(sum[:16])
+}
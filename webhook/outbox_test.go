@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/UserService/generated"
+	"github.com/UserService/outbox"
+	"github.com/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+// webhookEventMatcher matches the repository.WebhookEvent of a user lifecycle event derived from an outbox event.
+type webhookEventMatcher struct {
+	outboxEventID string
+	eventType     generated.WebhookEventType
+	data          EventData
+}
+
+func (m webhookEventMatcher) Matches(x interface{}) bool {
+	event, ok := x.(repository.WebhookEvent)
+	if !ok || event.EventType != string(m.eventType) || event.ID != webhookEventID(m.outboxEventID, m.eventType) {
+		return false
+	}
+
+	var payload Event
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return false
+	}
+
+	return payload.ID == event.ID && payload.Type == m.eventType && payload.Data == m.data
+}
+
+func (m webhookEventMatcher) String() string {
+	return fmt.Sprintf("is a %s webhook event of %+v", m.eventType, m.data)
+}
+
+func TestOutboxPublisher_Publish(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		event          outbox.Event
+		mockRepository func(mock *repository.MockRepositoryInterface)
+
+		wantErr error
+	}{
+		{
+			name:  "success-registered",
+			event: outbox.Event{ID: "e1", Type: repository.OutboxEventUserRegistered, Data: json.RawMessage(`{"user_id":123,"phone_number":"+628123456789","full_name":"User"}`)},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertWebhookEvent(gomock.Any(), webhookEventMatcher{"e1", generated.UserRegistered, EventData{UserID: 123, PhoneNumber: "+628123456789", FullName: "User"}}).Return(nil)
+			},
+		},
+		{
+			name:  "success-updated-queues-an-event-per-changed-field",
+			event: outbox.Event{ID: "e2", Type: repository.OutboxEventUserUpdated, Data: json.RawMessage(`{"user_id":123,"phone_number":"+628123456789","full_name":"User"}`)},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				gomock.InOrder(
+					mock.EXPECT().InsertWebhookEvent(gomock.Any(), webhookEventMatcher{"e2", generated.UserPhoneNumberChanged, EventData{UserID: 123, PhoneNumber: "+628123456789"}}).Return(nil),
+					mock.EXPECT().InsertWebhookEvent(gomock.Any(), webhookEventMatcher{"e2", generated.UserFullNameChanged, EventData{UserID: 123, FullName: "User"}}).Return(nil),
+				)
+			},
+		},
+		{
+			name:  "success-updated-queues-an-event-of-removed-full-name",
+			event: outbox.Event{ID: "e2", Type: repository.OutboxEventUserUpdated, Data: json.RawMessage(`{"user_id":123,"full_name_cleared":true}`)},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertWebhookEvent(gomock.Any(), webhookEventMatcher{"e2", generated.UserFullNameChanged, EventData{UserID: 123}}).Return(nil)
+			},
+		},
+		{
+			name:  "success-logged-in",
+			event: outbox.Event{ID: "e3", Type: repository.OutboxEventUserLoggedIn, Data: json.RawMessage(`{"user_id":123,"session_id":"s1"}`)},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertWebhookEvent(gomock.Any(), webhookEventMatcher{"e3", generated.UserLoggedIn, EventData{UserID: 123, SessionID: "s1"}}).Return(nil)
+			},
+		},
+		{
+			name:  "success-export-completed",
+			event: outbox.Event{ID: "e4", Type: repository.OutboxEventUserExportCompleted, Data: json.RawMessage(`{"user_id":123,"export_id":"a1b2"}`)},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertWebhookEvent(gomock.Any(), webhookEventMatcher{"e4", generated.UserExportCompleted, EventData{UserID: 123, ExportID: "a1b2"}}).Return(nil)
+			},
+		},
+		{
+			name:           "success-ignores-unknown-events",
+			event:          outbox.Event{ID: "e5", Type: "user.unknown", Data: json.RawMessage(`{"user_id":123}`)},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {},
+		},
+		{
+			name:  "fail-insert",
+			event: outbox.Event{ID: "e6", Type: repository.OutboxEventUserLoggedIn, Data: json.RawMessage(`{"user_id":123,"session_id":"s1"}`)},
+			mockRepository: func(mock *repository.MockRepositoryInterface) {
+				mock.EXPECT().InsertWebhookEvent(gomock.Any(), gomock.Any()).Return(repository.ErrUnavailable)
+			},
+			wantErr: repository.ErrUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			mock := repository.NewMockRepositoryInterface(controller)
+			test.mockRepository(mock)
+
+			publisher := NewOutboxPublisher(NewOutboxPublisherOptions{Repository: mock})
+
+			test.event.CreatedAt = createdAt
+			gotErr := publisher.Publish(context.Background(), test.event)
+			if !errors.Is(gotErr, test.wantErr) || (test.wantErr == nil && gotErr != nil) {
+				t.Errorf("webhook.OutboxPublisher.Publish() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+		})
+	}
+}
+
+func Test_webhookEventID(t *testing.T) {
+	// Relaying an event again derives the same IDs, so InsertWebhookEvent ignores the events already queued
+	first := webhookEventID("e1", generated.UserPhoneNumberChanged)
+	if again := webhookEventID("e1", generated.UserPhoneNumberChanged); again != first {
+		t.Errorf("webhookEventID() = %v, then %v", first, again)
+	}
+
+	ids := []string{first, webhookEventID("e1", generated.UserFullNameChanged), webhookEventID("e2", generated.UserPhoneNumberChanged)}
+	if ids[0] == ids[1] || ids[0] == ids[2] || ids[1] == ids[2] || len(first) != 32 {
+		t.Errorf("webhookEventID() = %v, want distinct 128-bit IDs", ids)
+	}
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header carrying the signature of a delivery, i.e. `t=1700000000,v1=5257a869...`
+const SignatureHeader = "X-Webhook-Signature"
+
+// DefaultTolerance is how old the timestamp of a signature may be before Verify rejects it, to limit replays.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned by Verify when the signature header is malformed or does not match the body.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+	// ErrSignatureExpired is returned by Verify when the timestamp of the signature is outside of the tolerance.
+	ErrSignatureExpired = errors.New("webhook: signature timestamp is outside of the tolerance")
+)
+
+// Sign returns the value of the signature header of a delivery of body sent at timestamp: the hex encoded
+// HMAC-SHA256 of `<unix timestamp>.<body>` keyed with the secret of the subscription.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := timestamp.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", unix, computeSignature(secret, unix, body))
+}
+
+// Verify checks the signature header of a delivery received at now. Receivers written in Go can use it as is, it
+// documents the scheme for the others.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var (
+		timestamp  int64
+		signatures []string
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidSignature
+		}
+
+		switch key {
+		case "t":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			timestamp = parsed
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	expected := computeSignature(secret, timestamp, body)
+
+	matched := false
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			matched = true
+		}
+	}
+	if !matched {
+		return ErrInvalidSignature
+	}
+
+	// Only trust the timestamp once it is known to be signed
+	if age := now.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	return nil
+}
+
+func computeSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":"event","type":"user.registered"}`)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+
+		wantErr error
+	}{
+		{
+			name:   "success",
+			secret: "secret",
+			header: Sign("secret", now, body),
+			body:   body,
+		},
+		{
+			name:   "success-one-of-several-signatures",
+			secret: "secret",
+			header: Sign("secret", now, body) + ",v1=" + computeSignature("old secret", now.Unix(), body),
+			body:   body,
+		},
+		{
+			name:    "fail-other-secret",
+			secret:  "other secret",
+			header:  Sign("secret", now, body),
+			body:    body,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "fail-tampered-body",
+			secret:  "secret",
+			header:  Sign("secret", now, body),
+			body:    []byte(`{"id":"event","type":"user.logged_in"}`),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "fail-tampered-timestamp",
+			secret:  "secret",
+			header:  "t=1704110401,v1=" + computeSignature("secret", now.Unix(), body),
+			body:    body,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "fail-expired",
+			secret:  "secret",
+			header:  Sign("secret", now.Add(-DefaultTolerance-time.Second), body),
+			body:    body,
+			wantErr: ErrSignatureExpired,
+		},
+		{
+			name:    "fail-malformed",
+			secret:  "secret",
+			header:  "signature",
+			body:    body,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "fail-missing-signature",
+			secret:  "secret",
+			header:  "t=1704110400",
+			body:    body,
+			wantErr: ErrInvalidSignature,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotErr := Verify(test.secret, test.header, test.body, DefaultTolerance, now)
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("webhook.Verify() err = %v, wantErr %v", gotErr, test.wantErr)
+			}
+		})
+	}
+}